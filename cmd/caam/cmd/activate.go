@@ -61,12 +61,13 @@ Examples:
   caam activate claude --auto
 
 The --auto flag enables smart profile rotation, which selects the best profile
-based on health status, cooldown state, and usage patterns. Three algorithms
+based on health status, cooldown state, and usage patterns. Four algorithms
 are available (configured in config.yaml):
 
   smart       - Multi-factor scoring (health, cooldown, recency)
   round_robin - Sequential rotation through profiles
   random      - Random selection
+  reset_aware - Spend quota that resets soonest, keep distant resets in reserve
 
 After activating, just run the tool normally - it will use the new account.`,
 	Args: cobra.RangeArgs(1, 2),
@@ -76,6 +77,7 @@ After activating, just run the tool normally - it will use the new account.`,
 func init() {
 	activateCmd.Flags().Bool("backup-current", false, "backup current auth before switching")
 	activateCmd.Flags().Bool("force", false, "activate even if the profile is in cooldown")
+	activateCmd.Flags().Bool("override", false, "activate even if an enforced budget is exceeded")
 	activateCmd.Flags().Bool("auto", false, "auto-select profile using rotation algorithm")
 	activateCmd.Flags().Bool("json", false, "output as JSON")
 }
@@ -109,7 +111,7 @@ func runActivate(cmd *cobra.Command, args []string) error {
 
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool))
+		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool))
 	}
 
 	// Ensure vault is initialized before using it
@@ -270,6 +272,12 @@ func runActivate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Budgets: refuse profiles whose enforced budget is used up.
+	override, _ := cmd.Flags().GetBool("override")
+	if err := enforceBudgets(cmd.Context(), tool, profileName, override, cmd.ErrOrStderr()); err != nil {
+		return emitJSONError(err)
+	}
+
 	// Step 1: Refresh if needed
 	refreshed := refreshIfNeeded(cmd.Context(), tool, profileName, jsonOutput)
 	output.Refreshed = refreshed
@@ -489,6 +497,13 @@ func selectProfileWithRotation(tool string, profiles []string, currentProfile st
 	}
 
 	selector := rotation.NewSelector(algorithm, healthStore, db)
+	applyRotationPolicies(selector, db, tool, profiles)
+	if err := applySelectionPolicy(selector, ""); err != nil {
+		return nil, err
+	}
+	if algorithm == rotation.AlgorithmResetAware && supportsUsageAPI(tool) {
+		selector.SetUsageData(fetchUsageDataForProfiles(tool, profiles, "", db))
+	}
 	result, err := selector.Select(tool, profiles, currentProfile)
 	if err != nil {
 		return nil, fmt.Errorf("rotation select: %w", err)
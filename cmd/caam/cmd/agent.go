@@ -168,6 +168,12 @@ func runAgentFromConfig(cmd *cobra.Command, logger *slog.Logger, path string) er
 }
 
 func runSingleAgent(cmd *cobra.Command, logger *slog.Logger, config agent.Config, strategy string, accounts []string, chromeProfile string) error {
+	filter, err := agentAccountFilter()
+	if err != nil {
+		return err
+	}
+	config.AccountFilter = filter
+
 	// Create agent
 	ag := agent.New(config)
 
@@ -240,6 +246,12 @@ func runSingleAgent(cmd *cobra.Command, logger *slog.Logger, config agent.Config
 }
 
 func runMultiAgent(cmd *cobra.Command, logger *slog.Logger, config agent.MultiConfig) error {
+	filter, err := agentAccountFilter()
+	if err != nil {
+		return err
+	}
+	config.AccountFilter = filter
+
 	ma := agent.NewMulti(config)
 
 	ma.OnAuthStart = func(coord, url, account string) {
@@ -93,6 +93,7 @@ func (m *MockProvider) ImportAuth(ctx context.Context, sourcePath string, target
 	return m.mockImportFiles, nil
 }
 func (m *MockProvider) ValidateToken(ctx context.Context, p *profile.Profile, passive bool) (*provider.ValidationResult, error) { return nil, nil }
+func (m *MockProvider) Capabilities() provider.Capabilities { return provider.Capabilities{} }
 
 func TestAuthCommands_Extended(t *testing.T) {
 	h := testutil.NewExtendedHarness(t)
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/budget"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show usage against configured budgets",
+	Long: `Show how much of each budget in ~/.caam/config.yaml has been used this period.
+
+Budgets cap tokens, API-equivalent dollars or 'caam run' minutes per day,
+week or month, for a provider, profile, tag or project directory:
+
+  budgets:
+    - name: client-acme
+      tag: client               # profiles tagged 'client' ('caam tag')
+      project: ~/clients/acme   # usage from 'caam run' sessions in this tree
+      period: month
+      dollars: 200
+      warn_at: [50, 80, 100]    # alert thresholds in percent
+    - name: claude-work
+      provider: claude
+      profile: work
+      period: week
+      tokens: 40000000
+      enforce: true             # refuse activate/run/exec once exceeded
+
+Alerts go to the channels under alerts.notifications, once per threshold and
+period. Enforced budgets block 'caam activate', 'caam run' and 'caam exec' in
+their scope until the period resets; pass --override to proceed anyway.`,
+	Args: cobra.NoArgs,
+	RunE: runBudgetStatus,
+}
+
+var budgetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show budget burn for the current period",
+	Args:  cobra.NoArgs,
+	RunE:  runBudgetStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	budgetCmd.AddCommand(budgetStatusCmd)
+	budgetCmd.PersistentFlags().Bool("json", false, "output in JSON format")
+}
+
+func runBudgetStatus(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return err
+	}
+	if len(spmCfg.Budgets) == 0 {
+		if jsonOutput {
+			fmt.Fprintln(cmd.OutOrStdout(), "[]")
+			return nil
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "No budgets configured. Add a 'budgets' list to ~/.caam/config.yaml (see 'caam budget --help').")
+		return nil
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	statuses, err := budgetStatuses(cmd.Context(), spmCfg.Budgets, db)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+	renderBudgets(cmd.OutOrStdout(), statuses)
+	return nil
+}
+
+func renderBudgets(out io.Writer, statuses []budget.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BUDGET\tSCOPE\tUSED\tBURN\tRESETS")
+	for _, s := range statuses {
+		burn := fmt.Sprintf("%.0f%%", s.Percent)
+		switch {
+		case s.Exceeded && s.Enforced:
+			burn += " (blocking)"
+		case s.Exceeded:
+			burn += " (exceeded)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Scope, s.Summary(), burn, s.ResetsAt.Format("Jan 2 15:04"))
+	}
+	w.Flush()
+}
+
+// budgetStatuses evaluates budgets against recorded usage, importing new
+// log lines first.
+func budgetStatuses(ctx context.Context, budgets []config.BudgetConfig, db *caamdb.DB) ([]budget.Status, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, p := range []string{"claude", "codex", "gemini"} {
+		for _, b := range budgets {
+			if b.Provider == "" || strings.EqualFold(b.Provider, p) {
+				_, _ = ingestLogSources(ctx, db, p)
+				break
+			}
+		}
+	}
+
+	now := time.Now()
+	usage, err := budget.Load(db, budgets, now, profileTags)
+	if err != nil {
+		return nil, err
+	}
+	return budget.Evaluate(budgets, usage, now), nil
+}
+
+// profileTags returns the tags of a profile from its metadata.
+func profileTags(provider, name string) []string {
+	store := profileStore
+	if store == nil {
+		store = profile.NewStore(profile.DefaultStorePath())
+	}
+	prof, err := store.Load(provider, name)
+	if err != nil {
+		return nil
+	}
+	return prof.Tags
+}
+
+// enforceBudgets checks the budgets that can cover a profile about to be
+// used, sending alerts for their newly crossed thresholds. It returns an error if an
+// enforced budget is used up, unless override is set. Budgets that cannot
+// be evaluated are not enforced.
+func enforceBudgets(ctx context.Context, tool, profileName string, override bool, stderr io.Writer) error {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil || len(spmCfg.Budgets) == 0 {
+		return nil
+	}
+	// Budgets scoped to another provider can never block this one.
+	var budgets []config.BudgetConfig
+	for _, b := range spmCfg.Budgets {
+		if b.Provider == "" || strings.EqualFold(b.Provider, tool) {
+			budgets = append(budgets, b)
+		}
+	}
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	db, err := getDB()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: budgets not checked: %v\n", err)
+		return nil
+	}
+	statuses, err := budgetStatuses(ctx, budgets, db)
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: budgets not checked: %v\n", err)
+		return nil
+	}
+
+	if spmCfg.Alerts.Enabled {
+		alerts, _ := budget.Alerts(statuses, db)
+		notifier := notify.FromConfig(spmCfg.Alerts.Notifications, stderr,
+			notify.WithDeduper(db), notify.WithOutbox(db), notify.WithTags(profileTags))
+		for _, a := range alerts {
+			_ = notifier.Notify(a)
+		}
+	}
+
+	dir, err := getWd()
+	if err != nil {
+		dir, _ = os.Getwd()
+	}
+	target := budget.Target{Provider: tool, Profile: profileName, Tags: profileTags(tool, profileName), Dir: dir}
+	blocking := budget.Blocking(statuses, target)
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	b := blocking[0]
+	if override {
+		for _, s := range blocking {
+			fmt.Fprintf(stderr, "Warning: budget %q exceeded (%s this %s); proceeding due to --override\n", s.Name, s.Summary(), s.Period)
+		}
+		return nil
+	}
+	return fmt.Errorf("budget %q exceeded: %s this %s (resets %s); re-run with --override to proceed anyway",
+		b.Name, b.Summary(), b.Period, b.ResetsAt.Format("Jan 2 15:04"))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func TestEnforceBudgets(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, ".caam"))
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex"))
+	t.Setenv("CAAM_ALERTS_NOTIFICATIONS_DESKTOP", "false")
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	cfg := `budgets:
+  - name: codex-work
+    provider: codex
+    profile: work
+    period: month
+    tokens: 100
+    enforce: true
+  - name: everything
+    tokens: 1000000
+`
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".caam"), 0700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".caam", "config.yaml"), []byte(cfg), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatalf("getDB() error = %v", err)
+	}
+	now := time.Now().UTC()
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "work", Timestamp: now.Add(-2 * time.Minute)}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+
+	logDir := filepath.Join(tmpDir, "codex", "logs")
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	line := fmt.Sprintf(`{"timestamp":%q,"event":"response","model":"gpt-4o","usage":{"prompt_tokens":150,"completion_tokens":0}}`+"\n",
+		now.Add(-time.Minute).Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(logDir, "session.jsonl"), []byte(line), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ctx := context.Background()
+	var stderr bytes.Buffer
+	err = enforceBudgets(ctx, "codex", "work", false, &stderr)
+	if err == nil || !strings.Contains(err.Error(), `budget "codex-work" exceeded`) || !strings.Contains(err.Error(), "--override") {
+		t.Fatalf("enforceBudgets(codex/work) error = %v, want codex-work exceeded", err)
+	}
+	if !strings.Contains(stderr.String(), "Budget codex-work at 150%") {
+		t.Errorf("expected a budget alert, got %q", stderr.String())
+	}
+
+	// The alert is sent once per period.
+	stderr.Reset()
+	if err := enforceBudgets(ctx, "codex", "work", true, &stderr); err != nil {
+		t.Fatalf("enforceBudgets(--override) error = %v", err)
+	}
+	if strings.Contains(stderr.String(), "Budget codex-work") || !strings.Contains(stderr.String(), "proceeding due to --override") {
+		t.Errorf("unexpected --override output %q", stderr.String())
+	}
+
+	// Other profiles are outside the budget's scope.
+	if err := enforceBudgets(ctx, "codex", "personal", false, &stderr); err != nil {
+		t.Errorf("enforceBudgets(codex/personal) error = %v", err)
+	}
+	if err := enforceBudgets(ctx, "claude", "work", false, &stderr); err != nil {
+		t.Errorf("enforceBudgets(claude/work) error = %v", err)
+	}
+
+	// Budgets of other providers are not evaluated, so their alerts wait
+	// until that provider is used.
+	cfg += `  - name: gemini-all
+    provider: gemini
+    tokens: 1
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".caam", "config.yaml"), []byte(cfg), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "gemini", ProfileName: "work", Timestamp: now.Add(-2 * time.Minute)}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+	if err := db.RecordScan("gemini", logs.Checkpoint{Path: "/logs/gemini.jsonl"}, []*logs.LogEntry{{Timestamp: now.Add(-time.Minute), InputTokens: 10}}, false); err != nil {
+		t.Fatalf("RecordScan() error = %v", err)
+	}
+	stderr.Reset()
+	if err := enforceBudgets(ctx, "claude", "work", false, &stderr); err != nil {
+		t.Errorf("enforceBudgets(claude/work) error = %v", err)
+	}
+	if strings.Contains(stderr.String(), "gemini-all") {
+		t.Errorf("claude check evaluated a gemini budget: %q", stderr.String())
+	}
+	stderr.Reset()
+	_ = enforceBudgets(ctx, "gemini", "work", false, &stderr)
+	if !strings.Contains(stderr.String(), "Budget gemini-all") {
+		t.Errorf("expected a gemini-all alert, got %q", stderr.String())
+	}
+}
@@ -14,6 +14,7 @@ import (
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/spf13/cobra"
 )
 
@@ -40,7 +41,10 @@ Examples:
 
 Subcommands:
   caam cost sessions               # List recent wrap sessions
-  caam cost rates                  # Show/set cost rate configuration`,
+  caam cost rates                  # Show/set cost rate configuration
+  caam cost tokens                 # Token usage and API-equivalent cost
+  caam cost roi                    # Subscription value per account and month
+  caam cost ingest                 # Import new CLI log lines into the database`,
 	Args: cobra.NoArgs,
 	RunE: runCostSummary,
 }
@@ -67,11 +71,31 @@ var costRatesCmd = &cobra.Command{
 Rates are specified in cents. Costs are calculated as:
   estimated_cost = cents_per_session + (cents_per_minute * session_minutes)
 
+With --models, show the per-token API prices used for API-equivalent costs
+instead: the built-in prices merged with ~/.caam/pricing.yaml, which can add
+models and dated price changes. Usage is priced at the rate in effect when it
+happened, so past reports keep their prices:
+
+  models:
+    - provider: claude
+      model: claude-sonnet-4
+      input_per_1m: 3.00
+      output_per_1m: 15.00
+      cache_read_per_1m: 0.30
+      cache_create_per_1m: 3.75
+    - provider: claude
+      model: claude-3-opus
+      input_per_1m: 12.00           # from this date; other prices carry over
+      effective_from: 2026-11-01
+
+The file may also be written as JSON (pricing.json) with the same fields.
+
 Examples:
   caam cost rates                   # Show current rates
   caam cost rates --json            # Show rates as JSON
   caam cost rates --set claude --per-minute 5 --per-session 0
-  caam cost rates --set codex --per-minute 3`,
+  caam cost rates --set codex --per-minute 3
+  caam cost rates --models          # Per-token API prices and their sources`,
 	Args: cobra.NoArgs,
 	RunE: runCostRates,
 }
@@ -85,6 +109,13 @@ This command scans local CLI logs to calculate actual token usage and estimates
 what the equivalent API cost would be. This helps you understand the value
 you're getting from your subscription.
 
+Token usage is kept in caam's database: each run only reads log lines written
+since the last one (see 'caam cost ingest').
+
+With --by-profile, usage is broken down per account: lines from an isolated
+profile's logs ('caam exec') belong to that profile, and other lines to the
+profile last activated for the provider before they were written.
+
 Examples:
   caam cost tokens                    # Show costs for all providers (last 30 days)
   caam cost tokens claude             # Show Claude costs only
@@ -92,6 +123,7 @@ Examples:
   caam cost tokens --last 24h         # Show costs for last 24 hours
   caam cost tokens --format json      # Output as JSON
   caam cost tokens --format csv       # Output as CSV
+  caam cost tokens claude --by-profile # Per-account breakdown
 
 The cost comparison shows:
   - Total tokens used broken down by type (input/output/cache)
@@ -102,11 +134,27 @@ The cost comparison shows:
 	RunE: runCostTokens,
 }
 
+var costIngestCmd = &cobra.Command{
+	Use:   "ingest [provider]",
+	Short: "Import new CLI log lines into the database",
+	Long: `Read token usage written to CLI logs since the last import and store it in
+caam's database. Each log file is read from where the previous import stopped,
+so this is cheap to run often. 'caam cost tokens', 'caam limits' and the daemon
+import automatically; run this to do it on demand.
+
+Examples:
+  caam cost ingest                  # All providers
+  caam cost ingest claude`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCostIngest,
+}
+
 func init() {
 	rootCmd.AddCommand(costCmd)
 	costCmd.AddCommand(costSessionsCmd)
 	costCmd.AddCommand(costRatesCmd)
 	costCmd.AddCommand(costTokensCmd)
+	costCmd.AddCommand(costIngestCmd)
 
 	// Cost summary flags
 	costCmd.Flags().String("provider", "", "filter by provider (claude, codex, gemini)")
@@ -124,10 +172,15 @@ func init() {
 	costRatesCmd.Flags().String("set", "", "set rates for provider (requires --per-minute or --per-session)")
 	costRatesCmd.Flags().Int("per-minute", -1, "cents per minute (use with --set)")
 	costRatesCmd.Flags().Int("per-session", -1, "cents per session (use with --set)")
+	costRatesCmd.Flags().Bool("models", false, "show per-token model prices and where they come from")
 
 	// Tokens flags
 	costTokensCmd.Flags().StringP("last", "l", "30d", "time period to analyze (e.g., 7d, 30d, 24h)")
 	costTokensCmd.Flags().StringP("format", "f", "table", "output format: table, json, csv")
+	costTokensCmd.Flags().Bool("by-profile", false, "break usage down by the profile that was active")
+
+	// Ingest flags
+	costIngestCmd.Flags().Bool("json", false, "output as JSON")
 }
 
 func runCostSummary(cmd *cobra.Command, args []string) error {
@@ -207,6 +260,21 @@ func runCostRates(cmd *cobra.Command, args []string) error {
 	setProvider, _ := cmd.Flags().GetString("set")
 	perMinute, _ := cmd.Flags().GetInt("per-minute")
 	perSession, _ := cmd.Flags().GetInt("per-session")
+	models, _ := cmd.Flags().GetBool("models")
+
+	if models {
+		table, err := pricing.LoadTable(pricing.DefaultPath())
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(table.Rates())
+		}
+		renderModelRates(cmd.OutOrStdout(), table.Rates())
+		return nil
+	}
 
 	db, err := caamdb.Open()
 	if err != nil {
@@ -459,6 +527,29 @@ func renderRates(w io.Writer, rates []caamdb.CostRate) error {
 	return nil
 }
 
+// renderModelRates lists per-token prices, one line per model and
+// effective date.
+func renderModelRates(w io.Writer, rates []pricing.Rate) {
+	fmt.Fprintln(w, "Model Prices (USD per 1M tokens):")
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PROVIDER\tMODEL\tINPUT\tOUTPUT\tCACHE READ\tCACHE WRITE\tEFFECTIVE\tSOURCE")
+	for _, r := range rates {
+		effective := "-"
+		if !r.EffectiveFrom.IsZero() {
+			effective = r.EffectiveFrom.Format("2006-01-02")
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%.4g\t%.4g\t%.4g\t%.4g\t%s\t%s\n",
+			r.Provider, r.Model, r.InputPer1M, r.OutputPer1M, r.CacheReadPer1M, r.CacheCreatePer1M,
+			effective, r.Source)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "To add models or price changes, edit %s (see 'caam cost rates --help').\n", pricing.DefaultPath())
+}
+
 // formatDollars converts cents to a dollar string
 func formatDollars(cents int) string {
 	if cents == 0 {
@@ -475,6 +566,8 @@ func formatDollars(cents int) string {
 // TokenCostAnalysis holds the token cost analysis results
 type TokenCostAnalysis struct {
 	Provider          string           `json:"provider"`
+	Profile           string           `json:"profile,omitempty"`
+	Unattributed      bool             `json:"unattributed,omitempty"`
 	Period            string           `json:"period"`
 	Since             time.Time        `json:"since"`
 	Until             time.Time        `json:"until"`
@@ -503,6 +596,7 @@ type TokenModelCost struct {
 func runCostTokens(cmd *cobra.Command, args []string) error {
 	lastStr, _ := cmd.Flags().GetString("last")
 	format, _ := cmd.Flags().GetString("format")
+	byProfile, _ := cmd.Flags().GetBool("by-profile")
 
 	// Parse the duration using the same parser as other commands
 	period, err := parseDuration(lastStr)
@@ -524,21 +618,30 @@ func runCostTokens(cmd *cobra.Command, args []string) error {
 	out := cmd.OutOrStdout()
 	since := time.Now().Add(-period)
 
-	// Initialize log scanners
-	scanner := logs.NewMultiScanner()
-	scanner.Register("claude", logs.NewClaudeScanner())
-	scanner.Register("codex", logs.NewCodexScanner())
-	scanner.Register("gemini", logs.NewGeminiScanner())
+	db, err := getDB()
+	if err != nil {
+		if byProfile {
+			return fmt.Errorf("--by-profile needs the caam database: %w", err)
+		}
+		db = nil
+	}
 
 	var analyses []TokenCostAnalysis
 
 	for _, provider := range providers {
-		providerScanner := scanner.Scanner(provider)
-		if providerScanner == nil {
+		if byProfile {
+			profileAnalyses, err := tokenCostsByProfile(ctx, db, provider, since, period)
+			if err != nil {
+				if format != "json" {
+					fmt.Fprintf(out, "%s: %v\n", provider, err)
+				}
+				continue
+			}
+			analyses = append(analyses, profileAnalyses...)
 			continue
 		}
 
-		result, err := providerScanner.Scan(ctx, "", since)
+		entries, err := tokenEntriesSince(ctx, db, provider, since)
 		if err != nil {
 			if format != "json" {
 				fmt.Fprintf(out, "%s: error scanning logs: %v\n", provider, err)
@@ -546,12 +649,10 @@ func runCostTokens(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		entries := result.Entries
-		if len(entries) == 0 {
+		analysis := analyzeTokenCosts(provider, entries, since, time.Now(), period)
+		if analysis.TotalTokens == 0 {
 			continue
 		}
-
-		analysis := analyzeTokenCosts(provider, entries, since, time.Now(), period)
 		analyses = append(analyses, analysis)
 	}
 
@@ -567,8 +668,210 @@ func runCostTokens(cmd *cobra.Command, args []string) error {
 	return renderTokenCostAnalysis(out, format, analyses)
 }
 
+// defaultLogSource returns the default log location of a provider.
+func defaultLogSource(provider string) (logs.Source, bool) {
+	for _, src := range logs.DefaultSources() {
+		if src.Provider == provider {
+			return src, true
+		}
+	}
+	return logs.Source{}, false
+}
+
+// logSources returns a provider's log sources: its default log location
+// followed by the logs of its isolated profiles.
+func logSources(provider string) []logs.Source {
+	src, ok := defaultLogSource(provider)
+	if !ok {
+		return nil
+	}
+	sources := []logs.Source{src}
+
+	store := profileStore
+	if store == nil {
+		store = profile.NewStore(profile.DefaultStorePath())
+	}
+	profiles, err := store.List(provider)
+	if err != nil {
+		return sources
+	}
+	for _, prof := range profiles {
+		if src, ok := logs.IsolatedSource(provider, prof.Name, prof.HomePath(), prof.CodexHomePath()); ok {
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// ingestLogSources ingests new lines from all of a provider's log sources.
+func ingestLogSources(ctx context.Context, db *caamdb.DB, provider string) (*logs.IngestResult, error) {
+	total := &logs.IngestResult{Provider: provider}
+	for _, src := range logSources(provider) {
+		result, err := logs.IngestSource(ctx, src, db)
+		if err != nil {
+			return total, err
+		}
+		total.Files += result.Files
+		total.Lines += result.Lines
+		total.Entries += result.Entries
+		total.ParseErrors += result.ParseErrors
+	}
+	return total, nil
+}
+
+// newLogScanner returns a scanner for all providers' CLI logs. With a
+// database, new log lines are ingested into it and queries are answered from
+// there instead of re-reading every file.
+func newLogScanner(db *caamdb.DB) *logs.MultiScanner {
+	scanner := logs.NewMultiScanner()
+	for _, src := range logs.DefaultSources() {
+		if db != nil {
+			scanner.Register(src.Provider, logs.NewStoredScanner(src.Provider, src.Scanner, db))
+		} else {
+			scanner.Register(src.Provider, src.Scanner)
+		}
+	}
+	return scanner
+}
+
+// tokenEntriesSince returns a provider's token usage since the given time.
+// With a database, it ingests new log lines and aggregates there; otherwise,
+// or if that fails, it scans the default log files.
+func tokenEntriesSince(ctx context.Context, db *caamdb.DB, provider string, since time.Time) ([]*logs.LogEntry, error) {
+	src, ok := defaultLogSource(provider)
+	if !ok {
+		return nil, nil
+	}
+
+	if db != nil {
+		if _, err := ingestLogSources(ctx, db, provider); err == nil {
+			if entries, err := db.TokenUsageEntries(provider, since); err == nil {
+				return entries, nil
+			}
+		}
+	}
+
+	result, err := src.Scanner.Scan(ctx, "", since)
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// tokenCostsByProfile analyzes a provider's token costs per attributed
+// profile, busiest first, with unattributed usage last.
+func tokenCostsByProfile(ctx context.Context, db *caamdb.DB, provider string, since time.Time, period time.Duration) ([]TokenCostAnalysis, error) {
+	if _, ok := defaultLogSource(provider); !ok {
+		return nil, nil
+	}
+	if _, err := ingestLogSources(ctx, db, provider); err != nil {
+		return nil, fmt.Errorf("ingest logs: %w", err)
+	}
+	entries, err := db.TokenUsageEntries(provider, since)
+	if err != nil {
+		return nil, err
+	}
+	byProfile := make(map[string][]*logs.LogEntry)
+	for _, e := range entries {
+		byProfile[e.Profile] = append(byProfile[e.Profile], e)
+	}
+
+	var analyses []TokenCostAnalysis
+	for name, profileEntries := range byProfile {
+		analysis := analyzeTokenCosts(provider, profileEntries, since, time.Now(), period)
+		if analysis.TotalTokens == 0 {
+			continue
+		}
+		analysis.Profile = name
+		if name == "" {
+			// No subscription to compare unattributed usage against.
+			analysis.Unattributed = true
+			analysis.SubscriptionCost = 0
+			analysis.Savings = 0
+			analysis.SavingsPercent = 0
+		}
+		analyses = append(analyses, analysis)
+	}
+	sort.Slice(analyses, func(i, j int) bool {
+		if analyses[i].Unattributed != analyses[j].Unattributed {
+			return !analyses[i].Unattributed
+		}
+		if analyses[i].TotalTokens != analyses[j].TotalTokens {
+			return analyses[i].TotalTokens > analyses[j].TotalTokens
+		}
+		return analyses[i].Profile < analyses[j].Profile
+	})
+	return analyses, nil
+}
+
+func runCostIngest(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	providers := []string{"claude", "codex", "gemini"}
+	if len(args) > 0 {
+		provider := strings.ToLower(args[0])
+		if _, ok := defaultLogSource(provider); !ok {
+			return fmt.Errorf("unknown provider: %s (supported: claude, codex, gemini)", provider)
+		}
+		providers = []string{provider}
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var results []*logs.IngestResult
+	for _, provider := range providers {
+		result, err := ingestLogSources(ctx, db, provider)
+		if err != nil {
+			return fmt.Errorf("ingest %s logs: %w", provider, err)
+		}
+		results = append(results, result)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		type ingestJSON struct {
+			Provider    string `json:"provider"`
+			Files       int    `json:"files"`
+			Lines       int    `json:"lines"`
+			Entries     int    `json:"entries"`
+			ParseErrors int    `json:"parse_errors"`
+		}
+		rows := make([]ingestJSON, 0, len(results))
+		for _, r := range results {
+			rows = append(rows, ingestJSON{r.Provider, r.Files, r.Lines, r.Entries, r.ParseErrors})
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(out, "%s: %d new lines in %d files, %d usage entries stored", r.Provider, r.Lines, r.Files, r.Entries)
+		if r.ParseErrors > 0 {
+			fmt.Fprintf(out, " (%d unparseable)", r.ParseErrors)
+		}
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// analyzeTokenCosts summarizes token usage, pricing each entry at the rate
+// in effect when it was written.
 func analyzeTokenCosts(provider string, entries []*logs.LogEntry, since, until time.Time, period time.Duration) TokenCostAnalysis {
 	usage := logs.Aggregate(entries)
+	prices := pricing.Default()
+	costByModel := make(map[string]float64)
+	for _, e := range entries {
+		if e != nil && e.Model != "" {
+			costByModel[e.Model] += prices.EntryCost(e, provider)
+		}
+	}
 
 	analysis := TokenCostAnalysis{
 		Provider:          provider,
@@ -598,19 +901,7 @@ func analyzeTokenCosts(provider string, entries []*logs.LogEntry, since, until t
 			mc.Percent = float64(mu.TotalTokens) / float64(usage.TotalTokens) * 100
 		}
 
-		// Calculate API cost for this model
-		modelUsage := &logs.TokenUsage{
-			InputTokens:  mu.InputTokens,
-			OutputTokens: mu.OutputTokens,
-			ByModel:      map[string]*logs.ModelTokenUsage{modelName: mu},
-		}
-		// Distribute cache tokens proportionally if single model
-		if len(usage.ByModel) == 1 {
-			modelUsage.CacheReadTokens = usage.CacheReadTokens
-			modelUsage.CacheCreateTokens = usage.CacheCreateTokens
-		}
-
-		mc.APICost = pricing.CalculateCost(modelUsage, modelName, provider)
+		mc.APICost = costByModel[modelName]
 		totalAPICost += mc.APICost
 
 		modelCosts = append(modelCosts, mc)
@@ -680,7 +971,7 @@ func renderTokenCostTable(w io.Writer, analyses []TokenCostAnalysis) error {
 		}
 
 		// Header
-		fmt.Fprintf(w, "TOKEN COST ANALYSIS - %s (Last %s)\n", strings.ToUpper(a.Provider), a.Period)
+		fmt.Fprintf(w, "TOKEN COST ANALYSIS - %s (Last %s)\n", tokenCostSubject(a), a.Period)
 		fmt.Fprintln(w, strings.Repeat("=", 70))
 
 		// Summary
@@ -746,6 +1037,19 @@ func renderTokenCostTable(w io.Writer, analyses []TokenCostAnalysis) error {
 	return nil
 }
 
+// tokenCostSubject names what an analysis covers: the provider, and with
+// --by-profile, the profile.
+func tokenCostSubject(a TokenCostAnalysis) string {
+	subject := strings.ToUpper(a.Provider)
+	switch {
+	case a.Unattributed:
+		subject += " / (unattributed)"
+	case a.Profile != "":
+		subject += " / " + a.Profile
+	}
+	return subject
+}
+
 func renderTokenCostCSV(w io.Writer, analyses []TokenCostAnalysis) error {
 	cw := csv.NewWriter(w)
 	defer cw.Flush()
@@ -754,7 +1058,7 @@ func renderTokenCostCSV(w io.Writer, analyses []TokenCostAnalysis) error {
 	if err := cw.Write([]string{
 		"provider", "period", "model", "input_tokens", "output_tokens",
 		"cache_read_tokens", "cache_create_tokens", "total_tokens",
-		"usage_percent", "api_cost", "subscription_cost", "savings",
+		"usage_percent", "api_cost", "subscription_cost", "savings", "profile",
 	}); err != nil {
 		return err
 	}
@@ -774,6 +1078,7 @@ func renderTokenCostCSV(w io.Writer, analyses []TokenCostAnalysis) error {
 				fmt.Sprintf("%.2f", mc.APICost),
 				fmt.Sprintf("%.2f", a.SubscriptionCost),
 				fmt.Sprintf("%.2f", a.Savings),
+				a.Profile,
 			}
 			if err := cw.Write(record); err != nil {
 				return err
@@ -795,6 +1100,7 @@ func renderTokenCostCSV(w io.Writer, analyses []TokenCostAnalysis) error {
 				fmt.Sprintf("%.2f", a.TotalAPICost),
 				fmt.Sprintf("%.2f", a.SubscriptionCost),
 				fmt.Sprintf("%.2f", a.Savings),
+				a.Profile,
 			}
 			if err := cw.Write(record); err != nil {
 				return err
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+)
+
+var costROICmd = &cobra.Command{
+	Use:   "roi [provider]",
+	Short: "Compare subscription prices with API-equivalent usage",
+	Long: `Report, per account and calendar month, the API-equivalent value of the
+tokens used (at the prices of the time, see 'caam cost rates --models'), the
+subscription price, their ratio, and the number of days the account was
+rate-limited.
+
+A ratio above 1 means the subscription was cheaper than paying per token.
+Subscription prices come from 'subscriptions' in ~/.caam/config.yaml, falling
+back to typical list prices:
+
+  subscriptions:
+    claude:
+      plan: max
+      monthly_cost: 200
+
+Rate-limited days are the days on which a recorded limit hit or its cooldown
+fell ('caam cooldown list'). The current month is reported to date.
+
+Examples:
+  caam cost roi                       # All providers, last 3 months
+  caam cost roi claude --months 12
+  caam cost roi --format csv > roi.csv
+  caam cost roi --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCostROI,
+}
+
+func init() {
+	costCmd.AddCommand(costROICmd)
+	costROICmd.Flags().Int("months", 3, "number of calendar months to report, including the current one")
+	costROICmd.Flags().StringP("format", "f", "table", "output format: table, json, csv")
+}
+
+// SubscriptionROI compares what one account's subscription cost in a month
+// with what its usage would have cost at API prices.
+type SubscriptionROI struct {
+	Provider         string  `json:"provider"`
+	Profile          string  `json:"profile"`
+	Month            string  `json:"month"`
+	TotalTokens      int64   `json:"total_tokens"`
+	APIValue         float64 `json:"api_value"`
+	SubscriptionCost float64 `json:"subscription_cost"`
+	Ratio            float64 `json:"ratio"`
+	RateLimitedDays  int     `json:"rate_limited_days"`
+}
+
+func runCostROI(cmd *cobra.Command, args []string) error {
+	months, _ := cmd.Flags().GetInt("months")
+	format, _ := cmd.Flags().GetString("format")
+
+	if months < 1 {
+		return fmt.Errorf("--months must be at least 1")
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "table", "", "json", "csv":
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	providers := []string{"claude", "codex", "gemini"}
+	if len(args) > 0 {
+		provider := strings.ToLower(args[0])
+		if _, ok := defaultLogSource(provider); !ok {
+			return fmt.Errorf("unknown provider: %s (supported: claude, codex, gemini)", provider)
+		}
+		providers = []string{provider}
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	var subs map[string]config.SubscriptionConfig
+	if spmCfg, err := config.LoadSPMConfig(); err == nil {
+		subs = spmCfg.Subscriptions
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, now.Location())
+
+	var rows []SubscriptionROI
+	for _, provider := range providers {
+		if _, err := ingestLogSources(ctx, db, provider); err != nil {
+			return fmt.Errorf("ingest %s logs: %w", provider, err)
+		}
+		entries, err := db.TokenUsageEntries(provider, from)
+		if err != nil {
+			return err
+		}
+		// Cooldowns that started before the report can still reach into it.
+		events, err := db.ListLimitEvents(provider, from.AddDate(0, -1, 0))
+		if err != nil {
+			return err
+		}
+		rows = append(rows, subscriptionROI(provider, entries, events, subscriptionPrice(subs, provider), from, now)...)
+	}
+
+	out := cmd.OutOrStdout()
+	switch format {
+	case "json":
+		if rows == nil {
+			rows = []SubscriptionROI{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		return renderROICSV(out, rows)
+	default:
+		if len(rows) == 0 {
+			fmt.Fprintln(out, "No attributed usage or limit hits found for the specified months.")
+			return nil
+		}
+		renderROITable(out, rows)
+		return nil
+	}
+}
+
+// subscriptionPrice returns the monthly price of a provider's subscription:
+// the configured one, or the typical list price.
+func subscriptionPrice(subs map[string]config.SubscriptionConfig, provider string) float64 {
+	if sub, ok := subs[provider]; ok && sub.MonthlyCost > 0 {
+		return sub.MonthlyCost
+	}
+	return subscriptionCosts[provider]
+}
+
+// subscriptionROI builds a provider's rows, one per profile and calendar
+// month (in now's location) from the month of from up to now. Each profile
+// is an account paying price per month. Unattributed usage is left out, as
+// there is no subscription to charge it to.
+func subscriptionROI(provider string, entries []*logs.LogEntry, events []caamdb.CooldownEvent, price float64, from, now time.Time) []SubscriptionROI {
+	loc := now.Location()
+	prices := pricing.Default()
+	type key struct{ profile, month string }
+	usage := make(map[key]*logs.TokenUsage)
+	value := make(map[key]float64)
+	limited := make(map[key]map[string]bool)
+	seen := make(map[key]bool)
+
+	for _, e := range entries {
+		if e.Profile == "" || e.Timestamp.Before(from) || e.Timestamp.After(now) {
+			continue
+		}
+		k := key{e.Profile, e.Timestamp.In(loc).Format("2006-01")}
+		if usage[k] == nil {
+			usage[k] = logs.NewTokenUsage()
+		}
+		usage[k].Add(e)
+		value[k] += prices.EntryCost(e, provider)
+		seen[k] = true
+	}
+
+	for _, ev := range events {
+		if ev.ProfileName == "" {
+			continue
+		}
+		last := ev.CooldownUntil
+		if last.Before(ev.HitAt) {
+			last = ev.HitAt
+		}
+		if last.After(now) {
+			last = now
+		}
+		last = last.In(loc)
+		for day := startOfDay(ev.HitAt.In(loc)); !day.After(last); day = day.AddDate(0, 0, 1) {
+			if day.Before(startOfDay(from.In(loc))) {
+				continue
+			}
+			k := key{ev.ProfileName, day.Format("2006-01")}
+			if limited[k] == nil {
+				limited[k] = make(map[string]bool)
+			}
+			limited[k][day.Format("2006-01-02")] = true
+			seen[k] = true
+		}
+	}
+
+	rows := make([]SubscriptionROI, 0, len(seen))
+	for k := range seen {
+		row := SubscriptionROI{
+			Provider:         provider,
+			Profile:          k.profile,
+			Month:            k.month,
+			SubscriptionCost: price,
+			RateLimitedDays:  len(limited[k]),
+		}
+		if u := usage[k]; u != nil {
+			row.TotalTokens = u.TotalTokens
+		}
+		row.APIValue = value[k]
+		if price > 0 {
+			row.Ratio = row.APIValue / price
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Month != rows[j].Month {
+			return rows[i].Month < rows[j].Month
+		}
+		return rows[i].Profile < rows[j].Profile
+	})
+	return rows
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func renderROITable(w io.Writer, rows []SubscriptionROI) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MONTH\tPROVIDER\tPROFILE\tTOKENS\tAPI VALUE\tSUBSCRIPTION\tRATIO\tLIMITED DAYS")
+	for _, r := range rows {
+		ratio := "-"
+		if r.SubscriptionCost > 0 {
+			ratio = fmt.Sprintf("%.1fx", r.Ratio)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t$%.2f\t$%.2f\t%s\t%d\n",
+			r.Month, r.Provider, r.Profile, formatTokenCount(r.TotalTokens),
+			r.APIValue, r.SubscriptionCost, ratio, r.RateLimitedDays)
+	}
+	tw.Flush()
+}
+
+func renderROICSV(w io.Writer, rows []SubscriptionROI) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"month", "provider", "profile", "total_tokens", "api_value",
+		"subscription_cost", "ratio", "rate_limited_days",
+	}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{
+			r.Month,
+			r.Provider,
+			r.Profile,
+			fmt.Sprintf("%d", r.TotalTokens),
+			fmt.Sprintf("%.2f", r.APIValue),
+			fmt.Sprintf("%.2f", r.SubscriptionCost),
+			fmt.Sprintf("%.2f", r.Ratio),
+			fmt.Sprintf("%d", r.RateLimitedDays),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 )
 
 func TestCostCommand(t *testing.T) {
@@ -416,3 +424,151 @@ func TestRenderTokenCostCSV_WithData(t *testing.T) {
 		t.Error("Expected CSV header row")
 	}
 }
+
+func TestTokenCostsByProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("CODEX_HOME", filepath.Join(tmpDir, "codex"))
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	sandbox, err := profileStore.Create("codex", "sandbox", "oauth")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	db, err := caamdb.OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC()
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "codex", ProfileName: "work", Timestamp: now.Add(-3 * time.Hour)}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+
+	line := func(ts time.Time, tokens int) string {
+		return fmt.Sprintf(`{"timestamp":%q,"event":"response","model":"gpt-4o","usage":{"prompt_tokens":%d,"completion_tokens":0}}`+"\n",
+			ts.Format(time.RFC3339), tokens)
+	}
+	writeLog := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	// Shared logs: one line before the activation, one after.
+	writeLog(filepath.Join(tmpDir, "codex", "logs"), line(now.Add(-4*time.Hour), 5)+line(now.Add(-time.Hour), 100))
+	// The isolated profile's logs belong to it regardless of activations.
+	writeLog(filepath.Join(sandbox.CodexHomePath(), "logs"), line(now.Add(-time.Hour), 40))
+
+	analyses, err := tokenCostsByProfile(context.Background(), db, "codex", now.Add(-24*time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("tokenCostsByProfile() error = %v", err)
+	}
+	if len(analyses) != 3 {
+		t.Fatalf("tokenCostsByProfile() = %d analyses, want 3", len(analyses))
+	}
+	want := []struct {
+		profile string
+		tokens  int64
+	}{{"work", 100}, {"sandbox", 40}, {"", 5}}
+	for i, w := range want {
+		if analyses[i].Profile != w.profile || analyses[i].TotalTokens != w.tokens {
+			t.Errorf("analyses[%d] = %s/%d tokens, want %s/%d", i, analyses[i].Profile, analyses[i].TotalTokens, w.profile, w.tokens)
+		}
+	}
+	if !analyses[2].Unattributed || analyses[2].SubscriptionCost != 0 {
+		t.Errorf("unattributed analysis = %+v, want no subscription cost", analyses[2])
+	}
+	if got := tokenCostSubject(analyses[0]); got != "CODEX / work" {
+		t.Errorf("tokenCostSubject() = %q, want %q", got, "CODEX / work")
+	}
+	if got := tokenCostSubject(analyses[2]); got != "CODEX / (unattributed)" {
+		t.Errorf("tokenCostSubject() = %q, want %q", got, "CODEX / (unattributed)")
+	}
+
+	// A second run ingests nothing new and reports the same totals.
+	again, err := tokenCostsByProfile(context.Background(), db, "codex", now.Add(-24*time.Hour), 24*time.Hour)
+	if err != nil || len(again) != 3 || again[0].TotalTokens != 100 {
+		t.Errorf("second tokenCostsByProfile() = %d analyses, err %v", len(again), err)
+	}
+}
+
+func TestSubscriptionROI(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*logs.LogEntry{
+		{Timestamp: time.Date(2026, 1, 30, 9, 0, 0, 0, time.UTC), Profile: "work", Model: "claude-3-opus", InputTokens: 1_000_000},
+		{Timestamp: time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC), Profile: "work", Model: "claude-3-opus", InputTokens: 10_000_000},
+		{Timestamp: time.Date(2026, 2, 20, 9, 0, 0, 0, time.UTC), Profile: "work", Model: "claude-3-opus", InputTokens: 10_000_000},
+		{Timestamp: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), Profile: "work", Model: "claude-3-opus", InputTokens: 2_000_000},
+		{Timestamp: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), Model: "claude-3-opus", InputTokens: 5_000_000},
+	}
+	events := []caamdb.CooldownEvent{
+		// Spans the month boundary: Feb 28 counts for February, Mar 1 for March.
+		{ProfileName: "work", HitAt: time.Date(2026, 2, 28, 22, 0, 0, 0, time.UTC), CooldownUntil: time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)},
+		{ProfileName: "work", HitAt: time.Date(2026, 2, 20, 10, 0, 0, 0, time.UTC), CooldownUntil: time.Date(2026, 2, 20, 15, 0, 0, 0, time.UTC)},
+		{ProfileName: "work", HitAt: time.Date(2026, 2, 20, 18, 0, 0, 0, time.UTC), CooldownUntil: time.Date(2026, 2, 20, 23, 0, 0, 0, time.UTC)},
+		{ProfileName: "spare", HitAt: time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC), CooldownUntil: time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)},
+	}
+
+	rows := subscriptionROI("claude", entries, events, 200, from, now)
+	want := []SubscriptionROI{
+		{Provider: "claude", Profile: "work", Month: "2026-02", TotalTokens: 20_000_000, APIValue: 300, SubscriptionCost: 200, Ratio: 1.5, RateLimitedDays: 2},
+		{Provider: "claude", Profile: "spare", Month: "2026-03", SubscriptionCost: 200, RateLimitedDays: 1},
+		{Provider: "claude", Profile: "work", Month: "2026-03", TotalTokens: 2_000_000, APIValue: 30, SubscriptionCost: 200, Ratio: 0.15, RateLimitedDays: 1},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("subscriptionROI() = %+v, want %d rows", rows, len(want))
+	}
+	for i, w := range want {
+		got := rows[i]
+		if got.Profile != w.Profile || got.Month != w.Month || got.TotalTokens != w.TotalTokens ||
+			got.RateLimitedDays != w.RateLimitedDays || got.SubscriptionCost != w.SubscriptionCost ||
+			math.Abs(got.APIValue-w.APIValue) > 0.01 || math.Abs(got.Ratio-w.Ratio) > 0.001 {
+			t.Errorf("rows[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := renderROICSV(&buf, rows); err != nil {
+		t.Fatalf("renderROICSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[1] != "2026-02,claude,work,20000000,300.00,200.00,1.50,2" {
+		t.Errorf("renderROICSV() = %q", buf.String())
+	}
+}
+
+func TestSubscriptionPrice(t *testing.T) {
+	subs := map[string]config.SubscriptionConfig{"gemini": {Plan: "ultra", MonthlyCost: 275}}
+	if got := subscriptionPrice(subs, "gemini"); got != 275 {
+		t.Errorf("subscriptionPrice(gemini) = %v, want 275", got)
+	}
+	if got := subscriptionPrice(subs, "claude"); got != subscriptionCosts["claude"] {
+		t.Errorf("subscriptionPrice(claude) = %v, want list price", got)
+	}
+}
+
+func TestRenderModelRates(t *testing.T) {
+	rates := []pricing.Rate{
+		{Provider: "claude", Model: "claude-3-opus", InputPer1M: 15, OutputPer1M: 75, Source: pricing.BuiltinSource},
+		{Provider: "claude", Model: "claude-3-opus", InputPer1M: 12, OutputPer1M: 75,
+			EffectiveFrom: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Source: "/home/u/.caam/pricing.yaml"},
+	}
+	var buf bytes.Buffer
+	renderModelRates(&buf, rates)
+	out := buf.String()
+	for _, want := range []string{"CACHE WRITE", "built-in", "2026-11-01", "/home/u/.caam/pricing.yaml"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderModelRates() missing %q:\n%s", want, out)
+		}
+	}
+}
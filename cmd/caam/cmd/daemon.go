@@ -72,6 +72,7 @@ func init() {
 	daemonStartCmd.Flags().Duration("threshold", daemon.DefaultRefreshThreshold, "refresh threshold (how long before expiry to refresh)")
 	daemonStartCmd.Flags().BoolP("verbose", "v", false, "verbose logging")
 	daemonStartCmd.Flags().Bool("pool", false, "enable auth pool for proactive token monitoring")
+	daemonStartCmd.Flags().Duration("vault-key-timeout", 0, "how long to cache an unlocked vault key (default: daemon.vault_key_timeout)")
 
 	// Logs flags
 	daemonLogsCmd.Flags().IntP("lines", "n", 50, "number of lines to show")
@@ -84,12 +85,16 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	threshold, _ := cmd.Flags().GetDuration("threshold")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	usePool, _ := cmd.Flags().GetBool("pool")
+	keyTimeout, _ := cmd.Flags().GetDuration("vault-key-timeout")
 
 	// Load global config to check for PID file setting
 	if spmCfg, err := config.LoadSPMConfig(); err == nil {
 		if spmCfg.Runtime.PIDFilePath != "" {
 			daemon.SetPIDFilePath(spmCfg.Runtime.PIDFilePath)
 		}
+		if keyTimeout <= 0 {
+			keyTimeout = spmCfg.Daemon.VaultKeyTimeout.Duration()
+		}
 	}
 
 	// Check if daemon is already running
@@ -102,13 +107,13 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	}
 
 	if foreground {
-		return runDaemonForeground(interval, threshold, verbose, usePool)
+		return runDaemonForeground(interval, threshold, keyTimeout, verbose, usePool)
 	}
 
-	return runDaemonBackground(interval, threshold, verbose, usePool)
+	return runDaemonBackground(interval, threshold, keyTimeout, verbose, usePool)
 }
 
-func runDaemonForeground(interval, threshold time.Duration, verbose, usePool bool) error {
+func runDaemonForeground(interval, threshold, keyTimeout time.Duration, verbose, usePool bool) error {
 	fmt.Println("Starting daemon in foreground mode...")
 	if usePool {
 		fmt.Println("Auth pool enabled")
@@ -124,6 +129,8 @@ func runDaemonForeground(interval, threshold time.Duration, verbose, usePool boo
 		RefreshThreshold: threshold,
 		Verbose:          verbose,
 		UseAuthPool:      usePool,
+		VaultKeyTimeout:  keyTimeout,
+		IngestLogs:       true,
 	}
 
 	d := daemon.New(v, hs, cfg)
@@ -131,12 +138,15 @@ func runDaemonForeground(interval, threshold time.Duration, verbose, usePool boo
 	return d.Start()
 }
 
-func runDaemonBackground(interval, threshold time.Duration, verbose, usePool bool) error {
+func runDaemonBackground(interval, threshold, keyTimeout time.Duration, verbose, usePool bool) error {
 	// Build the command to run in background
 	args := []string{"daemon", "start", "--fg",
 		"--interval", interval.String(),
 		"--threshold", threshold.String(),
 	}
+	if keyTimeout > 0 {
+		args = append(args, "--vault-key-timeout", keyTimeout.String())
+	}
 	if verbose {
 		args = append(args, "--verbose")
 	}
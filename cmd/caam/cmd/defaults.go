@@ -29,7 +29,7 @@ Use 'caam which' to see current defaults.`,
 
 		// Validate provider
 		if _, ok := tools[provider]; !ok {
-			return fmt.Errorf("unknown provider: %s (supported: codex, claude, gemini)", provider)
+			return fmt.Errorf("unknown provider: %s (supported: codex, claude, gemini, opencode)", provider)
 		}
 
 		// Check if vault profile exists
@@ -92,7 +92,7 @@ Examples:
 Use 'caam use <provider> <profile>' to set defaults.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		providers := []string{"codex", "claude", "gemini"}
+		providers := []string{"codex", "claude", "gemini", "opencode"}
 
 		if len(args) > 0 {
 			provider := strings.ToLower(args[0])
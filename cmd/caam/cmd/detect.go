@@ -121,6 +121,10 @@ func getAgentSpecs() []AgentSpec {
 	if xdgConfigHome == "" {
 		xdgConfigHome = filepath.Join(homeDir, ".config")
 	}
+	xdgDataHome := os.Getenv("XDG_DATA_HOME")
+	if xdgDataHome == "" {
+		xdgDataHome = filepath.Join(homeDir, ".local", "share")
+	}
 	claudeConfigDir := os.Getenv("CLAUDE_CONFIG_DIR")
 	if claudeConfigDir == "" {
 		claudeConfigDir = filepath.Join(xdgConfigHome, "claude-code")
@@ -226,6 +230,7 @@ func getAgentSpecs() []AgentSpec {
 			},
 			AuthPaths: func() []PathSpec {
 				return []PathSpec{
+					{filepath.Join(xdgDataHome, "opencode", "auth.json"), "OpenCode provider credentials"},
 					{filepath.Join(homeDir, ".opencode", "config.json"), "OpenCode config"},
 					{filepath.Join(xdgConfigHome, "opencode", "config.json"), "OpenCode XDG config"},
 				}
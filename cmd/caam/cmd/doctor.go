@@ -4,11 +4,13 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	osexec "os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,9 +20,6 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
-	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
-	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
-	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
 )
 
 // CheckResult represents the result of a single diagnostic check.
@@ -45,8 +44,12 @@ type DoctorReport struct {
 	Config          []CheckResult `json:"config"`
 	Profiles        []CheckResult `json:"profiles"`
 	Locks           []CheckResult `json:"locks"`
+	Activations     []CheckResult `json:"activations"`
 	AuthFiles       []CheckResult `json:"auth_files"`
 	TokenValidation []CheckResult `json:"token_validation,omitempty"`
+
+	// Capabilities lists what caam can do for each registered provider.
+	Capabilities map[string]provider.Capabilities `json:"capabilities"`
 }
 
 // DependencySpec defines an optional external dependency with install hints.
@@ -91,11 +94,13 @@ Checks performed:
   - Config: Is the configuration valid?
   - Profiles: Are all isolated profiles valid? Any broken symlinks?
   - Locks: Are there any stale lock files from crashed processes?
+  - Activations: Did a crash leave a profile switch half-applied?
   - Auth files: Do auth files exist for each provider?
   - Token validation (with --validate): Are auth tokens actually valid?
 
 Flags:
-  --fix       Attempt to fix issues (create directories, clean stale locks)
+  --fix       Attempt to fix issues (create directories, clean stale locks,
+              repair interrupted activations)
   --json      Output results in JSON format for scripting
   --validate  Validate that auth tokens actually work (passive check, no API calls)
   --auto      Automatically install missing optional dependencies (prompts for confirmation unless --yes)
@@ -160,9 +165,17 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 	// Check locks
 	report.Locks = checkLocks(fix)
 
+	// Check activation journals
+	report.Activations = checkActivationJournals(fix)
+
 	// Check auth files
 	report.AuthFiles = checkAuthFiles()
 
+	report.Capabilities = make(map[string]provider.Capabilities)
+	for _, p := range providerRegistry().All() {
+		report.Capabilities[p.ID()] = p.Capabilities()
+	}
+
 	// Check token validation (if requested)
 	if validate {
 		report.TokenValidation = checkTokenValidation()
@@ -174,6 +187,7 @@ func runDoctorChecks(fix bool, validate bool, autoInstall bool, skipConfirm bool
 	allChecks = append(allChecks, report.Config...)
 	allChecks = append(allChecks, report.Profiles...)
 	allChecks = append(allChecks, report.Locks...)
+	allChecks = append(allChecks, report.Activations...)
 	allChecks = append(allChecks, report.AuthFiles...)
 	allChecks = append(allChecks, report.TokenValidation...)
 
@@ -900,6 +914,91 @@ func checkLocks(fix bool) []CheckResult {
 	return results
 }
 
+// checkActivationJournals reports activations torn by a crash (some auth
+// files switched, others not) and, with fix, rolls them forward or back.
+func checkActivationJournals(fix bool) []CheckResult {
+	var results []CheckResult
+
+	// Guard against nil vault (e.g., in tests)
+	if vault == nil {
+		return results
+	}
+
+	journals, err := vault.PendingJournals()
+	if err != nil {
+		return append(results, CheckResult{
+			Name:    "activation journal",
+			Status:  "warn",
+			Message: "could not read activation journal",
+			Details: err.Error(),
+		})
+	}
+
+	for _, j := range journals {
+		name := fmt.Sprintf("activation %s", j.ID)
+		msg := "interrupted before any file was switched"
+		if j.Complete() {
+			name = fmt.Sprintf("%s/%s activation", j.Tool, j.Profile)
+			msg = fmt.Sprintf("torn activation from %s (%d files)", j.StartedAt.Local().Format(time.RFC3339), len(j.Entries))
+		}
+
+		if !fix {
+			results = append(results, CheckResult{
+				Name:    name,
+				Status:  "fail",
+				Message: msg,
+				Details: "Run with --fix to repair",
+			})
+			continue
+		}
+
+		results = append(results, repairActivationJournal(vault, j, name))
+	}
+
+	if len(results) == 0 {
+		results = append(results, CheckResult{
+			Name:    "activation journal",
+			Status:  "pass",
+			Message: "no interrupted activations",
+		})
+	}
+
+	return results
+}
+
+func repairActivationJournal(v *authfile.Vault, j *authfile.ActivationJournal, name string) CheckResult {
+	r := v.RecoverJournal(j)
+	if errors.Is(r.Err, authfile.ErrLiveFileChanged) {
+		// Newer writes (a login, a refresh, the tool itself) win over the
+		// interrupted activation.
+		if err := j.Discard(); err != nil {
+			return CheckResult{Name: name, Status: "fail", Message: "could not discard activation journal", Details: err.Error()}
+		}
+		return CheckResult{
+			Name:    name,
+			Status:  "fixed",
+			Message: "discarded interrupted activation; live files changed since",
+			Details: fmt.Sprintf("%v; re-run 'caam activate %s %s' if needed", r.Err, j.Tool, j.Profile),
+		}
+	}
+	if r.Err != nil {
+		return CheckResult{
+			Name:    name,
+			Status:  "fail",
+			Message: "could not repair torn activation",
+			Details: r.Err.Error(),
+		}
+	}
+	msg := "discarded incomplete activation journal"
+	switch r.Action {
+	case authfile.JournalRolledForward:
+		msg = "completed interrupted activation"
+	case authfile.JournalRolledBack:
+		msg = "rolled back interrupted activation"
+	}
+	return CheckResult{Name: name, Status: "fixed", Message: msg}
+}
+
 func checkAuthFiles() []CheckResult {
 	var results []CheckResult
 
@@ -958,11 +1057,7 @@ func checkTokenValidation() []CheckResult {
 		return results
 	}
 
-	// Build provider registry for validation
-	reg := provider.NewRegistry()
-	reg.Register(claude.New())
-	reg.Register(codex.New())
-	reg.Register(gemini.New())
+	reg := providerRegistry()
 
 	// Get all profiles and validate tokens
 	allProfiles, err := profileStore.ListAll()
@@ -1096,6 +1191,13 @@ func printDoctorReport(report *DoctorReport, validate bool) {
 	}
 	fmt.Println()
 
+	// Activations
+	fmt.Println("Checking activation journal...")
+	for _, check := range report.Activations {
+		printCheck(check)
+	}
+	fmt.Println()
+
 	// Auth Files
 	fmt.Println("Checking auth files...")
 	for _, check := range report.AuthFiles {
@@ -1103,6 +1205,20 @@ func printDoctorReport(report *DoctorReport, validate bool) {
 	}
 	fmt.Println()
 
+	// Capabilities
+	if len(report.Capabilities) > 0 {
+		fmt.Println("Provider capabilities...")
+		ids := make([]string, 0, len(report.Capabilities))
+		for id := range report.Capabilities {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			printCapabilities(id, report.Capabilities[id])
+		}
+		fmt.Println()
+	}
+
 	// Token Validation (only if --validate was used)
 	if validate && len(report.TokenValidation) > 0 {
 		fmt.Println("Validating tokens...")
@@ -1150,3 +1266,17 @@ func printCheck(check CheckResult) {
 		fmt.Printf("      %s\n", check.Details)
 	}
 }
+
+// printCapabilities prints the optional features caam supports for a provider.
+func printCapabilities(id string, caps provider.Capabilities) {
+	supported := caps.List()
+	if len(supported) == 0 {
+		fmt.Printf("  - %s: auth file switching only\n", id)
+		return
+	}
+	names := make([]string, len(supported))
+	for i, c := range supported {
+		names[i] = string(c)
+	}
+	fmt.Printf("  - %s: %s\n", id, strings.Join(names, ", "))
+}
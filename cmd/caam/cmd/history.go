@@ -13,10 +13,14 @@ import (
 )
 
 var historyCmd = &cobra.Command{
-	Use:   "history",
-	Short: "List recent activity events",
+	Use:   "history [tool profile]",
+	Short: "List recent activity events or a profile's revisions",
 	Long: `Show recent account activity from the event log.
 
+With a tool and profile, list the vault revisions of that profile instead:
+every backup, refresh, sync pull and import keeps the previous auth files,
+which 'caam restore-revision' can bring back.
+
 Examples:
   caam history                     # Show last 20 events
   caam history --limit 50          # Show last 50 events
@@ -24,9 +28,15 @@ Examples:
   caam history --type error        # Show only errors
   caam history --since 24h         # Events from last 24 hours
   caam history --json              # Output as JSON
+  caam history claude work         # Revisions of the claude/work profile
 
 Event types: activate, login, refresh, error, switch, deactivate`,
-	Args: cobra.NoArgs,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("accepts no arguments or <tool> <profile>, received %d", len(args))
+		}
+		return nil
+	},
 	RunE: runHistory,
 }
 
@@ -41,6 +51,10 @@ func init() {
 }
 
 func runHistory(cmd *cobra.Command, args []string) error {
+	if len(args) == 2 {
+		return runProfileHistory(cmd, args[0], args[1])
+	}
+
 	limit, _ := cmd.Flags().GetInt("limit")
 	providerFilter, _ := cmd.Flags().GetString("provider")
 	profileFilter, _ := cmd.Flags().GetString("profile")
@@ -463,3 +463,39 @@ func TestRenderEventList(t *testing.T) {
 		t.Error("output should contain event types")
 	}
 }
+
+func TestHistory_ProfileRevisions(t *testing.T) {
+	tmpDir, cleanup := setupHistoryTestEnv(t)
+	defer cleanup()
+
+	authPath := filepath.Join(tmpDir, "codex_home", "auth.json")
+	fileSet := authfile.AuthFileSet{
+		Tool:  "codex",
+		Files: []authfile.AuthFileSpec{{Tool: "codex", Path: authPath, Required: true}},
+	}
+	for _, token := range []string{"first", "second"} {
+		if err := os.WriteFile(authPath, []byte(`{"token":"`+token+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := vault.Backup(fileSet, "work"); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().IntP("limit", "n", 20, "")
+	cmd.Flags().Bool("json", false, "")
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	if err := runHistory(cmd, []string{"codex", "work"}); err != nil {
+		t.Fatalf("runHistory() error = %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "REVISION") || strings.Count(output, "backup") != 2 {
+		t.Errorf("expected two backup revisions, got: %s", output)
+	}
+	if !strings.Contains(output, "~auth.json") {
+		t.Errorf("expected auth.json change marker, got: %s", output)
+	}
+}
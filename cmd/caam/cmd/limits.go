@@ -13,7 +13,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
-	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/prediction"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
 
@@ -30,9 +32,15 @@ Examples:
   caam limits                     # Show limits for all providers
   caam limits claude              # Show Claude limits only
   caam limits codex               # Show Codex limits only
+  caam limits gemini              # Show Gemini limits only
   caam limits --profile work      # Show limits for a specific profile
   caam limits --format json       # Output as JSON
-  caam limits --best              # Show the best profile for rotation`,
+  caam limits --best              # Show the best profile for rotation
+  caam limits --forecast          # Predict when each profile runs out
+
+Forecasts follow each profile's usual hour-of-day and day-of-week usage,
+learned from the token usage caam has recorded, and report an 80% interval
+for when the quota will likely be exhausted.`,
 	RunE: runLimits,
 }
 
@@ -56,9 +64,13 @@ func runLimits(cmd *cobra.Command, args []string) error {
 
 	var providers []string
 	if len(args) > 0 {
-		providers = []string{strings.ToLower(args[0])}
+		tool := strings.ToLower(args[0])
+		if err := providerRegistry().Require(tool, provider.CapUsageAPI); err != nil {
+			return err
+		}
+		providers = []string{tool}
 	} else {
-		providers = []string{"claude", "codex", "gemini"}
+		providers = providersWith(provider.CapUsageAPI)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
@@ -68,12 +80,11 @@ func runLimits(cmd *cobra.Command, args []string) error {
 	out := cmd.OutOrStdout()
 
 	// Initialize log scanners for burn rate calculation
-	scanner := logs.NewMultiScanner()
-	scanner.Register("claude", logs.NewClaudeScanner())
-	scanner.Register("codex", logs.NewCodexScanner())
-	scanner.Register("gemini", logs.NewGeminiScanner())
-
-	fetcher := usage.NewMultiProfileFetcher(usage.WithLogScanner(scanner))
+	db, err := getDB()
+	if err != nil {
+		db = nil
+	}
+	fetcher := newUsageFetcher(db)
 
 	allResults := make([]usage.ProfileUsage, 0)
 
@@ -118,34 +129,15 @@ func runLimits(cmd *cobra.Command, args []string) error {
 	}
 
 	if showForecast {
-		return renderForecast(out, format, allResults)
+		return renderForecast(out, format, allResults, newForecastEngine(ctx, db, providers))
 	}
 
 	return renderLimits(out, format, allResults)
 }
 
 func getProfileToken(vaultDir, provider, profileName string) (string, error) {
-	profileDir := filepath.Join(vaultDir, provider, profileName)
-
-	switch provider {
-	case "claude":
-		// Try new location first
-		credPath := filepath.Join(profileDir, ".credentials.json")
-		token, _, err := usage.ReadClaudeCredentials(credPath)
-		if err == nil {
-			return token, nil
-		}
-		// Fall back to old location
-		oldPath := filepath.Join(profileDir, ".claude.json")
-		token, _, err = usage.ReadClaudeCredentials(oldPath)
-		return token, err
-	case "codex":
-		authPath := filepath.Join(profileDir, "auth.json")
-		token, _, err := usage.ReadCodexCredentials(authPath)
-		return token, err
-	default:
-		return "", fmt.Errorf("unsupported provider: %s", provider)
-	}
+	token, _, err := usage.ReadProfileToken(filepath.Join(vaultDir, provider, profileName), provider)
+	return token, err
 }
 
 func getVaultDir() string {
@@ -348,6 +340,12 @@ type Forecast struct {
 	SecondaryResetsIn string `json:"secondary_resets_in"`
 	SafeToUseIn       string `json:"safe_to_use_in,omitempty"`
 	Recommendation    string `json:"recommendation"`
+
+	// Likely exhaustion before the window resets, as an 80% interval.
+	// ExhaustedBy is unset if the quota may last until the reset.
+	ExhaustedFrom *time.Time `json:"likely_exhausted_from,omitempty"`
+	ExhaustedBy   *time.Time `json:"likely_exhausted_by,omitempty"`
+	Seasonal      bool       `json:"seasonal,omitempty"`
 }
 
 func renderRecommendations(w io.Writer, format string, results []usage.ProfileUsage, threshold float64) error {
@@ -488,10 +486,28 @@ func generateLimitsRecommendations(results []usage.ProfileUsage, threshold float
 	return recs
 }
 
-func renderForecast(w io.Writer, format string, results []usage.ProfileUsage) error {
+// newUsageFetcher returns a usage fetcher that estimates burn rates from the
+// local logs (stored in db when it is not nil).
+func newUsageFetcher(db *caamdb.DB) *usage.MultiProfileFetcher {
+	return usage.NewMultiProfileFetcher(usage.WithLogScanner(newLogScanner(db)), usage.WithVaultDir(getVaultDir()))
+}
+
+// newForecastEngine returns a prediction engine that learns usage patterns
+// from the database, after ingesting new log lines for the providers.
+func newForecastEngine(ctx context.Context, db *caamdb.DB, providers []string) *prediction.PredictionEngine {
+	if db == nil {
+		return prediction.NewPredictionEngine()
+	}
+	for _, p := range providers {
+		_, _ = ingestLogSources(ctx, db, p)
+	}
+	return prediction.NewPredictionEngine(prediction.WithHistory(db, 0))
+}
+
+func renderForecast(w io.Writer, format string, results []usage.ProfileUsage, engine *prediction.PredictionEngine) error {
 	format = strings.ToLower(strings.TrimSpace(format))
 
-	forecasts := generateForecasts(results)
+	forecasts := generateForecasts(results, engine)
 
 	switch format {
 	case "json":
@@ -518,6 +534,9 @@ func renderForecast(w io.Writer, format string, results []usage.ProfileUsage) er
 			if f.SafeToUseIn != "" {
 				fmt.Fprintf(w, "  Safe to use in: %s\n", f.SafeToUseIn)
 			}
+			if line := exhaustionLine(f, time.Now()); line != "" {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
 			fmt.Fprintf(w, "  Recommendation: %s\n", f.Recommendation)
 			fmt.Fprintln(w)
 		}
@@ -529,7 +548,34 @@ func renderForecast(w io.Writer, format string, results []usage.ProfileUsage) er
 	}
 }
 
-func generateForecasts(results []usage.ProfileUsage) []Forecast {
+// exhaustionLine describes when a forecast's quota will likely run out.
+func exhaustionLine(f Forecast, now time.Time) string {
+	if f.ExhaustedFrom == nil {
+		return ""
+	}
+	var line string
+	if f.ExhaustedBy != nil {
+		line = fmt.Sprintf("Likely exhausted between %s and %s", formatForecastTime(*f.ExhaustedFrom, now), formatForecastTime(*f.ExhaustedBy, now))
+	} else {
+		line = fmt.Sprintf("May be exhausted from %s, or last until reset", formatForecastTime(*f.ExhaustedFrom, now))
+	}
+	if f.Seasonal {
+		line += " (usual usage pattern)"
+	}
+	return line
+}
+
+// formatForecastTime formats t in local time, with the weekday unless it is
+// today.
+func formatForecastTime(t, now time.Time) string {
+	t, now = t.Local(), now.Local()
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+	return t.Format("Mon 15:04")
+}
+
+func generateForecasts(results []usage.ProfileUsage, engine *prediction.PredictionEngine) []Forecast {
 	var forecasts []Forecast
 
 	for _, r := range results {
@@ -569,8 +615,34 @@ func generateForecasts(results []usage.ProfileUsage) []Forecast {
 			f.Recommendation += " (watch secondary limit)"
 		}
 
+		if engine != nil {
+			if window := r.Usage.MostConstrainedWindow(); window != nil {
+				setExhaustion(&f, engine.Predict(context.Background(), r.Usage), window.ResetsAt)
+			}
+		}
+
 		forecasts = append(forecasts, f)
 	}
 
 	return forecasts
 }
+
+// setExhaustion fills in when a forecast's quota will likely run out, if
+// that may happen before the window resets at resetsAt (zero if unknown).
+func setExhaustion(f *Forecast, pred *prediction.Prediction, resetsAt time.Time) {
+	if pred == nil || pred.Error != "" || pred.PredictedTime.IsZero() {
+		return
+	}
+	from := pred.EarliestTime
+	if from.IsZero() {
+		from = pred.PredictedTime
+	}
+	if !resetsAt.IsZero() && !from.Before(resetsAt) {
+		return
+	}
+	f.ExhaustedFrom = &from
+	if by := pred.LatestTime; !by.IsZero() && (resetsAt.IsZero() || by.Before(resetsAt)) {
+		f.ExhaustedBy = &by
+	}
+	f.Seasonal = pred.Seasonal
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/prediction"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/spf13/cobra"
@@ -25,13 +26,22 @@ Uses the configured rotation algorithm to select the next profile:
   smart       - Multi-factor scoring (health, cooldown, recency) [default]
   round_robin - Sequential rotation through profiles
   random      - Random selection
+  reset_aware - Spend quota that resets soonest, keep distant resets in reserve
 
 Examples:
   caam next claude      # Switch to next healthy Claude profile
   caam next codex       # Switch to next healthy Codex profile
   caam next gemini      # Switch to next healthy Gemini profile
   caam next claude --dry-run   # Show what would be selected
-  caam next claude -q   # Quiet mode, minimal output`,
+  caam next claude -n --explain  # Show every candidate's score and exclusions
+  caam next claude -q   # Quiet mode, minimal output
+  caam next claude --model opus  # Skip profiles whose Opus limit is used up
+
+--model scores profiles on the quota window of that model (for example Claude's
+separate Opus limit) and implies --usage-aware.
+
+Per-profile weights, caps and reserve profiles (see 'caam rotation set') are
+honored by every algorithm; --explain shows when a cap excluded a profile.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runNext,
 }
@@ -40,8 +50,10 @@ func init() {
 	nextCmd.Flags().BoolP("dry-run", "n", false, "show next profile without switching")
 	nextCmd.Flags().BoolP("quiet", "q", false, "minimal output")
 	nextCmd.Flags().Bool("force", false, "activate even if profile is in cooldown")
-	nextCmd.Flags().String("algorithm", "", "override rotation algorithm (smart, round_robin, random)")
+	nextCmd.Flags().String("algorithm", "", "override rotation algorithm (smart, round_robin, random, reset_aware)")
 	nextCmd.Flags().Bool("usage-aware", false, "fetch real-time rate limits to inform selection")
+	nextCmd.Flags().Bool("explain", false, "show scores and exclusion reasons for every candidate")
+	nextCmd.Flags().String("model", "", "model the session will use (selects on that model's quota window)")
 	rootCmd.AddCommand(nextCmd)
 }
 
@@ -52,11 +64,13 @@ func runNext(cmd *cobra.Command, args []string) error {
 	force, _ := cmd.Flags().GetBool("force")
 	algoOverride, _ := cmd.Flags().GetString("algorithm")
 	usageAware, _ := cmd.Flags().GetBool("usage-aware")
+	explain, _ := cmd.Flags().GetBool("explain")
+	model, _ := cmd.Flags().GetString("model")
 
 	// Validate tool
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
 	}
 
 	// Ensure vault is initialized
@@ -120,17 +134,21 @@ func runNext(cmd *cobra.Command, args []string) error {
 		defer db.Close()
 	}
 
-	// Fetch usage data if --usage-aware is set
+	// Fetch usage data if --usage-aware is set; reset_aware and --model
+	// always need it.
 	var usageData map[string]*rotation.UsageInfo
-	if usageAware && (tool == "claude" || tool == "codex") {
+	if spmCfg.Stealth.Rotation.Algorithm == string(rotation.AlgorithmResetAware) || model != "" {
+		usageAware = true
+	}
+	if usageAware && supportsUsageAPI(tool) {
 		if !quiet {
 			fmt.Printf("Fetching real-time usage data for %d profiles...\n", len(profiles))
 		}
-		usageData = fetchUsageDataForProfiles(tool, profiles)
+		usageData = fetchUsageDataForProfiles(tool, profiles, model, db)
 	}
 
 	// Select next profile using rotation
-	selection, err := selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData)
+	selection, err := selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData, model)
 	if err != nil {
 		return err
 	}
@@ -139,7 +157,7 @@ func runNext(cmd *cobra.Command, args []string) error {
 	// use round_robin to force rotation to a different profile.
 	if selection.Selected == currentProfile && len(profiles) > 1 {
 		spmCfg.Stealth.Rotation.Algorithm = "round_robin"
-		selection, err = selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData)
+		selection, err = selectProfileWithRotationAndUsage(tool, profiles, currentProfile, spmCfg, db, usageData, model)
 		if err != nil {
 			return err
 		}
@@ -153,7 +171,15 @@ func runNext(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Current: %s (no active profile)\n", tool)
 		}
 		fmt.Printf("Next:    %s/%s\n", tool, selection.Selected)
-		fmt.Println(rotation.FormatResult(selection))
+		if explain {
+			if text := explainSelectionPolicy(tool, profiles, model); text != "" {
+				fmt.Println(text)
+				fmt.Println()
+			}
+			fmt.Println(rotation.FormatExplanation(selection))
+		} else {
+			fmt.Println(rotation.FormatResult(selection))
+		}
 	}
 
 	// Dry-run: stop here
@@ -214,8 +240,10 @@ func pluralize(n int) string {
 	return "s"
 }
 
-// fetchUsageDataForProfiles fetches real-time usage data for all profiles.
-func fetchUsageDataForProfiles(tool string, profiles []string) map[string]*rotation.UsageInfo {
+// fetchUsageDataForProfiles fetches real-time usage data for all profiles,
+// scoped to model when one is given. Burn rates come from the local logs and
+// usage history in db (which may be nil).
+func fetchUsageDataForProfiles(tool string, profiles []string, model string, db *caamdb.DB) map[string]*rotation.UsageInfo {
 	vaultDir := authfile.DefaultVaultPath()
 	credentials, err := usage.LoadProfileCredentials(vaultDir, tool)
 	if err != nil || len(credentials) == 0 {
@@ -225,36 +253,33 @@ func fetchUsageDataForProfiles(tool string, profiles []string) map[string]*rotat
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	fetcher := usage.NewMultiProfileFetcher()
-	results := fetcher.FetchAllProfiles(ctx, tool, credentials)
+	results := newUsageFetcher(db).FetchAllProfiles(ctx, tool, credentials)
 
+	return rotationUsage(results, model, newForecastEngine(ctx, db, []string{tool}))
+}
+
+// rotationUsage converts fetched usage into rotation selector input, using
+// engine to estimate each profile's burn rate. A non-empty model scopes
+// usage to the windows that limit that model.
+func rotationUsage(results []usage.ProfileUsage, model string, engine *prediction.PredictionEngine) map[string]*rotation.UsageInfo {
 	usageData := make(map[string]*rotation.UsageInfo)
 	for _, r := range results {
 		if r.Usage == nil {
 			continue
 		}
 
-		info := &rotation.UsageInfo{
-			ProfileName: r.ProfileName,
-			AvailScore:  r.Usage.AvailabilityScore(),
-			Error:       r.Usage.Error,
-		}
-
-		if r.Usage.PrimaryWindow != nil {
-			info.PrimaryPercent = r.Usage.PrimaryWindow.UsedPercent
+		var burnRate float64
+		if pred := engine.Predict(context.Background(), r.Usage.ForModel(model)); pred.BurnRate != nil {
+			burnRate = pred.BurnRate.PercentPerHour
 		}
-		if r.Usage.SecondaryWindow != nil {
-			info.SecondaryPercent = r.Usage.SecondaryWindow.UsedPercent
-		}
-
-		usageData[r.ProfileName] = info
+		usageData[r.ProfileName] = rotation.UsageForModel(r.ProfileName, r.Usage, model, burnRate)
 	}
 
 	return usageData
 }
 
 // selectProfileWithRotationAndUsage selects a profile using rotation with optional usage data.
-func selectProfileWithRotationAndUsage(tool string, profiles []string, currentProfile string, spmCfg *config.SPMConfig, db *caamdb.DB, usageData map[string]*rotation.UsageInfo) (*rotation.Result, error) {
+func selectProfileWithRotationAndUsage(tool string, profiles []string, currentProfile string, spmCfg *config.SPMConfig, db *caamdb.DB, usageData map[string]*rotation.UsageInfo, model string) (*rotation.Result, error) {
 	if len(profiles) == 0 {
 		return nil, fmt.Errorf("no profiles found for %s; create one with 'caam backup %s <name>'", tool, tool)
 	}
@@ -269,6 +294,10 @@ func selectProfileWithRotationAndUsage(tool string, profiles []string, currentPr
 	}
 
 	selector := rotation.NewSelector(algorithm, healthStore, db)
+	applyRotationPolicies(selector, db, tool, profiles)
+	if err := applySelectionPolicy(selector, model); err != nil {
+		return nil, err
+	}
 
 	// Set usage data if available
 	if usageData != nil {
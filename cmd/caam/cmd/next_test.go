@@ -9,6 +9,8 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/prediction"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/spf13/cobra"
 )
 
@@ -308,3 +310,30 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRotationUsage_CarriesResetsAndBurnRate(t *testing.T) {
+	resetsAt := time.Now().Add(2 * time.Hour)
+	results := []usage.ProfileUsage{
+		{
+			ProfileName: "work",
+			Usage: &usage.UsageInfo{
+				ProfileName:   "work",
+				PrimaryWindow: &usage.UsageWindow{Utilization: 0.4, UsedPercent: 40, ResetsAt: resetsAt},
+				BurnRate:      &usage.BurnRateInfo{PercentPerHour: 12},
+			},
+		},
+		{ProfileName: "missing"},
+	}
+
+	got := rotationUsage(results, "", prediction.NewPredictionEngine())
+	if len(got) != 1 {
+		t.Fatalf("rotationUsage() returned %d entries, want 1", len(got))
+	}
+	work := got["work"]
+	if work == nil || work.PrimaryPercent != 40 || !work.PrimaryResetsAt.Equal(resetsAt) {
+		t.Fatalf("work usage = %+v", work)
+	}
+	if work.BurnRate != 12 {
+		t.Errorf("BurnRate = %v, want 12", work.BurnRate)
+	}
+}
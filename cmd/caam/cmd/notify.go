@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Test notification channels and inspect webhook deliveries",
+	Long: `Test the channels under alerts.notifications and inspect webhook deliveries.
+
+Webhook requests carry X-Caam-Delivery and X-Caam-Timestamp headers. With a
+secret configured (webhook_secret, or secret on a webhook channel) they are
+also signed: X-Caam-Signature is "sha256=" and the hex HMAC-SHA256 of
+"<timestamp>.<body>". Failed requests are retried with exponential backoff;
+deliveries that still fail are kept in an outbox that the daemon retries.
+
+Examples:
+  caam notify test                # Send a test alert to every channel
+  caam notify test ops phone      # Only to these channels
+  caam notify outbox              # Pending and failed webhook deliveries
+  caam notify outbox retry 12     # Replay a failed delivery now
+  caam notify outbox retry --all  # Replay every failed delivery`,
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test [channel...]",
+	Short: "Send a test alert to notification channels",
+	Long: `Send a test alert straight to the named channels, or to all of them,
+ignoring routes, dedup windows and quiet hours. Failures are reported
+rather than queued.`,
+	RunE: runNotifyTest,
+}
+
+var notifyOutboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "List webhook deliveries in the outbox",
+	Args:  cobra.NoArgs,
+	RunE:  runNotifyOutbox,
+}
+
+var notifyOutboxRetryCmd = &cobra.Command{
+	Use:   "retry [id...] [--all]",
+	Short: "Retry webhook deliveries now",
+	RunE:  runNotifyOutboxRetry,
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+	notifyCmd.AddCommand(notifyOutboxCmd)
+	notifyOutboxCmd.AddCommand(notifyOutboxRetryCmd)
+
+	notifyOutboxCmd.Flags().String("status", "", "show only pending, failed or delivered deliveries (default: pending and failed)")
+	notifyOutboxCmd.Flags().Int("limit", 50, "maximum deliveries to show")
+	notifyOutboxCmd.Flags().Bool("json", false, "output in JSON format")
+	notifyOutboxRetryCmd.Flags().Bool("all", false, "retry all failed deliveries")
+}
+
+func runNotifyTest(cmd *cobra.Command, args []string) error {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	channels := notify.Channels(spmCfg.Alerts.Notifications, out)
+	names := args
+	if len(names) == 0 {
+		for name := range channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no notification channels configured under alerts.notifications in ~/.caam/config.yaml")
+	}
+
+	alert := &notify.Alert{
+		Level:     notify.Info,
+		Title:     "caam test notification",
+		Message:   "This is a test alert from 'caam notify test'.",
+		Timestamp: time.Now(),
+	}
+	failed := 0
+	for _, name := range names {
+		n, ok := channels[name]
+		if !ok {
+			fmt.Fprintf(out, "%s: not configured\n", name)
+			failed++
+			continue
+		}
+		if !n.Available() {
+			fmt.Fprintf(out, "%s: not available\n", name)
+			failed++
+			continue
+		}
+		if w, ok := n.(*notify.WebhookNotifier); ok {
+			// Report the first failure rather than waiting out retries.
+			w.Retries = 0
+		}
+		if err := n.Notify(alert); err != nil {
+			fmt.Fprintf(out, "%s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s: ok\n", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d channel(s) failed", failed, len(names))
+	}
+	return nil
+}
+
+func runNotifyOutbox(cmd *cobra.Command, args []string) error {
+	status, _ := cmd.Flags().GetString("status")
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	switch status {
+	case "", caamdb.WebhookPending, caamdb.WebhookFailed, caamdb.WebhookDelivered, "all":
+	default:
+		return fmt.Errorf("--status must be pending, failed, delivered or all")
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	var deliveries []caamdb.WebhookDelivery
+	switch status {
+	case "":
+		for _, s := range []string{caamdb.WebhookFailed, caamdb.WebhookPending} {
+			list, err := db.ListWebhooks(s, limit)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, list...)
+		}
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+		if len(deliveries) > limit {
+			deliveries = deliveries[:limit]
+		}
+	case "all":
+		deliveries, err = db.ListWebhooks("", limit)
+	default:
+		deliveries, err = db.ListWebhooks(status, limit)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		if deliveries == nil {
+			deliveries = []caamdb.WebhookDelivery{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(deliveries)
+	}
+	if len(deliveries) == 0 {
+		fmt.Fprintln(out, "No webhook deliveries in the outbox.")
+		return nil
+	}
+	renderOutbox(out, deliveries)
+	return nil
+}
+
+func renderOutbox(out io.Writer, deliveries []caamdb.WebhookDelivery) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCHANNEL\tSTATUS\tATTEMPTS\tCREATED\tNEXT/DONE\tLAST ERROR")
+	for _, d := range deliveries {
+		when := d.NextAttemptAt
+		if d.DeliveredAt != nil {
+			when = *d.DeliveredAt
+		}
+		next := when.Local().Format("Jan 2 15:04")
+		if d.Status == caamdb.WebhookFailed {
+			next = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			d.ID, d.Channel, d.Status, d.Attempts, d.CreatedAt.Local().Format("Jan 2 15:04"), next, d.LastError)
+	}
+	w.Flush()
+}
+
+func runNotifyOutboxRetry(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	if all == (len(args) > 0) {
+		return fmt.Errorf("provide delivery ids or --all")
+	}
+	var ids []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid delivery id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return err
+	}
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	now := time.Now()
+	n, err := db.RequeueWebhooks(ids, now)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No deliveries to retry.")
+		return nil
+	}
+
+	res, err := notify.DrainOutbox(db, notify.Channels(spmCfg.Alerts.Notifications, nil), now)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Requeued %d deliveries. Outbox: %d delivered, %d retrying, %d failed\n",
+		n, res.Delivered, res.Retrying, res.Failed)
+	return nil
+}
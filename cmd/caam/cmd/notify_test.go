@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+)
+
+func TestNotifyTestAndOutboxRetry(t *testing.T) {
+	var signed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signed = append(signed, r.Header.Get(notify.HeaderSignature))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("CAAM_HOME", filepath.Join(tmpDir, ".caam"))
+	t.Setenv("CAAM_ALERTS_NOTIFICATIONS_DESKTOP", "false")
+
+	cfg := `alerts:
+  notifications:
+    terminal: false
+    channels:
+      ops:
+        type: webhook
+        url: ` + server.URL + `
+        secret: s3cret
+`
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".caam"), 0700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".caam", "config.yaml"), []byte(cfg), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	notifyTestCmd.SetOut(&out)
+	defer notifyTestCmd.SetOut(nil)
+	if err := runNotifyTest(notifyTestCmd, nil); err != nil {
+		t.Fatalf("notify test error = %v\n%s", err, out.String())
+	}
+	if out.String() != "ops: ok\n" || len(signed) != 1 || !strings.HasPrefix(signed[0], "sha256=") {
+		t.Fatalf("notify test output %q, signatures %v", out.String(), signed)
+	}
+	out.Reset()
+	if err := runNotifyTest(notifyTestCmd, []string{"pager"}); err == nil || out.String() != "pager: not configured\n" {
+		t.Errorf("notify test pager = %v, %q", err, out.String())
+	}
+
+	db, err := getDB()
+	if err != nil {
+		t.Fatalf("getDB() error = %v", err)
+	}
+	id, err := db.EnqueueWebhook("ops", server.URL, "d1", []byte(`{"title":"Limit hit"}`), time.Now())
+	if err != nil {
+		t.Fatalf("EnqueueWebhook() error = %v", err)
+	}
+	if err := db.MarkWebhookFailed(id, "410 Gone", time.Now(), true); err != nil {
+		t.Fatalf("MarkWebhookFailed() error = %v", err)
+	}
+
+	out.Reset()
+	notifyOutboxCmd.SetOut(&out)
+	defer notifyOutboxCmd.SetOut(nil)
+	if err := runNotifyOutbox(notifyOutboxCmd, nil); err != nil {
+		t.Fatalf("notify outbox error = %v", err)
+	}
+	if !strings.Contains(out.String(), "failed") || !strings.Contains(out.String(), "410 Gone") {
+		t.Errorf("notify outbox output:\n%s", out.String())
+	}
+
+	out.Reset()
+	notifyOutboxRetryCmd.SetOut(&out)
+	defer notifyOutboxRetryCmd.SetOut(nil)
+	if err := runNotifyOutboxRetry(notifyOutboxRetryCmd, nil); err == nil {
+		t.Error("retry without ids or --all should fail")
+	}
+	if err := runNotifyOutboxRetry(notifyOutboxRetryCmd, []string{strconv.FormatInt(id, 10)}); err != nil {
+		t.Fatalf("notify outbox retry error = %v", err)
+	}
+	if !strings.Contains(out.String(), "1 delivered") || len(signed) != 2 {
+		t.Errorf("retry output %q, %d requests", out.String(), len(signed))
+	}
+	if delivered, _ := db.ListWebhooks(caamdb.WebhookDelivered, 0); len(delivered) != 1 {
+		t.Errorf("got %d delivered, want 1", len(delivered))
+	}
+}
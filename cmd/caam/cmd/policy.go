@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/policy"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the profile selection policy",
+	Long: `Inspect the rule-based selection policy in ~/.caam/policy.yaml.
+
+Rules narrow the candidate profiles before rotation scores them. They are
+shared by 'caam activate --auto', 'caam run', 'caam next' and the auth agent.
+
+Example policy.yaml:
+
+  rules:
+    - name: work-hours
+      when:
+        days: [weekdays]        # mon..sun, weekdays, weekends
+        hours: "09:00-18:00"    # may wrap midnight
+      prefer: tag:work
+    - name: no-personal-for-clients
+      when:
+        dir: ~/clients/*        # matches the directory and everything below
+      deny: tag:personal
+    - name: opus-on-max
+      when:
+        provider: claude
+        model: opus             # substring or glob of the requested model
+      prefer: plan:max
+
+Selectors are tag:<tag>, plan:<plan> or name:<glob> (a bare value is a name
+glob); a list matches any of them. Deny rules remove matching profiles; then
+prefer rules, in order, narrow the candidates to matching profiles unless
+none match.`,
+}
+
+var policyTestCmd = &cobra.Command{
+	Use:   "test <tool>",
+	Short: "Dry-run the selection policy",
+	Long: `Evaluate the selection policy against a tool's profiles and show which
+rules matched and which profiles remain.
+
+Examples:
+  caam policy test claude
+  caam policy test claude --dir ~/clients/acme --at "2026-01-05 10:30"
+  caam policy test claude --model opus --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPolicyTest,
+}
+
+var policyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Validate and print the selection policy",
+	Args:  cobra.NoArgs,
+	RunE:  runPolicyShow,
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyTestCmd)
+	policyCmd.AddCommand(policyShowCmd)
+
+	policyTestCmd.Flags().String("dir", "", "working directory to evaluate (default: current)")
+	policyTestCmd.Flags().String("model", "", "requested model to evaluate")
+	policyTestCmd.Flags().String("at", "", "time to evaluate (\"2006-01-02 15:04\", \"15:04\" or RFC3339; default: now)")
+	policyTestCmd.Flags().Bool("json", false, "output in JSON format")
+}
+
+func runPolicyTest(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	if _, ok := tools[tool]; !ok {
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
+	}
+
+	dir, _ := cmd.Flags().GetString("dir")
+	model, _ := cmd.Flags().GetString("model")
+	at, _ := cmd.Flags().GetString("at")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	now := time.Now()
+	if at != "" {
+		t, err := parsePolicyTime(at, now)
+		if err != nil {
+			return err
+		}
+		now = t
+	}
+	if dir == "" {
+		dir, _ = getWd()
+	}
+
+	pol, err := policy.Load(policy.DefaultPath())
+	if err != nil {
+		return err
+	}
+
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	profiles, err := vault.List(tool)
+	if err != nil {
+		return fmt.Errorf("list profiles: %w", err)
+	}
+
+	ctx := policy.Context{Provider: tool, Dir: dir, Model: model, Now: now}
+	decision := pol.Evaluate(ctx, policyProfiles(tool, profiles))
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(decision)
+	}
+
+	fmt.Fprintf(out, "Tool:  %s\n", tool)
+	fmt.Fprintf(out, "Dir:   %s\n", dir)
+	if model != "" {
+		fmt.Fprintf(out, "Model: %s\n", model)
+	}
+	fmt.Fprintf(out, "Time:  %s\n\n", now.Format("Mon 2006-01-02 15:04"))
+	fmt.Fprintln(out, policy.Format(decision))
+	for _, name := range profiles {
+		if rule, ok := decision.Denied[name]; ok {
+			fmt.Fprintf(out, "  denied: %s (rule %s)\n", name, rule)
+		}
+	}
+	return nil
+}
+
+func runPolicyShow(cmd *cobra.Command, args []string) error {
+	path := policy.DefaultPath()
+	pol, err := policy.Load(path)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Policy file: %s\n", path)
+	if len(pol.Rules) == 0 {
+		fmt.Fprintln(out, "No rules defined")
+		return nil
+	}
+	for _, r := range pol.Rules {
+		var conds []string
+		if len(r.When.Providers) > 0 {
+			conds = append(conds, "provider "+strings.Join(r.When.Providers, "|"))
+		}
+		if len(r.When.Days) > 0 {
+			conds = append(conds, "days "+strings.Join(r.When.Days, "|"))
+		}
+		if r.When.Hours != "" {
+			conds = append(conds, "hours "+r.When.Hours)
+		}
+		if len(r.When.Dirs) > 0 {
+			conds = append(conds, "dir "+strings.Join(r.When.Dirs, "|"))
+		}
+		if len(r.When.Models) > 0 {
+			conds = append(conds, "model "+strings.Join(r.When.Models, "|"))
+		}
+		when := "always"
+		if len(conds) > 0 {
+			when = strings.Join(conds, ", ")
+		}
+		var actions []string
+		if len(r.Deny) > 0 {
+			actions = append(actions, "deny "+strings.Join(r.Deny, ", "))
+		}
+		if len(r.Prefer) > 0 {
+			actions = append(actions, "prefer "+strings.Join(r.Prefer, ", "))
+		}
+		fmt.Fprintf(out, "  %s: when %s: %s\n", r.Name, when, strings.Join(actions, "; "))
+	}
+	return nil
+}
+
+// parsePolicyTime parses --at values. A bare clock time is taken on now's date.
+func parsePolicyTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at %q (use \"2006-01-02 15:04\", \"15:04\" or RFC3339)", s)
+}
+
+// policyProfiles builds the attributes policy rules match against: tags from
+// profile metadata, and plan types from the vault credentials (as reported by
+// the provider) and health data (as normalized by caam).
+func policyProfiles(tool string, names []string) []policy.Profile {
+	store := profileStore
+	if store == nil {
+		store = profile.NewStore(profile.DefaultStorePath())
+	}
+	hs := healthStore
+	if hs == nil {
+		hs = health.NewStorage("")
+	}
+
+	out := make([]policy.Profile, 0, len(names))
+	for _, name := range names {
+		p := policy.Profile{Name: name}
+		if prof, err := store.Load(tool, name); err == nil {
+			p.Tags = prof.Tags
+		}
+		if id := readVaultIdentity(tool, name); id != nil && id.PlanType != "" {
+			p.Plans = append(p.Plans, id.PlanType)
+			if normalized := normalizePlanType(id.PlanType); normalized != id.PlanType {
+				p.Plans = append(p.Plans, normalized)
+			}
+		} else if h, err := hs.GetProfile(tool, name); err == nil && h != nil && h.PlanType != "" {
+			p.Plans = append(p.Plans, h.PlanType)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// selectionPolicyFilter returns a rotation filter that applies the selection
+// policy, or nil when no rules are defined.
+func selectionPolicyFilter(model string) (rotation.Filter, error) {
+	pol, err := policy.Load(policy.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(pol.Rules) == 0 {
+		return nil, nil
+	}
+	return func(tool string, profiles []string) ([]string, error) {
+		dir, _ := getWd()
+		ctx := policy.Context{Provider: tool, Dir: dir, Model: model, Now: time.Now()}
+		return pol.Evaluate(ctx, policyProfiles(tool, profiles)).Profiles, nil
+	}, nil
+}
+
+// explainSelectionPolicy describes how the selection policy treats profiles,
+// or returns "" when no rules are defined.
+func explainSelectionPolicy(tool string, profiles []string, model string) string {
+	pol, err := policy.Load(policy.DefaultPath())
+	if err != nil {
+		return fmt.Sprintf("Policy: %v", err)
+	}
+	if len(pol.Rules) == 0 {
+		return ""
+	}
+	dir, _ := getWd()
+	ctx := policy.Context{Provider: tool, Dir: dir, Model: model, Now: time.Now()}
+	return policy.Format(pol.Evaluate(ctx, policyProfiles(tool, profiles)))
+}
+
+// applySelectionPolicy installs the selection policy on a selector.
+func applySelectionPolicy(selector *rotation.Selector, model string) error {
+	filter, err := selectionPolicyFilter(model)
+	if err != nil {
+		return err
+	}
+	selector.SetFilter(filter)
+	return nil
+}
+
+// modelFromArgs returns the value of a --model/-m flag passed through to a
+// provider CLI, if any.
+func modelFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--model="); ok {
+			return v
+		}
+		if (arg == "--model" || arg == "-m") && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// agentAccountFilter applies the selection policy to the auth agent's
+// accounts. Accounts are the email addresses the agent logs in with; each is
+// evaluated with the attributes (tags, plans) of the claude profile that
+// holds it, so tag: and plan: rules work. Name rules match the account.
+func agentAccountFilter() (func([]string) []string, error) {
+	pol, err := policy.Load(policy.DefaultPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(pol.Rules) == 0 {
+		return nil, nil
+	}
+	return func(accounts []string) []string {
+		dir, _ := getWd()
+		ctx := policy.Context{Provider: "claude", Dir: dir, Now: time.Now()}
+		return pol.Evaluate(ctx, agentAccountProfiles(accounts)).Profiles
+	}, nil
+}
+
+// agentAccountProfiles builds policy attributes for agent accounts from the
+// claude profiles holding them: matched by the email in the vault
+// credentials or profile metadata, or by a profile named after the account.
+func agentAccountProfiles(accounts []string) []policy.Profile {
+	store := profileStore
+	if store == nil {
+		store = profile.NewStore(profile.DefaultStorePath())
+	}
+
+	byEmail := make(map[string]string)
+	if vault != nil {
+		names, _ := vault.List("claude")
+		for _, name := range names {
+			var emails []string
+			if id := readVaultIdentity("claude", name); id != nil {
+				emails = append(emails, id.Email)
+			}
+			if prof, err := store.Load("claude", name); err == nil {
+				emails = append(emails, prof.AccountLabel)
+				if prof.Identity != nil {
+					emails = append(emails, prof.Identity.Email)
+				}
+			}
+			for _, email := range emails {
+				if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+					byEmail[email] = name
+				}
+			}
+		}
+	}
+
+	out := make([]policy.Profile, 0, len(accounts))
+	for _, account := range accounts {
+		name, ok := byEmail[strings.ToLower(strings.TrimSpace(account))]
+		if !ok {
+			name = account
+		}
+		p := policyProfiles("claude", []string{name})[0]
+		p.Name = account
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+func TestModelFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"-p", "hello"}, ""},
+		{[]string{"--model", "opus", "-p", "hi"}, "opus"},
+		{[]string{"--model=claude-sonnet-4"}, "claude-sonnet-4"},
+		{[]string{"-m", "gemini-2.5-pro"}, "gemini-2.5-pro"},
+		{[]string{"--", "--model", "opus"}, ""},
+	}
+	for _, tt := range tests {
+		if got := modelFromArgs(tt.args); got != tt.want {
+			t.Errorf("modelFromArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicyTime(t *testing.T) {
+	now := time.Date(2026, 3, 4, 8, 0, 0, 0, time.Local)
+
+	got, err := parsePolicyTime("10:30", now)
+	if err != nil {
+		t.Fatalf("parsePolicyTime() error = %v", err)
+	}
+	if want := time.Date(2026, 3, 4, 10, 30, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("parsePolicyTime(10:30) = %v, want %v", got, want)
+	}
+
+	got, err = parsePolicyTime("2026-01-05 18:15", now)
+	if err != nil {
+		t.Fatalf("parsePolicyTime() error = %v", err)
+	}
+	if got.Weekday() != time.Monday || got.Hour() != 18 {
+		t.Errorf("parsePolicyTime(date) = %v", got)
+	}
+
+	if _, err := parsePolicyTime("tomorrow", now); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func TestSelectProfileWithRotation_AppliesSelectionPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", tmpDir)
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	for name, tag := range map[string]string{"a": "personal", "b": "work"} {
+		prof, err := profileStore.Create("codex", name, "oauth")
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+		prof.Tags = []string{tag}
+		if err := prof.Save(); err != nil {
+			t.Fatalf("Save(%s) error = %v", name, err)
+		}
+	}
+
+	policyYAML := "rules:\n  - name: no-personal\n    deny: tag:personal\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "policy.yaml"), []byte(policyYAML), 0600); err != nil {
+		t.Fatalf("WriteFile(policy) error = %v", err)
+	}
+
+	// Only "b" survives the policy, so it is picked every time.
+	for i := 0; i < 3; i++ {
+		result, err := selectProfileWithRotation("codex", []string{"a", "b"}, "b", nil, nil)
+		if err != nil {
+			t.Fatalf("selectProfileWithRotation() error = %v", err)
+		}
+		if result.Selected != "b" {
+			t.Fatalf("Selected = %q, want %q", result.Selected, "b")
+		}
+	}
+
+	if text := explainSelectionPolicy("codex", []string{"a", "b"}, ""); text == "" {
+		t.Error("expected policy explanation")
+	}
+
+	// A broken policy file is reported rather than ignored.
+	if err := os.WriteFile(filepath.Join(tmpDir, "policy.yaml"), []byte("rules:\n  - name: x\n"), 0600); err != nil {
+		t.Fatalf("WriteFile(policy) error = %v", err)
+	}
+	if _, err := selectProfileWithRotation("codex", []string{"a", "b"}, "b", nil, nil); err == nil {
+		t.Error("expected error for invalid policy")
+	}
+}
+
+func TestAgentAccountFilter_MatchesProfilesByAccount(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CAAM_HOME", tmpDir)
+
+	oldVault := vault
+	vault = authfile.NewVault(filepath.Join(tmpDir, "vault"))
+	t.Cleanup(func() { vault = oldVault })
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	for name, account := range map[string]string{"work": "me@work.com", "home": "me@home.com"} {
+		if err := os.MkdirAll(vault.ProfilePath("claude", name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		prof, err := profileStore.Create("claude", name, "oauth")
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+		prof.Tags = []string{name}
+		prof.AccountLabel = account
+		if err := prof.Save(); err != nil {
+			t.Fatalf("Save(%s) error = %v", name, err)
+		}
+	}
+
+	policyYAML := "rules:\n  - name: no-home\n    deny: tag:home\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "policy.yaml"), []byte(policyYAML), 0600); err != nil {
+		t.Fatalf("WriteFile(policy) error = %v", err)
+	}
+
+	filter, err := agentAccountFilter()
+	if err != nil || filter == nil {
+		t.Fatalf("agentAccountFilter() error = %v (nil filter: %t)", err, filter == nil)
+	}
+	got := filter([]string{"me@work.com", "ME@home.com", "other@example.com"})
+	want := []string{"me@work.com", "other@example.com"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("filter() = %v, want %v", got, want)
+	}
+}
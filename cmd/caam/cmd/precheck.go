@@ -41,7 +41,11 @@ Examples:
   caam precheck codex               # Full session planner for Codex
   caam precheck claude --format json    # JSON output for scripting
   caam precheck claude --format brief   # One-line recommendation
-  caam precheck claude --no-fetch       # Skip API calls (use cached data)`,
+  caam precheck claude --no-fetch       # Skip API calls (use cached data)
+  caam precheck claude --model opus     # Plan on the Opus quota window
+
+With --model, usage, scores and alerts only count the windows that limit that
+model, so a profile out of Opus quota is still recommended for Sonnet.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runPrecheckCmd,
 }
@@ -50,13 +54,15 @@ func init() {
 	precheckCmd.Flags().String("format", "table", "output format: table, json, brief")
 	precheckCmd.Flags().Bool("no-fetch", false, "skip real-time API fetch (use cached/health data)")
 	precheckCmd.Flags().Duration("timeout", 30*time.Second, "timeout for API fetches")
-	precheckCmd.Flags().String("algorithm", "", "override rotation algorithm (smart, round_robin, random)")
+	precheckCmd.Flags().String("algorithm", "", "override rotation algorithm (smart, round_robin, random, reset_aware)")
+	precheckCmd.Flags().String("model", "", "model the session will use (plan on that model's quota window)")
 	rootCmd.AddCommand(precheckCmd)
 }
 
 // PrecheckResult contains the structured output for precheck.
 type PrecheckResult struct {
 	Provider    string                `json:"provider"`
+	Model       string                `json:"model,omitempty"`
 	Recommended *ProfileRecommendation `json:"recommended,omitempty"`
 	Backups     []ProfileRecommendation `json:"backups"`
 	InCooldown  []CooldownProfile      `json:"in_cooldown"`
@@ -72,6 +78,7 @@ type ProfileRecommendation struct {
 	Name           string   `json:"name"`
 	Score          float64  `json:"score"`
 	UsagePercent   int      `json:"usage_percent"`
+	ModelPercent   *int     `json:"model_usage_percent,omitempty"`
 	AvailScore     int      `json:"availability_score"`
 	HealthStatus   string   `json:"health_status"`
 	TokenExpiry    string   `json:"token_expiry,omitempty"`
@@ -119,6 +126,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	noFetch, _ := cmd.Flags().GetBool("no-fetch")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 	algoOverride, _ := cmd.Flags().GetString("algorithm")
+	model, _ := cmd.Flags().GetString("model")
 
 	// Default to claude if no provider specified
 	provider := "claude"
@@ -184,19 +192,18 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	var usageResults []usage.ProfileUsage
 	var usageMap map[string]*usage.UsageInfo
 
-	if !noFetch && (provider == "claude" || provider == "codex") {
+	if !noFetch && supportsUsageAPI(provider) {
 		credentials, err := usage.LoadProfileCredentials(authfile.DefaultVaultPath(), provider)
 		if err == nil && len(credentials) > 0 {
 			fetchCtx, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
 
-			fetcher := usage.NewMultiProfileFetcher()
-			usageResults = fetcher.FetchAllProfiles(fetchCtx, provider, credentials)
+			usageResults = newUsageFetcher(db).FetchAllProfiles(fetchCtx, provider, credentials)
 
 			usageMap = make(map[string]*usage.UsageInfo)
 			for _, r := range usageResults {
 				if r.Usage != nil {
-					usageMap[r.ProfileName] = r.Usage
+					usageMap[r.ProfileName] = r.Usage.ForModel(model)
 				}
 			}
 		}
@@ -209,25 +216,16 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	selector := rotation.NewSelector(algorithm, healthStoreInst, db)
+	applyRotationPolicies(selector, db, provider, userProfiles)
+	if err := applySelectionPolicy(selector, model); err != nil {
+		return err
+	}
 
 	// Set usage data for smart selection
+	var engine *prediction.PredictionEngine
 	if len(usageMap) > 0 {
-		rotationUsage := make(map[string]*rotation.UsageInfo)
-		for name, info := range usageMap {
-			ru := &rotation.UsageInfo{
-				ProfileName: name,
-				AvailScore:  info.AvailabilityScore(),
-				Error:       info.Error,
-			}
-			if info.PrimaryWindow != nil {
-				ru.PrimaryPercent = info.PrimaryWindow.UsedPercent
-			}
-			if info.SecondaryWindow != nil {
-				ru.SecondaryPercent = info.SecondaryWindow.UsedPercent
-			}
-			rotationUsage[name] = ru
-		}
-		selector.SetUsageData(rotationUsage)
+		engine = newForecastEngine(ctx, db, []string{provider})
+		selector.SetUsageData(rotationUsage(usageResults, model, engine))
 	}
 
 	// Get current active profile
@@ -241,7 +239,7 @@ func runPrecheckCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build precheck result
-	result := buildPrecheckResult(provider, userProfiles, selectionResult, usageMap, pool, healthStoreInst, db, string(algorithm))
+	result := buildPrecheckResult(provider, userProfiles, selectionResult, usageMap, engine, pool, healthStoreInst, db, string(algorithm), model)
 
 	// Output based on format
 	switch format {
@@ -259,13 +257,16 @@ func buildPrecheckResult(
 	profiles []string,
 	selection *rotation.Result,
 	usageMap map[string]*usage.UsageInfo,
+	engine *prediction.PredictionEngine,
 	pool *authpool.AuthPool,
 	healthStore *health.Storage,
 	db *caamdb.DB,
 	algorithm string,
+	model string,
 ) *PrecheckResult {
 	result := &PrecheckResult{
 		Provider:   provider,
+		Model:      model,
 		Backups:    make([]ProfileRecommendation, 0),
 		InCooldown: make([]CooldownProfile, 0),
 		Alerts:     make([]PrecheckAlert, 0),
@@ -301,6 +302,19 @@ func buildPrecheckResult(
 				if info.TimeToDepletion() > 0 {
 					rec.TimeToDepletion = formatDurationShort(info.TimeToDepletion())
 				}
+				if w := info.WindowForModel(model); w != nil {
+					pct := w.UsedPercent
+					rec.ModelPercent = &pct
+					if pct >= 100 {
+						result.Alerts = append(result.Alerts, PrecheckAlert{
+							Type:    "model_exhausted",
+							Profile: profileName,
+							Message: fmt.Sprintf("%s has no %s quota left", profileName, model),
+							Urgency: "high",
+							Action:  fmt.Sprintf("use another profile for %s", model),
+						})
+					}
+				}
 			}
 		}
 
@@ -396,7 +410,9 @@ func buildPrecheckResult(
 			usageInfos = append(usageInfos, info)
 		}
 
-		engine := prediction.NewPredictionEngine()
+		if engine == nil {
+			engine = prediction.NewPredictionEngine()
+		}
 		var predictions []*prediction.Prediction
 		for _, info := range usageInfos {
 			pred := engine.Predict(context.Background(), info)
@@ -468,6 +484,9 @@ func precheckOutputBrief(w io.Writer, result *PrecheckResult) error {
 	if rec.UsagePercent > 0 {
 		extra = fmt.Sprintf(" (%d%% used)", rec.UsagePercent)
 	}
+	if rec.ModelPercent != nil {
+		extra += fmt.Sprintf(" (%s %d%% used)", result.Model, *rec.ModelPercent)
+	}
 	fmt.Fprintf(w, "%s: %s%s\n", result.Provider, rec.Name, extra)
 	return nil
 }
@@ -479,6 +498,9 @@ func precheckOutputTable(w io.Writer, result *PrecheckResult) error {
 		strings.ToUpper(result.Provider),
 		result.Algorithm,
 		result.FetchedAt.Format("15:04:05"))
+	if result.Model != "" {
+		fmt.Fprintf(w, "  Model: %s\n", result.Model)
+	}
 	fmt.Fprintln(w, strings.Repeat("=", 72))
 	fmt.Fprintln(w)
 
@@ -497,6 +519,9 @@ func precheckOutputTable(w io.Writer, result *PrecheckResult) error {
 			fmt.Fprintf(w, "    Usage:  %s %d%%\n", renderUsageBar(rec.UsagePercent, 20), rec.UsagePercent)
 			fmt.Fprintf(w, "    Avail:  %d/100\n", rec.AvailScore)
 		}
+		if rec.ModelPercent != nil {
+			fmt.Fprintf(w, "    %s:  %s %d%%\n", result.Model, renderUsageBar(*rec.ModelPercent, 20), *rec.ModelPercent)
+		}
 
 		// Time to depletion
 		if rec.TimeToDepletion != "" {
@@ -534,6 +559,9 @@ func precheckOutputTable(w io.Writer, result *PrecheckResult) error {
 			if backup.UsagePercent > 0 {
 				usageStr = fmt.Sprintf(" %d%%", backup.UsagePercent)
 			}
+			if backup.ModelPercent != nil {
+				usageStr += fmt.Sprintf(" (%s %d%%)", result.Model, *backup.ModelPercent)
+			}
 			fmt.Fprintf(w, "    %d. %s%s%s\n", i+1, backup.Name, statusStr, usageStr)
 		}
 		fmt.Fprintln(w)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -294,7 +295,7 @@ func TestBuildPrecheckResult(t *testing.T) {
 	profiles := []string{"work", "personal"}
 
 	// Build result without usage data or selection
-	result := buildPrecheckResult("claude", profiles, nil, nil, nil, healthStore, nil, "smart")
+	result := buildPrecheckResult("claude", profiles, nil, nil, nil, nil, healthStore, nil, "smart", "")
 
 	assert.Equal(t, "claude", result.Provider)
 	assert.Equal(t, "smart", result.Algorithm)
@@ -308,6 +309,34 @@ func TestBuildPrecheckResult(t *testing.T) {
 	}
 }
 
+func TestBuildPrecheckResult_ModelWindow(t *testing.T) {
+	info := &usage.UsageInfo{
+		Provider:       "claude",
+		PrimaryWindow:  &usage.UsageWindow{Utilization: 0.1, UsedPercent: 10},
+		TertiaryWindow: &usage.UsageWindow{Utilization: 1, UsedPercent: 100},
+	}
+
+	opus := buildPrecheckResult("claude", []string{"work"}, nil,
+		map[string]*usage.UsageInfo{"work": info.ForModel("opus")}, nil, nil, nil, nil, "smart", "opus")
+	assert.Equal(t, "opus", opus.Model)
+	require.Len(t, opus.Backups, 1)
+	require.NotNil(t, opus.Backups[0].ModelPercent)
+	assert.Equal(t, 100, *opus.Backups[0].ModelPercent)
+	var exhausted bool
+	for _, a := range opus.Alerts {
+		exhausted = exhausted || a.Type == "model_exhausted"
+	}
+	assert.True(t, exhausted, "expected model_exhausted alert, got %+v", opus.Alerts)
+
+	sonnet := buildPrecheckResult("claude", []string{"work"}, nil,
+		map[string]*usage.UsageInfo{"work": info.ForModel("sonnet")}, nil, nil, nil, nil, "smart", "sonnet")
+	require.Len(t, sonnet.Backups, 1)
+	assert.Nil(t, sonnet.Backups[0].ModelPercent)
+	for _, a := range sonnet.Alerts {
+		assert.NotEqual(t, "model_exhausted", a.Type)
+	}
+}
+
 func TestPrecheckResultAlerts(t *testing.T) {
 	result := &PrecheckResult{
 		Provider: "claude",
@@ -3,59 +3,79 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/spf13/cobra"
 )
 
 var resumeCmd = &cobra.Command{
 	Use:   "resume <tool> <profile> [prompt...]",
-	Short: "Resume a Codex session for a profile",
-	Long: `Resumes a Codex chat session using an isolated profile.
+	Short: "Resume a chat session on an isolated profile",
+	Long: `Resumes a Codex, Claude Code or Gemini CLI chat session using an isolated profile.
+
+Codex resumes the most recent session ID captured from 'caam exec'.
+
+Claude and Gemini resume the most recent session for the current working
+directory, wherever it was recorded: this profile, another isolated profile
+of the same tool, or your own ~/.claude / ~/.gemini. Sessions from elsewhere
+are copied into the profile first, so a rate-limited session can continue on
+another account.
 
-By default, this uses the most recent session ID captured from 'caam exec'.
 Use --session to resume a specific session ID.
 
 Examples:
   caam resume codex work
   caam resume codex work --session 019b2e3d-b524-7c22-91da-47de9068d09a
-  caam resume codex work "proceed"`,
+  caam resume codex work "proceed"
+  caam resume claude backup          # continue this directory's latest session
+  caam resume gemini alt --session 7f1c2d9e-...`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := strings.ToLower(args[0])
 		name := args[1]
 
-		if tool != "codex" {
-			return fmt.Errorf("resume is currently only supported for codex")
-		}
-
 		prov, ok := registry.Get(tool)
 		if !ok {
 			return fmt.Errorf("unknown provider: %s", tool)
 		}
+		if err := provider.Require(prov, provider.CapSessionResume); err != nil {
+			return err
+		}
 
 		prof, err := profileStore.Load(tool, name)
 		if err != nil {
 			return err
 		}
 
-		sessionFlag, _ := cmd.Flags().GetString("session")
-		sessionID := sessionFlag
-		if sessionID == "" {
+		sessionID, _ := cmd.Flags().GetString("session")
+		ctx := context.Background()
+
+		if exec.SupportsSessionDiscovery(tool) {
+			sessionID, err = prepareDiskSession(ctx, cmd, prov, prof, sessionID)
+			if err != nil {
+				return err
+			}
+		} else if sessionID == "" {
 			sessionID = prof.LastSessionID
 		}
 		if sessionID == "" {
 			return fmt.Errorf("no session to resume. Start one with: caam exec %s %s", tool, name)
 		}
 
-		toolArgs := []string{"resume", sessionID}
+		toolArgs, err := exec.ResumeArgs(tool, sessionID)
+		if err != nil {
+			return err
+		}
 		if len(args) > 2 {
 			toolArgs = append(toolArgs, args[2:]...)
 		}
 
 		noLock, _ := cmd.Flags().GetBool("no-lock")
-		ctx := context.Background()
 		return runner.Run(ctx, exec.RunOptions{
 			Profile:  prof,
 			Provider: prov,
@@ -67,6 +87,81 @@ Examples:
 
 func init() {
 	rootCmd.AddCommand(resumeCmd)
-	resumeCmd.Flags().StringP("session", "s", "", "session ID to resume (defaults to last captured)")
+	resumeCmd.Flags().StringP("session", "s", "", "session ID to resume (defaults to the latest for this directory, or last captured for codex)")
 	resumeCmd.Flags().Bool("no-lock", false, "don't lock the profile during execution")
 }
+
+// prepareDiskSession finds the session to resume for the current directory
+// and makes sure its transcript is in prof's session root. It returns the
+// session ID.
+func prepareDiskSession(ctx context.Context, cmd *cobra.Command, prov provider.Provider, prof *profile.Profile, sessionID string) (string, error) {
+	tool := prov.ID()
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %w", err)
+	}
+
+	targetEnv, err := prov.Env(ctx, prof)
+	if err != nil {
+		return "", fmt.Errorf("profile env: %w", err)
+	}
+	targetRoots := exec.SessionRoots(tool, targetEnv)
+
+	sessions, err := exec.FindSessions(tool, sessionSearchRoots(ctx, prov, targetRoots), workDir)
+	if err != nil {
+		return "", fmt.Errorf("find %s sessions: %w", tool, err)
+	}
+
+	var chosen *exec.Session
+	for i := range sessions {
+		if sessionID == "" || sessions[i].ID == sessionID {
+			chosen = &sessions[i]
+			break
+		}
+	}
+	if chosen == nil {
+		if sessionID != "" {
+			// Not found on disk for this directory; let the tool decide.
+			return sessionID, nil
+		}
+		return "", fmt.Errorf("no %s session found for %s. Start one with: caam exec %s %s", tool, workDir, tool, prof.Name)
+	}
+
+	for _, root := range targetRoots {
+		if chosen.Root == root {
+			return chosen.ID, nil
+		}
+	}
+	if _, err := exec.CopySession(*chosen, targetRoots[0]); err != nil {
+		return "", fmt.Errorf("copy session %s into %s/%s: %w", chosen.ID, tool, prof.Name, err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Continuing %s session %s from %s\n", tool, chosen.ID, filepath.Dir(chosen.Root))
+	return chosen.ID, nil
+}
+
+// sessionSearchRoots returns the session roots to search: the target
+// profile first, then every other isolated profile of the tool, then the
+// user's own home.
+func sessionSearchRoots(ctx context.Context, prov provider.Provider, targetRoots []string) []string {
+	seen := make(map[string]bool)
+	var roots []string
+	add := func(rs []string) {
+		for _, r := range rs {
+			if !seen[r] {
+				seen[r] = true
+				roots = append(roots, r)
+			}
+		}
+	}
+
+	add(targetRoots)
+	if profiles, err := profileStore.List(prov.ID()); err == nil {
+		for _, p := range profiles {
+			if env, err := prov.Env(ctx, p); err == nil {
+				add(exec.SessionRoots(prov.ID(), env))
+			}
+		}
+	}
+	add(exec.SessionRoots(prov.ID(), nil))
+	return roots
+}
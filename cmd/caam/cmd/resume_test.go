@@ -1,6 +1,15 @@
 package cmd
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
+)
 
 func TestResumeCommandFlags(t *testing.T) {
 	// Check session flag with shorthand exists
@@ -29,3 +38,78 @@ func TestResumeCommandArgs(t *testing.T) {
 		t.Fatal("expected error for missing profile arg")
 	}
 }
+
+func TestPrepareDiskSession_ContinuesFromOtherProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	cwd := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(cwd, 0700); err != nil {
+		t.Fatal(err)
+	}
+	oldWD, _ := os.Getwd()
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWD) })
+	cwd, _ = os.Getwd()
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	limited, err := profileStore.Create("claude", "limited", "oauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := profileStore.Create("claude", "fresh", "oauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prov := claude.New()
+	ctx := context.Background()
+	limitedEnv, _ := prov.Env(ctx, limited)
+	sessionID := "5d0c1c9e-1111-4222-8333-444455556666"
+	transcript := filepath.Join(exec.SessionDir("claude", exec.SessionRoots("claude", limitedEnv)[0], cwd), sessionID+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(transcript), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(transcript, []byte(`{"sessionId":"`+sessionID+`"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := prepareDiskSession(ctx, resumeCmd, prov, fresh, "")
+	if err != nil {
+		t.Fatalf("prepareDiskSession() error = %v", err)
+	}
+	if got != sessionID {
+		t.Errorf("session = %q, want %q", got, sessionID)
+	}
+
+	// The transcript is now resumable from the fresh profile.
+	freshEnv, _ := prov.Env(ctx, fresh)
+	s, err := exec.LatestSession("claude", exec.SessionRoots("claude", freshEnv)[:1], cwd)
+	if err != nil || s == nil || s.ID != sessionID {
+		t.Errorf("fresh profile sessions = %+v, %v", s, err)
+	}
+}
+
+func TestPrepareDiskSession_NoSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	oldStore := profileStore
+	profileStore = profile.NewStore(filepath.Join(tmpDir, "profiles"))
+	t.Cleanup(func() { profileStore = oldStore })
+
+	prof, err := profileStore.Create("claude", "work", "oauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prepareDiskSession(context.Background(), resumeCmd, claude.New(), prof, ""); err == nil {
+		t.Error("expected error when no session exists for the directory")
+	}
+}
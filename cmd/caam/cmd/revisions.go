@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+var restoreRevisionCmd = &cobra.Command{
+	Use:   "restore-revision <tool> <profile> <revision>",
+	Short: "Roll a vault profile back to an earlier revision",
+	Long: `Restore a vault profile's auth files from its revision history.
+
+Revision IDs come from 'caam history <tool> <profile>'; any unique prefix
+works. The current contents are kept as a new revision, so a rollback can
+itself be undone. If the profile is active, the live auth files are updated
+too.
+
+Examples:
+  caam history claude work
+  caam restore-revision claude work 3f9a2c`,
+	Args: cobra.ExactArgs(3),
+	RunE: runRestoreRevision,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreRevisionCmd)
+}
+
+// profileRevisionEntry is one row of `caam history <tool> <profile> --json`.
+type profileRevisionEntry struct {
+	ID        string                `json:"id"`
+	CreatedAt string                `json:"created_at"`
+	Cause     string                `json:"cause"`
+	Files     []string              `json:"files"`
+	Changes   authfile.RevisionDiff `json:"changes"`
+	Current   bool                  `json:"current"`
+}
+
+func runProfileHistory(cmd *cobra.Command, tool, profileName string) error {
+	tool = strings.ToLower(tool)
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if _, ok := tools[tool]; !ok {
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
+	}
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+
+	revs, err := vault.Revisions(tool, profileName)
+	if err != nil {
+		return fmt.Errorf("read revisions: %w", err)
+	}
+
+	entries := make([]profileRevisionEntry, 0, len(revs))
+	for i := range revs {
+		// Revisions are newest first; diff each against the one before it.
+		var prev *authfile.Revision
+		if i+1 < len(revs) {
+			prev = &revs[i+1]
+		}
+		entry := profileRevisionEntry{
+			ID:        revs[i].ID,
+			CreatedAt: revs[i].CreatedAt.UTC().Format(time.RFC3339),
+			Cause:     revs[i].Cause,
+			Changes:   authfile.DiffRevisions(prev, &revs[i]),
+			Current:   i == 0,
+		}
+		for name := range revs[i].Files {
+			entry.Files = append(entry.Files, name)
+		}
+		sort.Strings(entry.Files)
+		entries = append(entries, entry)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{
+			"tool":      tool,
+			"profile":   profileName,
+			"revisions": entries,
+			"count":     len(entries),
+		})
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No revisions recorded for %s/%s.\n", tool, profileName)
+		return nil
+	}
+	return renderRevisionList(cmd.OutOrStdout(), revs[:len(entries)], entries)
+}
+
+func renderRevisionList(w io.Writer, revs []authfile.Revision, entries []profileRevisionEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REVISION\tTIMESTAMP\tCAUSE\tCHANGES")
+	for i, e := range entries {
+		id := e.ID
+		if e.Current {
+			id += " *"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			id,
+			revs[i].CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			e.Cause,
+			formatRevisionDiff(e.Changes),
+		)
+	}
+	return tw.Flush()
+}
+
+func formatRevisionDiff(d authfile.RevisionDiff) string {
+	if d.Empty() {
+		return "-"
+	}
+	var parts []string
+	for _, name := range d.Changed {
+		parts = append(parts, "~"+name)
+	}
+	for _, name := range d.Added {
+		parts = append(parts, "+"+name)
+	}
+	for _, name := range d.Removed {
+		parts = append(parts, "-"+name)
+	}
+	return strings.Join(parts, " ")
+}
+
+func runRestoreRevision(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	profileName := args[1]
+
+	getFileSet, ok := tools[tool]
+	if !ok {
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
+	}
+	if vault == nil {
+		vault = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+
+	fileSet := getFileSet()
+	active, _ := vault.ActiveProfile(fileSet)
+
+	rev, err := vault.RestoreRevision(tool, profileName, args[2])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored %s/%s to revision %s (%s, %s)\n",
+		tool, profileName, rev.ID, rev.Cause, rev.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+
+	if active == profileName {
+		if err := vault.Restore(fileSet, profileName); err != nil {
+			return fmt.Errorf("revision restored in vault, but updating active auth files failed: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Active %s auth files updated.\n", tool)
+	}
+	return nil
+}
@@ -12,9 +12,12 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/budget"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -67,6 +70,7 @@ type RobotStatusData struct {
 	Providers    []RobotProviderInfo `json:"providers"`
 	Summary      RobotStatusSummary  `json:"summary"`
 	Coordinators []RobotCoordinator  `json:"coordinators,omitempty"`
+	Budgets      []budget.Status     `json:"budgets,omitempty"`
 }
 
 // RobotStatusSummary provides quick counts.
@@ -81,12 +85,13 @@ type RobotStatusSummary struct {
 
 // RobotProviderInfo contains provider-specific status.
 type RobotProviderInfo struct {
-	ID            string               `json:"id"`
-	DisplayName   string               `json:"display_name"`
-	LoggedIn      bool                 `json:"logged_in"`
-	ActiveProfile string               `json:"active_profile,omitempty"`
-	Profiles      []RobotProfileInfo   `json:"profiles"`
-	AuthPaths     []RobotAuthPath      `json:"auth_paths"`
+	ID            string                 `json:"id"`
+	DisplayName   string                 `json:"display_name"`
+	LoggedIn      bool                   `json:"logged_in"`
+	ActiveProfile string                 `json:"active_profile,omitempty"`
+	Profiles      []RobotProfileInfo     `json:"profiles"`
+	AuthPaths     []RobotAuthPath        `json:"auth_paths"`
+	Capabilities  *provider.Capabilities `json:"capabilities,omitempty"`
 }
 
 // RobotProfileInfo contains profile details optimized for agents.
@@ -139,12 +144,13 @@ type RobotCoordinator struct {
 
 // RobotNextData contains recommended next action.
 type RobotNextData struct {
-	Provider        string            `json:"provider"`
-	Profile         string            `json:"profile"`
-	Score           float64           `json:"score"`
-	Reasons         []string          `json:"reasons"`
-	Command         string            `json:"command"`
-	AlternateChoice *RobotNextProfile `json:"alternate,omitempty"`
+	Provider        string             `json:"provider"`
+	Profile         string             `json:"profile"`
+	Score           float64            `json:"score"`
+	Reasons         []string           `json:"reasons"`
+	Command         string             `json:"command"`
+	AlternateChoice *RobotNextProfile  `json:"alternate,omitempty"`
+	Alternatives    []RobotNextProfile `json:"alternatives,omitempty"` // All candidates (reset_aware)
 }
 
 // RobotNextProfile is an alternate profile option.
@@ -208,6 +214,10 @@ Scoring factors:
 - Recent error count (fewer errors preferred)
 - Last used time (LRU by default)
 
+With --strategy reset_aware, live usage is fetched and the profile whose
+remaining quota resets soonest is preferred; every candidate is listed
+under "alternatives" with its reasons.
+
 Returns the recommended profile with activation command.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRobotNext,
@@ -293,16 +303,16 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 	includeCoords, _ := cmd.Flags().GetBool("include-coordinators")
 
 	// Determine which providers to check
-	providersToCheck := []string{"codex", "claude", "gemini"}
+	providersToCheck := []string{"codex", "claude", "gemini", "opencode"}
 	if len(args) > 0 {
 		providerFilter = strings.ToLower(args[0])
 	}
 	if providerFilter != "" {
-		validProviders := map[string]bool{"codex": true, "claude": true, "gemini": true}
+		validProviders := map[string]bool{"codex": true, "claude": true, "gemini": true, "opencode": true}
 		if !validProviders[providerFilter] {
 			return robotError(cmd, "status", "INVALID_PROVIDER",
 				fmt.Sprintf("unknown provider: %s", providerFilter),
-				"valid providers: codex, claude, gemini",
+				"valid providers: codex, claude, gemini, opencode",
 				[]string{"caam robot status claude", "caam robot status codex", "caam robot status gemini"})
 		}
 		providersToCheck = []string{providerFilter}
@@ -372,6 +382,25 @@ func runRobotStatus(cmd *cobra.Command, args []string) error {
 		data.Coordinators = checkCoordinators()
 	}
 
+	// Current budget burn
+	if spmCfg, err := config.LoadSPMConfig(); err == nil && len(spmCfg.Budgets) > 0 {
+		if db, err := getDB(); err == nil {
+			if statuses, err := budgetStatuses(cmd.Context(), spmCfg.Budgets, db); err == nil {
+				data.Budgets = statuses
+				for _, b := range statuses {
+					if !b.Exceeded {
+						continue
+					}
+					if b.Enforced {
+						suggestions = append(suggestions, fmt.Sprintf("Budget %s is exceeded (%s); activate/run/exec in %s need --override until %s.", b.Name, b.Summary(), b.Scope, b.ResetsAt.Format(time.RFC3339)))
+					} else {
+						suggestions = append(suggestions, fmt.Sprintf("Budget %s is exceeded (%s).", b.Name, b.Summary()))
+					}
+				}
+			}
+		}
+	}
+
 	duration := time.Since(start)
 	output := RobotOutput{
 		Success:     true,
@@ -395,6 +424,10 @@ func buildProviderInfo(tool string, compact bool) RobotProviderInfo {
 		AuthPaths:   []RobotAuthPath{},
 	}
 
+	if caps, ok := providerRegistry().Capabilities(tool); ok {
+		info.Capabilities = &caps
+	}
+
 	// Check if logged in
 	fileSet := tools[tool]()
 	info.LoggedIn = authfile.HasAuthFiles(fileSet)
@@ -628,7 +661,7 @@ func runRobotNext(cmd *cobra.Command, args []string) error {
 	if _, ok := tools[provider]; !ok {
 		return robotError(cmd, "next", "INVALID_PROVIDER",
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: codex, claude, gemini, opencode",
 			nil)
 	}
 
@@ -661,6 +694,10 @@ func runRobotNext(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	if strategy == string(rotation.AlgorithmResetAware) {
+		return runRobotNextResetAware(cmd, start, provider, profiles, db)
+	}
+
 	// Score each profile
 	type scoredProfile struct {
 		name    string
@@ -801,6 +838,69 @@ func runRobotNext(cmd *cobra.Command, args []string) error {
 	return robotOutput(cmd, output)
 }
 
+// runRobotNextResetAware answers robot next using the reset_aware rotation
+// algorithm over live usage data.
+func runRobotNextResetAware(cmd *cobra.Command, start time.Time, provider string, profiles []string, db *caamdb.DB) error {
+	selector := rotation.NewSelector(rotation.AlgorithmResetAware, healthStore, db)
+	applyRotationPolicies(selector, db, provider, profiles)
+	if err := applySelectionPolicy(selector, ""); err != nil {
+		return robotError(cmd, "next", "INVALID_POLICY",
+			err.Error(),
+			"",
+			[]string{"caam policy show"})
+	}
+	if supportsUsageAPI(provider) {
+		selector.SetUsageData(fetchUsageDataForProfiles(provider, profiles, "", db))
+	}
+
+	result, err := selector.Select(provider, profiles, "")
+	if err != nil {
+		return robotError(cmd, "next", "ALL_BLOCKED",
+			err.Error(),
+			"",
+			[]string{fmt.Sprintf("caam robot status %s", provider)})
+	}
+
+	data := RobotNextData{
+		Provider: provider,
+		Profile:  result.Selected,
+		Reasons:  []string{},
+		Command:  fmt.Sprintf("caam activate %s %s", provider, result.Selected),
+	}
+	for _, ps := range result.Alternatives {
+		var reasons []string
+		for _, r := range ps.Reasons {
+			reasons = append(reasons, r.Text)
+		}
+		if ps.Name == result.Selected {
+			data.Score = ps.Score
+			data.Reasons = append(data.Reasons, reasons...)
+			continue
+		}
+		alt := RobotNextProfile{
+			Provider: provider,
+			Profile:  ps.Name,
+			Score:    ps.Score,
+			Reason:   strings.Join(reasons, "; "),
+		}
+		if data.AlternateChoice == nil && ps.Score > -9000 {
+			data.AlternateChoice = &alt
+		}
+		data.Alternatives = append(data.Alternatives, alt)
+	}
+
+	duration := time.Since(start)
+	return robotOutput(cmd, RobotOutput{
+		Success: true,
+		Command: "next",
+		Data:    data,
+		Timing: &RobotTiming{
+			StartedAt:  start.UTC().Format(time.RFC3339),
+			DurationMs: duration.Milliseconds(),
+		},
+	})
+}
+
 func runRobotAct(cmd *cobra.Command, args []string) error {
 	start := time.Now()
 	action := strings.ToLower(args[0])
@@ -809,7 +909,7 @@ func runRobotAct(cmd *cobra.Command, args []string) error {
 	if _, ok := tools[provider]; !ok {
 		return robotError(cmd, "act", "INVALID_PROVIDER",
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: codex, claude, gemini, opencode",
 			nil)
 	}
 
@@ -1021,7 +1121,7 @@ func runRobotHealth(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check each provider
-	for _, tool := range []string{"codex", "claude", "gemini"} {
+	for _, tool := range []string{"codex", "claude", "gemini", "opencode"} {
 		profiles, err := vault.List(tool)
 		if err != nil {
 			continue
@@ -1094,7 +1194,7 @@ func runRobotWatch(cmd *cobra.Command, args []string) error {
 		if _, ok := tools[providerFilter]; !ok {
 			return robotError(cmd, "watch", "INVALID_PROVIDER",
 				fmt.Sprintf("unknown provider: %s", providerFilter),
-				"valid providers: codex, claude, gemini",
+				"valid providers: codex, claude, gemini, opencode",
 				nil)
 		}
 	}
@@ -1126,7 +1226,7 @@ func runRobotWatch(cmd *cobra.Command, args []string) error {
 }
 
 func emitWatchStatus(cmd *cobra.Command, providerFilter string) error {
-	providersToCheck := []string{"codex", "claude", "gemini"}
+	providersToCheck := []string{"codex", "claude", "gemini", "opencode"}
 	if providerFilter != "" {
 		providersToCheck = []string{providerFilter}
 	}
@@ -1324,7 +1424,7 @@ func init() {
 	robotStatusCmd.Flags().Bool("include-coordinators", false, "check coordinator status")
 
 	// Next flags
-	robotNextCmd.Flags().String("strategy", "smart", "selection strategy: smart, lru, random")
+	robotNextCmd.Flags().String("strategy", "smart", "selection strategy: smart, lru, random, reset_aware")
 	robotNextCmd.Flags().Bool("include-cooldown", false, "include profiles in cooldown")
 
 	// Watch flags
@@ -1377,7 +1477,7 @@ func runRobotLimits(cmd *cobra.Command, args []string) error {
 	if _, ok := tools[provider]; !ok {
 		return robotError(cmd, "limits", "INVALID_PROVIDER",
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: codex, claude, gemini, opencode",
 			nil)
 	}
 
@@ -1506,7 +1606,7 @@ func runRobotPrecheck(cmd *cobra.Command, args []string) error {
 	if _, ok := tools[provider]; !ok {
 		return robotError(cmd, "precheck", "INVALID_PROVIDER",
 			fmt.Sprintf("unknown provider: %s", provider),
-			"valid providers: codex, claude, gemini",
+			"valid providers: codex, claude, gemini, opencode",
 			nil)
 	}
 
@@ -1690,7 +1790,7 @@ func runRobotValidate(cmd *cobra.Command, args []string) error {
 		if _, ok := tools[provider]; !ok {
 			return robotError(cmd, "validate", "INVALID_PROVIDER",
 				fmt.Sprintf("unknown provider: %s", provider),
-				"valid providers: codex, claude, gemini",
+				"valid providers: codex, claude, gemini, opencode",
 				nil)
 		}
 		providersToCheck = []string{provider}
@@ -1698,7 +1798,7 @@ func runRobotValidate(cmd *cobra.Command, args []string) error {
 			profileFilter = args[1]
 		}
 	} else {
-		providersToCheck = []string{"codex", "claude", "gemini"}
+		providersToCheck = []string{"codex", "claude", "gemini", "opencode"}
 	}
 
 	data := RobotValidateData{
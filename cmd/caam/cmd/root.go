@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +31,9 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/opencode"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/plugin"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/tui"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/warnings"
@@ -50,9 +54,10 @@ var (
 
 // Tools supported for auth file swapping
 var tools = map[string]func() authfile.AuthFileSet{
-	"codex":  authfile.CodexAuthFiles,
-	"claude": authfile.ClaudeAuthFiles,
-	"gemini": authfile.GeminiAuthFiles,
+	"codex":    authfile.CodexAuthFiles,
+	"claude":   authfile.ClaudeAuthFiles,
+	"gemini":   authfile.GeminiAuthFiles,
+	"opencode": authfile.OpenCodeAuthFiles,
 }
 
 // getDB returns the global database connection, initializing it if necessary.
@@ -100,7 +105,7 @@ Advanced: Profile isolation for simultaneous sessions:
 Run 'caam' without arguments to launch the interactive TUI.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If called with no subcommand, launch TUI
-		return tui.Run()
+		return tui.RunWithRegistry(registry)
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if _, err := config.MigrateDataToCAAMHome(); err != nil {
@@ -110,6 +115,22 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 		// Initialize vault
 		vault = authfile.NewVault(authfile.DefaultVaultPath())
 
+		// Encrypted vaults are unlocked by the daemon's key agent or by
+		// CAAM_VAULT_PASSPHRASE (see 'caam vault').
+		authfile.SetKeySource(authfile.ChainKeySources(
+			authfile.AgentKeySource(authfile.DefaultKeyAgentSocket()),
+			authfile.EnvKeySource(vault),
+		))
+		// Profiles pulled by end-to-end encrypted sync are opened with this
+		// machine's sync key (see 'caam sync trust').
+		authfile.SetPayloadOpener(sync.OpenLocalPayload)
+
+		// Finish activations interrupted by a crash or Ctrl-C. Doctor reports
+		// them itself.
+		if cmd.Name() != "doctor" {
+			recoverTornActivations()
+		}
+
 		// Initialize profile store
 		profileStore = profile.NewStore(profile.DefaultStorePath())
 
@@ -124,6 +145,8 @@ Run 'caam' without arguments to launch the interactive TUI.`,
 		registry.Register(codex.New())
 		registry.Register(claude.New())
 		registry.Register(gemini.New())
+		registry.Register(opencode.New())
+		loadProviderPlugins()
 
 		// Initialize runner
 		runner = exec.NewRunner(registry)
@@ -154,6 +177,86 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// recoverTornActivations rolls pending activation journals forward or back
+// and tells the user what happened.
+func recoverTornActivations() {
+	results, err := vault.RecoverJournals()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: check activation journal: %v\n", err)
+		return
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: interrupted activation of %s/%s could not be repaired: %v (run 'caam doctor --fix')\n",
+				r.Journal.Tool, r.Journal.Profile, r.Err)
+			continue
+		}
+		switch r.Action {
+		case authfile.JournalRolledForward:
+			fmt.Fprintf(os.Stderr, "Completed interrupted activation of %s/%s\n", r.Journal.Tool, r.Journal.Profile)
+		case authfile.JournalRolledBack:
+			fmt.Fprintf(os.Stderr, "Rolled back interrupted activation of %s/%s\n", r.Journal.Tool, r.Journal.Profile)
+		}
+	}
+}
+
+// pluginSpecs holds the declarative provider plugins loaded at startup.
+var pluginSpecs []*plugin.Spec
+
+// loadProviderPlugins registers the providers defined in providers.d and
+// makes their tools available to every command.
+func loadProviderPlugins() {
+	specs, errs := plugin.LoadAndRegister(registry, plugin.DefaultDir())
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: provider plugin skipped: %v\n", err)
+	}
+	pluginSpecs = specs
+	for _, spec := range specs {
+		tools[spec.ID] = spec.AuthFileSet
+	}
+}
+
+// providerRegistry returns the registry set up at startup, or the built-in
+// providers when a command runs without it (e.g., called directly in tests).
+func providerRegistry() *provider.Registry {
+	if registry != nil {
+		return registry
+	}
+	reg := provider.NewRegistry()
+	reg.Register(codex.New())
+	reg.Register(claude.New())
+	reg.Register(gemini.New())
+	reg.Register(opencode.New())
+	for _, spec := range pluginSpecs {
+		reg.Register(plugin.New(spec))
+	}
+	return reg
+}
+
+// providerSupports reports whether tool is a registered provider with the
+// given capability.
+func providerSupports(tool string, capability provider.Capability) bool {
+	caps, ok := providerRegistry().Capabilities(tool)
+	return ok && caps.Has(capability)
+}
+
+// supportsUsageAPI reports whether live usage can be fetched for tool.
+func supportsUsageAPI(tool string) bool {
+	return providerSupports(tool, provider.CapUsageAPI)
+}
+
+// providersWith returns the sorted IDs of the providers with capability.
+func providersWith(capability provider.Capability) []string {
+	var ids []string
+	for _, p := range providerRegistry().All() {
+		if p.Capabilities().Has(capability) {
+			ids = append(ids, p.ID())
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // shouldShowWarnings returns true if the current command should display token warnings.
 // Some commands are excluded because they're:
 // - Quick info commands (version, paths)
@@ -262,6 +365,14 @@ func getProfileHealthWithIdentity(tool, profileName string) (*health.ProfileHeal
 }
 
 func getVaultIdentity(tool, profileName string) *identity.Identity {
+	id := readVaultIdentity(tool, profileName)
+	normalizeIdentityPlan(id)
+	return id
+}
+
+// readVaultIdentity extracts identity from a vault profile without
+// normalizing its plan type.
+func readVaultIdentity(tool, profileName string) *identity.Identity {
 	if vault == nil {
 		return nil
 	}
@@ -273,14 +384,12 @@ func getVaultIdentity(tool, profileName string) *identity.Identity {
 		if err != nil {
 			return nil
 		}
-		normalizeIdentityPlan(id)
 		return id
 	case "claude":
 		id, err := identity.ExtractFromClaudeCredentials(filepath.Join(vaultPath, ".credentials.json"))
 		if err != nil {
 			return nil
 		}
-		normalizeIdentityPlan(id)
 		return id
 	case "gemini":
 		candidates := []string{
@@ -292,9 +401,14 @@ func getVaultIdentity(tool, profileName string) *identity.Identity {
 			if err != nil {
 				continue
 			}
-			normalizeIdentityPlan(id)
 			return id
 		}
+	case "opencode":
+		id, err := identity.ExtractFromOpenCodeAuth(filepath.Join(vaultPath, "auth.json"))
+		if err != nil {
+			return nil
+		}
+		return id
 	}
 
 	return nil
@@ -599,7 +713,7 @@ func runBackup(cmd *cobra.Command, args []string) error {
 
 	getFileSet, ok := tools[tool]
 	if !ok {
-		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool))
+		return emitJSONError(fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool))
 	}
 
 	fileSet := getFileSet()
@@ -678,7 +792,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 	formatOpts := health.FormatOptions{NoColor: noColor || !isTerminal()}
 
-	toolsToCheck := []string{"codex", "claude", "gemini"}
+	toolsToCheck := []string{"codex", "claude", "gemini", "opencode"}
 	if len(args) > 0 {
 		tool := strings.ToLower(args[0])
 		if _, ok := tools[tool]; !ok {
@@ -1146,7 +1260,7 @@ Examples:
   caam paths claude    # Show just Claude`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		toolsToShow := []string{"codex", "claude", "gemini"}
+		toolsToShow := []string{"codex", "claude", "gemini", "opencode"}
 		if len(args) > 0 {
 			tool := strings.ToLower(args[0])
 			if _, ok := tools[tool]; !ok {
@@ -1735,9 +1849,12 @@ Examples:
 		ctx := context.Background()
 		deviceCode, _ := cmd.Flags().GetBool("device-code")
 		if deviceCode {
+			if err := provider.Require(prov, provider.CapDeviceCode); err != nil {
+				return err
+			}
 			deviceCodeProv, ok := prov.(provider.DeviceCodeProvider)
-			if !ok || !deviceCodeProv.SupportsDeviceCode() {
-				return fmt.Errorf("%s does not support --device-code", tool)
+			if !ok {
+				return &provider.UnsupportedError{Provider: tool, Capability: provider.CapDeviceCode}
 			}
 			if err := deviceCodeProv.LoginWithDeviceCode(ctx, prof); err != nil {
 				return fmt.Errorf("device-code login failed: %w", err)
@@ -1794,6 +1911,11 @@ Examples:
 		ctx := context.Background()
 		noLock, _ := cmd.Flags().GetBool("no-lock")
 
+		override, _ := cmd.Flags().GetBool("override")
+		if err := enforceBudgets(ctx, tool, name, override, os.Stderr); err != nil {
+			return err
+		}
+
 		return runner.Run(ctx, exec.RunOptions{
 			Profile:  prof,
 			Provider: prov,
@@ -1805,4 +1927,5 @@ Examples:
 
 func init() {
 	execCmd.Flags().Bool("no-lock", false, "don't lock the profile during execution")
+	execCmd.Flags().Bool("override", false, "run even if an enforced budget is exceeded")
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/spf13/cobra"
 )
 
@@ -606,3 +607,24 @@ func TestFormatIdentityDisplay_ClaudeEmptyEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderCapabilityHelpers(t *testing.T) {
+	// Falls back to the built-in providers when no registry was set up.
+	oldRegistry := registry
+	registry = nil
+	t.Cleanup(func() { registry = oldRegistry })
+
+	got := providersWith(provider.CapUsageAPI)
+	if strings.Join(got, ",") != "claude,codex,gemini" {
+		t.Errorf("providersWith(usage_api) = %v, want [claude codex gemini]", got)
+	}
+	if !providerSupports("gemini", provider.CapSessionResume) {
+		t.Error("gemini should support session resume")
+	}
+	if providerSupports("opencode", provider.CapSessionResume) {
+		t.Error("opencode should not support session resume")
+	}
+	if supportsUsageAPI("unknown") {
+		t.Error("unknown provider should not support the usage API")
+	}
+}
@@ -0,0 +1,523 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/rotation"
+)
+
+var rotationCmd = &cobra.Command{
+	Use:   "rotation",
+	Short: "Manage and backtest profile rotation",
+	Long: `Manage how rotation treats individual profiles, and backtest rotation
+algorithms against recorded usage.
+
+A rotation policy can give a profile:
+  - a weight: its relative share of activations (default 1)
+  - daily/weekly caps on activations or tokens (rolling 24h / 7d windows)
+  - a reserve flag: only used when no other profile is eligible
+
+Policies are stored with the profile's metadata and honored by the smart,
+round_robin, random and reset_aware algorithms. Use 'caam next --explain'
+to see how they affected a selection. Token caps count the tokens in the
+tool's local logs attributed to the profile (see 'caam cost').
+
+Examples:
+  caam rotation set claude work --weight 0.7
+  caam rotation set claude personal --weight 0.3 --daily-activations 5
+  caam rotation set claude backup --reserve
+  caam rotation show claude
+  caam rotation clear claude personal
+  caam rotation simulate claude --since 30d`,
+}
+
+var rotationSetCmd = &cobra.Command{
+	Use:   "set <tool> <profile>",
+	Short: "Set rotation policy for a profile",
+	Long: `Set weight, caps or the reserve flag for a profile. Only the flags given
+are changed; a value of 0 removes a weight or cap.
+
+Examples:
+  caam rotation set claude work --weight 0.7
+  caam rotation set claude personal --daily-activations 5 --weekly-tokens 2000000
+  caam rotation set claude backup --reserve
+  caam rotation set claude backup --reserve=false`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRotationSet,
+}
+
+var rotationShowCmd = &cobra.Command{
+	Use:   "show [tool]",
+	Short: "Show rotation policies",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRotationShow,
+}
+
+var rotationClearCmd = &cobra.Command{
+	Use:   "clear <tool> <profile>",
+	Short: "Remove the rotation policy from a profile",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRotationClear,
+}
+
+var rotationSimulateCmd = &cobra.Command{
+	Use:   "simulate <tool>",
+	Short: "Backtest rotation algorithms against recorded history",
+	Long: `Replay recorded usage from the caam database (wrap_sessions, activity_log
+and limit_events) through each rotation algorithm and compare the outcomes.
+
+Each recorded session is treated as a rotation decision; when the chosen
+profile runs out of quota mid-session the algorithm picks again. Quota is
+modeled as a window (--window, default 5h) that opens on first use and can
+serve --capacity of session time. Without --capacity, it is inferred from
+how much each profile was used before its recorded limit hits.
+
+The "recorded" row shows what actually happened. Current per-profile
+rotation policies (see 'caam rotation set') are applied to the simulation.
+
+Reported per algorithm:
+  limit hits  - profiles exhausting their quota
+  switches    - changes of active profile
+  blocked     - demand that found every profile exhausted
+  idle quota  - capacity in started windows that reset unused
+  load        - share of session time served by each profile
+
+Examples:
+  caam rotation simulate claude
+  caam rotation simulate claude --since 90d --capacity 2h
+  caam rotation simulate codex --algorithm smart --algorithm reset_aware --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRotationSimulate,
+}
+
+func init() {
+	rootCmd.AddCommand(rotationCmd)
+	rotationCmd.AddCommand(rotationSetCmd)
+	rotationCmd.AddCommand(rotationShowCmd)
+	rotationCmd.AddCommand(rotationClearCmd)
+	rotationCmd.AddCommand(rotationSimulateCmd)
+
+	rotationSetCmd.Flags().Float64("weight", 0, "relative share of activations (default 1)")
+	rotationSetCmd.Flags().Int("daily-activations", 0, "max activations in the last 24h (0 = no cap)")
+	rotationSetCmd.Flags().Int("weekly-activations", 0, "max activations in the last 7d (0 = no cap)")
+	rotationSetCmd.Flags().Int64("daily-tokens", 0, "max tokens in the last 24h (0 = no cap)")
+	rotationSetCmd.Flags().Int64("weekly-tokens", 0, "max tokens in the last 7d (0 = no cap)")
+	rotationSetCmd.Flags().Bool("reserve", false, "only use this profile when no other is eligible")
+
+	rotationShowCmd.Flags().Bool("json", false, "output in JSON format")
+
+	rotationSimulateCmd.Flags().String("since", "30d", "history to replay (e.g., '7d', '30d')")
+	rotationSimulateCmd.Flags().Duration("window", rotation.DefaultSimWindow, "quota window length")
+	rotationSimulateCmd.Flags().Duration("capacity", 0, "session time one profile serves per window (default: inferred)")
+	rotationSimulateCmd.Flags().StringSlice("algorithm", nil, "algorithms to simulate (default: all)")
+	rotationSimulateCmd.Flags().Int64("seed", 1, "random seed for reproducible runs")
+	rotationSimulateCmd.Flags().Bool("json", false, "output in JSON format")
+}
+
+// loadPolicyProfile loads the stored metadata for a profile, creating it for
+// vault profiles that have none yet.
+func loadPolicyProfile(tool, name string) (*profile.Profile, error) {
+	if profileStore == nil {
+		profileStore = profile.NewStore(profile.DefaultStorePath())
+	}
+	if prof, err := profileStore.Load(tool, name); err == nil {
+		return prof, nil
+	} else if profileStore.Exists(tool, name) {
+		return nil, fmt.Errorf("load profile: %w", err)
+	}
+
+	v := vault
+	if v == nil {
+		v = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	names, _ := v.List(tool)
+	if !slices.Contains(names, name) {
+		return nil, fmt.Errorf("profile %s/%s not found", tool, name)
+	}
+	prof, err := profileStore.Create(tool, name, "oauth")
+	if err != nil {
+		return nil, fmt.Errorf("create profile metadata: %w", err)
+	}
+	return prof, nil
+}
+
+func runRotationSet(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	name := args[1]
+
+	prof, err := loadPolicyProfile(tool, name)
+	if err != nil {
+		return err
+	}
+
+	pol := profile.RotationPolicy{}
+	if prof.Rotation != nil {
+		pol = *prof.Rotation
+	}
+	flags := cmd.Flags()
+	if flags.Changed("weight") {
+		pol.Weight, _ = flags.GetFloat64("weight")
+	}
+	if flags.Changed("daily-activations") {
+		pol.DailyActivations, _ = flags.GetInt("daily-activations")
+	}
+	if flags.Changed("weekly-activations") {
+		pol.WeeklyActivations, _ = flags.GetInt("weekly-activations")
+	}
+	if flags.Changed("daily-tokens") {
+		pol.DailyTokens, _ = flags.GetInt64("daily-tokens")
+	}
+	if flags.Changed("weekly-tokens") {
+		pol.WeeklyTokens, _ = flags.GetInt64("weekly-tokens")
+	}
+	if flags.Changed("reserve") {
+		pol.Reserve, _ = flags.GetBool("reserve")
+	}
+	if err := pol.Validate(); err != nil {
+		return err
+	}
+
+	prof.Rotation = &pol
+	if pol.IsZero() {
+		prof.Rotation = nil
+	}
+	if err := prof.Save(); err != nil {
+		return fmt.Errorf("save profile: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Rotation policy for %s/%s: %s\n", tool, name, formatRotationPolicy(prof.Rotation))
+	return nil
+}
+
+func runRotationClear(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	name := args[1]
+
+	if profileStore == nil {
+		profileStore = profile.NewStore(profile.DefaultStorePath())
+	}
+	prof, err := profileStore.Load(tool, name)
+	if err != nil {
+		return fmt.Errorf("load profile: %w", err)
+	}
+	prof.Rotation = nil
+	if err := prof.Save(); err != nil {
+		return fmt.Errorf("save profile: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Cleared rotation policy for %s/%s\n", tool, name)
+	return nil
+}
+
+func runRotationShow(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if profileStore == nil {
+		profileStore = profile.NewStore(profile.DefaultStorePath())
+	}
+
+	var toolNames []string
+	if len(args) > 0 {
+		toolNames = []string{strings.ToLower(args[0])}
+	} else {
+		for name := range tools {
+			toolNames = append(toolNames, name)
+		}
+		sort.Strings(toolNames)
+	}
+
+	type policyEntry struct {
+		Tool    string                  `json:"tool"`
+		Profile string                  `json:"profile"`
+		Policy  *profile.RotationPolicy `json:"policy"`
+	}
+	entries := []policyEntry{}
+	for _, tool := range toolNames {
+		profs, err := profileStore.List(tool)
+		if err != nil {
+			continue
+		}
+		for _, prof := range profs {
+			if prof.Rotation.IsZero() {
+				continue
+			}
+			entries = append(entries, policyEntry{Tool: tool, Profile: prof.Name, Policy: prof.Rotation})
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No rotation policies set")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Fprintf(out, "%s/%s: %s\n", e.Tool, e.Profile, formatRotationPolicy(e.Policy))
+	}
+	return nil
+}
+
+// formatRotationPolicy renders a policy as a short human-readable summary.
+func formatRotationPolicy(pol *profile.RotationPolicy) string {
+	if pol.IsZero() {
+		return "default (weight 1, no caps)"
+	}
+	var parts []string
+	parts = append(parts, fmt.Sprintf("weight %g", pol.EffectiveWeight()))
+	if pol.DailyActivations > 0 {
+		parts = append(parts, fmt.Sprintf("%d activations/day", pol.DailyActivations))
+	}
+	if pol.WeeklyActivations > 0 {
+		parts = append(parts, fmt.Sprintf("%d activations/week", pol.WeeklyActivations))
+	}
+	if pol.DailyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens/day", pol.DailyTokens))
+	}
+	if pol.WeeklyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens/week", pol.WeeklyTokens))
+	}
+	if pol.Reserve {
+		parts = append(parts, "reserve")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// applyRotationPolicies loads the rotation policies of profiles into
+// selector. If any policy caps tokens, the tool's logs are ingested into db
+// first and db counts the tokens; without a database, token caps are
+// reported but not enforced.
+func applyRotationPolicies(selector *rotation.Selector, db *caamdb.DB, tool string, profiles []string) {
+	policies := rotationPolicies(tool, profiles)
+	selector.SetPolicies(policies)
+	if db == nil {
+		return
+	}
+	for _, pol := range policies {
+		if pol.DailyTokens > 0 || pol.WeeklyTokens > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			_, _ = ingestLogSources(ctx, db, tool)
+			selector.SetTokenCounter(db)
+			return
+		}
+	}
+}
+
+// rotationPolicies returns the rotation policies stored for profiles.
+func rotationPolicies(tool string, profiles []string) map[string]*profile.RotationPolicy {
+	store := profileStore
+	if store == nil {
+		store = profile.NewStore(profile.DefaultStorePath())
+	}
+	policies := make(map[string]*profile.RotationPolicy)
+	for _, name := range profiles {
+		prof, err := store.Load(tool, name)
+		if err != nil || prof.Rotation.IsZero() {
+			continue
+		}
+		policies[name] = prof.Rotation
+	}
+	return policies
+}
+
+// simulationJSON is the JSON form of a simulation run.
+type simulationJSON struct {
+	Provider        string                 `json:"provider"`
+	Since           time.Time              `json:"since"`
+	Sessions        int                    `json:"sessions"`
+	Profiles        []string               `json:"profiles"`
+	WindowMinutes   float64                `json:"window_minutes"`
+	CapacityMinutes float64                `json:"capacity_minutes"`
+	CapacitySource  string                 `json:"capacity_source"`
+	Results         []simulationResultJSON `json:"results"`
+}
+
+type simulationResultJSON struct {
+	Algorithm      string             `json:"algorithm"`
+	LimitHits      int                `json:"limit_hits"`
+	Switches       int                `json:"switches"`
+	BlockedMinutes float64            `json:"blocked_minutes"`
+	IdleQuota      *float64           `json:"idle_quota,omitempty"`
+	LoadMinutes    map[string]float64 `json:"load_minutes"`
+}
+
+func runRotationSimulate(cmd *cobra.Command, args []string) error {
+	tool := strings.ToLower(args[0])
+	if _, ok := tools[tool]; !ok {
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
+	}
+
+	sinceStr, _ := cmd.Flags().GetString("since")
+	window, _ := cmd.Flags().GetDuration("window")
+	capacity, _ := cmd.Flags().GetDuration("capacity")
+	algoNames, _ := cmd.Flags().GetStringSlice("algorithm")
+	seed, _ := cmd.Flags().GetInt64("seed")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	sinceDur, err := parseDuration(sinceStr)
+	if err != nil {
+		return fmt.Errorf("invalid --since duration: %w", err)
+	}
+	since := time.Now().Add(-sinceDur)
+	if window <= 0 {
+		return fmt.Errorf("--window must be positive")
+	}
+	if capacity < 0 {
+		return fmt.Errorf("--capacity cannot be negative")
+	}
+
+	algorithms := []rotation.Algorithm{
+		rotation.AlgorithmSmart,
+		rotation.AlgorithmRoundRobin,
+		rotation.AlgorithmRandom,
+		rotation.AlgorithmResetAware,
+	}
+	if len(algoNames) > 0 {
+		algorithms = nil
+		for _, name := range algoNames {
+			algo := rotation.Algorithm(strings.ToLower(strings.TrimSpace(name)))
+			switch algo {
+			case rotation.AlgorithmSmart, rotation.AlgorithmRoundRobin, rotation.AlgorithmRandom, rotation.AlgorithmResetAware:
+				algorithms = append(algorithms, algo)
+			default:
+				return fmt.Errorf("unknown algorithm: %s (supported: smart, round_robin, random, reset_aware)", name)
+			}
+		}
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	history, err := rotation.LoadSimHistory(db, tool, since)
+	if err != nil {
+		return fmt.Errorf("load history: %w", err)
+	}
+	v := vault
+	if v == nil {
+		v = authfile.NewVault(authfile.DefaultVaultPath())
+	}
+	if names, err := v.List(tool); err == nil {
+		history.AddProfiles(names)
+	}
+	if len(history.Sessions) == 0 {
+		return fmt.Errorf("no recorded sessions for %s since %s; history comes from 'caam run' and activity logs", tool, since.Format("2006-01-02"))
+	}
+
+	capacitySource := "--capacity"
+	if capacity == 0 {
+		capacity = history.InferCapacity(window)
+		capacitySource = fmt.Sprintf("inferred from %d limit hits", len(history.Limits))
+		if capacity == 0 {
+			capacitySource = "unknown (no limit hits recorded)"
+		}
+	}
+
+	cfg := rotation.SimConfig{
+		Window:   window,
+		Capacity: capacity,
+		Policies: rotationPolicies(tool, history.Profiles),
+		Seed:     seed,
+	}
+
+	reports := make([]*rotation.SimReport, 0, len(algorithms)+1)
+	for _, algo := range append([]rotation.Algorithm{rotation.AlgorithmRecorded}, algorithms...) {
+		report, err := rotation.Simulate(history, algo, cfg)
+		if err != nil {
+			return fmt.Errorf("simulate %s: %w", algo, err)
+		}
+		reports = append(reports, report)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		payload := simulationJSON{
+			Provider:        tool,
+			Since:           since.UTC(),
+			Sessions:        len(history.Sessions),
+			Profiles:        history.Profiles,
+			WindowMinutes:   window.Minutes(),
+			CapacityMinutes: capacity.Minutes(),
+			CapacitySource:  capacitySource,
+		}
+		for _, r := range reports {
+			res := simulationResultJSON{
+				Algorithm:      string(r.Algorithm),
+				LimitHits:      r.LimitHits,
+				Switches:       r.Switches,
+				BlockedMinutes: r.Blocked.Minutes(),
+				LoadMinutes:    make(map[string]float64, len(r.Load)),
+			}
+			if r.IdleQuota >= 0 {
+				idle := r.IdleQuota
+				res.IdleQuota = &idle
+			}
+			for p, d := range r.Load {
+				res.LoadMinutes[p] = d.Minutes()
+			}
+			payload.Results = append(payload.Results, res)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(payload)
+	}
+
+	fmt.Fprintf(out, "Replayed %d sessions for %s since %s across %d profiles\n",
+		len(history.Sessions), tool, since.Format("2006-01-02"), len(history.Profiles))
+	if capacity > 0 {
+		fmt.Fprintf(out, "Quota model: %s windows, %s of session time per window (%s)\n\n",
+			formatDurationShort(window), formatDurationShort(capacity), capacitySource)
+	} else {
+		fmt.Fprintf(out, "Quota model: %s windows, capacity %s; limit hits and idle quota are not modeled\n\n",
+			formatDurationShort(window), capacitySource)
+	}
+	renderSimulationTable(out, reports, history.Profiles)
+	return nil
+}
+
+func renderSimulationTable(out io.Writer, reports []*rotation.SimReport, profiles []string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALGORITHM\tLIMIT HITS\tSWITCHES\tBLOCKED\tIDLE QUOTA\tLOAD")
+	for _, r := range reports {
+		var total time.Duration
+		for _, d := range r.Load {
+			total += d
+		}
+		var load []string
+		for _, p := range profiles {
+			if d := r.Load[p]; d > 0 && total > 0 {
+				load = append(load, fmt.Sprintf("%s %.0f%%", p, 100*float64(d)/float64(total)))
+			}
+		}
+		idle := "-"
+		if r.IdleQuota >= 0 {
+			idle = fmt.Sprintf("%.0f%%", 100*r.IdleQuota)
+		}
+		blocked := "-"
+		if r.Blocked > 0 {
+			blocked = formatDurationShort(r.Blocked)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
+			r.Algorithm, r.LimitHits, r.Switches, blocked, idle, strings.Join(load, ", "))
+	}
+	w.Flush()
+}
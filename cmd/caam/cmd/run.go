@@ -53,6 +53,10 @@ Examples:
   # Proactive switching (checks usage before running)
   caam run claude --precheck -- "explain this code"
 
+  # Select and precheck on the Opus quota window; --model is passed on to
+  # the CLI (a --model after -- is recognized the same way)
+  caam run claude --model opus --precheck -- "refactor this module"
+
   # Interactive mode (no auto-retry on rate limit)
   caam run claude
 
@@ -73,9 +77,11 @@ func init() {
 	runCmd.Flags().Int("max-retries", 1, "maximum retry attempts on rate limit (0 = no retries)")
 	runCmd.Flags().Duration("cooldown", 60*time.Minute, "cooldown duration after rate limit")
 	runCmd.Flags().Bool("quiet", false, "suppress profile switch notifications")
-	runCmd.Flags().String("algorithm", "smart", "rotation algorithm (smart, round_robin, random)")
+	runCmd.Flags().String("algorithm", "smart", "rotation algorithm (smart, round_robin, random, reset_aware)")
 	runCmd.Flags().Bool("precheck", false, "check usage levels before running and switch if near limit")
 	runCmd.Flags().Float64("precheck-threshold", 0.8, "usage threshold for precheck switching (0-1)")
+	runCmd.Flags().String("model", "", "model to run (passed to the CLI; selection uses that model's quota window)")
+	runCmd.Flags().Bool("override", false, "run even if an enforced budget is exceeded")
 }
 
 func runWrap(cmd *cobra.Command, args []string) error {
@@ -87,7 +93,7 @@ func runWrap(cmd *cobra.Command, args []string) error {
 
 	// Validate tool
 	if _, ok := tools[tool]; !ok {
-		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini)", tool)
+		return fmt.Errorf("unknown tool: %s (supported: codex, claude, gemini, opencode)", tool)
 	}
 
 	// Parse CLI args (everything after the tool name)
@@ -95,6 +101,17 @@ func runWrap(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
 		cliArgs = args[1:]
 	}
+	model := modelFromArgs(cliArgs)
+	if m, _ := cmd.Flags().GetString("model"); m != "" {
+		switch model {
+		case "":
+			model = m
+			cliArgs = append([]string{"--model", m}, cliArgs...)
+		case m:
+		default:
+			return fmt.Errorf("--model %s conflicts with --model %s passed to %s", m, model, tool)
+		}
+	}
 
 	// Get flags
 	quiet, _ := cmd.Flags().GetBool("quiet")
@@ -110,8 +127,10 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		algorithm = rotation.AlgorithmRoundRobin
 	case "random":
 		algorithm = rotation.AlgorithmRandom
+	case "reset_aware", "resetaware":
+		algorithm = rotation.AlgorithmResetAware
 	default:
-		return fmt.Errorf("unknown algorithm: %s (supported: smart, round_robin, random)", algorithmStr)
+		return fmt.Errorf("unknown algorithm: %s (supported: smart, round_robin, random, reset_aware)", algorithmStr)
 	}
 
 	// Initialize vault
@@ -146,8 +165,8 @@ func runWrap(cmd *cobra.Command, args []string) error {
 	// Precheck: switch profile if near limit before running
 	precheck, _ := cmd.Flags().GetBool("precheck")
 	precheckThreshold, _ := cmd.Flags().GetFloat64("precheck-threshold")
-	if precheck && (tool == "claude" || tool == "codex") {
-		if switched := runPrecheck(tool, precheckThreshold, quiet, db, algorithm); switched && !quiet {
+	if precheck && supportsUsageAPI(tool) {
+		if switched := runPrecheck(tool, precheckThreshold, quiet, db, algorithm, model); switched && !quiet {
 			fmt.Fprintf(os.Stderr, "caam: switched profile before running (usage was near limit)\n")
 		}
 	}
@@ -162,6 +181,15 @@ func runWrap(cmd *cobra.Command, args []string) error {
 
 	// Initialize Rotation Selector
 	selector := rotation.NewSelector(algorithm, healthStore, db)
+	if err := applySelectionPolicy(selector, model); err != nil {
+		return err
+	}
+	if profiles, err := vault.List(tool); err == nil {
+		applyRotationPolicies(selector, db, tool, profiles)
+		if algorithm == rotation.AlgorithmResetAware && supportsUsageAPI(tool) {
+			selector.SetUsageData(fetchUsageDataForProfiles(tool, profiles, model, db))
+		}
+	}
 
 	// Initialize Runner
 	if runner == nil {
@@ -172,7 +200,7 @@ func runWrap(cmd *cobra.Command, args []string) error {
 	// Initialize Notifier
 	var notifier notify.Notifier
 	if !quiet {
-		notifier = notify.NewTerminalNotifier(os.Stderr, true)
+		notifier = runNotifier(spmCfg, db)
 	}
 
 	// Create SmartRunner
@@ -216,6 +244,12 @@ func runWrap(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Budgets: refuse to run on a profile whose enforced budget is used up.
+	override, _ := cmd.Flags().GetBool("override")
+	if err := enforceBudgets(cmd.Context(), tool, activeProfileName, override, os.Stderr); err != nil {
+		return err
+	}
+
 	// Load profile object
 	prof, err := profileStore.Load(tool, activeProfileName)
 	if err != nil {
@@ -286,8 +320,23 @@ func runWrap(cmd *cobra.Command, args []string) error {
 }
 
 // runPrecheck checks current usage levels and switches profile if near limit.
+// With a model, only the windows that limit that model are considered.
 // Returns true if a switch was performed.
-func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algorithm rotation.Algorithm) bool {
+// runNotifier returns the notifier for profile switches during 'caam run':
+// the configured alert channels when alerts are enabled, otherwise the
+// terminal.
+func runNotifier(spmCfg *config.SPMConfig, db *caamdb.DB) notify.Notifier {
+	if !spmCfg.Alerts.Enabled {
+		return notify.NewTerminalNotifier(os.Stderr, true)
+	}
+	opts := []notify.RouterOption{notify.WithTags(profileTags)}
+	if db != nil {
+		opts = append(opts, notify.WithDeduper(db), notify.WithOutbox(db))
+	}
+	return notify.FromConfig(spmCfg.Alerts.Notifications, os.Stderr, opts...)
+}
+
+func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algorithm rotation.Algorithm, model string) bool {
 	// Get current profile's access token
 	vaultDir := authfile.DefaultVaultPath()
 
@@ -313,14 +362,14 @@ func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algo
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	fetcher := usage.NewMultiProfileFetcher()
+	fetcher := newUsageFetcher(db)
 	results := fetcher.FetchAllProfiles(ctx, tool, map[string]string{currentProfile: token})
 
 	if len(results) == 0 || results[0].Usage == nil {
 		return false
 	}
 
-	currentUsage := results[0].Usage
+	currentUsage := results[0].Usage.ForModel(model)
 
 	// Check if near limit
 	if !currentUsage.IsNearLimit(threshold) {
@@ -342,28 +391,14 @@ func runPrecheck(tool string, threshold float64, quiet bool, db *caamdb.DB, algo
 	// Fetch usage for all profiles
 	allResults := fetcher.FetchAllProfiles(ctx, tool, allCredentials)
 
-	// Convert to rotation.UsageInfo format
-	usageData := make(map[string]*rotation.UsageInfo)
-	for _, r := range allResults {
-		if r.Usage == nil {
-			continue
-		}
-		info := &rotation.UsageInfo{
-			ProfileName: r.ProfileName,
-			AvailScore:  r.Usage.AvailabilityScore(),
-			Error:       r.Usage.Error,
-		}
-		if r.Usage.PrimaryWindow != nil {
-			info.PrimaryPercent = r.Usage.PrimaryWindow.UsedPercent
-		}
-		if r.Usage.SecondaryWindow != nil {
-			info.SecondaryPercent = r.Usage.SecondaryWindow.UsedPercent
-		}
-		usageData[r.ProfileName] = info
-	}
+	usageData := rotationUsage(allResults, model, newForecastEngine(ctx, db, []string{tool}))
 
 	// Use rotation selector with usage data
 	selector := rotation.NewSelector(algorithm, nil, db)
+	applyRotationPolicies(selector, db, tool, allProfiles)
+	if err := applySelectionPolicy(selector, model); err != nil {
+		return false
+	}
 	selector.SetUsageData(usageData)
 
 	result, err := selector.Select(tool, allProfiles, currentProfile)
@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	caamexec "github.com/Dicklesworthstone/coding_agent_account_manager/internal/exec"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
@@ -201,4 +203,21 @@ db, err = caamdb.Open()
 	require.NotNil(t, ev, "Active profile should be in cooldown")
 	
 h.EndStep("Failover")
-}
\ No newline at end of file
+}
+func TestRunNotifierUsesAlertChannels(t *testing.T) {
+	cfg := config.DefaultSPMConfig()
+	cfg.Alerts.Enabled = false
+	if _, ok := runNotifier(cfg, nil).(*notify.TerminalNotifier); !ok {
+		t.Errorf("runNotifier() with alerts disabled should print to the terminal")
+	}
+
+	cfg.Alerts.Enabled = true
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	for _, d := range []*caamdb.DB{db, nil} {
+		if _, ok := runNotifier(cfg, d).(*notify.Router); !ok {
+			t.Errorf("runNotifier(db=%v) with alerts enabled should route through the configured channels", d != nil)
+		}
+	}
+}
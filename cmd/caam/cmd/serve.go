@@ -27,7 +27,9 @@ ENDPOINTS:
   GET  /api/v1/profiles?tool=X  List profiles for a specific tool
   GET  /api/v1/profiles/X/Y     Get profile details
   DELETE /api/v1/profiles/X/Y   Delete a profile
-  GET  /api/v1/usage            Usage statistics
+  GET  /api/v1/providers        Providers and their capabilities
+  GET  /api/v1/usage            Usage statistics and per-profile token costs
+                                (?tool=X, ?period=7d; default 30d)
   GET  /api/v1/coordinators     Coordinator status
   POST /api/v1/actions/activate Activate a profile
   POST /api/v1/actions/backup   Backup current auth to a profile
@@ -97,6 +99,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 
 	handlers := api.NewHandlers(vault, healthStore, db)
+	handlers.SetRegistry(registry)
+	if db != nil {
+		handlers.SetLogIngester(func(ctx context.Context, tool string) error {
+			_, err := ingestLogSources(ctx, db, tool)
+			return err
+		})
+	}
 
 	// Create server config
 	serverCfg := api.DefaultConfig()
@@ -137,6 +146,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 	fmt.Println("  GET  /health              - Health check")
 	fmt.Println("  GET  /api/v1/status       - Overall status")
 	fmt.Println("  GET  /api/v1/profiles     - List profiles")
+	fmt.Println("  GET  /api/v1/providers    - Provider capabilities")
 	fmt.Println("  GET  /api/v1/usage        - Usage statistics")
 	fmt.Println("  GET  /api/v1/events       - SSE live updates")
 	fmt.Println("  POST /api/v1/actions/*    - Actions (activate, backup)")
@@ -36,13 +36,19 @@ Machine management:
   caam sync remove <name>          # Remove machine from pool
   caam sync test [name]            # Test connectivity
 
+End-to-end encryption:
+  caam sync key                    # Show this machine's sync key
+  caam sync trust <name>           # Seal pushed profiles to a machine's key
+  caam sync trust revoke <name>    # Revoke a key and re-seal
+
 Auto-sync:
   caam sync enable      # Enable auto-sync after backup/refresh
   caam sync disable     # Disable auto-sync
 
 Troubleshooting:
   caam sync log         # View sync history
-  caam sync queue       # View/manage retry queue`,
+  caam sync queue       # View/manage retry queue
+  caam sync conflicts   # Profiles changed on two machines`,
 	RunE: runSync,
 }
 
@@ -67,13 +73,19 @@ var syncAddCmd = &cobra.Command{
 
 Arguments:
   name      Friendly name for the machine (e.g., "work-laptop")
-  address   IP address or hostname, optionally with user/port (e.g., "jeff@192.168.1.100:22")
+  address   IP address or hostname, optionally with user/port (e.g., "jeff@192.168.1.100:22"),
+            or a transport URL:
+              dir:///path   a vault in a shared directory (NFS, Syncthing folder)
+              git:///path   encrypted profiles committed to a local bare git repo
+                            (needs the same CAAM_SYNC_PASSPHRASE on every machine)
 
 Examples:
   caam sync add work-laptop 192.168.1.100
   caam sync add home-desktop jeff@10.0.0.50
   caam sync add dev-server admin@dev.example.com:2222
-  caam sync add cloud-vm 34.123.45.67 --key ~/.ssh/cloud_key`,
+  caam sync add cloud-vm 34.123.45.67 --key ~/.ssh/cloud_key
+  caam sync add nas dir:///mnt/nas/caam
+  caam sync add repo git:///srv/caam-sync.git`,
 	Args: cobra.ExactArgs(2),
 	RunE: runSyncAdd,
 }
@@ -91,7 +103,7 @@ var syncRemoveCmd = &cobra.Command{
 var syncTestCmd = &cobra.Command{
 	Use:   "test [name]",
 	Short: "Test connectivity to machines",
-	Long: `Test SSH connectivity to one or all machines in the sync pool.
+	Long: `Test connectivity to one or all machines in the sync pool.
 
 Without arguments, tests all machines. With a machine name, tests only that machine.
 
@@ -329,11 +341,14 @@ func runSync(cmd *cobra.Command, args []string) error {
 			if r.Success {
 				switch r.Operation.Direction {
 				case sync.SyncPush:
-					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pushed (local fresher)\n", profile)
+					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pushed (%s)\n", profile, r.Operation.Reason)
 				case sync.SyncPull:
-					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pulled (remote fresher)\n", profile)
+					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: pulled (%s)\n", profile, r.Operation.Reason)
 				case sync.SyncSkip:
 					fmt.Fprintf(cmd.OutOrStdout(), "    ✓ %s: up to date\n", profile)
+				case sync.SyncConflict:
+					fmt.Fprintf(cmd.OutOrStdout(), "    ⚠ %s: %s; kept theirs as %s\n",
+						profile, r.Operation.Reason, sync.ConflictName(r.Operation.Profile, m.Name))
 				}
 			} else {
 				fmt.Fprintf(cmd.OutOrStdout(), "    ✗ %s: %v\n", profile, r.Error)
@@ -348,6 +363,9 @@ func runSync(cmd *cobra.Command, args []string) error {
 	stats := sync.AggregateResults(allResults)
 	fmt.Fprintf(cmd.OutOrStdout(), "Sync complete: %d pushed, %d pulled, %d up to date, %d errors\n",
 		stats.Pushed, stats.Pulled, stats.Skipped, stats.Failed)
+	if stats.Conflicts > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%d profile(s) changed on both sides; run 'caam sync conflicts' to resolve\n", stats.Conflicts)
+	}
 
 	return nil
 }
@@ -383,6 +401,13 @@ func runSyncStatus(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintf(out, "Auto-sync: %s\n", autoSyncStatus)
 
+	// End-to-end encryption
+	if recipients, err := state.Recipients(); err == nil && recipients != nil {
+		fmt.Fprintf(out, "Sealed sync: on (%d trusted, key %s)\n", len(state.TrustedKeys()), state.Identity.Fingerprint())
+	} else {
+		fmt.Fprintln(out, "Sealed sync: off (see 'caam sync trust')")
+	}
+
 	// Last full sync
 	if !state.Pool.LastFullSync.IsZero() {
 		fmt.Fprintf(out, "Last full sync: %s\n", formatTimeAgo(state.Pool.LastFullSync))
@@ -445,6 +470,15 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 	remotePath, _ := cmd.Flags().GetString("remote-path")
 	testAfter, _ := cmd.Flags().GetBool("test")
 
+	// Transport URLs (dir://, git://) are kept as they are
+	scheme, target := sync.ParseTransport(address)
+	if scheme != sync.TransportSSH {
+		machine := sync.NewMachine(name, address)
+		machine.Source = sync.SourceManual
+		return addSyncMachine(cmd, state, machine, testAfter)
+	}
+	address = target
+
 	// Parse user from address if present
 	if strings.Contains(address, "@") {
 		parts := strings.SplitN(address, "@", 2)
@@ -478,6 +512,13 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 	machine.RemotePath = remotePath
 	machine.Source = sync.SourceManual
 
+	return addSyncMachine(cmd, state, machine, testAfter)
+}
+
+// addSyncMachine adds a machine to the pool, saves it and optionally tests
+// connectivity.
+func addSyncMachine(cmd *cobra.Command, state *sync.SyncState, machine *sync.Machine, testAfter bool) error {
+	name := machine.Name
 	if err := state.Pool.AddMachine(machine); err != nil {
 		return fmt.Errorf("add machine: %w", err)
 	}
@@ -486,7 +527,7 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("save state: %w", err)
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Added machine %q (%s) to sync pool\n", name, address)
+	fmt.Fprintf(cmd.OutOrStdout(), "Added machine %q (%s) to sync pool\n", name, machine.Address)
 
 	if testAfter {
 		fmt.Fprintln(cmd.OutOrStdout(), "")
@@ -585,9 +626,17 @@ func runSyncTest(cmd *cobra.Command, args []string) error {
 }
 
 func testSyncMachine(out io.Writer, pool *sync.ConnectionPool, m *sync.Machine) bool {
+	label := "SSH connection"
+	switch m.TransportScheme() {
+	case sync.TransportDir:
+		label = "Shared directory"
+	case sync.TransportGit:
+		label = "Git repository"
+	}
+
 	client, err := pool.Get(m)
 	if err != nil {
-		fmt.Fprintf(out, "  SSH connection: ✗ %v\n", err)
+		fmt.Fprintf(out, "  %s: ✗ %v\n", label, err)
 		return false
 	}
 
@@ -595,15 +644,15 @@ func testSyncMachine(out io.Writer, pool *sync.ConnectionPool, m *sync.Machine)
 	vaultPath := remoteVaultPath(m)
 	exists, err := client.FileExists(vaultPath)
 	if err != nil {
-		fmt.Fprintf(out, "  SSH connection: ✓ connected\n")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintf(out, "  CAAM vault: ⚠️  could not check (%v)\n", err)
 		return true
 	}
 	if exists {
-		fmt.Fprintln(out, "  SSH connection: ✓ connected")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintln(out, "  CAAM vault: ✓ found")
 	} else {
-		fmt.Fprintln(out, "  SSH connection: ✓ connected")
+		fmt.Fprintf(out, "  %s: ✓ connected\n", label)
 		fmt.Fprintln(out, "  CAAM vault: ⚠️  not found (will be created on first sync)")
 	}
 	return true
@@ -613,6 +662,10 @@ func remoteVaultPath(m *sync.Machine) string {
 	if m == nil {
 		return sync.DefaultSyncerConfig().RemoteVaultPath
 	}
+	if m.TransportScheme() != sync.TransportSSH {
+		// The vault is at the transport's root
+		return ""
+	}
 	if strings.TrimSpace(m.RemotePath) == "" {
 		return sync.DefaultSyncerConfig().RemoteVaultPath
 	}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var syncConflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "List and resolve profiles that changed on two machines",
+	Long: `List profiles that changed both here and on another machine since they
+last synced, e.g. after logging in to different accounts on each or after
+both refreshed at once.
+
+Sync never overwrites either side of such a conflict. The other machine's
+version is kept in the vault as <profile>.conflict-<machine> until you pick
+one:
+
+  caam sync conflicts                                   # List conflicts
+  caam sync conflicts resolve claude/work --keep local  # Keep this machine's
+  caam sync conflicts resolve claude/work --keep remote # Take the other one
+
+Keeping local pushes it to the other machine on the next sync. Keeping
+remote replaces the local profile; the replaced files stay in its revision
+history ('caam history <tool> <profile>').`,
+	Args: cobra.NoArgs,
+	RunE: runSyncConflicts,
+}
+
+var syncConflictsResolveCmd = &cobra.Command{
+	Use:   "resolve <provider/profile> --keep local|remote",
+	Short: "Resolve a sync conflict",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSyncConflictsResolve,
+}
+
+func init() {
+	syncCmd.AddCommand(syncConflictsCmd)
+	syncConflictsCmd.AddCommand(syncConflictsResolveCmd)
+
+	syncConflictsCmd.Flags().Bool("json", false, "output as JSON")
+	syncConflictsResolveCmd.Flags().String("keep", "", "version to keep: local or remote")
+	syncConflictsResolveCmd.Flags().String("machine", "", "machine whose conflict to resolve (if several)")
+}
+
+func runSyncConflicts(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	vaultPath := sync.DefaultSyncerConfig().VaultPath
+	conflicts, err := sync.ListConflicts(vaultPath)
+	if err != nil {
+		return fmt.Errorf("list conflicts: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		if conflicts == nil {
+			conflicts = []*sync.Conflict{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(conflicts)
+	}
+	if len(conflicts) == 0 {
+		fmt.Fprintln(out, "No sync conflicts.")
+		return nil
+	}
+	renderSyncConflicts(out, vaultPath, conflicts)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Use 'caam sync conflicts resolve <provider/profile> --keep local|remote' to resolve.")
+	return nil
+}
+
+func renderSyncConflicts(out io.Writer, vaultPath string, conflicts []*sync.Conflict) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tMACHINE\tDETECTED\tLOCAL EXPIRES\tREMOTE EXPIRES")
+	for _, c := range conflicts {
+		detected := "-"
+		if !c.DetectedAt.IsZero() {
+			detected = formatTimeAgo(c.DetectedAt)
+		}
+		local := profileDirExpiry(filepath.Join(vaultPath, c.Provider, c.Profile), c.Provider, c.Profile)
+		remote := profileDirExpiry(c.Path, c.Provider, c.Profile)
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\n", c.Provider, c.Profile, c.Machine, detected, local, remote)
+	}
+	w.Flush()
+}
+
+// profileDirExpiry describes when the token in a profile directory
+// expires, or "-" if that cannot be told.
+func profileDirExpiry(dir, provider, profile string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "-"
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	fresh, err := sync.ExtractFreshnessFromFiles(provider, profile, paths)
+	if err != nil || fresh.ExpiresAt.IsZero() {
+		return "-"
+	}
+	return fresh.ExpiresAt.Local().Format("2006-01-02 15:04")
+}
+
+func runSyncConflictsResolve(cmd *cobra.Command, args []string) error {
+	keep, _ := cmd.Flags().GetString("keep")
+	machine, _ := cmd.Flags().GetString("machine")
+	if keep != "local" && keep != "remote" {
+		return fmt.Errorf("--keep must be local or remote")
+	}
+
+	provider, profile, ok := strings.Cut(strings.TrimSpace(args[0]), "/")
+	if !ok || provider == "" || profile == "" {
+		return fmt.Errorf("profile must be in provider/name format")
+	}
+
+	vaultPath := sync.DefaultSyncerConfig().VaultPath
+	conflicts, err := sync.ListConflicts(vaultPath)
+	if err != nil {
+		return fmt.Errorf("list conflicts: %w", err)
+	}
+	var matches []*sync.Conflict
+	for _, c := range conflicts {
+		if c.Provider != provider || c.Profile != profile {
+			continue
+		}
+		if machine != "" && !strings.EqualFold(c.Machine, machine) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("no sync conflict for %s/%s", provider, profile)
+	case len(matches) > 1:
+		var names []string
+		for _, c := range matches {
+			names = append(names, c.Machine)
+		}
+		return fmt.Errorf("%s/%s conflicts with several machines (%s); pick one with --machine", provider, profile, strings.Join(names, ", "))
+	}
+	c := matches[0]
+
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	if err := sync.ResolveConflict(vaultPath, state, c, keep == "remote"); err != nil {
+		return err
+	}
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+
+	if keep == "remote" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Replaced %s/%s with the version from %s.\n", provider, profile, c.Machine)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Kept local %s/%s; it will be pushed to %s on the next sync.\n", provider, profile, c.Machine)
+	}
+	return nil
+}
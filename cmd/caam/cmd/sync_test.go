@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -218,3 +219,57 @@ func TestRemoteVaultPath(t *testing.T) {
 		t.Fatalf("remoteVaultPath(custom) = %q, want %q", got, "/data/caam/vault")
 	}
 }
+
+func TestSyncTrustRevokeReportsResealFailures(t *testing.T) {
+	// Each machine keeps its sync key under its own CAAM_HOME.
+	peer := func() *sync.SyncState {
+		t.Helper()
+		t.Setenv("CAAM_HOME", t.TempDir())
+		state, err := loadSyncState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+	laptop, nas := peer(), peer()
+
+	t.Setenv("CAAM_HOME", t.TempDir())
+	state, err := loadSyncState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, peer := range map[string]*sync.SyncState{"laptop": laptop, "nas": nas} {
+		if _, err := state.TrustKey(name, peer.Identity.PublicKey); err != nil {
+			t.Fatalf("TrustKey(%s) error = %v", name, err)
+		}
+	}
+	// An unreachable machine, and a git repository whose history keeps
+	// copies sealed to the revoked key.
+	for _, m := range []*sync.Machine{
+		sync.NewMachine("nas", "dir://"+filepath.Join(t.TempDir(), "missing")),
+		sync.NewMachine("archive", "git://"+filepath.Join(t.TempDir(), "caam-sync.git")),
+	} {
+		if err := state.Pool.AddMachine(m); err != nil {
+			t.Fatalf("AddMachine() error = %v", err)
+		}
+	}
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, stderr bytes.Buffer
+	syncTrustRevokeCmd.SetOut(&out)
+	syncTrustRevokeCmd.SetErr(&stderr)
+	defer syncTrustRevokeCmd.SetOut(nil)
+	defer syncTrustRevokeCmd.SetErr(nil)
+
+	err = runSyncTrustRevoke(syncTrustRevokeCmd, []string{"laptop"})
+	if err == nil || !strings.Contains(err.Error(), "re-seal failed on 1 of 2") {
+		t.Fatalf("runSyncTrustRevoke() error = %v, want a re-seal failure", err)
+	}
+	for _, want := range []string{"rotate the tokens", "older commits in git sync repositories"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want %q", stderr.String(), want)
+		}
+	}
+}
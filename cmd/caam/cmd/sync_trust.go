@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
+)
+
+var syncKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Show this machine's sync key",
+	Long: `Show this machine's sync public key and its fingerprint, and publish the
+key in the vault, where other machines fetch it with 'caam sync trust'.
+
+Compare the fingerprint with the one 'caam sync trust' shows on the other
+machine before trusting it.`,
+	Args: cobra.NoArgs,
+	RunE: runSyncKey,
+}
+
+var syncTrustCmd = &cobra.Command{
+	Use:   "trust [machine]",
+	Short: "Trust a machine's key for end-to-end encrypted sync",
+	Long: `Trust a machine's sync key. Once a machine is trusted, profiles are sealed
+to the keys of this machine and every trusted machine before they are
+pushed, so they only travel and rest as ciphertext on remotes, jump boxes
+and shared directories. Pulled profiles stay sealed in the vault and are
+opened only when activated.
+
+Every machine in the pool should trust every other one, or it cannot open
+the profiles they push.
+
+  caam sync trust                         # List trusted machines
+  caam sync trust laptop                  # Fetch laptop's key over SSH and trust it
+  caam sync trust nas --key <public-key>  # Trust a key from 'caam sync key' on nas
+  caam sync trust revoke laptop           # Stop trusting laptop and re-seal
+  caam sync trust reseal                  # Re-seal remote profiles to the trusted keys
+
+Machines reached through a shared directory or git repository cannot be
+asked for their key; run 'caam sync key' on them and pass it with --key.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSyncTrust,
+}
+
+var syncTrustRevokeCmd = &cobra.Command{
+	Use:   "revoke <machine>",
+	Short: "Stop trusting a machine and re-seal profiles without its key",
+	Long: `Stop trusting a machine's sync key, then re-seal the sealed profiles on
+every other machine in the pool to the remaining keys, so the revoked
+machine cannot open their future contents.
+
+Re-sealing does not undo what the revoked machine already read. Rotate the
+tokens of profiles it held, and note that git transports keep old copies in
+their history.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSyncTrustRevoke,
+}
+
+var syncTrustResealCmd = &cobra.Command{
+	Use:   "reseal",
+	Short: "Re-seal remote profiles to the currently trusted keys",
+	Args:  cobra.NoArgs,
+	RunE:  runSyncTrustReseal,
+}
+
+func init() {
+	syncCmd.AddCommand(syncKeyCmd)
+	syncCmd.AddCommand(syncTrustCmd)
+	syncTrustCmd.AddCommand(syncTrustRevokeCmd)
+	syncTrustCmd.AddCommand(syncTrustResealCmd)
+
+	syncKeyCmd.Flags().Bool("json", false, "output as JSON")
+	syncTrustCmd.Flags().String("key", "", "public key to trust (from 'caam sync key' on that machine)")
+	syncTrustCmd.Flags().Bool("force", false, "skip fingerprint confirmation")
+	syncTrustCmd.Flags().Bool("json", false, "output as JSON")
+}
+
+func runSyncKey(cmd *cobra.Command, args []string) error {
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	if err := sync.PublishLocalKey(sync.DefaultSyncerConfig().VaultPath, state.Identity); err != nil {
+		return fmt.Errorf("publish sync key: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]string{
+			"hostname":    state.Identity.Hostname,
+			"public_key":  state.Identity.PublicKey,
+			"fingerprint": state.Identity.Fingerprint(),
+		})
+	}
+	fmt.Fprintf(out, "Machine:     %s\n", state.Identity.Hostname)
+	fmt.Fprintf(out, "Public key:  %s\n", state.Identity.PublicKey)
+	fmt.Fprintf(out, "Fingerprint: %s\n", state.Identity.Fingerprint())
+	return nil
+}
+
+func runSyncTrust(cmd *cobra.Command, args []string) error {
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+
+	if len(args) == 0 {
+		keys := state.TrustedKeys()
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			if keys == nil {
+				keys = []sync.TrustedKey{}
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(keys)
+		}
+		if len(keys) == 0 {
+			fmt.Fprintln(out, "No trusted machines; profiles are pushed unsealed.")
+			fmt.Fprintln(out, "Use 'caam sync trust <machine>' to seal them to that machine's key.")
+			return nil
+		}
+		renderTrustedKeys(out, keys)
+		return nil
+	}
+
+	name := args[0]
+	publicKey, _ := cmd.Flags().GetString("key")
+	source := "--key"
+	if publicKey == "" {
+		m := state.Pool.GetMachineByName(name)
+		if m == nil {
+			return fmt.Errorf("machine %q not found in pool; add it with 'caam sync add' or pass its key with --key", name)
+		}
+		if m.TransportScheme() != sync.TransportSSH {
+			return fmt.Errorf("cannot fetch the key of %s machine %q; run 'caam sync key' on it and pass the key with --key", m.TransportScheme(), name)
+		}
+
+		pool := sync.NewConnectionPool(sync.DefaultConnectOptions())
+		defer pool.CloseAll()
+		client, err := pool.Get(m)
+		if err != nil {
+			return fmt.Errorf("connect to %s: %w", name, err)
+		}
+		published, err := sync.FetchPublishedKey(client, remoteVaultPath(m))
+		if err != nil {
+			return fmt.Errorf("fetch key of %s: %w", name, err)
+		}
+		publicKey = published.PublicKey
+		source = published.Hostname
+	}
+
+	pub, err := sync.ParsePublicKey(strings.TrimSpace(publicKey))
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	if !force {
+		fmt.Fprintf(out, "Key of %s (from %s): %s\n", name, source, sync.KeyFingerprint(pub))
+		fmt.Fprintf(out, "Does this match 'caam sync key' on %s? (y/N): ", name)
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Fprintln(out, "Cancelled")
+			return nil
+		}
+	}
+
+	key, err := state.TrustKey(name, publicKey)
+	if err != nil {
+		return err
+	}
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	fmt.Fprintf(out, "Trusted %s (%s). Profiles are sealed to %d machine(s) including this one.\n",
+		key.Name, key.Fingerprint, len(state.TrustedKeys())+1)
+
+	// Profiles already pushed are sealed without the new key
+	return resealPool(cmd, state, "")
+}
+
+func runSyncTrustRevoke(cmd *cobra.Command, args []string) error {
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	key, err := state.RevokeKey(args[0])
+	if err != nil {
+		return err
+	}
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Revoked %s (%s).\n", key.Name, key.Fingerprint)
+
+	stderr := cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "Warning: %s can still use the tokens it already opened; rotate the tokens of the profiles it held.\n", key.Name)
+	for _, m := range state.Pool.ListMachines() {
+		if m.TransportScheme() == sync.TransportGit {
+			fmt.Fprintf(stderr, "Warning: older commits in git sync repositories (such as %s) still hold copies sealed to %s.\n", m.Name, key.Name)
+			break
+		}
+	}
+
+	return resealPool(cmd, state, key.Name)
+}
+
+func runSyncTrustReseal(cmd *cobra.Command, args []string) error {
+	state, err := loadSyncState()
+	if err != nil {
+		return err
+	}
+	return resealPool(cmd, state, "")
+}
+
+// resealPool re-seals the profiles on every machine in the pool except
+// skip to the trusted keys. It fails if any machine could not be re-sealed.
+func resealPool(cmd *cobra.Command, state *sync.SyncState, skip string) error {
+	out := cmd.OutOrStdout()
+	var machines []*sync.Machine
+	for _, m := range state.Pool.ListMachines() {
+		if !strings.EqualFold(m.Name, skip) {
+			machines = append(machines, m)
+		}
+	}
+	if len(machines) == 0 {
+		return nil
+	}
+
+	syncer, err := sync.NewSyncer(sync.DefaultSyncerConfig())
+	if err != nil {
+		return fmt.Errorf("create syncer: %w", err)
+	}
+	defer syncer.Close()
+
+	failed := 0
+	for _, m := range machines {
+		res, err := syncer.Reseal(cmd.Context(), m)
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ %s: %v\n", m.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "  ✓ %s: re-sealed %d profile(s)\n", m.Name, res.Resealed)
+		for _, p := range res.Skipped {
+			fmt.Fprintf(out, "    ⚠ %s: not sealed to this machine; re-seal it from a machine that can open it\n", p)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("re-seal failed on %d of %d machine(s); run 'caam sync trust reseal' once they are reachable", failed, len(machines))
+	}
+	return nil
+}
+
+func renderTrustedKeys(out io.Writer, keys []sync.TrustedKey) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MACHINE\tFINGERPRINT\tTRUSTED")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", k.Name, k.Fingerprint, formatTimeAgo(k.AddedAt))
+	}
+	w.Flush()
+}
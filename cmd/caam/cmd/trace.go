@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/spf13/cobra"
 )
 
@@ -93,7 +94,9 @@ This is a safe, read-only tool that:
 The report shows added/changed/removed files with sizes and hashes,
 plus derived "watch rules" for caam watch configuration.
 
-Currently supports: claude (other agents planned)
+Supports every registered provider. Claude, Codex and Gemini scan their
+known config directories; other providers scan the directories holding
+their auth files.
 
 Examples:
   caam trace claude                    # Trace Claude Code login files
@@ -169,7 +172,22 @@ func getTracePaths(agent string, noProjects bool, extraPaths []string) ([]string
 		}
 
 	default:
-		return nil, fmt.Errorf("unsupported agent: %s (supported: claude, codex, gemini)", agent)
+		// Other providers: scan the directories holding their auth files.
+		var prov provider.Provider
+		if registry != nil {
+			prov, _ = registry.Get(strings.ToLower(agent))
+		}
+		if prov == nil {
+			return nil, fmt.Errorf("unsupported agent: %s (supported: claude, codex, gemini)", agent)
+		}
+		seen := make(map[string]bool)
+		for _, spec := range prov.AuthFiles() {
+			dir := filepath.Dir(spec.Path)
+			if !seen[dir] {
+				seen[dir] = true
+				paths = append(paths, dir)
+			}
+		}
 	}
 
 	// Add extra paths
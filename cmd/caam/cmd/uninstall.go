@@ -155,7 +155,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 }
 
 func resolveOriginalRestorePlan() (restore []string, missing []string, err error) {
-	for _, tool := range []string{"codex", "claude", "gemini"} {
+	for _, tool := range []string{"codex", "claude", "gemini", "opencode"} {
 		hasOriginal, checkErr := vault.HasOriginalBackup(tool)
 		if checkErr != nil {
 			return nil, nil, fmt.Errorf("check %s/_original: %w", tool, checkErr)
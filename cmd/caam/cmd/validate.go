@@ -14,6 +14,8 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/claude"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/codex"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/gemini"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/opencode"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider/plugin"
 )
 
 var validateCmd = &cobra.Command{
@@ -75,6 +77,10 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	registry.Register(claude.New())
 	registry.Register(codex.New())
 	registry.Register(gemini.New())
+	registry.Register(opencode.New())
+	for _, spec := range pluginSpecs {
+		registry.Register(plugin.New(spec))
+	}
 
 	var results []ValidationOutput
 	var err error
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Manage vault encryption at rest",
+	Long: `Encrypt the profile vault so stored OAuth tokens are sealed on disk.
+
+In encrypted mode every auth file under the vault is sealed with AES-256-GCM.
+The data key is wrapped with your passphrase (Argon2id) and is only held in
+memory: by the current command, or by the daemon's key agent after
+'caam vault unlock' until the key timeout (daemon.vault_key_timeout) expires.
+
+Non-interactive use can set ` + authfile.PassphraseEnvVar + `.
+
+Examples:
+  caam vault encrypt          # Enable encryption and seal existing profiles
+  caam vault unlock           # Cache the key in the daemon (default 15m)
+  caam vault unlock --timeout 1h
+  caam vault lock             # Forget the cached key now
+  caam vault status
+  caam vault passwd           # Change the passphrase
+  caam vault decrypt          # Return to plaintext storage`,
+}
+
+var vaultEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Enable vault encryption and seal existing profiles",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultEncrypt,
+}
+
+var vaultDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Disable vault encryption and store profiles in plaintext",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultDecrypt,
+}
+
+var vaultUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the vault and cache the key in the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultUnlock,
+}
+
+var vaultLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Forget the vault key cached by the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultLock,
+}
+
+var vaultStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show vault encryption and lock state",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultStatus,
+}
+
+var vaultPasswdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change the vault passphrase",
+	Args:  cobra.NoArgs,
+	RunE:  runVaultPasswd,
+}
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+	vaultCmd.AddCommand(vaultEncryptCmd)
+	vaultCmd.AddCommand(vaultDecryptCmd)
+	vaultCmd.AddCommand(vaultUnlockCmd)
+	vaultCmd.AddCommand(vaultLockCmd)
+	vaultCmd.AddCommand(vaultStatusCmd)
+	vaultCmd.AddCommand(vaultPasswdCmd)
+
+	vaultUnlockCmd.Flags().Duration("timeout", 0, "how long the daemon keeps the key (default: daemon.vault_key_timeout)")
+}
+
+// vaultPassphrase returns the passphrase from the environment or a prompt.
+func vaultPassphrase(prompt string) (string, error) {
+	if p := os.Getenv(authfile.PassphraseEnvVar); p != "" {
+		return p, nil
+	}
+	return promptPassword(prompt)
+}
+
+func runVaultEncrypt(cmd *cobra.Command, args []string) error {
+	if vault.IsEncrypted() {
+		return fmt.Errorf("vault is already encrypted")
+	}
+
+	passphrase, err := vaultPassphrase("New vault passphrase: ")
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	if os.Getenv(authfile.PassphraseEnvVar) == "" {
+		confirm, err := promptPassword("Confirm passphrase: ")
+		if err != nil {
+			return fmt.Errorf("read passphrase: %w", err)
+		}
+		if confirm != passphrase {
+			return fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	if err := vault.EnableEncryption(passphrase); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Vault encrypted: %s\n", vault.BasePath())
+	fmt.Fprintln(cmd.OutOrStdout(), "Restart the daemon, then run 'caam vault unlock' to cache the key.")
+	return nil
+}
+
+func runVaultDecrypt(cmd *cobra.Command, args []string) error {
+	if !vault.IsEncrypted() {
+		return fmt.Errorf("vault is not encrypted")
+	}
+	if !vault.IsUnlocked() {
+		passphrase, err := vaultPassphrase("Vault passphrase: ")
+		if err != nil {
+			return fmt.Errorf("read passphrase: %w", err)
+		}
+		if _, err := vault.Unlock(passphrase); err != nil {
+			return err
+		}
+	}
+
+	if err := vault.DisableEncryption(); err != nil {
+		return err
+	}
+	_ = authfile.LockKeyAgent(authfile.DefaultKeyAgentSocket())
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Vault decrypted; profiles are stored in plaintext.")
+	return nil
+}
+
+func runVaultUnlock(cmd *cobra.Command, args []string) error {
+	if !vault.IsEncrypted() {
+		return fmt.Errorf("vault is not encrypted (run 'caam vault encrypt' first)")
+	}
+
+	passphrase, err := vaultPassphrase("Vault passphrase: ")
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	key, err := vault.Unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	defer seal.Wipe(key)
+
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	status, err := authfile.SendKeyToAgent(authfile.DefaultKeyAgentSocket(), key, timeout)
+	if err != nil {
+		return fmt.Errorf("passphrase is correct, but no key agent is running; start it with 'caam daemon start': %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Vault unlocked until %s\n", status.ExpiresAt.Local().Format(time.Kitchen))
+	return nil
+}
+
+func runVaultLock(cmd *cobra.Command, args []string) error {
+	if err := authfile.LockKeyAgent(authfile.DefaultKeyAgentSocket()); err != nil {
+		return fmt.Errorf("lock key agent: %w", err)
+	}
+	authfile.ForgetKeys()
+	fmt.Fprintln(cmd.OutOrStdout(), "Vault locked.")
+	return nil
+}
+
+func runVaultStatus(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	if !vault.IsEncrypted() {
+		fmt.Fprintln(out, "Encryption: off")
+		return nil
+	}
+
+	fmt.Fprintln(out, "Encryption: on (aes-256-gcm, argon2id)")
+	fmt.Fprintf(out, "Key ID:     %s\n", vault.KeyID())
+
+	status, err := authfile.QueryKeyAgent(authfile.DefaultKeyAgentSocket())
+	switch {
+	case err != nil:
+		fmt.Fprintln(out, "Key agent:  not running")
+	case status.Unlocked:
+		fmt.Fprintf(out, "Key agent:  unlocked until %s\n", status.ExpiresAt.Local().Format(time.RFC3339))
+	default:
+		fmt.Fprintln(out, "Key agent:  locked")
+	}
+	return nil
+}
+
+func runVaultPasswd(cmd *cobra.Command, args []string) error {
+	if !vault.IsEncrypted() {
+		return fmt.Errorf("vault is not encrypted")
+	}
+
+	oldPassphrase, err := promptPassword("Current passphrase: ")
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	newPassphrase, err := promptPassword("New passphrase: ")
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	confirm, err := promptPassword("Confirm new passphrase: ")
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	if confirm != newPassphrase {
+		return fmt.Errorf("passphrases do not match")
+	}
+
+	if err := vault.ChangePassphrase(oldPassphrase, newPassphrase); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Vault passphrase changed.")
+	return nil
+}
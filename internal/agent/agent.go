@@ -43,6 +43,10 @@ type Config struct {
 	// Accounts is the list of account emails to cycle through.
 	Accounts []string
 
+	// AccountFilter optionally narrows Accounts before the strategy picks
+	// one (e.g. the selection policy).
+	AccountFilter func(accounts []string) []string `json:"-"`
+
 	// Logger for structured logging.
 	Logger *slog.Logger
 }
@@ -380,6 +384,11 @@ func (a *Agent) selectAccount() string {
 	defer a.mu.RUnlock()
 
 	accounts := a.config.Accounts
+	if len(accounts) > 0 && a.config.AccountFilter != nil {
+		if accounts = a.config.AccountFilter(accounts); len(accounts) == 0 {
+			a.logger.Warn("no accounts allowed by selection policy; using current browser session")
+		}
+	}
 	if len(accounts) == 0 {
 		return "" // Will use whatever account is currently logged in
 	}
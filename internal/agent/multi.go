@@ -64,6 +64,10 @@ type MultiConfig struct {
 	// Accounts is the list of account emails to cycle through.
 	Accounts []string `json:"accounts"`
 
+	// AccountFilter optionally narrows Accounts before the strategy picks
+	// one (e.g. the selection policy).
+	AccountFilter func(accounts []string) []string `json:"-"`
+
 	// Logger for structured logging.
 	Logger *slog.Logger `json:"-"`
 }
@@ -432,6 +436,11 @@ func (a *MultiAgent) selectAccount() string {
 	defer a.mu.RUnlock()
 
 	accounts := a.config.Accounts
+	if len(accounts) > 0 && a.config.AccountFilter != nil {
+		if accounts = a.config.AccountFilter(accounts); len(accounts) == 0 {
+			a.logger.Warn("no accounts allowed by selection policy; using current browser session")
+		}
+	}
 	if len(accounts) == 0 {
 		return ""
 	}
@@ -1,13 +1,17 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 )
 
 // Handlers provides the business logic for API endpoints.
@@ -15,8 +19,16 @@ type Handlers struct {
 	vault       *authfile.Vault
 	healthStore *health.Storage
 	db          *caamdb.DB
+	registry    *provider.Registry
+
+	// ingestLogs imports new CLI log lines for a tool on POST
+	// /actions/ingest (may be nil)
+	ingestLogs func(ctx context.Context, tool string) error
 }
 
+// DefaultTokenPeriod is how far back GET /usage reports token usage.
+const DefaultTokenPeriod = 30 * 24 * time.Hour
+
 // NewHandlers creates a new Handlers instance.
 func NewHandlers(vault *authfile.Vault, healthStore *health.Storage, db *caamdb.DB) *Handlers {
 	return &Handlers{
@@ -26,6 +38,18 @@ func NewHandlers(vault *authfile.Vault, healthStore *health.Storage, db *caamdb.
 	}
 }
 
+// SetRegistry sets the provider registry used to report capabilities.
+func (h *Handlers) SetRegistry(reg *provider.Registry) {
+	h.registry = reg
+}
+
+// SetLogIngester sets the function that imports new CLI log lines into the
+// database. GET /usage reports what is already stored (the daemon ingests
+// on every check); POST /actions/ingest runs it on demand.
+func (h *Handlers) SetLogIngester(ingest func(ctx context.Context, tool string) error) {
+	h.ingestLogs = ingest
+}
+
 // Event represents a server-sent event.
 type Event struct {
 	Type      string      `json:"type"`
@@ -47,6 +71,20 @@ type ToolStatus struct {
 	ActiveProfile string             `json:"active_profile,omitempty"`
 	Health        *HealthStatus      `json:"health,omitempty"`
 	Identity      *identity.Identity `json:"identity,omitempty"`
+
+	Capabilities *provider.Capabilities `json:"capabilities,omitempty"`
+}
+
+// ProvidersResponse is the response for GET /providers.
+type ProvidersResponse struct {
+	Providers []ProviderInfo `json:"providers"`
+}
+
+// ProviderInfo describes a registered provider and what caam can do for it.
+type ProviderInfo struct {
+	ID           string                `json:"id"`
+	DisplayName  string                `json:"display_name"`
+	Capabilities provider.Capabilities `json:"capabilities"`
 }
 
 // HealthStatus represents profile health.
@@ -78,6 +116,10 @@ type UsageResponse struct {
 	Tool   string       `json:"tool,omitempty"`
 	Period string       `json:"period"`
 	Usage  []UsageEntry `json:"usage"`
+
+	// Token usage from CLI logs per profile, with API-equivalent cost
+	TokenPeriod string            `json:"token_period,omitempty"`
+	Tokens      []TokenUsageEntry `json:"tokens,omitempty"`
 }
 
 // UsageEntry represents usage for a profile.
@@ -89,6 +131,18 @@ type UsageEntry struct {
 	LastUsed   string `json:"last_used,omitempty"`
 }
 
+// TokenUsageEntry represents token usage attributed to a profile.
+type TokenUsageEntry struct {
+	Tool              string  `json:"tool"`
+	Profile           string  `json:"profile,omitempty"` // Empty when unattributed
+	InputTokens       int64   `json:"input_tokens"`
+	OutputTokens      int64   `json:"output_tokens"`
+	CacheReadTokens   int64   `json:"cache_read_tokens"`
+	CacheCreateTokens int64   `json:"cache_create_tokens"`
+	TotalTokens       int64   `json:"total_tokens"`
+	APICost           float64 `json:"api_cost"`
+}
+
 // CoordinatorsResponse is the response for GET /coordinators.
 type CoordinatorsResponse struct {
 	Coordinators []CoordinatorStatus `json:"coordinators"`
@@ -133,6 +187,17 @@ type BackupResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// IngestRequest is the request for POST /actions/ingest.
+type IngestRequest struct {
+	Tool string `json:"tool,omitempty"` // Empty ingests every tool
+}
+
+// IngestResponse is the response for POST /actions/ingest.
+type IngestResponse struct {
+	Success bool     `json:"success"`
+	Tools   []string `json:"tools"`
+}
+
 // Tools supported for auth file swapping.
 var tools = map[string]func() authfile.AuthFileSet{
 	"codex":  authfile.CodexAuthFiles,
@@ -156,6 +221,11 @@ func (h *Handlers) GetStatus() (*StatusResponse, error) {
 			Tool:     tool,
 			LoggedIn: hasAuth,
 		}
+		if h.registry != nil {
+			if caps, ok := h.registry.Capabilities(tool); ok {
+				ts.Capabilities = &caps
+			}
+		}
 
 		if hasAuth && h.vault != nil {
 			activeProfile, err := h.vault.ActiveProfile(fileSet)
@@ -172,6 +242,28 @@ func (h *Handlers) GetStatus() (*StatusResponse, error) {
 	return resp, nil
 }
 
+// GetProviders returns the registered providers and their capabilities.
+func (h *Handlers) GetProviders() (*ProvidersResponse, error) {
+	resp := &ProvidersResponse{
+		Providers: []ProviderInfo{},
+	}
+	if h.registry == nil {
+		return resp, nil
+	}
+
+	for _, p := range h.registry.All() {
+		resp.Providers = append(resp.Providers, ProviderInfo{
+			ID:           p.ID(),
+			DisplayName:  p.DisplayName(),
+			Capabilities: p.Capabilities(),
+		})
+	}
+	sort.Slice(resp.Providers, func(i, j int) bool {
+		return resp.Providers[i].ID < resp.Providers[j].ID
+	})
+	return resp, nil
+}
+
 // GetProfiles returns profiles, optionally filtered by tool.
 func (h *Handlers) GetProfiles(tool string) (*ProfilesResponse, error) {
 	resp := &ProfilesResponse{
@@ -294,14 +386,34 @@ func (h *Handlers) DeleteProfile(tool, name string) error {
 	return h.vault.Delete(tool, name)
 }
 
-// GetUsage returns usage statistics.
+// GetUsage returns usage statistics, with token usage over the default period.
 func (h *Handlers) GetUsage(tool string) (*UsageResponse, error) {
+	return h.GetUsageForPeriod(tool, DefaultTokenPeriod)
+}
+
+// GetUsageForPeriod returns usage statistics, with token usage per profile
+// over the given period.
+func (h *Handlers) GetUsageForPeriod(tool string, tokenPeriod time.Duration) (*UsageResponse, error) {
 	resp := &UsageResponse{
 		Tool:   tool,
 		Period: "1h",
 		Usage:  []UsageEntry{},
 	}
 
+	toolsToCheck := h.usageTools(tool)
+
+	if h.db != nil {
+		resp.TokenPeriod = tokenPeriod.String()
+		since := time.Now().Add(-tokenPeriod)
+		for _, t := range toolsToCheck {
+			entries, err := h.tokenUsage(t, since)
+			if err != nil {
+				return nil, err
+			}
+			resp.Tokens = append(resp.Tokens, entries...)
+		}
+	}
+
 	if h.healthStore == nil {
 		return resp, nil
 	}
@@ -310,11 +422,6 @@ func (h *Handlers) GetUsage(tool string) (*UsageResponse, error) {
 	}
 
 	// Get all health data
-	toolsToCheck := []string{"codex", "claude", "gemini"}
-	if tool != "" {
-		toolsToCheck = []string{tool}
-	}
-
 	for _, t := range toolsToCheck {
 		profiles, err := h.vault.List(t)
 		if err != nil {
@@ -343,6 +450,85 @@ func (h *Handlers) GetUsage(tool string) (*UsageResponse, error) {
 	return resp, nil
 }
 
+// IngestUsage imports new CLI log lines into the database for a tool, or
+// for every tool when tool is empty.
+func (h *Handlers) IngestUsage(ctx context.Context, req IngestRequest) (*IngestResponse, error) {
+	if h.ingestLogs == nil {
+		return nil, fmt.Errorf("log ingestion not available")
+	}
+	toolsToIngest := h.usageTools(req.Tool)
+	for _, t := range toolsToIngest {
+		if err := h.ingestLogs(ctx, t); err != nil {
+			return nil, fmt.Errorf("ingest %s logs: %w", t, err)
+		}
+	}
+	return &IngestResponse{Success: true, Tools: toolsToIngest}, nil
+}
+
+// usageTools returns the tools to report usage for: just tool when given,
+// otherwise every registered provider (including plugins) plus the built-in
+// tools, sorted.
+func (h *Handlers) usageTools(tool string) []string {
+	if tool != "" {
+		return []string{tool}
+	}
+	seen := make(map[string]bool)
+	for t := range tools {
+		seen[t] = true
+	}
+	if h.registry != nil {
+		for _, id := range h.registry.IDs() {
+			seen[id] = true
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for t := range seen {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// tokenUsage returns a tool's stored token usage since the given time per
+// attributed profile, busiest first, with unattributed usage last.
+func (h *Handlers) tokenUsage(tool string, since time.Time) ([]TokenUsageEntry, error) {
+	logEntries, err := h.db.TokenUsageEntries(tool, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each log entry is priced at the rate in effect when it was written.
+	prices := pricing.Default()
+	byProfile := make(map[string]*TokenUsageEntry)
+	for _, e := range logEntries {
+		u := byProfile[e.Profile]
+		if u == nil {
+			u = &TokenUsageEntry{Tool: tool, Profile: e.Profile}
+			byProfile[e.Profile] = u
+		}
+		u.InputTokens += e.InputTokens
+		u.OutputTokens += e.OutputTokens
+		u.CacheReadTokens += e.CacheReadTokens
+		u.CacheCreateTokens += e.CacheCreateTokens
+		u.TotalTokens += e.InputTokens + e.OutputTokens + e.CacheReadTokens + e.CacheCreateTokens
+		u.APICost += prices.EntryCost(e, tool)
+	}
+	entries := make([]TokenUsageEntry, 0, len(byProfile))
+	for _, u := range byProfile {
+		entries = append(entries, *u)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].Profile == "") != (entries[j].Profile == "") {
+			return entries[j].Profile == ""
+		}
+		if entries[i].TotalTokens != entries[j].TotalTokens {
+			return entries[i].TotalTokens > entries[j].TotalTokens
+		}
+		return entries[i].Profile < entries[j].Profile
+	})
+	return entries, nil
+}
+
 // GetCoordinators returns coordinator status.
 func (h *Handlers) GetCoordinators() (*CoordinatorsResponse, error) {
 	// For now, return empty - coordinator discovery could be added later
@@ -1,9 +1,17 @@
 package api
 
 import (
+	"context"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -240,3 +248,153 @@ func TestToolsMapContainsExpectedTools(t *testing.T) {
 		}
 	}
 }
+
+// capsProvider is a minimal provider.Provider reporting fixed capabilities.
+type capsProvider struct {
+	provider.Provider
+	id   string
+	caps provider.Capabilities
+}
+
+func (p *capsProvider) ID() string                          { return p.id }
+func (p *capsProvider) DisplayName() string                 { return strings.ToUpper(p.id) }
+func (p *capsProvider) Capabilities() provider.Capabilities { return p.caps }
+func (p *capsProvider) Env(context.Context, *profile.Profile) (map[string]string, error) {
+	return nil, nil
+}
+
+func TestGetProviders(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+
+	resp, err := h.GetProviders()
+	if err != nil {
+		t.Fatalf("GetProviders() error = %v", err)
+	}
+	if len(resp.Providers) != 0 {
+		t.Errorf("expected no providers without a registry, got %d", len(resp.Providers))
+	}
+
+	reg := provider.NewRegistry()
+	reg.Register(&capsProvider{id: "gemini", caps: provider.Capabilities{SessionResume: true}})
+	reg.Register(&capsProvider{id: "codex", caps: provider.Capabilities{UsageAPI: true, DeviceCode: true}})
+	h.SetRegistry(reg)
+
+	resp, err = h.GetProviders()
+	if err != nil {
+		t.Fatalf("GetProviders() error = %v", err)
+	}
+	if len(resp.Providers) != 2 || resp.Providers[0].ID != "codex" || resp.Providers[1].ID != "gemini" {
+		t.Fatalf("providers = %+v, want codex then gemini", resp.Providers)
+	}
+	if !resp.Providers[0].Capabilities.DeviceCode || resp.Providers[1].Capabilities.UsageAPI {
+		t.Errorf("capabilities not reported as registered: %+v", resp.Providers)
+	}
+
+	status, err := h.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	for _, ts := range status.Tools {
+		if ts.Tool == "codex" && (ts.Capabilities == nil || !ts.Capabilities.UsageAPI) {
+			t.Errorf("codex status capabilities = %+v", ts.Capabilities)
+		}
+		if ts.Tool == "claude" && ts.Capabilities != nil {
+			t.Errorf("unregistered tool should have no capabilities, got %+v", ts.Capabilities)
+		}
+	}
+}
+
+func TestGetUsageForPeriod_TokensByProfile(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC()
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "claude", ProfileName: "work", Timestamp: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+	entries := []*logs.LogEntry{
+		{Timestamp: now.Add(-3 * time.Hour), Model: "claude-3-opus", InputTokens: 10},
+		{Timestamp: now.Add(-time.Hour), Model: "claude-3-opus", InputTokens: 1000000, OutputTokens: 1000},
+	}
+	if err := db.RecordScan("claude", logs.Checkpoint{Path: "/logs/a.jsonl"}, entries, false); err != nil {
+		t.Fatalf("RecordScan() error = %v", err)
+	}
+
+	h := NewHandlers(nil, nil, db)
+	var ingested []string
+	h.SetLogIngester(func(ctx context.Context, tool string) error {
+		ingested = append(ingested, tool)
+		return nil
+	})
+
+	resp, err := h.GetUsageForPeriod("claude", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetUsageForPeriod() error = %v", err)
+	}
+	if len(ingested) != 0 {
+		t.Errorf("GET usage ingested %v, want stored usage only", ingested)
+	}
+	if len(resp.Tokens) != 2 {
+		t.Fatalf("Tokens = %+v, want work and unattributed", resp.Tokens)
+	}
+	work := resp.Tokens[0]
+	if work.Profile != "work" || work.TotalTokens != 1001000 || work.APICost <= 0 {
+		t.Errorf("Tokens[0] = %+v, want work with its cost", work)
+	}
+	if resp.Tokens[1].Profile != "" || resp.Tokens[1].TotalTokens != 10 {
+		t.Errorf("Tokens[1] = %+v, want unattributed last", resp.Tokens[1])
+	}
+
+	resp, err = h.GetUsageForPeriod("claude", 90*time.Minute)
+	if err != nil {
+		t.Fatalf("GetUsageForPeriod() error = %v", err)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].Profile != "work" {
+		t.Errorf("Tokens over 90m = %+v, want only work", resp.Tokens)
+	}
+}
+
+func TestIngestUsage(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+	if _, err := h.IngestUsage(context.Background(), IngestRequest{}); err == nil {
+		t.Error("IngestUsage() without an ingester should fail")
+	}
+
+	var ingested []string
+	h.SetLogIngester(func(ctx context.Context, tool string) error {
+		ingested = append(ingested, tool)
+		return nil
+	})
+	resp, err := h.IngestUsage(context.Background(), IngestRequest{Tool: "claude"})
+	if err != nil {
+		t.Fatalf("IngestUsage() error = %v", err)
+	}
+	if !resp.Success || len(ingested) != 1 || ingested[0] != "claude" {
+		t.Errorf("IngestUsage() = %+v, ingested %v; want claude", resp, ingested)
+	}
+
+	ingested = nil
+	if _, err := h.IngestUsage(context.Background(), IngestRequest{}); err != nil {
+		t.Fatalf("IngestUsage(all) error = %v", err)
+	}
+	if len(ingested) != 3 {
+		t.Errorf("IngestUsage(all) ingested %v, want every tool", ingested)
+	}
+
+	reg := provider.NewRegistry()
+	reg.Register(&capsProvider{id: "opencode"})
+	reg.Register(&capsProvider{id: "acme"})
+	h.SetRegistry(reg)
+	ingested = nil
+	resp, err = h.IngestUsage(context.Background(), IngestRequest{})
+	if err != nil {
+		t.Fatalf("IngestUsage(all) error = %v", err)
+	}
+	want := []string{"acme", "claude", "codex", "gemini", "opencode"}
+	if !reflect.DeepEqual(ingested, want) || !reflect.DeepEqual(resp.Tools, want) {
+		t.Errorf("IngestUsage(all) ingested %v (reported %v), want registered providers too: %v", ingested, resp.Tools, want)
+	}
+}
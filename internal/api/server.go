@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -138,10 +139,12 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/v1/status", s.authMiddleware(s.handleStatus))
 	mux.HandleFunc("/api/v1/profiles", s.authMiddleware(s.handleProfiles))
 	mux.HandleFunc("/api/v1/profiles/", s.authMiddleware(s.handleProfileAction))
+	mux.HandleFunc("/api/v1/providers", s.authMiddleware(s.handleProviders))
 	mux.HandleFunc("/api/v1/usage", s.authMiddleware(s.handleUsage))
 	mux.HandleFunc("/api/v1/coordinators", s.authMiddleware(s.handleCoordinators))
 	mux.HandleFunc("/api/v1/actions/activate", s.authMiddleware(s.handleActivate))
 	mux.HandleFunc("/api/v1/actions/backup", s.authMiddleware(s.handleBackup))
+	mux.HandleFunc("/api/v1/actions/ingest", s.authMiddleware(s.handleIngest))
 	mux.HandleFunc("/api/v1/events", s.authMiddleware(s.handleSSE))
 
 	// CORS middleware for localhost only
@@ -370,6 +373,22 @@ func (s *Server) handleProfileAction(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleProviders returns the registered providers and their capabilities.
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	providers, err := s.handlers.GetProviders()
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.jsonResponse(w, providers)
+}
+
 // handleUsage returns usage statistics.
 func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -378,7 +397,17 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	tool := r.URL.Query().Get("tool")
-	usage, err := s.handlers.GetUsage(tool)
+	period := DefaultTokenPeriod
+	if v := r.URL.Query().Get("period"); v != "" {
+		d, err := parsePeriod(v)
+		if err != nil {
+			s.jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		period = d
+	}
+
+	usage, err := s.handlers.GetUsageForPeriod(tool, period)
 	if err != nil {
 		s.jsonError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -387,6 +416,21 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, usage)
 }
 
+// parsePeriod parses a ?period= value: a Go duration or a number of days
+// ("7d").
+func parsePeriod(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid period %q (use e.g. 24h or 7d)", s)
+	}
+	return d, nil
+}
+
 // handleCoordinators returns coordinator status.
 func (s *Server) handleCoordinators(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -461,6 +505,30 @@ func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, result)
 }
 
+// handleIngest imports new CLI log lines so GET /usage reports them.
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req IngestRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	result, err := s.handlers.IngestUsage(r.Context(), req)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.jsonResponse(w, result)
+}
+
 // handleSSE handles Server-Sent Events for live updates.
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -372,3 +372,25 @@ func TestDefaultTokenPath(t *testing.T) {
 		t.Errorf("without CAAM_HOME: path = %s, expected .config/caam/.api_token", path)
 	}
 }
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"xd", 0, true},
+		{"week", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePeriod(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePeriod(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
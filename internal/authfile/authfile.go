@@ -14,12 +14,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,6 +38,12 @@ type AuthFileSpec struct {
 
 	// Required indicates if this file must exist for auth to work.
 	Required bool
+
+	// JSONKeys, when set, limits the spec to these top-level keys of a JSON
+	// object file. Backup captures only these keys, Restore patches them into
+	// the live file in place, and content hashing ignores every other key.
+	// Use this for files that mix account state with user state.
+	JSONKeys []string
 }
 
 // AuthFileSet is a collection of auth files that together represent
@@ -73,7 +81,7 @@ func CodexAuthFiles() AuthFileSet {
 // ClaudeAuthFiles returns the auth files for Claude Code.
 // Claude Code stores OAuth credentials in:
 //   - ~/.claude/.credentials.json (primary - contains claudeAiOauth with tokens)
-//   - ~/.claude.json (settings file - not auth, but backed up for completeness)
+//   - ~/.claude.json (account keys only - see ClaudeStateKeys; the rest is user state)
 //   - ~/.config/claude-code/auth.json (auth credentials; or $CLAUDE_CONFIG_DIR/auth.json)
 //   - ~/.claude/settings.json (user settings)
 func ClaudeAuthFiles() AuthFileSet {
@@ -99,8 +107,9 @@ func ClaudeAuthFiles() AuthFileSet {
 			{
 				Tool:        "claude",
 				Path:        filepath.Join(homeDir, ".claude.json"),
-				Description: "Claude Code settings and session state",
+				Description: "Claude Code account state (oauthAccount and API key keys only)",
 				Required:    false, // This is a settings file, not strictly required for auth
+				JSONKeys:    ClaudeStateKeys,
 			},
 			{
 				Tool:        "claude",
@@ -157,6 +166,31 @@ func GeminiAuthFiles() AuthFileSet {
 	}
 }
 
+// OpenCodeAuthFiles returns the auth files for OpenCode.
+// OpenCode keeps the credentials of every upstream provider (Anthropic,
+// OpenAI, Copilot, API keys...) in one store at
+// $XDG_DATA_HOME/opencode/auth.json (default ~/.local/share/opencode/auth.json),
+// so a single file captures the whole account set.
+func OpenCodeAuthFiles() AuthFileSet {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+
+	return AuthFileSet{
+		Tool: "opencode",
+		Files: []AuthFileSpec{
+			{
+				Tool:        "opencode",
+				Path:        filepath.Join(dataHome, "opencode", "auth.json"),
+				Description: "OpenCode provider credentials (OAuth tokens and API keys)",
+				Required:    true,
+			},
+		},
+	}
+}
+
 // GetAuthFileSet returns the AuthFileSet for the given provider name.
 func GetAuthFileSet(provider string) (AuthFileSet, bool) {
 	switch strings.ToLower(provider) {
@@ -166,11 +200,32 @@ func GetAuthFileSet(provider string) (AuthFileSet, bool) {
 		return CodexAuthFiles(), true
 	case "gemini":
 		return GeminiAuthFiles(), true
+	case "opencode":
+		return OpenCodeAuthFiles(), true
 	default:
-		return AuthFileSet{}, false
+		extraAuthFileSetsMu.RLock()
+		fn, ok := extraAuthFileSets[strings.ToLower(provider)]
+		extraAuthFileSetsMu.RUnlock()
+		if !ok {
+			return AuthFileSet{}, false
+		}
+		return fn(), true
 	}
 }
 
+var (
+	extraAuthFileSetsMu sync.RWMutex
+	extraAuthFileSets   = make(map[string]func() AuthFileSet)
+)
+
+// RegisterAuthFileSet makes GetAuthFileSet return fn() for tool. It is used
+// by declarative provider plugins; built-in tools cannot be overridden.
+func RegisterAuthFileSet(tool string, fn func() AuthFileSet) {
+	extraAuthFileSetsMu.Lock()
+	defer extraAuthFileSetsMu.Unlock()
+	extraAuthFileSets[strings.ToLower(tool)] = fn
+}
+
 // Vault manages stored auth file backups.
 type Vault struct {
 	basePath string // ~/.local/share/caam/vault
@@ -188,6 +243,21 @@ func IsSystemProfile(name string) bool {
 
 var errProtectedSystemProfile = fmt.Errorf("protected system profile")
 
+// ConflictMarker separates a profile name from the machine name in the
+// directory of a conflict copy: a version of the profile from another
+// machine kept by sync when both sides changed, e.g. "work.conflict-laptop".
+const ConflictMarker = ".conflict-"
+
+// ConflictInfoFileName holds a conflict copy's metadata. It is not part of
+// the copy's content and is never sealed.
+const ConflictInfoFileName = ".conflict.json"
+
+// IsConflictCopy reports whether a vault directory name is a sync conflict
+// copy rather than a profile.
+func IsConflictCopy(name string) bool {
+	return strings.Contains(name, ConflictMarker)
+}
+
 // NewVault creates a new vault at the given path.
 func NewVault(basePath string) *Vault {
 	return &Vault{basePath: basePath}
@@ -227,8 +297,19 @@ func (v *Vault) BackupPath(tool, profile, filename string) string {
 	return filepath.Join(v.ProfilePath(tool, profile), filename)
 }
 
-// Backup saves the current auth files to the vault.
+// Backup saves the current auth files to the vault. The previous contents of
+// the profile stay available in its revision history (see Revisions).
 func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
+	// System profiles are written once; they have no history to keep.
+	if IsSystemProfile(profile) {
+		return v.backup(fileSet, profile)
+	}
+	return v.TrackRevision(fileSet.Tool, profile, RevisionBackup, func() error {
+		return v.backup(fileSet, profile)
+	})
+}
+
+func (v *Vault) backup(fileSet AuthFileSet, profile string) error {
 	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
 	if err != nil {
 		return err
@@ -262,18 +343,18 @@ func (v *Vault) Backup(fileSet AuthFileSet, profile string) error {
 	var missingRequired []string
 	var originalPaths []string
 	for _, spec := range fileSet.Files {
-		if _, err := os.Stat(spec.Path); os.IsNotExist(err) {
+		if !specPresent(spec) {
 			if spec.Required {
 				missingRequired = append(missingRequired, spec.Path)
 			}
 			continue // Skip optional files that don't exist
 		}
 
-		// Copy file to vault
+		// Copy file (or its selected keys) to vault
 		filename := filepath.Base(spec.Path)
 		destPath := filepath.Join(profileDir, filename)
 
-		if err := copyFile(spec.Path, destPath); err != nil {
+		if err := v.backupSpec(spec, destPath); err != nil {
 			return fmt.Errorf("backup %s: %w", spec.Path, err)
 		}
 		backedUp++
@@ -481,6 +562,10 @@ func (v *Vault) BackupOriginal(fileSet AuthFileSet) (bool, error) {
 }
 
 // Restore copies backed-up auth files to their original locations.
+//
+// The whole file set is staged in an activation journal before any live file
+// is touched, so an interrupted restore never leaves files from two accounts
+// in place; RecoverJournals finishes or undoes it on the next run.
 func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
 	profileDir, err := v.safeProfileDir(fileSet.Tool, profile)
 	if err != nil {
@@ -491,7 +576,7 @@ func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
 		return fmt.Errorf("profile %s/%s not found in vault; run 'caam ls %s' to see available profiles", fileSet.Tool, profile, fileSet.Tool)
 	}
 
-	restored := 0
+	var writes []journalWrite
 	requiredFound := false
 	optionalFound := false
 	var missingRequired []string
@@ -507,16 +592,12 @@ func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
 			continue // Skip optional files
 		}
 
-		// Ensure parent directory exists
-		if err := os.MkdirAll(filepath.Dir(spec.Path), 0700); err != nil {
-			return fmt.Errorf("create parent dir for %s: %w", spec.Path, err)
-		}
-
-		// Copy from vault to original location
-		if err := copyFile(srcPath, spec.Path); err != nil {
+		// Render the live content (selector specs are patched in place)
+		content, err := restoreContent(spec, srcPath)
+		if err != nil {
 			return fmt.Errorf("restore %s: %w", spec.Path, err)
 		}
-		restored++
+		writes = append(writes, journalWrite{path: spec.Path, data: content})
 		if spec.Required {
 			requiredFound = true
 		} else {
@@ -524,7 +605,7 @@ func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
 		}
 	}
 
-	if restored == 0 {
+	if len(writes) == 0 {
 		return fmt.Errorf("no auth files restored for %s/%s", fileSet.Tool, profile)
 	}
 	if len(missingRequired) > 0 {
@@ -533,10 +614,11 @@ func (v *Vault) Restore(fileSet AuthFileSet, profile string) error {
 		}
 	}
 
-	return nil
+	return v.applyJournaled(fileSet.Tool, profile, writes)
 }
 
-// List returns all profiles stored for a tool.
+// List returns all profiles stored for a tool. Sync conflict copies are
+// left out.
 func (v *Vault) List(tool string) ([]string, error) {
 	toolDir, err := v.safeToolDir(tool)
 	if err != nil {
@@ -552,7 +634,7 @@ func (v *Vault) List(tool string) ([]string, error) {
 
 	var profiles []string
 	for _, e := range entries {
-		if e.IsDir() {
+		if e.IsDir() && !IsConflictCopy(e.Name()) {
 			profiles = append(profiles, e.Name())
 		}
 	}
@@ -693,15 +775,17 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 	currentHashes := make(map[string]string)
 	optionalHashes := make(map[string]string)
 	requiredFound := false
+	specs := make(map[string]AuthFileSpec)
 	for _, spec := range fileSet.Files {
 		if _, err := os.Stat(spec.Path); os.IsNotExist(err) {
 			continue
 		}
-		hash, err := hashFile(spec.Path)
+		hash, err := hashSpecFile(spec, spec.Path)
 		if err != nil {
 			continue
 		}
 		base := filepath.Base(spec.Path)
+		specs[base] = spec
 		if spec.Required {
 			requiredFound = true
 			currentHashes[base] = hash
@@ -727,8 +811,11 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 
 		for filename, currentHash := range currentHashes {
 			backupPath := filepath.Join(profileDir, filename)
-			backupHash, err := hashFile(backupPath)
+			backupHash, err := hashSpecFile(specs[filename], backupPath)
 			if err != nil {
+				if errors.Is(err, ErrVaultLocked) {
+					return "", err
+				}
 				matches = false
 				break
 			}
@@ -750,7 +837,7 @@ func (v *Vault) ActiveProfile(fileSet AuthFileSet) (string, error) {
 func HasAuthFiles(fileSet AuthFileSet) bool {
 	optionalFound := false
 	for _, spec := range fileSet.Files {
-		if _, err := os.Stat(spec.Path); err == nil {
+		if specPresent(spec) {
 			if spec.Required {
 				return true
 			}
@@ -764,9 +851,10 @@ func HasAuthFiles(fileSet AuthFileSet) bool {
 }
 
 // ClearAuthFiles removes all auth files for a tool (logout).
+// JSON-selector files keep their non-auth keys; only the selected keys are removed.
 func ClearAuthFiles(fileSet AuthFileSet) error {
 	for _, spec := range fileSet.Files {
-		if err := os.Remove(spec.Path); err != nil && !os.IsNotExist(err) {
+		if err := clearSpec(spec); err != nil {
 			return fmt.Errorf("remove %s: %w", spec.Path, err)
 		}
 	}
@@ -839,6 +927,11 @@ func hashFile(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 func (v *Vault) safeToolDir(tool string) (string, error) {
 	if v == nil || strings.TrimSpace(v.basePath) == "" {
 		return "", fmt.Errorf("vault base path is empty")
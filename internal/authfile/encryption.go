@@ -0,0 +1,595 @@
+package authfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
+)
+
+// Encrypted vault mode.
+//
+// When the vault root contains VaultKeyFileName, every auth file written into
+// the vault is sealed with AES-256-GCM under a random 256-bit data key. The
+// data key itself is stored in VaultKeyFileName wrapped with a key derived
+// from the user's passphrase (Argon2id), so changing the passphrase never
+// re-encrypts profiles. meta.json stays plaintext: it holds no secrets and
+// keeps listing and status working while the vault is locked.
+//
+// Sealed files are decrypted only in memory (Restore, ActiveProfile, refresh)
+// and written to their live locations in plaintext, since the tools
+// themselves need to read them.
+
+func init() {
+	// Identities (email, plan) are read from vault copies for listings,
+	// the TUI and selection policy; open sealed ones in memory.
+	identity.SetFileReader(ReadVaultFile)
+}
+
+// VaultKeyFileName is the file at the vault root whose presence enables
+// encrypted vault mode.
+const VaultKeyFileName = ".vault_key.json"
+
+// PassphraseEnvVar lets non-interactive callers unlock an encrypted vault.
+const PassphraseEnvVar = "CAAM_VAULT_PASSPHRASE"
+
+// sealedMagic prefixes every sealed vault file.
+var sealedMagic = []byte("CAAMVLT1")
+
+const keyIDSize = 8
+
+// ErrVaultLocked is returned when a sealed file is read without the data key.
+var ErrVaultLocked = errors.New("vault is encrypted and locked; run 'caam vault unlock'")
+
+// vaultKeyFile is the on-disk form of VaultKeyFileName.
+type vaultKeyFile struct {
+	Version      int                `json:"version"`
+	Algorithm    string             `json:"algorithm"`
+	KDF          string             `json:"kdf"`
+	Argon2Params *seal.Argon2Params `json:"argon2_params"`
+	Salt         string             `json:"salt"`
+	Nonce        string             `json:"nonce"`
+	WrappedKey   string             `json:"wrapped_key"`
+	KeyID        string             `json:"key_id"`
+	CreatedAt    string             `json:"created_at"`
+}
+
+// KeySource supplies the data key for a key ID when it isn't cached in
+// process (e.g., from the daemon's key agent or an environment passphrase).
+// It is asked on every read; keys it returns are not cached.
+type KeySource func(keyID string) ([]byte, error)
+
+var keyCache = struct {
+	sync.Mutex
+	keys   map[string][]byte
+	source KeySource
+}{keys: make(map[string][]byte)}
+
+// SetKeySource installs the fallback used when a sealed file's key is not
+// cached in this process. Pass nil to remove it.
+func SetKeySource(src KeySource) {
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	keyCache.source = src
+}
+
+// CacheKey keeps a data key in process memory and returns its key ID.
+func CacheKey(key []byte) string {
+	id := KeyID(key)
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	keyCache.keys[id] = append([]byte(nil), key...)
+	return id
+}
+
+// ForgetKeys wipes every data key cached in this process.
+func ForgetKeys() {
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	for id, key := range keyCache.keys {
+		seal.Wipe(key)
+		delete(keyCache.keys, id)
+	}
+}
+
+// KeyID returns the public identifier of a data key.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(append([]byte("caam-vault-key-id:"), key...))
+	return hex.EncodeToString(sum[:keyIDSize])
+}
+
+func lookupKey(id string) ([]byte, error) {
+	keyCache.Lock()
+	if key, ok := keyCache.keys[id]; ok {
+		keyCache.Unlock()
+		return key, nil
+	}
+	src := keyCache.source
+	keyCache.Unlock()
+
+	if src == nil {
+		return nil, ErrVaultLocked
+	}
+	key, err := src(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 || KeyID(key) != id {
+		return nil, ErrVaultLocked
+	}
+	// Not cached: the source decides how long the key lives. The daemon's
+	// key agent forgets it on timeout and on 'caam vault lock', and a
+	// long-running process (daemon, TUI, API) must see that.
+	return key, nil
+}
+
+// PayloadOpener opens a vault file in another sealed format, such as a
+// profile pulled by sync that is sealed to this machine's key. ok is false
+// if data is not in its format.
+type PayloadOpener func(data []byte) (plaintext []byte, ok bool, err error)
+
+var payloadOpener struct {
+	sync.Mutex
+	open PayloadOpener
+}
+
+// SetPayloadOpener installs the opener ReadVaultFile uses for files that are
+// not vault-sealed. Pass nil to remove it.
+func SetPayloadOpener(open PayloadOpener) {
+	payloadOpener.Lock()
+	defer payloadOpener.Unlock()
+	payloadOpener.open = open
+}
+
+// openPayload opens data with the installed PayloadOpener, if any.
+func openPayload(data []byte) ([]byte, bool, error) {
+	payloadOpener.Lock()
+	open := payloadOpener.open
+	payloadOpener.Unlock()
+	if open == nil {
+		return nil, false, nil
+	}
+	return open(data)
+}
+
+// IsSealed reports whether data is a sealed vault file.
+func IsSealed(data []byte) bool {
+	return len(data) >= len(sealedMagic)+keyIDSize+seal.NonceSize && bytes.HasPrefix(data, sealedMagic)
+}
+
+// sealData encrypts plaintext into the sealed file format:
+// magic | key id | nonce | AES-256-GCM ciphertext (magic and key id are AAD).
+func sealData(key, plaintext []byte) ([]byte, error) {
+	rawID, err := hex.DecodeString(KeyID(key))
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := seal.RandomBytes(seal.NonceSize)
+	if err != nil {
+		return nil, err
+	}
+	header := append(append([]byte(nil), sealedMagic...), rawID...)
+	ciphertext, err := seal.Encrypt(key, nonce, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(ciphertext))
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return append(out, ciphertext...), nil
+}
+
+// openData decrypts a sealed file, resolving its key through the key cache.
+func openData(data []byte) ([]byte, error) {
+	headerLen := len(sealedMagic) + keyIDSize
+	header := data[:headerLen]
+	id := hex.EncodeToString(data[len(sealedMagic):headerLen])
+	key, err := lookupKey(id)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[headerLen : headerLen+seal.NonceSize]
+	plaintext, err := seal.Decrypt(key, nonce, data[headerLen+seal.NonceSize:], header)
+	if err != nil {
+		return nil, fmt.Errorf("open sealed vault file: %w", err)
+	}
+	return plaintext, nil
+}
+
+// ReadVaultFile reads a vault file, decrypting it in memory if it is sealed
+// (by the vault key or in a format a PayloadOpener handles). Plaintext files
+// (including live auth files) are returned unchanged, so it is safe to use
+// for any path that may point into the vault.
+func ReadVaultFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsSealed(data) {
+		if data, err = openData(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	// Sync payloads pulled into an encrypted vault are sealed twice.
+	plaintext, ok, err := openPayload(data)
+	if !ok {
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plaintext, nil
+}
+
+// WriteVaultFile atomically replaces a vault file. If the file being replaced
+// is sealed, the new content is sealed with the same key, so in-place updates
+// (e.g., token refresh) never downgrade an encrypted vault to plaintext.
+func WriteVaultFile(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && IsSealed(existing) {
+		id := hex.EncodeToString(existing[len(sealedMagic) : len(sealedMagic)+keyIDSize])
+		key, err := lookupKey(id)
+		if err != nil {
+			return err
+		}
+		sealed, err := sealData(key, data)
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, sealed)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// WriteFile atomically stores data at a path in the vault, sealing it when
+// the vault is encrypted.
+func (v *Vault) WriteFile(path string, data []byte) error {
+	key, err := v.dataKey()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return writeFileAtomic(path, data)
+	}
+	sealed, err := sealData(key, data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, sealed)
+}
+
+// dataKey returns the vault's data key, or nil if the vault is not encrypted.
+func (v *Vault) dataKey() ([]byte, error) {
+	kf, err := v.readKeyFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return lookupKey(kf.KeyID)
+}
+
+func (v *Vault) keyFilePath() string {
+	return filepath.Join(v.basePath, VaultKeyFileName)
+}
+
+func (v *Vault) readKeyFile() (*vaultKeyFile, error) {
+	data, err := os.ReadFile(v.keyFilePath())
+	if err != nil {
+		return nil, err
+	}
+	var kf vaultKeyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", VaultKeyFileName, err)
+	}
+	if kf.KeyID == "" || kf.WrappedKey == "" {
+		return nil, fmt.Errorf("invalid %s: missing key", VaultKeyFileName)
+	}
+	return &kf, nil
+}
+
+func (v *Vault) writeKeyFile(kf *vaultKeyFile) error {
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", VaultKeyFileName, err)
+	}
+	return writeFileAtomic(v.keyFilePath(), data)
+}
+
+// IsEncrypted reports whether the vault is in encrypted mode.
+func (v *Vault) IsEncrypted() bool {
+	_, err := os.Stat(v.keyFilePath())
+	return err == nil
+}
+
+// IsUnlocked reports whether the data key of an encrypted vault is available
+// to this process. Unencrypted vaults are always unlocked.
+func (v *Vault) IsUnlocked() bool {
+	_, err := v.dataKey()
+	return err == nil
+}
+
+// KeyID returns the ID of the vault's data key, or "" if not encrypted.
+func (v *Vault) KeyID() string {
+	kf, err := v.readKeyFile()
+	if err != nil {
+		return ""
+	}
+	return kf.KeyID
+}
+
+func wrapKey(key []byte, passphrase string) (*vaultKeyFile, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	salt, err := seal.RandomBytes(seal.SaltSize)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := seal.RandomBytes(seal.NonceSize)
+	if err != nil {
+		return nil, err
+	}
+	params := seal.DefaultArgon2Params()
+	kek := seal.DeriveKey([]byte(passphrase), salt, params)
+	defer seal.Wipe(kek)
+
+	id := KeyID(key)
+	wrapped, err := seal.Encrypt(kek, nonce, key, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	return &vaultKeyFile{
+		Version:      1,
+		Algorithm:    "aes-256-gcm",
+		KDF:          "argon2id",
+		Argon2Params: params,
+		Salt:         base64.StdEncoding.EncodeToString(salt),
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		WrappedKey:   base64.StdEncoding.EncodeToString(wrapped),
+		KeyID:        id,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+func unwrapKey(kf *vaultKeyFile, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	salt, err := base64.StdEncoding.DecodeString(kf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(kf.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("decode nonce: %w", err)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(kf.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped key: %w", err)
+	}
+
+	kek := seal.DeriveKey([]byte(passphrase), salt, kf.Argon2Params)
+	defer seal.Wipe(kek)
+
+	key, err := seal.Decrypt(kek, nonce, wrapped, []byte(kf.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unlock vault: wrong passphrase")
+	}
+	return key, nil
+}
+
+// Unlock derives the data key from passphrase, caches it in this process and
+// returns it (so it can be handed to the daemon's key agent).
+func (v *Vault) Unlock(passphrase string) ([]byte, error) {
+	kf, err := v.readKeyFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("vault is not encrypted")
+		}
+		return nil, err
+	}
+	key, err := unwrapKey(kf, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	CacheKey(key)
+	return key, nil
+}
+
+// EnableEncryption switches the vault to encrypted mode and seals every
+// existing profile file in place.
+func (v *Vault) EnableEncryption(passphrase string) error {
+	if v.IsEncrypted() {
+		return fmt.Errorf("vault is already encrypted")
+	}
+	key, err := seal.RandomBytes(seal.KeySize)
+	if err != nil {
+		return err
+	}
+	defer seal.Wipe(key)
+
+	kf, err := wrapKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(v.basePath, 0700); err != nil {
+		return fmt.Errorf("create vault dir: %w", err)
+	}
+	CacheKey(key)
+
+	// Seal files before publishing the key file so a failure leaves a vault
+	// that still reads correctly (sealed and plaintext files can coexist).
+	if err := v.walkProfileFiles(func(path string, data []byte) ([]byte, error) {
+		if IsSealed(data) {
+			return nil, nil
+		}
+		return sealData(key, data)
+	}); err != nil {
+		return fmt.Errorf("seal vault files: %w", err)
+	}
+
+	return v.writeKeyFile(kf)
+}
+
+// DisableEncryption decrypts every profile file and leaves encrypted mode.
+// The vault must be unlocked.
+func (v *Vault) DisableEncryption() error {
+	if !v.IsEncrypted() {
+		return fmt.Errorf("vault is not encrypted")
+	}
+	if _, err := v.dataKey(); err != nil {
+		return err
+	}
+	if err := v.walkProfileFiles(func(path string, data []byte) ([]byte, error) {
+		if !IsSealed(data) {
+			return nil, nil
+		}
+		return openData(data)
+	}); err != nil {
+		return fmt.Errorf("open vault files: %w", err)
+	}
+	if err := os.Remove(v.keyFilePath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %s: %w", VaultKeyFileName, err)
+	}
+	return nil
+}
+
+// ChangePassphrase re-wraps the data key under a new passphrase. Profile files
+// are not touched.
+func (v *Vault) ChangePassphrase(oldPassphrase, newPassphrase string) error {
+	kf, err := v.readKeyFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("vault is not encrypted")
+		}
+		return err
+	}
+	key, err := unwrapKey(kf, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	defer seal.Wipe(key)
+
+	updated, err := wrapKey(key, newPassphrase)
+	if err != nil {
+		return err
+	}
+	updated.CreatedAt = kf.CreatedAt
+	return v.writeKeyFile(updated)
+}
+
+// walkProfileFiles rewrites every auth file in every profile and sync
+// conflict copy, and every revision history object, through fn. fn returns
+// nil to leave a file unchanged. Metadata (meta.json, conflict info) is
+// never passed to fn.
+func (v *Vault) walkProfileFiles(fn func(path string, data []byte) ([]byte, error)) error {
+	tools, err := os.ReadDir(v.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, tool := range tools {
+		if !tool.IsDir() {
+			continue
+		}
+		toolDir, err := v.safeToolDir(tool.Name())
+		if err != nil {
+			continue
+		}
+		// Read the directory itself rather than List, which leaves out
+		// conflict copies.
+		profiles, err := os.ReadDir(toolDir)
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			if !profile.IsDir() {
+				continue
+			}
+			dir, err := v.safeProfileDir(tool.Name(), profile.Name())
+			if err != nil {
+				continue
+			}
+			// Revision history objects hold old tokens and are sealed too.
+			for _, d := range []string{dir, filepath.Join(dir, HistoryDirName, "objects")} {
+				if err := rewriteDirFiles(d, fn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func rewriteDirFiles(dir string, fn func(path string, data []byte) ([]byte, error)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == "meta.json" || name == ConflictInfoFileName || strings.Contains(name, ".tmp.") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated, err := fn(path, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if updated == nil {
+			continue
+		}
+		if err := writeFileAtomic(path, updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnvKeySource returns a KeySource that unlocks v with the passphrase in
+// PassphraseEnvVar, if set.
+func EnvKeySource(v *Vault) KeySource {
+	return func(keyID string) ([]byte, error) {
+		passphrase := os.Getenv(PassphraseEnvVar)
+		if passphrase == "" || v == nil {
+			return nil, ErrVaultLocked
+		}
+		return v.Unlock(passphrase)
+	}
+}
+
+// ChainKeySources tries each source in order and returns the first key found.
+func ChainKeySources(sources ...KeySource) KeySource {
+	return func(keyID string) ([]byte, error) {
+		for _, src := range sources {
+			if src == nil {
+				continue
+			}
+			if key, err := src(keyID); err == nil && len(key) > 0 {
+				return key, nil
+			}
+		}
+		return nil, ErrVaultLocked
+	}
+}
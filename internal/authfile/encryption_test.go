@@ -0,0 +1,321 @@
+package authfile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+)
+
+func setupEncryptedVault(t *testing.T) (*Vault, AuthFileSet, string) {
+	t.Helper()
+	ForgetKeys()
+	SetKeySource(nil)
+	t.Cleanup(func() {
+		ForgetKeys()
+		SetKeySource(nil)
+	})
+
+	tmpDir := t.TempDir()
+	authPath := filepath.Join(tmpDir, "home", "auth.json")
+	if err := os.MkdirAll(filepath.Dir(authPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	fileSet := AuthFileSet{
+		Tool:  "codex",
+		Files: []AuthFileSpec{{Tool: "codex", Path: authPath, Required: true}},
+	}
+	return v, fileSet, authPath
+}
+
+func TestEncryptedVault_SealsAndRestores(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	secret := []byte(`{"refresh_token":"rt-before-encryption"}`)
+	if err := os.WriteFile(authPath, secret, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if err := v.EnableEncryption("correct horse"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if !v.IsEncrypted() {
+		t.Fatal("IsEncrypted() = false after EnableEncryption")
+	}
+
+	// Existing profile is sealed in place; meta.json stays readable.
+	raw, err := os.ReadFile(v.BackupPath("codex", "work", "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(raw) || bytes.Contains(raw, []byte("rt-before-encryption")) {
+		t.Fatal("existing profile file was not sealed")
+	}
+	if meta, _ := os.ReadFile(v.BackupPath("codex", "work", "meta.json")); IsSealed(meta) {
+		t.Error("meta.json should stay plaintext")
+	}
+
+	// New backups are sealed too.
+	personal := []byte(`{"refresh_token":"rt-personal"}`)
+	if err := os.WriteFile(authPath, personal, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "personal"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	raw, _ = os.ReadFile(v.BackupPath("codex", "personal", "auth.json"))
+	if !IsSealed(raw) {
+		t.Fatal("new backup was not sealed")
+	}
+
+	if active, err := v.ActiveProfile(fileSet); err != nil || active != "personal" {
+		t.Fatalf("ActiveProfile() = %q, %v; want personal", active, err)
+	}
+
+	if err := v.Restore(fileSet, "work"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	live, _ := os.ReadFile(authPath)
+	if !bytes.Equal(live, secret) {
+		t.Errorf("restored live file = %q, want %q", live, secret)
+	}
+}
+
+func TestEncryptedVault_LockedAndUnlock(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	if err := v.EnableEncryption("pass-1"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := os.WriteFile(authPath, []byte(`{"token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	ForgetKeys()
+	if err := v.Restore(fileSet, "work"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Restore() while locked error = %v, want ErrVaultLocked", err)
+	}
+	if _, err := v.ActiveProfile(fileSet); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("ActiveProfile() while locked error = %v, want ErrVaultLocked", err)
+	}
+	if err := v.Backup(fileSet, "other"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Backup() while locked error = %v, want ErrVaultLocked", err)
+	}
+
+	if _, err := v.Unlock("wrong"); err == nil {
+		t.Fatal("Unlock() with wrong passphrase should fail")
+	}
+	if err := v.ChangePassphrase("pass-1", "pass-2"); err != nil {
+		t.Fatalf("ChangePassphrase() error = %v", err)
+	}
+	if _, err := v.Unlock("pass-1"); err == nil {
+		t.Fatal("Unlock() with old passphrase should fail")
+	}
+
+	t.Setenv(PassphraseEnvVar, "pass-2")
+	SetKeySource(EnvKeySource(v))
+	if err := v.Restore(fileSet, "work"); err != nil {
+		t.Fatalf("Restore() with env passphrase error = %v", err)
+	}
+}
+
+func TestEncryptedVault_DisableEncryption(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	content := []byte(`{"token":"plain-again"}`)
+	if err := os.WriteFile(authPath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if err := v.DisableEncryption(); err != nil {
+		t.Fatalf("DisableEncryption() error = %v", err)
+	}
+	if v.IsEncrypted() {
+		t.Fatal("IsEncrypted() = true after DisableEncryption")
+	}
+	raw, _ := os.ReadFile(v.BackupPath("codex", "work", "auth.json"))
+	if !bytes.Equal(raw, content) {
+		t.Errorf("vault file = %q, want plaintext %q", raw, content)
+	}
+}
+
+func TestWriteVaultFile_PreservesSealing(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := os.WriteFile(authPath, []byte(`{"token":"old"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	path := v.BackupPath("codex", "work", "auth.json")
+	if err := WriteVaultFile(path, []byte(`{"token":"refreshed"}`)); err != nil {
+		t.Fatalf("WriteVaultFile() error = %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !IsSealed(raw) {
+		t.Fatal("WriteVaultFile downgraded a sealed file to plaintext")
+	}
+	got, err := ReadVaultFile(path)
+	if err != nil || string(got) != `{"token":"refreshed"}` {
+		t.Fatalf("ReadVaultFile() = %q, %v", got, err)
+	}
+}
+
+func TestKeyAgent_ServesKeyUntilTimeout(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := os.WriteFile(authPath, []byte(`{"token":"a"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	key, err := v.Unlock("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ForgetKeys()
+
+	// Unix socket paths are length-limited; keep it short.
+	sockDir, err := os.MkdirTemp("", "caam-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+	socketPath := filepath.Join(sockDir, "a.sock")
+
+	agent := NewKeyAgent(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agent.Serve(ctx, socketPath)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := QueryKeyAgent(socketPath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("key agent did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	SetKeySource(AgentKeySource(socketPath))
+	if err := v.Restore(fileSet, "work"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Restore() before unlock error = %v, want ErrVaultLocked", err)
+	}
+
+	status, err := SendKeyToAgent(socketPath, key, 0)
+	if err != nil {
+		t.Fatalf("SendKeyToAgent() error = %v", err)
+	}
+	if !status.Unlocked || status.KeyID != v.KeyID() {
+		t.Fatalf("status = %+v, want unlocked with key %s", status, v.KeyID())
+	}
+	if err := v.Restore(fileSet, "work"); err != nil {
+		t.Fatalf("Restore() via agent error = %v", err)
+	}
+
+	// Keys served by the agent are not cached in process, so a long-running
+	// process is locked as soon as the agent is.
+	if err := LockKeyAgent(socketPath); err != nil {
+		t.Fatalf("LockKeyAgent() error = %v", err)
+	}
+	if err := v.Restore(fileSet, "work"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Restore() after lock error = %v, want ErrVaultLocked", err)
+	}
+
+	// Keys expire on their own.
+	agent.Put(key, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, err := agent.Key(""); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Key() after ttl error = %v, want ErrVaultLocked", err)
+	}
+	if err := v.Restore(fileSet, "work"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Restore() after agent timeout error = %v, want ErrVaultLocked", err)
+	}
+}
+
+func TestEncryptedVault_IdentityReadsSealedCopy(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+
+	creds := []byte(`{"claudeAiOauth":{"email":"work@example.com","subscriptionType":"max"}}`)
+	if err := os.WriteFile(authPath, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	id, err := identity.ExtractFromClaudeCredentials(v.BackupPath("codex", "work", "auth.json"))
+	if err != nil {
+		t.Fatalf("ExtractFromClaudeCredentials() error = %v", err)
+	}
+	if id.Email != "work@example.com" || id.PlanType != "max" {
+		t.Errorf("identity from sealed copy = %+v", id)
+	}
+}
+
+func TestEncryptedVault_SealsConflictCopies(t *testing.T) {
+	v, _, _ := setupEncryptedVault(t)
+
+	dir := filepath.Join(v.basePath, "codex", "work"+ConflictMarker+"laptop")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(`{"token":"from-laptop"}`)
+	info := []byte(`{"machine":"laptop"}`)
+	if err := os.WriteFile(filepath.Join(dir, "auth.json"), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConflictInfoFileName), info, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, "auth.json"))
+	if !IsSealed(raw) {
+		t.Error("conflict copy auth file not sealed by EnableEncryption")
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dir, ConflictInfoFileName)); !bytes.Equal(raw, info) {
+		t.Errorf("conflict info = %q, want it left plain", raw)
+	}
+
+	if err := v.DisableEncryption(); err != nil {
+		t.Fatalf("DisableEncryption() error = %v", err)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dir, "auth.json")); !bytes.Equal(raw, content) {
+		t.Errorf("conflict copy auth file = %q, want plaintext %q", raw, content)
+	}
+}
@@ -0,0 +1,352 @@
+package authfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+// Activation journal
+//
+// Restore writes a journal before touching live files:
+//
+//	<data>/journal/<id>/
+//	  0.new, 1.new ...   content to install at each live path
+//	  0.old, 1.old ...   previous content of live paths that existed
+//	  journal.json       manifest, written last
+//
+// A journal directory without journal.json never touched a live file and is
+// simply discarded. Once journal.json exists every staged file is complete,
+// so an interrupted activation is rolled forward by re-applying all of them
+// (each write is atomic and idempotent). If a staged file fails its checksum
+// the journal is rolled back from the .old copies instead. Either way, a
+// journal is only replayed while each live file still holds its content
+// from before or after the activation: if anything else (the tool itself, a
+// manual login, a token refresh) has written a live file since, the journal
+// is kept for 'caam doctor --fix', which discards it.
+//
+// Staged files hold tokens, so they are written like vault files: sealed
+// when the vault is encrypted. Checksums are over the plaintext.
+
+const journalManifestName = "journal.json"
+
+// journalGracePeriod is how long a journal may belong to a running activation.
+const journalGracePeriod = time.Minute
+
+// Journal recovery actions.
+const (
+	JournalRolledForward = "rolled_forward"
+	JournalRolledBack    = "rolled_back"
+	JournalDiscarded     = "discarded"
+	JournalKept          = "kept"
+)
+
+// ErrLiveFileChanged is reported for a journal whose live files were
+// written by something else after the activation was interrupted.
+var ErrLiveFileChanged = errors.New("changed since the interrupted activation; not overwriting it")
+
+// ActivationJournal describes one pending (torn) activation.
+type ActivationJournal struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Profile   string         `json:"profile"`
+	PID       int            `json:"pid"`
+	StartedAt time.Time      `json:"started_at"`
+	Entries   []JournalEntry `json:"entries"`
+
+	// dir is the journal directory; complete is false when journal.json was
+	// never written.
+	dir      string
+	complete bool
+}
+
+// JournalEntry is one live file written by an activation.
+type JournalEntry struct {
+	Path       string `json:"path"`
+	Staged     string `json:"staged"`
+	StagedHash string `json:"staged_hash"`
+	Backup     string `json:"backup,omitempty"` // empty if Path did not exist
+	BackupHash string `json:"backup_hash,omitempty"`
+}
+
+// Complete reports whether the journal was fully written, i.e. whether live
+// files may have been modified.
+func (j *ActivationJournal) Complete() bool {
+	return j.complete
+}
+
+// JournalRecovery reports what RecoverJournals did with one journal.
+type JournalRecovery struct {
+	Journal *ActivationJournal
+	Action  string // JournalRolledForward, JournalRolledBack, JournalDiscarded, JournalKept
+	Err     error
+}
+
+// journalWrite is a pending live-file write.
+type journalWrite struct {
+	path string
+	data []byte
+}
+
+// JournalDir returns the directory holding activation journals. It lives in
+// the caam data dir, next to the vault.
+func (v *Vault) JournalDir() string {
+	return filepath.Join(filepath.Dir(v.basePath), "journal")
+}
+
+// applyJournaled writes all files through an activation journal.
+func (v *Vault) applyJournaled(tool, profileName string, writes []journalWrite) error {
+	j, err := v.prepareJournal(tool, profileName, writes)
+	if err != nil {
+		return err
+	}
+
+	if err := j.rollForward(); err != nil {
+		// Leave the live files as they were before this activation.
+		if rbErr := j.rollBack(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v; run 'caam doctor --fix')", err, rbErr)
+		}
+		j.remove()
+		return err
+	}
+	return j.remove()
+}
+
+// prepareJournal stages writes and the current live content, then commits
+// the manifest. Live files are not touched.
+func (v *Vault) prepareJournal(tool, profileName string, writes []journalWrite) (*ActivationJournal, error) {
+	j := &ActivationJournal{
+		ID:        fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid()),
+		Tool:      tool,
+		Profile:   profileName,
+		PID:       os.Getpid(),
+		StartedAt: time.Now().UTC(),
+	}
+	j.dir = filepath.Join(v.JournalDir(), j.ID)
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return nil, fmt.Errorf("create activation journal: %w", err)
+	}
+
+	for i, w := range writes {
+		entry := JournalEntry{
+			Path:       w.path,
+			Staged:     fmt.Sprintf("%d.new", i),
+			StagedHash: hashBytes(w.data),
+		}
+		if err := v.WriteFile(filepath.Join(j.dir, entry.Staged), w.data); err != nil {
+			os.RemoveAll(j.dir)
+			return nil, fmt.Errorf("stage %s: %w", w.path, err)
+		}
+
+		old, err := os.ReadFile(w.path)
+		switch {
+		case err == nil:
+			entry.Backup = fmt.Sprintf("%d.old", i)
+			entry.BackupHash = hashBytes(old)
+			if err := v.WriteFile(filepath.Join(j.dir, entry.Backup), old); err != nil {
+				os.RemoveAll(j.dir)
+				return nil, fmt.Errorf("save previous %s: %w", w.path, err)
+			}
+		case !os.IsNotExist(err):
+			os.RemoveAll(j.dir)
+			return nil, fmt.Errorf("read %s: %w", w.path, err)
+		}
+		j.Entries = append(j.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		os.RemoveAll(j.dir)
+		return nil, fmt.Errorf("marshal activation journal: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(j.dir, journalManifestName), data); err != nil {
+		os.RemoveAll(j.dir)
+		return nil, fmt.Errorf("write activation journal: %w", err)
+	}
+	j.complete = true
+	return j, nil
+}
+
+// rollForward installs every staged file at its live path.
+func (j *ActivationJournal) rollForward() error {
+	for _, e := range j.Entries {
+		data, err := readJournalFile(j.dir, e.Staged, e.StagedHash)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(e.Path, data); err != nil {
+			return fmt.Errorf("restore %s: %w", e.Path, err)
+		}
+	}
+	return nil
+}
+
+// rollBack puts every live path back to its pre-activation state.
+func (j *ActivationJournal) rollBack() error {
+	var errs []error
+	for _, e := range j.Entries {
+		if e.Backup == "" {
+			if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		data, err := readJournalFile(j.dir, e.Backup, e.BackupHash)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := writeFileAtomic(e.Path, data); err != nil {
+			errs = append(errs, fmt.Errorf("roll back %s: %w", e.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkLive returns an error wrapping ErrLiveFileChanged unless every live
+// path holds either its pre-activation content or its staged content.
+func (j *ActivationJournal) checkLive() error {
+	for _, e := range j.Entries {
+		data, err := os.ReadFile(e.Path)
+		switch {
+		case os.IsNotExist(err):
+			if e.Backup == "" {
+				continue
+			}
+		case err != nil:
+			return fmt.Errorf("read %s: %w", e.Path, err)
+		default:
+			if h := hashBytes(data); h == e.StagedHash || (e.Backup != "" && h == e.BackupHash) {
+				continue
+			}
+		}
+		return fmt.Errorf("%s %w", e.Path, ErrLiveFileChanged)
+	}
+	return nil
+}
+
+// Discard removes the journal without touching live files.
+func (j *ActivationJournal) Discard() error {
+	return j.remove()
+}
+
+func (j *ActivationJournal) remove() error {
+	// Drop the manifest first so a crash here leaves a discardable journal.
+	if err := os.Remove(filepath.Join(j.dir, journalManifestName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.RemoveAll(j.dir)
+}
+
+func readJournalFile(dir, name, wantHash string) ([]byte, error) {
+	data, err := ReadVaultFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("read journal file %s: %w", name, err)
+	}
+	if hashBytes(data) != wantHash {
+		return nil, fmt.Errorf("journal file %s is corrupt (checksum mismatch)", name)
+	}
+	return data, nil
+}
+
+// PendingJournals returns activation journals left behind by interrupted
+// activations, oldest first. Journals owned by a running process are skipped.
+func (v *Vault) PendingJournals() ([]*ActivationJournal, error) {
+	entries, err := os.ReadDir(v.JournalDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var journals []*ActivationJournal
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(v.JournalDir(), e.Name())
+		j := &ActivationJournal{ID: e.Name(), dir: dir}
+
+		data, err := os.ReadFile(filepath.Join(dir, journalManifestName))
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, j); err != nil {
+				return nil, fmt.Errorf("parse journal %s: %w", e.Name(), err)
+			}
+			j.dir = dir
+			j.complete = true
+		case os.IsNotExist(err):
+			// Staging was interrupted. Use the directory mtime for age.
+			if info, statErr := e.Info(); statErr == nil {
+				j.StartedAt = info.ModTime()
+			}
+		default:
+			return nil, err
+		}
+
+		// An activation still in progress is not torn. Activations take
+		// milliseconds, so an old journal is torn even if its PID was reused.
+		if time.Since(j.StartedAt) < journalGracePeriod {
+			if !j.complete || (j.PID != 0 && profile.IsProcessAlive(j.PID)) {
+				continue
+			}
+		}
+		journals = append(journals, j)
+	}
+
+	sort.Slice(journals, func(a, b int) bool {
+		return journals[a].StartedAt.Before(journals[b].StartedAt)
+	})
+	return journals, nil
+}
+
+// RecoverJournals completes or undoes every torn activation: complete
+// journals are rolled forward, journals with corrupt staged data are rolled
+// back, and incomplete journals (which never touched live files) are
+// discarded.
+func (v *Vault) RecoverJournals() ([]JournalRecovery, error) {
+	journals, err := v.PendingJournals()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []JournalRecovery
+	for _, j := range journals {
+		results = append(results, v.RecoverJournal(j))
+	}
+	return results, nil
+}
+
+// RecoverJournal rolls a single pending journal forward or back. A journal
+// whose live files changed since is kept, with an error wrapping
+// ErrLiveFileChanged.
+func (v *Vault) RecoverJournal(j *ActivationJournal) JournalRecovery {
+	if !j.complete {
+		return JournalRecovery{Journal: j, Action: JournalDiscarded, Err: os.RemoveAll(j.dir)}
+	}
+	if err := j.checkLive(); err != nil {
+		return JournalRecovery{Journal: j, Action: JournalKept, Err: err}
+	}
+
+	err := j.rollForward()
+	if err == nil {
+		return JournalRecovery{Journal: j, Action: JournalRolledForward, Err: j.remove()}
+	}
+	if errors.Is(err, ErrVaultLocked) {
+		// Nothing is corrupt; retry once the vault is unlocked.
+		return JournalRecovery{Journal: j, Action: JournalKept, Err: err}
+	}
+	if err := j.rollBack(); err != nil {
+		// Keep the journal so the user (or doctor) can retry.
+		return JournalRecovery{Journal: j, Action: JournalRolledBack, Err: err}
+	}
+	return JournalRecovery{Journal: j, Action: JournalRolledBack, Err: j.remove()}
+}
@@ -0,0 +1,258 @@
+package authfile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// journalFixture is a two-file tool with "work" and "personal" profiles and
+// "work" currently live.
+func journalFixture(t *testing.T) (*Vault, AuthFileSet) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	home := filepath.Join(tmpDir, "home")
+	fileSet := AuthFileSet{
+		Tool: "claude",
+		Files: []AuthFileSpec{
+			{Tool: "claude", Path: filepath.Join(home, ".credentials.json"), Required: true},
+			{Tool: "claude", Path: filepath.Join(home, "settings.json"), Required: true},
+		},
+	}
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+
+	for _, name := range []string{"personal", "work"} {
+		for _, spec := range fileSet.Files {
+			if err := os.MkdirAll(filepath.Dir(spec.Path), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(spec.Path, []byte(name+":"+filepath.Base(spec.Path)), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := v.Backup(fileSet, name); err != nil {
+			t.Fatalf("Backup(%s) error = %v", name, err)
+		}
+	}
+	return v, fileSet
+}
+
+// tornJournal stages an activation of profile and applies only the first
+// file, as if the process died midway.
+func tornJournal(t *testing.T, v *Vault, fileSet AuthFileSet, profile string) *ActivationJournal {
+	t.Helper()
+	var writes []journalWrite
+	for _, spec := range fileSet.Files {
+		data, err := restoreContent(spec, v.BackupPath(fileSet.Tool, profile, filepath.Base(spec.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writes = append(writes, journalWrite{path: spec.Path, data: data})
+	}
+	j, err := v.prepareJournal(fileSet.Tool, profile, writes)
+	if err != nil {
+		t.Fatalf("prepareJournal() error = %v", err)
+	}
+	if err := os.WriteFile(writes[0].path, writes[0].data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the owning process is gone.
+	j.PID = 0
+	data, _ := json.Marshal(j)
+	if err := os.WriteFile(filepath.Join(j.dir, journalManifestName), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func readLive(t *testing.T, fileSet AuthFileSet) []string {
+	t.Helper()
+	var out []string
+	for _, spec := range fileSet.Files {
+		data, err := os.ReadFile(spec.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func TestRestore_LeavesNoJournal(t *testing.T) {
+	v, fileSet := journalFixture(t)
+
+	if err := v.Restore(fileSet, "personal"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	live := readLive(t, fileSet)
+	if live[0] != "personal:.credentials.json" || live[1] != "personal:settings.json" {
+		t.Errorf("live files = %v", live)
+	}
+
+	entries, _ := os.ReadDir(v.JournalDir())
+	if len(entries) != 0 {
+		t.Errorf("journal dir has %d entries after Restore, want 0", len(entries))
+	}
+}
+
+func TestRestore_MissingRequiredTouchesNothing(t *testing.T) {
+	v, fileSet := journalFixture(t)
+
+	if err := os.Remove(v.BackupPath("claude", "personal", "settings.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Restore(fileSet, "personal"); err == nil {
+		t.Fatal("Restore() should fail when a required backup is missing")
+	}
+	live := readLive(t, fileSet)
+	if live[0] != "work:.credentials.json" {
+		t.Errorf("live credentials changed to %q", live[0])
+	}
+}
+
+func TestRecoverJournals_RollsForward(t *testing.T) {
+	v, fileSet := journalFixture(t)
+	tornJournal(t, v, fileSet, "personal")
+
+	// Torn state: one file from each account.
+	live := readLive(t, fileSet)
+	if live[0] != "personal:.credentials.json" || live[1] != "work:settings.json" {
+		t.Fatalf("fixture not torn: %v", live)
+	}
+
+	pending, err := v.PendingJournals()
+	if err != nil || len(pending) != 1 || !pending[0].Complete() {
+		t.Fatalf("PendingJournals() = %v, %v; want one complete journal", pending, err)
+	}
+
+	results, err := v.RecoverJournals()
+	if err != nil {
+		t.Fatalf("RecoverJournals() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Action != JournalRolledForward || results[0].Err != nil {
+		t.Fatalf("RecoverJournals() = %+v", results)
+	}
+
+	live = readLive(t, fileSet)
+	if live[0] != "personal:.credentials.json" || live[1] != "personal:settings.json" {
+		t.Errorf("live files after roll forward = %v", live)
+	}
+	if pending, _ := v.PendingJournals(); len(pending) != 0 {
+		t.Errorf("journal not removed after recovery")
+	}
+}
+
+func TestRecoverJournals_RollsBackCorruptStage(t *testing.T) {
+	v, fileSet := journalFixture(t)
+	j := tornJournal(t, v, fileSet, "personal")
+
+	if err := os.WriteFile(filepath.Join(j.dir, j.Entries[1].Staged), []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := v.RecoverJournals()
+	if err != nil {
+		t.Fatalf("RecoverJournals() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Action != JournalRolledBack || results[0].Err != nil {
+		t.Fatalf("RecoverJournals() = %+v", results)
+	}
+
+	live := readLive(t, fileSet)
+	if live[0] != "work:.credentials.json" || live[1] != "work:settings.json" {
+		t.Errorf("live files after roll back = %v", live)
+	}
+}
+
+func TestRecoverJournals_KeepsJournalWhenLiveChanged(t *testing.T) {
+	v, fileSet := journalFixture(t)
+	j := tornJournal(t, v, fileSet, "personal")
+
+	// The tool rewrote a live file after the activation was torn.
+	if err := os.WriteFile(fileSet.Files[1].Path, []byte("work:settings.json+edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := v.RecoverJournals()
+	if err != nil {
+		t.Fatalf("RecoverJournals() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Action != JournalKept || !errors.Is(results[0].Err, ErrLiveFileChanged) {
+		t.Fatalf("RecoverJournals() = %+v, want kept with ErrLiveFileChanged", results)
+	}
+	live := readLive(t, fileSet)
+	if live[0] != "personal:.credentials.json" || live[1] != "work:settings.json+edited" {
+		t.Errorf("live files = %v, want them left alone", live)
+	}
+
+	if err := j.Discard(); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if pending, _ := v.PendingJournals(); len(pending) != 0 {
+		t.Error("journal not removed by Discard")
+	}
+}
+
+func TestRecoverJournals_SkipsLiveActivation(t *testing.T) {
+	v, fileSet := journalFixture(t)
+
+	var writes []journalWrite
+	for _, spec := range fileSet.Files {
+		writes = append(writes, journalWrite{path: spec.Path, data: []byte("x")})
+	}
+	if _, err := v.prepareJournal(fileSet.Tool, "personal", writes); err != nil {
+		t.Fatal(err)
+	}
+
+	// Owned by this (running) process: not torn.
+	pending, err := v.PendingJournals()
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("PendingJournals() = %v, %v; want none", pending, err)
+	}
+}
+
+func TestRecoverJournals_EncryptedVaultStagesSealed(t *testing.T) {
+	ForgetKeys()
+	t.Cleanup(ForgetKeys)
+	v, fileSet := journalFixture(t)
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	j := tornJournal(t, v, fileSet, "personal")
+
+	for _, e := range j.Entries {
+		for _, name := range []string{e.Staged, e.Backup} {
+			raw, err := os.ReadFile(filepath.Join(j.dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsSealed(raw) {
+				t.Errorf("journal file %s is plaintext in an encrypted vault", name)
+			}
+		}
+	}
+
+	ForgetKeys()
+	results, _ := v.RecoverJournals()
+	if len(results) != 1 || !errors.Is(results[0].Err, ErrVaultLocked) {
+		t.Fatalf("RecoverJournals() while locked = %+v, want ErrVaultLocked", results)
+	}
+	if pending, _ := v.PendingJournals(); len(pending) != 1 {
+		t.Fatal("journal should be kept while the vault is locked")
+	}
+
+	if _, err := v.Unlock("pass"); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = v.RecoverJournals()
+	if len(results) != 1 || results[0].Action != JournalRolledForward || results[0].Err != nil {
+		t.Fatalf("RecoverJournals() = %+v", results)
+	}
+	live := readLive(t, fileSet)
+	if live[0] != "personal:.credentials.json" || live[1] != "personal:settings.json" {
+		t.Errorf("live files after roll forward = %v", live)
+	}
+}
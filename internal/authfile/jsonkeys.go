@@ -0,0 +1,325 @@
+package authfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ClaudeStateKeys are the top-level keys of ~/.claude.json that identify the
+// signed-in account. Everything else in that file (per-project history, MCP
+// server config, onboarding flags) belongs to the user, not the account, and
+// must survive profile switches.
+var ClaudeStateKeys = []string{
+	"oauthAccount",
+	"oauthToken", // legacy OAuth session state
+	"primaryApiKey",
+	"customApiKeyResponses",
+}
+
+// errNoSelectedKeys is returned when a JSON-selector file exists but contains
+// none of the selected keys (e.g., ~/.claude.json after logout).
+var errNoSelectedKeys = fmt.Errorf("no selected keys present: %w", os.ErrNotExist)
+
+// HasJSONKeys reports whether the spec only covers a subset of a JSON file.
+func (s AuthFileSpec) HasJSONKeys() bool {
+	return len(s.JSONKeys) > 0
+}
+
+// SpecContent returns the bytes that represent spec's auth state in the file
+// at path. For plain specs this is the raw file content. For JSON-selector
+// specs it is a canonical JSON object holding only the selected keys (sorted,
+// compacted), so hashes are stable across unrelated edits and formatting.
+//
+// Sealed vault files are decrypted in memory first. A selector file
+// containing none of the selected keys is reported as not-existing
+// (errors.Is(err, os.ErrNotExist)).
+func SpecContent(spec AuthFileSpec, path string) ([]byte, error) {
+	data, err := ReadVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !spec.HasJSONKeys() {
+		return data, nil
+	}
+	return selectJSONKeys(data, spec.JSONKeys)
+}
+
+// specPresent reports whether the live file for spec exists and holds auth
+// state worth capturing.
+func specPresent(spec AuthFileSpec) bool {
+	if !spec.HasJSONKeys() {
+		_, err := os.Stat(spec.Path)
+		return err == nil
+	}
+	_, err := SpecContent(spec, spec.Path)
+	return err == nil
+}
+
+// hashSpecFile hashes the auth state of spec as stored in the file at path.
+// Sealed vault files are hashed by their plaintext.
+func hashSpecFile(spec AuthFileSpec, path string) (string, error) {
+	content, err := SpecContent(spec, path)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(content), nil
+}
+
+// selectJSONKeys extracts the selected top-level keys from a JSON object.
+func selectJSONKeys(data []byte, keys []string) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("parse JSON object: %w", err)
+	}
+
+	selected := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		if val, ok := obj[key]; ok {
+			selected[key] = val
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errNoSelectedKeys
+	}
+
+	// json.Marshal sorts map keys and compacts RawMessage values.
+	return json.Marshal(selected)
+}
+
+// backupSpec stores spec's live auth state at destPath in the vault.
+func (v *Vault) backupSpec(spec AuthFileSpec, destPath string) error {
+	content, err := SpecContent(spec, spec.Path)
+	if err != nil {
+		return err
+	}
+	return v.WriteFile(destPath, content)
+}
+
+// restoreContent returns the bytes that restoring the vault copy at srcPath
+// would write to spec's live location. JSON-selector specs are patched: the
+// selected keys are replaced with the vault values (or removed when the vault
+// has none), and every other key in the live file is kept.
+func restoreContent(spec AuthFileSpec, srcPath string) ([]byte, error) {
+	if !spec.HasJSONKeys() {
+		return ReadVaultFile(srcPath)
+	}
+
+	// Legacy vault entries hold the full file; selecting from them keeps
+	// restore surgical either way. A copy holding none of the keys means
+	// "signed out": clear them.
+	var selected map[string]json.RawMessage
+	stored, err := SpecContent(spec, srcPath)
+	switch {
+	case errors.Is(err, errNoSelectedKeys):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(stored, &selected); err != nil {
+			return nil, fmt.Errorf("parse vault copy: %w", err)
+		}
+	}
+
+	live, err := os.ReadFile(spec.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(live)) == 0 {
+		live = []byte("{}\n")
+	}
+
+	merged, err := patchJSONKeys(live, spec.JSONKeys, selected)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", spec.Path, err)
+	}
+	return merged, nil
+}
+
+// clearSpec removes spec's auth state from its live location.
+func clearSpec(spec AuthFileSpec) error {
+	if !spec.HasJSONKeys() {
+		if err := os.Remove(spec.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := os.ReadFile(spec.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	updated, err := patchJSONKeys(data, spec.JSONKeys, nil)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", spec.Path, err)
+	}
+	if bytes.Equal(updated, data) {
+		return nil
+	}
+	return writeFileAtomic(spec.Path, updated)
+}
+
+// jsonMember locates one top-level member of a JSON object in its source.
+type jsonMember struct {
+	key        string
+	lead       []byte // whitespace (and comma) before the key
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// patchJSONKeys sets the given top-level keys of a JSON object to values,
+// removing those values lacks, and leaves every other byte of data as it
+// is: key order, formatting and escaping of the user's keys are kept. New
+// keys are appended, indented like the existing ones.
+func patchJSONKeys(data []byte, keys []string, values map[string]json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	openEnd := int(dec.InputOffset())
+
+	var members []jsonMember
+	prevEnd := openEnd
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		m := jsonMember{key: tok.(string), keyEnd: int(dec.InputOffset())}
+		m.keyStart = m.keyEnd - len(bytes.TrimLeft(data[prevEnd:m.keyEnd], " \t\r\n,"))
+		m.lead = data[prevEnd:m.keyStart]
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		m.valueEnd = int(dec.InputOffset())
+		m.valueStart = m.valueEnd - len(raw)
+		members = append(members, m)
+		prevEnd = m.valueEnd
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+
+	owned := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		owned[key] = true
+	}
+
+	// Layout for new members, taken from the existing ones
+	ws, colon := []byte("\n  "), []byte(": ")
+	if len(members) > 0 {
+		ws = bytes.Replace(members[len(members)-1].lead, []byte(","), nil, 1)
+		colon = data[members[0].keyEnd:members[0].valueStart]
+	}
+	format := func(v json.RawMessage) []byte {
+		nl := bytes.LastIndexByte(ws, '\n')
+		if nl < 0 {
+			return v
+		}
+		indent := string(ws[nl+1:])
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, v, indent, indent); err != nil {
+			return v
+		}
+		return buf.Bytes()
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:openEnd])
+	written := 0
+	present := make(map[string]bool)
+	for _, m := range members {
+		if owned[m.key] {
+			present[m.key] = true
+			if _, ok := values[m.key]; !ok {
+				continue
+			}
+		}
+		if written > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(bytes.Replace(m.lead, []byte(","), nil, 1))
+		out.Write(data[m.keyStart:m.valueStart])
+		if owned[m.key] && !sameJSON(data[m.valueStart:m.valueEnd], values[m.key]) {
+			out.Write(format(values[m.key]))
+		} else {
+			out.Write(data[m.valueStart:m.valueEnd])
+		}
+		written++
+	}
+	for _, key := range keys {
+		val, ok := values[key]
+		if !ok || present[key] {
+			continue
+		}
+		if written > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(ws)
+		out.Write(name)
+		out.Write(colon)
+		out.Write(format(val))
+		written++
+	}
+
+	tail := data[prevEnd:]
+	if len(members) == 0 && written > 0 && bytes.IndexByte(ws, '\n') >= 0 {
+		out.WriteByte('\n')
+		tail = bytes.TrimLeft(tail, " \t\r\n")
+	}
+	out.Write(tail)
+	return out.Bytes(), nil
+}
+
+// sameJSON reports whether two JSON values differ only in formatting.
+func sameJSON(a, b []byte) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// writeFileAtomic writes data to dst via temp file + fsync + rename, with
+// 0600 permissions (matching copyFile).
+func writeFileAtomic(dst string, data []byte) error {
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(dst)+".tmp.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, dst)
+}
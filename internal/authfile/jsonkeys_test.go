@@ -0,0 +1,290 @@
+package authfile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeJSONFixture(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readJSONFixture(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	return out
+}
+
+func selectorFileSet(statePath string) AuthFileSet {
+	return AuthFileSet{
+		Tool: "claude",
+		Files: []AuthFileSpec{
+			{Tool: "claude", Path: statePath, JSONKeys: []string{"oauthAccount", "primaryApiKey"}},
+		},
+		AllowOptionalOnly: true,
+	}
+}
+
+func TestJSONKeys_BackupCapturesOnlySelectedKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount":           map[string]interface{}{"emailAddress": "work@example.com"},
+		"projects":               map[string]interface{}{"/src/app": map[string]interface{}{"history": []string{"a"}}},
+		"hasCompletedOnboarding": true,
+	})
+
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	if err := v.Backup(selectorFileSet(statePath), "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	stored := readJSONFixture(t, v.BackupPath("claude", "work", ".claude.json"))
+	if len(stored) != 1 {
+		t.Fatalf("vault copy has %d keys, want 1: %v", len(stored), stored)
+	}
+	if _, ok := stored["oauthAccount"]; !ok {
+		t.Error("vault copy missing oauthAccount")
+	}
+}
+
+func TestJSONKeys_RestorePatchesInPlace(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	fileSet := selectorFileSet(statePath)
+
+	// Back up the "work" account.
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount":  map[string]interface{}{"emailAddress": "work@example.com"},
+		"primaryApiKey": "sk-work",
+	})
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup(work) error = %v", err)
+	}
+
+	// User switches accounts and accumulates unrelated state.
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "personal@example.com"},
+		"projects":     map[string]interface{}{"/src/app": map[string]interface{}{"allowedTools": []string{"Bash"}}},
+		"mcpServers":   map[string]interface{}{"fs": map[string]interface{}{"command": "mcp-fs"}},
+	})
+
+	if err := v.Restore(fileSet, "work"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	live := readJSONFixture(t, statePath)
+	account, _ := live["oauthAccount"].(map[string]interface{})
+	if account["emailAddress"] != "work@example.com" {
+		t.Errorf("oauthAccount = %v, want work account", live["oauthAccount"])
+	}
+	if live["primaryApiKey"] != "sk-work" {
+		t.Errorf("primaryApiKey = %v, want sk-work", live["primaryApiKey"])
+	}
+	if _, ok := live["projects"]; !ok {
+		t.Error("restore dropped projects")
+	}
+	if _, ok := live["mcpServers"]; !ok {
+		t.Error("restore dropped mcpServers")
+	}
+
+	// Restoring a profile without primaryApiKey must remove the stale key.
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "personal@example.com"},
+		"projects":     map[string]interface{}{},
+	})
+	if err := v.Backup(fileSet, "personal"); err != nil {
+		t.Fatalf("Backup(personal) error = %v", err)
+	}
+	if err := v.Restore(fileSet, "work"); err != nil {
+		t.Fatalf("Restore(work) error = %v", err)
+	}
+	if err := v.Restore(fileSet, "personal"); err != nil {
+		t.Fatalf("Restore(personal) error = %v", err)
+	}
+	live = readJSONFixture(t, statePath)
+	if _, ok := live["primaryApiKey"]; ok {
+		t.Error("primaryApiKey leaked from previous profile")
+	}
+}
+
+func TestJSONKeys_ActiveProfileIgnoresUnselectedKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	fileSet := selectorFileSet(statePath)
+
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "work@example.com"},
+		"numStartups":  1,
+	})
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	// Unrelated keys change and the file is reformatted.
+	if err := os.WriteFile(statePath, []byte(`{"numStartups":42,"oauthAccount":{"emailAddress":"work@example.com"},"tipsHistory":{}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := v.ActiveProfile(fileSet)
+	if err != nil {
+		t.Fatalf("ActiveProfile() error = %v", err)
+	}
+	if active != "work" {
+		t.Errorf("ActiveProfile() = %q, want %q", active, "work")
+	}
+}
+
+func TestJSONKeys_LegacyFullFileBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	fileSet := selectorFileSet(statePath)
+
+	// Vault entries created before selectors hold the whole file.
+	writeJSONFixture(t, v.BackupPath("claude", "legacy", ".claude.json"), map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "legacy@example.com"},
+		"projects":     map[string]interface{}{"/old": map[string]interface{}{}},
+	})
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"projects": map[string]interface{}{"/new": map[string]interface{}{}},
+	})
+
+	if err := v.Restore(fileSet, "legacy"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	live := readJSONFixture(t, statePath)
+	projects, _ := live["projects"].(map[string]interface{})
+	if _, ok := projects["/new"]; !ok {
+		t.Errorf("projects = %v, want live projects preserved", projects)
+	}
+
+	active, err := v.ActiveProfile(fileSet)
+	if err != nil {
+		t.Fatalf("ActiveProfile() error = %v", err)
+	}
+	if active != "legacy" {
+		t.Errorf("ActiveProfile() = %q, want %q", active, "legacy")
+	}
+}
+
+func TestJSONKeys_ClearKeepsUserState(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, ".claude.json")
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "work@example.com"},
+		"projects":     map[string]interface{}{},
+	})
+	fileSet := selectorFileSet(statePath)
+
+	if err := ClearAuthFiles(fileSet); err != nil {
+		t.Fatalf("ClearAuthFiles() error = %v", err)
+	}
+
+	live := readJSONFixture(t, statePath)
+	if _, ok := live["oauthAccount"]; ok {
+		t.Error("oauthAccount should be removed")
+	}
+	if _, ok := live["projects"]; !ok {
+		t.Error("projects should be preserved")
+	}
+	if HasAuthFiles(fileSet) {
+		t.Error("HasAuthFiles() = true after clearing selected keys")
+	}
+	if _, err := SpecContent(fileSet.Files[0], statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SpecContent() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestJSONKeys_RestoreCopyWithoutKeysClears(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	writeJSONFixture(t, statePath, map[string]interface{}{
+		"oauthAccount": map[string]interface{}{"emailAddress": "work@example.com"},
+		"projects":     map[string]interface{}{},
+	})
+
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	writeJSONFixture(t, v.BackupPath("claude", "empty", ".claude.json"), map[string]interface{}{})
+
+	if err := v.Restore(selectorFileSet(statePath), "empty"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	live := readJSONFixture(t, statePath)
+	if _, ok := live["oauthAccount"]; ok {
+		t.Error("oauthAccount should be cleared")
+	}
+	if _, ok := live["projects"]; !ok {
+		t.Error("projects should be preserved")
+	}
+}
+
+func TestJSONKeys_RestoreKeepsUserBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "home", ".claude.json")
+	if err := os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Unsorted keys, HTML characters and a trailing newline must survive
+	live := "{\n" +
+		"  \"zeta\": \"<b>&co</b>\",\n" +
+		"  \"oauthAccount\": {\"emailAddress\": \"home@example.com\"},\n" +
+		"  \"alpha\": [1, 2]\n" +
+		"}\n"
+	if err := os.WriteFile(statePath, []byte(live), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+	writeJSONFixture(t, v.BackupPath("claude", "work", ".claude.json"), map[string]interface{}{
+		"oauthAccount":  map[string]interface{}{"emailAddress": "work@example.com"},
+		"primaryApiKey": "sk-work",
+	})
+	if err := v.Restore(selectorFileSet(statePath), "work"); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	want := "{\n" +
+		"  \"zeta\": \"<b>&co</b>\",\n" +
+		"  \"oauthAccount\": {\n    \"emailAddress\": \"work@example.com\"\n  },\n" +
+		"  \"alpha\": [1, 2],\n" +
+		"  \"primaryApiKey\": \"sk-work\"\n" +
+		"}\n"
+	if got, _ := os.ReadFile(statePath); string(got) != want {
+		t.Errorf("restored file =\n%s\nwant\n%s", got, want)
+	}
+
+	// Clearing removes the owned keys and nothing else
+	if err := clearSpec(selectorFileSet(statePath).Files[0]); err != nil {
+		t.Fatalf("clearSpec() error = %v", err)
+	}
+	want = "{\n" +
+		"  \"zeta\": \"<b>&co</b>\",\n" +
+		"  \"alpha\": [1, 2]\n" +
+		"}\n"
+	if got, _ := os.ReadFile(statePath); string(got) != want {
+		t.Errorf("cleared file =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+package authfile
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
+)
+
+// DefaultKeyTimeout is how long the key agent keeps an unlocked data key.
+const DefaultKeyTimeout = 15 * time.Minute
+
+// DefaultKeyAgentSocket returns the key agent socket path, next to the vault
+// in the caam data directory.
+func DefaultKeyAgentSocket() string {
+	return filepath.Join(filepath.Dir(DefaultVaultPath()), "vault-agent.sock")
+}
+
+// keyAgentRequest is one line of the key agent protocol.
+type keyAgentRequest struct {
+	Op    string `json:"op"` // get | put | lock | status
+	KeyID string `json:"key_id,omitempty"`
+	Key   string `json:"key,omitempty"` // base64, put only
+	TTL   int64  `json:"ttl_seconds,omitempty"`
+}
+
+// keyAgentResponse is the reply to a keyAgentRequest.
+type keyAgentResponse struct {
+	OK        bool   `json:"ok"`
+	Key       string `json:"key,omitempty"`
+	KeyID     string `json:"key_id,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// KeyAgent holds an unlocked vault data key in memory for a limited time and
+// serves it to other caam processes over a Unix socket (like ssh-agent).
+// The socket is created with 0600 permissions inside the 0700 data dir.
+type KeyAgent struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	key     []byte
+	keyID   string
+	expires time.Time
+}
+
+// NewKeyAgent creates a key agent that forgets keys after timeout.
+func NewKeyAgent(timeout time.Duration) *KeyAgent {
+	if timeout <= 0 {
+		timeout = DefaultKeyTimeout
+	}
+	return &KeyAgent{timeout: timeout}
+}
+
+// Put stores key, replacing any previous key. A ttl of 0 uses the agent timeout.
+func (a *KeyAgent) Put(key []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > a.timeout {
+		ttl = a.timeout
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.wipeLocked()
+	a.key = append([]byte(nil), key...)
+	a.keyID = KeyID(key)
+	a.expires = time.Now().Add(ttl)
+}
+
+// Key returns the cached key for keyID. It satisfies KeySource.
+func (a *KeyAgent) Key(keyID string) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.key == nil || time.Now().After(a.expires) {
+		a.wipeLocked()
+		return nil, ErrVaultLocked
+	}
+	if keyID != "" && keyID != a.keyID {
+		return nil, ErrVaultLocked
+	}
+	return append([]byte(nil), a.key...), nil
+}
+
+// Lock forgets the cached key immediately.
+func (a *KeyAgent) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.wipeLocked()
+}
+
+// ExpiresAt returns when the cached key expires (zero if locked).
+func (a *KeyAgent) ExpiresAt() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.key == nil || time.Now().After(a.expires) {
+		return time.Time{}
+	}
+	return a.expires
+}
+
+func (a *KeyAgent) wipeLocked() {
+	if a.key != nil {
+		seal.Wipe(a.key)
+	}
+	a.key = nil
+	a.keyID = ""
+	a.expires = time.Time{}
+}
+
+// Serve listens on socketPath until ctx is cancelled.
+func (a *KeyAgent) Serve(ctx context.Context, socketPath string) error {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return fmt.Errorf("create socket dir: %w", err)
+	}
+	// A stale socket from a crashed daemon would make Listen fail.
+	_ = os.Remove(socketPath)
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("chmod socket: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		a.Lock()
+	}()
+	defer os.Remove(socketPath)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go a.handle(conn)
+	}
+}
+
+func (a *KeyAgent) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req keyAgentRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+
+	var resp keyAgentResponse
+	switch req.Op {
+	case "get":
+		key, err := a.Key(req.KeyID)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		resp.OK = true
+		resp.Key = base64.StdEncoding.EncodeToString(key)
+		seal.Wipe(key)
+	case "put":
+		key, err := base64.StdEncoding.DecodeString(req.Key)
+		if err != nil || len(key) != seal.KeySize {
+			resp.Error = "invalid key"
+			break
+		}
+		a.Put(key, time.Duration(req.TTL)*time.Second)
+		seal.Wipe(key)
+		resp.OK = true
+	case "lock":
+		a.Lock()
+		resp.OK = true
+	case "status":
+		resp.OK = true
+	default:
+		resp.Error = fmt.Sprintf("unknown op %q", req.Op)
+	}
+
+	if exp := a.ExpiresAt(); !exp.IsZero() {
+		a.mu.Lock()
+		resp.KeyID = a.keyID
+		a.mu.Unlock()
+		resp.ExpiresAt = exp.Format(time.RFC3339)
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// KeyAgentStatus describes a running key agent.
+type KeyAgentStatus struct {
+	Unlocked  bool
+	KeyID     string
+	ExpiresAt time.Time
+}
+
+func callKeyAgent(socketPath string, req keyAgentRequest) (*keyAgentResponse, error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp keyAgentResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		if resp.Error == ErrVaultLocked.Error() {
+			return &resp, ErrVaultLocked
+		}
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// AgentKeySource returns a KeySource backed by the key agent at socketPath.
+func AgentKeySource(socketPath string) KeySource {
+	return func(keyID string) ([]byte, error) {
+		resp, err := callKeyAgent(socketPath, keyAgentRequest{Op: "get", KeyID: keyID})
+		if err != nil {
+			return nil, ErrVaultLocked
+		}
+		return base64.StdEncoding.DecodeString(resp.Key)
+	}
+}
+
+// SendKeyToAgent hands an unlocked data key to the key agent. A ttl of 0 uses
+// the agent's configured timeout.
+func SendKeyToAgent(socketPath string, key []byte, ttl time.Duration) (*KeyAgentStatus, error) {
+	resp, err := callKeyAgent(socketPath, keyAgentRequest{
+		Op:  "put",
+		Key: base64.StdEncoding.EncodeToString(key),
+		TTL: int64(ttl / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statusFromResponse(resp), nil
+}
+
+// LockKeyAgent tells the key agent to forget its key.
+func LockKeyAgent(socketPath string) error {
+	_, err := callKeyAgent(socketPath, keyAgentRequest{Op: "lock"})
+	return err
+}
+
+// QueryKeyAgent reports the key agent's state. It returns an error if no
+// agent is listening.
+func QueryKeyAgent(socketPath string) (*KeyAgentStatus, error) {
+	resp, err := callKeyAgent(socketPath, keyAgentRequest{Op: "status"})
+	if err != nil {
+		return nil, err
+	}
+	return statusFromResponse(resp), nil
+}
+
+func statusFromResponse(resp *keyAgentResponse) *KeyAgentStatus {
+	status := &KeyAgentStatus{KeyID: resp.KeyID}
+	if t, err := time.Parse(time.RFC3339, resp.ExpiresAt); err == nil {
+		status.Unlocked = true
+		status.ExpiresAt = t
+	}
+	return status
+}
@@ -0,0 +1,369 @@
+package authfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Revision history
+//
+// Each vault profile keeps a bounded history of the auth file sets it has
+// held, so a bad backup or refresh can be rolled back:
+//
+//	vault/<tool>/<profile>/.history/
+//	  objects/<sha256>   file content, stored as it was in the vault (sealed
+//	                     files stay sealed); named by the plaintext hash
+//	  revisions.json     revision list, oldest first
+//
+// File contents are deduplicated across revisions, and a revision identical
+// to the latest one is not recorded again.
+
+// HistoryDirName is the per-profile directory holding revision history.
+const HistoryDirName = ".history"
+
+// DefaultRevisionLimit is how many revisions each profile keeps.
+const DefaultRevisionLimit = 20
+
+const revisionsFileName = "revisions.json"
+
+// Revision causes.
+const (
+	RevisionBackup    = "backup"
+	RevisionRefresh   = "refresh"
+	RevisionSyncPull  = "sync_pull"
+	RevisionImport    = "import"
+	RevisionRollback  = "rollback"
+	RevisionUntracked = "untracked" // changed outside caam's tracked writes
+)
+
+// Revision is one recorded state of a vault profile.
+type Revision struct {
+	// ID identifies the file set: a short hash over file names and contents.
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Cause     string    `json:"cause"`
+
+	// Files maps auth file name to the SHA-256 of its plaintext content.
+	Files map[string]string `json:"files"`
+}
+
+// RevisionDiff lists how two revisions' file sets differ.
+type RevisionDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty reports whether the revisions hold identical files.
+func (d RevisionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffRevisions compares the file sets of two revisions. A nil from is
+// treated as an empty file set.
+func DiffRevisions(from, to *Revision) RevisionDiff {
+	var d RevisionDiff
+	var fromFiles map[string]string
+	if from != nil {
+		fromFiles = from.Files
+	}
+	for name, hash := range to.Files {
+		old, ok := fromFiles[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, name)
+		case old != hash:
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for name := range fromFiles {
+		if _, ok := to.Files[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// historyTracked returns a filter for the files of a tool's vault profiles
+// that belong in a revision (not metadata or temp files). Many auth files
+// are dotfiles, such as Claude's .credentials.json and Gemini's .env, but so
+// is profile metadata (.lock, a sync conflict copy's .conflict.json): a
+// dotfile is tracked only if it is one of the tool's auth files.
+func historyTracked(tool string) func(name string) bool {
+	authDotfiles := make(map[string]bool)
+	if fileSet, ok := GetAuthFileSet(tool); ok {
+		for _, spec := range fileSet.Files {
+			if name := filepath.Base(spec.Path); strings.HasPrefix(name, ".") {
+				authDotfiles[name] = true
+			}
+		}
+	}
+	return func(name string) bool {
+		if name == "meta.json" || strings.Contains(name, ".tmp") || strings.HasPrefix(name, ".caam_tmp_") {
+			return false
+		}
+		return !strings.HasPrefix(name, ".") || authDotfiles[name]
+	}
+}
+
+func (v *Vault) historyDir(tool, profile string) (string, error) {
+	dir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, HistoryDirName), nil
+}
+
+// Revisions returns the recorded revisions of a profile, newest first.
+func (v *Vault) Revisions(tool, profile string) ([]Revision, error) {
+	dir, err := v.historyDir(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	revs, err := readRevisions(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(revs)-1; i < j; i, j = i+1, j-1 {
+		revs[i], revs[j] = revs[j], revs[i]
+	}
+	return revs, nil
+}
+
+// GetRevision returns the revision whose ID starts with id. Ambiguous
+// prefixes are rejected.
+func (v *Vault) GetRevision(tool, profile, id string) (*Revision, error) {
+	revs, err := v.Revisions(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("revision id is empty")
+	}
+
+	var match *Revision
+	for i := range revs {
+		if !strings.HasPrefix(revs[i].ID, id) {
+			continue
+		}
+		// The same file set can recur; the newest entry wins.
+		if match != nil && match.ID != revs[i].ID {
+			return nil, fmt.Errorf("revision %q is ambiguous for %s/%s", id, tool, profile)
+		}
+		if match == nil {
+			match = &revs[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("revision %q not found for %s/%s; run 'caam history %s %s'", id, tool, profile, tool, profile)
+	}
+	return match, nil
+}
+
+// RecordRevision snapshots the profile's current auth files as a new
+// revision. It returns nil (and records nothing) if the profile has no auth
+// files or matches the latest revision.
+func (v *Vault) RecordRevision(tool, profile, cause string) (*Revision, error) {
+	profileDir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(profileDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	histDir := filepath.Join(profileDir, HistoryDirName)
+	tracked := historyTracked(tool)
+	rev := Revision{CreatedAt: time.Now().UTC(), Cause: cause, Files: make(map[string]string)}
+	raw := make(map[string][]byte)
+	for _, e := range entries {
+		if e.IsDir() || !tracked(e.Name()) {
+			continue
+		}
+		path := filepath.Join(profileDir, e.Name())
+		stored, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		plain, err := ReadVaultFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rev.Files[e.Name()] = hashBytes(plain)
+		raw[e.Name()] = stored
+	}
+	if len(rev.Files) == 0 {
+		return nil, nil
+	}
+	rev.ID = revisionID(rev.Files)
+
+	revs, err := readRevisions(histDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) > 0 && revs[len(revs)-1].ID == rev.ID {
+		return nil, nil
+	}
+
+	objDir := filepath.Join(histDir, "objects")
+	for name, hash := range rev.Files {
+		objPath := filepath.Join(objDir, hash)
+		if _, err := os.Stat(objPath); err == nil {
+			continue
+		}
+		if err := writeFileAtomic(objPath, raw[name]); err != nil {
+			return nil, fmt.Errorf("store revision object: %w", err)
+		}
+	}
+
+	revs = append(revs, rev)
+	if len(revs) > DefaultRevisionLimit {
+		revs = revs[len(revs)-DefaultRevisionLimit:]
+	}
+	if err := writeRevisions(histDir, revs); err != nil {
+		return nil, err
+	}
+	pruneRevisionObjects(objDir, revs)
+	return &rev, nil
+}
+
+// TrackRevision runs write, which modifies the profile's vault files, and
+// records the result as a revision with the given cause. If the profile
+// changed since its latest revision, that state is recorded first (as
+// RevisionUntracked) so it is never lost.
+//
+// History is best effort: failures to record never fail the write.
+func (v *Vault) TrackRevision(tool, profile, cause string, write func() error) error {
+	_, _ = v.RecordRevision(tool, profile, RevisionUntracked)
+	if err := write(); err != nil {
+		return err
+	}
+	_, _ = v.RecordRevision(tool, profile, cause)
+	return nil
+}
+
+// RestoreRevision rewrites the profile's vault files to match a revision.
+// Auth files not in the revision are removed; metadata (meta.json and
+// dotfiles that are not auth files) is kept. The
+// rollback itself is recorded as a new revision.
+func (v *Vault) RestoreRevision(tool, profile, id string) (*Revision, error) {
+	rev, err := v.GetRevision(tool, profile, id)
+	if err != nil {
+		return nil, err
+	}
+	profileDir, err := v.safeProfileDir(tool, profile)
+	if err != nil {
+		return nil, err
+	}
+	objDir := filepath.Join(profileDir, HistoryDirName, "objects")
+
+	// Load every object before touching the profile.
+	contents := make(map[string][]byte, len(rev.Files))
+	for name, hash := range rev.Files {
+		data, err := os.ReadFile(filepath.Join(objDir, hash))
+		if err != nil {
+			return nil, fmt.Errorf("read revision %s file %s: %w", rev.ID, name, err)
+		}
+		contents[name] = data
+	}
+
+	tracked := historyTracked(tool)
+	err = v.TrackRevision(tool, profile, RevisionRollback, func() error {
+		entries, err := os.ReadDir(profileDir)
+		if err != nil {
+			return err
+		}
+		for name, data := range contents {
+			if err := writeFileAtomic(filepath.Join(profileDir, name), data); err != nil {
+				return fmt.Errorf("restore %s: %w", name, err)
+			}
+		}
+		for _, e := range entries {
+			if e.IsDir() || !tracked(e.Name()) {
+				continue
+			}
+			if _, ok := contents[e.Name()]; !ok {
+				if err := os.Remove(filepath.Join(profileDir, e.Name())); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// revisionID hashes a file set into a short, stable identifier.
+func revisionID(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\n", name, files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+func readRevisions(histDir string) ([]Revision, error) {
+	data, err := os.ReadFile(filepath.Join(histDir, revisionsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var revs []Revision
+	if err := json.Unmarshal(data, &revs); err != nil {
+		return nil, fmt.Errorf("parse revision history: %w", err)
+	}
+	return revs, nil
+}
+
+func writeRevisions(histDir string, revs []Revision) error {
+	data, err := json.MarshalIndent(revs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(histDir, revisionsFileName), data)
+}
+
+// pruneRevisionObjects removes objects no longer referenced by any revision.
+func pruneRevisionObjects(objDir string, revs []Revision) {
+	keep := make(map[string]bool)
+	for _, rev := range revs {
+		for _, hash := range rev.Files {
+			keep[hash] = true
+		}
+	}
+	entries, err := os.ReadDir(objDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !keep[e.Name()] {
+			os.Remove(filepath.Join(objDir, e.Name()))
+		}
+	}
+}
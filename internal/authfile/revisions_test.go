@@ -0,0 +1,205 @@
+package authfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func revisionFixture(t *testing.T) (*Vault, AuthFileSet, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	authPath := filepath.Join(tmpDir, "home", "auth.json")
+	if err := os.MkdirAll(filepath.Dir(authPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	fileSet := AuthFileSet{
+		Tool:  "codex",
+		Files: []AuthFileSpec{{Tool: "codex", Path: authPath, Required: true}},
+	}
+	return NewVault(filepath.Join(tmpDir, "vault")), fileSet, authPath
+}
+
+func backupContent(t *testing.T, v *Vault, fileSet AuthFileSet, authPath, content string) {
+	t.Helper()
+	if err := os.WriteFile(authPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Backup(fileSet, "work"); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+}
+
+func TestRevisions_RecordedOnBackup(t *testing.T) {
+	v, fileSet, authPath := revisionFixture(t)
+
+	backupContent(t, v, fileSet, authPath, `{"token":"one"}`)
+	backupContent(t, v, fileSet, authPath, `{"token":"two"}`)
+	backupContent(t, v, fileSet, authPath, `{"token":"two"}`) // unchanged: no new revision
+
+	revs, err := v.Revisions("codex", "work")
+	if err != nil {
+		t.Fatalf("Revisions() error = %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("len(Revisions()) = %d, want 2", len(revs))
+	}
+	if revs[0].Cause != RevisionBackup || revs[1].Cause != RevisionBackup {
+		t.Errorf("causes = %q, %q; want backup", revs[0].Cause, revs[1].Cause)
+	}
+	if revs[0].ID == revs[1].ID {
+		t.Error("different contents should have different revision IDs")
+	}
+
+	diff := DiffRevisions(&revs[1], &revs[0])
+	if len(diff.Changed) != 1 || diff.Changed[0] != "auth.json" {
+		t.Errorf("DiffRevisions() = %+v, want auth.json changed", diff)
+	}
+	if _, ok := revs[0].Files["meta.json"]; ok {
+		t.Error("meta.json should not be part of a revision")
+	}
+}
+
+func TestRevisions_TracksDotfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	credPath := filepath.Join(tmpDir, "home", ".claude", ".credentials.json")
+	if err := os.MkdirAll(filepath.Dir(credPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	fileSet := AuthFileSet{
+		Tool:  "claude",
+		Files: []AuthFileSpec{{Tool: "claude", Path: credPath, Required: true}},
+	}
+	v := NewVault(filepath.Join(tmpDir, "vault"))
+
+	backupContent(t, v, fileSet, credPath, `{"claudeAiOauth":{"accessToken":"one"}}`)
+	backupContent(t, v, fileSet, credPath, `{"claudeAiOauth":{"accessToken":"two"}}`)
+
+	revs, err := v.Revisions("claude", "work")
+	if err != nil {
+		t.Fatalf("Revisions() error = %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("len(Revisions()) = %d, want 2", len(revs))
+	}
+	if _, ok := revs[0].Files[".credentials.json"]; !ok {
+		t.Errorf("revision files = %v, want .credentials.json", revs[0].Files)
+	}
+}
+
+func TestRevisions_TrackRecordsUntrackedState(t *testing.T) {
+	v, fileSet, authPath := revisionFixture(t)
+	backupContent(t, v, fileSet, authPath, `{"token":"one"}`)
+
+	// Something outside caam's tracked writes changes the vault copy.
+	path := v.BackupPath("codex", "work", "auth.json")
+	if err := os.WriteFile(path, []byte(`{"token":"manual"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := v.TrackRevision("codex", "work", RevisionRefresh, func() error {
+		return os.WriteFile(path, []byte(`{"token":"refreshed"}`), 0600)
+	})
+	if err != nil {
+		t.Fatalf("TrackRevision() error = %v", err)
+	}
+
+	revs, _ := v.Revisions("codex", "work")
+	var causes []string
+	for _, r := range revs {
+		causes = append(causes, r.Cause)
+	}
+	want := []string{RevisionRefresh, RevisionUntracked, RevisionBackup}
+	if len(causes) != len(want) {
+		t.Fatalf("causes = %v, want %v", causes, want)
+	}
+	for i := range want {
+		if causes[i] != want[i] {
+			t.Fatalf("causes = %v, want %v", causes, want)
+		}
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	v, fileSet, authPath := revisionFixture(t)
+	backupContent(t, v, fileSet, authPath, `{"token":"good"}`)
+	revs, _ := v.Revisions("codex", "work")
+	good := revs[0].ID
+
+	backupContent(t, v, fileSet, authPath, `{"token":"bad"}`)
+	extra := v.BackupPath("codex", "work", "extra.json")
+	if err := os.WriteFile(extra, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lock := v.BackupPath("codex", "work", ".lock")
+	if err := os.WriteFile(lock, []byte("123"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rev, err := v.RestoreRevision("codex", "work", good[:6])
+	if err != nil {
+		t.Fatalf("RestoreRevision() error = %v", err)
+	}
+	if rev.ID != good {
+		t.Errorf("restored revision = %s, want %s", rev.ID, good)
+	}
+
+	data, _ := os.ReadFile(v.BackupPath("codex", "work", "auth.json"))
+	if string(data) != `{"token":"good"}` {
+		t.Errorf("vault auth.json = %s", data)
+	}
+	if _, err := os.Stat(extra); !os.IsNotExist(err) {
+		t.Error("files not in the revision should be removed")
+	}
+	if _, err := os.Stat(v.BackupPath("codex", "work", "meta.json")); err != nil {
+		t.Error("meta.json should be kept")
+	}
+	if _, err := os.Stat(lock); err != nil {
+		t.Error("metadata dotfiles should be kept")
+	}
+
+	revs, _ = v.Revisions("codex", "work")
+	if revs[0].Cause != RevisionRollback || revs[0].ID != good {
+		t.Errorf("latest revision = %+v, want rollback to %s", revs[0], good)
+	}
+
+	if _, err := v.RestoreRevision("codex", "work", "zzzz"); err == nil {
+		t.Error("RestoreRevision() with unknown id should fail")
+	}
+}
+
+func TestRevisions_Bounded(t *testing.T) {
+	v, fileSet, authPath := revisionFixture(t)
+	for i := 0; i < DefaultRevisionLimit+5; i++ {
+		backupContent(t, v, fileSet, authPath, `{"token":"`+string(rune('a'+i))+`"}`)
+	}
+
+	revs, _ := v.Revisions("codex", "work")
+	if len(revs) != DefaultRevisionLimit {
+		t.Fatalf("len(Revisions()) = %d, want %d", len(revs), DefaultRevisionLimit)
+	}
+	objects, _ := os.ReadDir(filepath.Join(v.ProfilePath("codex", "work"), HistoryDirName, "objects"))
+	if len(objects) != DefaultRevisionLimit {
+		t.Errorf("objects = %d, want %d (unreferenced objects pruned)", len(objects), DefaultRevisionLimit)
+	}
+}
+
+func TestRevisions_SealedWithVault(t *testing.T) {
+	v, fileSet, authPath := setupEncryptedVault(t)
+	backupContent(t, v, fileSet, authPath, `{"token":"plain-history"}`)
+
+	if err := v.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	objects, _ := os.ReadDir(filepath.Join(v.ProfilePath("codex", "work"), HistoryDirName, "objects"))
+	if len(objects) == 0 {
+		t.Fatal("expected history objects")
+	}
+	for _, o := range objects {
+		data, _ := os.ReadFile(filepath.Join(v.ProfilePath("codex", "work"), HistoryDirName, "objects", o.Name()))
+		if !IsSealed(data) {
+			t.Errorf("history object %s not sealed after EnableEncryption", o.Name())
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"os"
@@ -111,15 +112,20 @@ func (t *Tracker) Capture(provider string) (*AuthState, error) {
 			return nil, fmt.Errorf("stat %s: %w", spec.Path, err)
 		}
 
-		if info.ModTime().After(latestMod) {
-			latestMod = info.ModTime()
-		}
-
-		content, err := os.ReadFile(spec.Path)
+		// Selector specs hash only their selected keys so unrelated edits
+		// (e.g., Claude project history in ~/.claude.json) are not changes.
+		content, err := authfile.SpecContent(spec, spec.Path)
 		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
 			return nil, fmt.Errorf("read %s: %w", spec.Path, err)
 		}
 
+		if info.ModTime().After(latestMod) {
+			latestMod = info.ModTime()
+		}
+
 		fileHash := sha256.Sum256(content)
 		hashStr := hex.EncodeToString(fileHash[:])
 		state.FileHashes[spec.Path] = hashStr
@@ -307,9 +313,9 @@ func (t *Tracker) getProfileHash(provider, profile string) (string, error) {
 		fileName := filepath.Base(spec.Path)
 		profileFilePath := filepath.Join(profilePath, fileName)
 
-		content, err := os.ReadFile(profileFilePath)
+		content, err := authfile.SpecContent(spec, profileFilePath)
 		if err != nil {
-			if os.IsNotExist(err) {
+			if errors.Is(err, os.ErrNotExist) {
 				if spec.Required {
 					missingRequired = append(missingRequired, profileFilePath)
 				}
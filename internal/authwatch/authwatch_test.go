@@ -739,7 +739,7 @@ func TestMatchesProfile_OptionalOnlyAllowed(t *testing.T) {
 		os.Setenv("XDG_CONFIG_HOME", oldXDG)
 	}()
 
-	authContent := []byte(`{"session":"opt"}`)
+	authContent := []byte(`{"oauthAccount":{"emailAddress":"opt@example.com"}}`)
 	if err := os.WriteFile(filepath.Join(tmpDir, ".claude.json"), authContent, 0600); err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,430 @@
+// Package budget tracks usage against configured budgets.
+//
+// A budget caps tokens, API-equivalent dollars or 'caam run' session minutes
+// over a calendar period (day, week or month) within a scope: a provider, a
+// profile, a profile tag, a project directory, or any combination of them.
+// Token usage comes from the CLI logs caam ingests and is attributed to
+// profiles as for 'caam cost tokens --by-profile'; it is attributed to a
+// project when it was written during a 'caam run' session started in that
+// project.
+package budget
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pricing"
+)
+
+// Metrics a budget can limit.
+const (
+	Tokens  = "tokens"
+	Dollars = "dollars"
+	Minutes = "minutes"
+)
+
+// DefaultWarnAt are the alert thresholds, in percent, of budgets that set
+// none.
+var DefaultWarnAt = []int{80, 100}
+
+// providers are the providers whose logs carry token usage.
+var providers = []string{"claude", "codex", "gemini"}
+
+// Session is a wrapped CLI session ('caam run').
+type Session struct {
+	Provider string
+	Profile  string
+	Dir      string
+	Start    time.Time
+	End      time.Time
+}
+
+// Usage is the recorded usage budgets are evaluated against.
+type Usage struct {
+	// Entries holds token usage by provider, oldest first, with Profile set
+	// where the entry could be attributed.
+	Entries map[string][]*logs.LogEntry
+
+	Sessions []Session
+
+	// Tags returns the tags of a profile (may be nil). It is called once per
+	// profile; the result is cached.
+	Tags func(provider, profile string) []string
+
+	tagCache map[[2]string][]string
+}
+
+// Target is what a command is about to use.
+type Target struct {
+	Provider string
+	Profile  string
+	Tags     []string
+	Dir      string // Working directory
+}
+
+// MetricStatus is the burn of one of a budget's limits.
+type MetricStatus struct {
+	Metric  string  `json:"metric"`
+	Used    float64 `json:"used"`
+	Limit   float64 `json:"limit"`
+	Percent float64 `json:"percent"`
+}
+
+// Status is a budget's burn in its current period.
+type Status struct {
+	Name        string         `json:"name"`
+	Scope       string         `json:"scope"`
+	Period      string         `json:"period"`
+	PeriodStart time.Time      `json:"period_start"`
+	ResetsAt    time.Time      `json:"resets_at"`
+	Metrics     []MetricStatus `json:"metrics"`
+	Percent     float64        `json:"percent"` // Highest percent of any limit
+	Exceeded    bool           `json:"exceeded"`
+	Enforced    bool           `json:"enforced"`
+
+	Budget config.BudgetConfig `json:"-"`
+}
+
+// PeriodBounds returns the calendar period containing now, in now's
+// location. Weeks start on Monday; an empty period means a month.
+func PeriodBounds(period string, now time.Time) (start, end time.Time) {
+	y, m, d := now.Date()
+	switch period {
+	case "day":
+		start = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 0, 1)
+	case "week":
+		offset := (int(now.Weekday()) + 6) % 7
+		start = time.Date(y, m, d-offset, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 0, 7)
+	default:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Load reads the usage the budgets need from the database: token usage
+// and sessions since the earliest current period start.
+func Load(db *caamdb.DB, budgets []config.BudgetConfig, now time.Time, tags func(provider, profile string) []string) (*Usage, error) {
+	u := &Usage{Entries: make(map[string][]*logs.LogEntry), Tags: tags}
+	if len(budgets) == 0 {
+		return u, nil
+	}
+
+	since := now
+	wanted := make(map[string]bool)
+	for _, b := range budgets {
+		if start, _ := PeriodBounds(b.Period, now); start.Before(since) {
+			since = start
+		}
+		for _, p := range providers {
+			if b.Provider == "" || strings.EqualFold(b.Provider, p) {
+				wanted[p] = true
+			}
+		}
+	}
+
+	for _, p := range providers {
+		if !wanted[p] {
+			continue
+		}
+		entries, err := db.TokenUsageEntries(p, since)
+		if err != nil {
+			return nil, err
+		}
+		u.Entries[p] = entries
+	}
+
+	wraps, err := db.GetWrapSessions("", since, 1<<30)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range wraps {
+		end := w.EndedAt
+		if end.IsZero() {
+			end = w.StartedAt.Add(time.Duration(w.DurationSeconds) * time.Second)
+		}
+		u.Sessions = append(u.Sessions, Session{
+			Provider: w.Provider,
+			Profile:  w.ProfileName,
+			Dir:      w.ProjectDir,
+			Start:    w.StartedAt,
+			End:      end,
+		})
+	}
+	sort.Slice(u.Sessions, func(i, j int) bool { return u.Sessions[i].Start.Before(u.Sessions[j].Start) })
+	return u, nil
+}
+
+// Evaluate computes the burn of each budget in its current period.
+func Evaluate(budgets []config.BudgetConfig, u *Usage, now time.Time) []Status {
+	out := make([]Status, 0, len(budgets))
+	for _, b := range budgets {
+		out = append(out, evaluate(b, u, now))
+	}
+	return out
+}
+
+func evaluate(b config.BudgetConfig, u *Usage, now time.Time) Status {
+	start, end := PeriodBounds(b.Period, now)
+	st := Status{
+		Name:        b.Name,
+		Scope:       Scope(b),
+		Period:      periodName(b.Period),
+		PeriodStart: start,
+		ResetsAt:    end,
+		Enforced:    b.Enforce,
+		Budget:      b,
+	}
+
+	var tokens int64
+	var dollars float64
+	prices := pricing.Default()
+	for provider, entries := range u.Entries {
+		usage := logs.NewTokenUsage()
+		for _, e := range entries {
+			if e.Timestamp.Before(start) || !e.Timestamp.Before(end) {
+				continue
+			}
+			t := Target{Provider: provider, Profile: e.Profile, Tags: u.tags(provider, e.Profile)}
+			if b.Project != "" {
+				s := u.sessionAt(provider, e.Timestamp)
+				if s == nil {
+					continue
+				}
+				t.Dir = s.Dir
+			}
+			if !Covers(b, t) {
+				continue
+			}
+			usage.Add(e)
+			dollars += prices.EntryCost(e, provider)
+		}
+		tokens += usage.TotalTokens
+	}
+
+	var minutes float64
+	for _, s := range u.Sessions {
+		if s.Start.Before(start) || !s.Start.Before(end) {
+			continue
+		}
+		if Covers(b, Target{Provider: s.Provider, Profile: s.Profile, Tags: u.tags(s.Provider, s.Profile), Dir: s.Dir}) {
+			minutes += s.End.Sub(s.Start).Minutes()
+		}
+	}
+
+	add := func(metric string, used, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		m := MetricStatus{Metric: metric, Used: used, Limit: limit, Percent: used / limit * 100}
+		st.Metrics = append(st.Metrics, m)
+		st.Percent = math.Max(st.Percent, m.Percent)
+	}
+	add(Tokens, float64(tokens), float64(b.Tokens))
+	add(Dollars, dollars, b.Dollars)
+	add(Minutes, minutes, b.Minutes)
+	st.Exceeded = st.Percent >= 100
+	return st
+}
+
+func (u *Usage) tags(provider, profile string) []string {
+	if u.Tags == nil || profile == "" {
+		return nil
+	}
+	key := [2]string{provider, profile}
+	if tags, ok := u.tagCache[key]; ok {
+		return tags
+	}
+	if u.tagCache == nil {
+		u.tagCache = make(map[[2]string][]string)
+	}
+	tags := u.Tags(provider, profile)
+	u.tagCache[key] = tags
+	return tags
+}
+
+// sessionAt returns the session of provider running at t, if any.
+func (u *Usage) sessionAt(provider string, t time.Time) *Session {
+	for i := range u.Sessions {
+		s := &u.Sessions[i]
+		if s.Provider == provider && !t.Before(s.Start) && !t.After(s.End) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Covers reports whether a budget's scope includes the target.
+func Covers(b config.BudgetConfig, t Target) bool {
+	if b.Provider != "" && !strings.EqualFold(b.Provider, t.Provider) {
+		return false
+	}
+	if b.Profile != "" && b.Profile != t.Profile {
+		return false
+	}
+	if b.Tag != "" {
+		found := false
+		for _, tag := range t.Tags {
+			if strings.EqualFold(tag, b.Tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if b.Project != "" && (t.Dir == "" || !dirWithin(expandHome(b.Project), t.Dir)) {
+		return false
+	}
+	return true
+}
+
+// Blocking returns the enforced budgets covering the target that are used
+// up.
+func Blocking(statuses []Status, t Target) []Status {
+	var out []Status
+	for _, s := range statuses {
+		if s.Enforced && s.Exceeded && Covers(s.Budget, t) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// AlertRecorder records which thresholds have been alerted on.
+// *db.DB implements it.
+type AlertRecorder interface {
+	RecordBudgetAlert(budget string, periodStart time.Time, threshold int) (bool, error)
+}
+
+// Alerts returns an alert for each budget that crossed a threshold not yet
+// alerted on in the current period, for the highest such threshold.
+func Alerts(statuses []Status, rec AlertRecorder) ([]*notify.Alert, error) {
+	var alerts []*notify.Alert
+	for _, s := range statuses {
+		thresholds := s.Budget.WarnAt
+		if len(thresholds) == 0 {
+			thresholds = DefaultWarnAt
+		}
+		crossed := 0
+		for _, th := range thresholds {
+			if s.Percent < float64(th) {
+				continue
+			}
+			fresh, err := rec.RecordBudgetAlert(s.Name, s.PeriodStart, th)
+			if err != nil {
+				return alerts, err
+			}
+			if fresh && th > crossed {
+				crossed = th
+			}
+		}
+		if crossed == 0 {
+			continue
+		}
+
+		alert := &notify.Alert{
+			Level:     notify.Warning,
+			Title:     fmt.Sprintf("Budget %s at %.0f%%", s.Name, s.Percent),
+			Message:   fmt.Sprintf("%s: %s this %s", s.Scope, s.Summary(), s.Period),
+			Provider:  s.Budget.Provider,
+			Profile:   s.Budget.Profile,
+			Timestamp: time.Now(),
+		}
+		if s.Exceeded {
+			alert.Level = notify.Critical
+			if s.Enforced {
+				alert.Action = "Further activate/run/exec in this scope need --override"
+			}
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// Scope describes a budget's scope, e.g. "claude, tag work".
+func Scope(b config.BudgetConfig) string {
+	var parts []string
+	if b.Provider != "" {
+		parts = append(parts, strings.ToLower(b.Provider))
+	}
+	if b.Profile != "" {
+		parts = append(parts, "profile "+b.Profile)
+	}
+	if b.Tag != "" {
+		parts = append(parts, "tag "+b.Tag)
+	}
+	if b.Project != "" {
+		parts = append(parts, "project "+b.Project)
+	}
+	if len(parts) == 0 {
+		return "all usage"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary describes the burn of each limit, e.g.
+// "1.2M of 5M tokens, $3.10 of $20.00".
+func (s Status) Summary() string {
+	parts := make([]string, 0, len(s.Metrics))
+	for _, m := range s.Metrics {
+		parts = append(parts, FormatMetric(m))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatMetric formats a limit's burn, e.g. "95 of 600 min".
+func FormatMetric(m MetricStatus) string {
+	switch m.Metric {
+	case Tokens:
+		return fmt.Sprintf("%s of %s tokens", formatCount(m.Used), formatCount(m.Limit))
+	case Dollars:
+		return fmt.Sprintf("$%.2f of $%.2f", m.Used, m.Limit)
+	default:
+		return fmt.Sprintf("%.0f of %.0f min", m.Used, m.Limit)
+	}
+}
+
+func formatCount(n float64) string {
+	switch {
+	case n >= 1e6:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", n/1e6), ".0") + "M"
+	case n >= 1e3:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", n/1e3), ".0") + "k"
+	default:
+		return fmt.Sprintf("%.0f", n)
+	}
+}
+
+func periodName(period string) string {
+	if period == "" {
+		return "month"
+	}
+	return period
+}
+
+// dirWithin reports whether dir is root or lies below it.
+func dirWithin(root, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
@@ -0,0 +1,209 @@
+package budget
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	now := time.Date(2026, 1, 15, 14, 30, 0, 0, time.UTC) // Thursday
+	tests := []struct {
+		period     string
+		start, end time.Time
+	}{
+		{"day", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		start, end := PeriodBounds(tt.period, now)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("PeriodBounds(%q) = %v, %v; want %v, %v", tt.period, start, end, tt.start, tt.end)
+		}
+	}
+
+	// Sunday belongs to the week that started on Monday.
+	sunday := time.Date(2026, 1, 18, 23, 0, 0, 0, time.UTC)
+	if start, _ := PeriodBounds("week", sunday); !start.Equal(time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("PeriodBounds(week, Sunday) start = %v", start)
+	}
+}
+
+func testUsage(now time.Time) *Usage {
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+	return &Usage{
+		Entries: map[string][]*logs.LogEntry{
+			"claude": {
+				{Timestamp: at(40 * 24 * time.Hour), Profile: "work", Model: "claude-3-opus", InputTokens: 9_000_000},
+				{Timestamp: at(3 * time.Hour), Profile: "work", Model: "claude-3-opus", InputTokens: 600_000},
+				{Timestamp: at(90 * time.Minute), Profile: "work", Model: "claude-3-opus", InputTokens: 400_000},
+				{Timestamp: at(50 * time.Minute), Profile: "personal", Model: "claude-3-opus", InputTokens: 250_000},
+			},
+			"codex": {
+				{Timestamp: at(time.Hour), Profile: "work", InputTokens: 50_000},
+			},
+		},
+		Sessions: []Session{
+			{Provider: "claude", Profile: "work", Dir: "/src/acme/api", Start: at(2 * time.Hour), End: at(time.Hour)},
+			{Provider: "claude", Profile: "personal", Dir: "/src/hobby", Start: at(70 * time.Minute), End: at(40 * time.Minute)},
+		},
+		Tags: func(provider, profile string) []string {
+			if profile == "work" {
+				return []string{"client"}
+			}
+			return nil
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 1, 15, 14, 0, 0, 0, time.UTC)
+	u := testUsage(now)
+	budgets := []config.BudgetConfig{
+		{Name: "all", Tokens: 2_000_000},
+		{Name: "claude-work", Provider: "claude", Profile: "work", Tokens: 1_000_000, Enforce: true},
+		{Name: "client", Tag: "client", Dollars: 100},
+		{Name: "acme", Project: "/src/acme", Tokens: 1_000_000, Minutes: 120},
+		{Name: "today", Period: "day", Minutes: 60},
+	}
+
+	got := Evaluate(budgets, u, now)
+	want := []struct {
+		tokens, dollars, minutes float64
+		exceeded                 bool
+	}{
+		{1_300_000, -1, -1, false},
+		{1_000_000, -1, -1, true},
+		{-1, 15, -1, false}, // 1M opus input tokens at $15 per million; codex usage has no model
+		{400_000, -1, 60, false},
+		{-1, -1, 90, true},
+	}
+	for i, w := range want {
+		s := got[i]
+		for _, m := range s.Metrics {
+			var expected float64
+			switch m.Metric {
+			case Tokens:
+				expected = w.tokens
+			case Dollars:
+				expected = w.dollars
+			case Minutes:
+				expected = w.minutes
+			}
+			if diff := m.Used - expected; diff > 0.01 || diff < -0.01 {
+				t.Errorf("%s %s used = %v, want %v", s.Name, m.Metric, m.Used, expected)
+			}
+		}
+		if s.Exceeded != w.exceeded {
+			t.Errorf("%s exceeded = %v (%.1f%%), want %v", s.Name, s.Exceeded, s.Percent, w.exceeded)
+		}
+	}
+
+	blocking := Blocking(got, Target{Provider: "claude", Profile: "work"})
+	if len(blocking) != 1 || blocking[0].Name != "claude-work" {
+		t.Errorf("Blocking(claude/work) = %v, want claude-work", blocking)
+	}
+	if blocking := Blocking(got, Target{Provider: "claude", Profile: "personal"}); len(blocking) != 0 {
+		t.Errorf("Blocking(claude/personal) = %v, want none", blocking)
+	}
+}
+
+func TestEvaluateLooksUpTagsOncePerProfile(t *testing.T) {
+	now := time.Date(2026, 1, 15, 14, 0, 0, 0, time.UTC)
+	u := testUsage(now)
+	tags := u.Tags
+	calls := make(map[string]int)
+	u.Tags = func(provider, profile string) []string {
+		calls[provider+"/"+profile]++
+		return tags(provider, profile)
+	}
+
+	Evaluate([]config.BudgetConfig{{Name: "client", Tag: "client", Tokens: 1}, {Name: "all", Minutes: 1}}, u, now)
+	for key, n := range calls {
+		if n != 1 {
+			t.Errorf("Tags(%s) called %d times, want once", key, n)
+		}
+	}
+	if calls["claude/work"] == 0 {
+		t.Errorf("Tags never called for claude/work: %v", calls)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	b := config.BudgetConfig{Provider: "claude", Tag: "client", Project: "/src/acme"}
+	tests := []struct {
+		target Target
+		want   bool
+	}{
+		{Target{Provider: "claude", Tags: []string{"Client"}, Dir: "/src/acme"}, true},
+		{Target{Provider: "claude", Tags: []string{"client"}, Dir: "/src/acme/web"}, true},
+		{Target{Provider: "claude", Tags: []string{"client"}, Dir: "/src/acme-old"}, false},
+		{Target{Provider: "claude", Tags: []string{"client"}}, false},
+		{Target{Provider: "codex", Tags: []string{"client"}, Dir: "/src/acme"}, false},
+		{Target{Provider: "claude", Dir: "/src/acme"}, false},
+	}
+	for _, tt := range tests {
+		if got := Covers(b, tt.target); got != tt.want {
+			t.Errorf("Covers(%+v) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+	if !Covers(config.BudgetConfig{}, Target{Provider: "gemini"}) {
+		t.Error("an unscoped budget should cover everything")
+	}
+}
+
+type memAlerts map[string]bool
+
+func (m memAlerts) RecordBudgetAlert(budget string, periodStart time.Time, threshold int) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%d", budget, periodStart, threshold)
+	if m[key] {
+		return false, nil
+	}
+	m[key] = true
+	return true, nil
+}
+
+func TestAlerts(t *testing.T) {
+	period := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := func(percent float64) []Status {
+		return []Status{{
+			Name:        "work",
+			Scope:       "claude",
+			Period:      "month",
+			PeriodStart: period,
+			Percent:     percent,
+			Exceeded:    percent >= 100,
+			Enforced:    true,
+			Metrics:     []MetricStatus{{Metric: Tokens, Used: percent * 1000, Limit: 100000, Percent: percent}},
+			Budget:      config.BudgetConfig{Name: "work", WarnAt: []int{50, 80}},
+		}}
+	}
+
+	rec := memAlerts{}
+	steps := []struct {
+		percent float64
+		want    int
+	}{
+		{40, 0},
+		{85, 1}, // Crosses 50 and 80 at once: one alert
+		{90, 0},
+		{110, 0}, // No threshold at 100 configured
+	}
+	for _, s := range steps {
+		alerts, err := Alerts(status(s.percent), rec)
+		if err != nil {
+			t.Fatalf("Alerts() error = %v", err)
+		}
+		if len(alerts) != s.want {
+			t.Errorf("Alerts(%.0f%%) = %d alerts, want %d", s.percent, len(alerts), s.want)
+		}
+		if len(alerts) == 1 && alerts[0].Title != "Budget work at 85%" {
+			t.Errorf("alert title = %q", alerts[0].Title)
+		}
+	}
+}
@@ -1,17 +1,13 @@
 package bundle
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 
-	"golang.org/x/crypto/argon2"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
 )
 
 // EncryptionMarkerFile is the name of the file that indicates a bundle is encrypted.
@@ -21,10 +17,10 @@ const EncryptionMarkerFile = ".caam_encrypted"
 const EncryptedPayloadFile = "payload.enc"
 
 // NonceSize is the size of the GCM nonce in bytes.
-const NonceSize = 12
+const NonceSize = seal.NonceSize
 
 // SaltSize is the size of the salt in bytes.
-const SaltSize = 32
+const SaltSize = seal.SaltSize
 
 // EncryptBundle encrypts data using AES-256-GCM with Argon2id key derivation.
 // Returns the encrypted data and metadata needed for decryption.
@@ -34,14 +30,14 @@ func EncryptBundle(plainData []byte, password string) ([]byte, *EncryptionMetada
 	}
 
 	// Generate salt
-	salt := make([]byte, SaltSize)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+	salt, err := seal.RandomBytes(SaltSize)
+	if err != nil {
 		return nil, nil, fmt.Errorf("generate salt: %w", err)
 	}
 
 	// Generate nonce
-	nonce := make([]byte, NonceSize)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+	nonce, err := seal.RandomBytes(NonceSize)
+	if err != nil {
 		return nil, nil, fmt.Errorf("generate nonce: %w", err)
 	}
 
@@ -57,30 +53,15 @@ func EncryptBundle(plainData []byte, password string) ([]byte, *EncryptionMetada
 	}
 
 	// Derive key using Argon2id
-	key := argon2.IDKey(
-		[]byte(password),
-		salt,
-		params.Time,
-		params.Memory,
-		params.Threads,
-		params.KeyLen,
-	)
-
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, nil, fmt.Errorf("create cipher: %w", err)
-	}
+	key := seal.DeriveKey([]byte(password), salt, params)
+	defer seal.Wipe(key)
 
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	// Encrypt data
+	ciphertext, err := seal.Encrypt(key, nonce, plainData, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("create GCM: %w", err)
+		return nil, nil, err
 	}
 
-	// Encrypt data
-	ciphertext := gcm.Seal(nil, nonce, plainData, nil)
-
 	return ciphertext, meta, nil
 }
 
@@ -110,38 +91,14 @@ func DecryptBundle(ciphertext []byte, meta *EncryptionMetadata, password string)
 		return nil, fmt.Errorf("decode nonce: %w", err)
 	}
 
-	// Get Argon2 parameters
-	params := meta.Argon2Params
-	if params == nil {
-		params = DefaultArgon2Params()
-	}
-
-	// Derive key using Argon2id
-	key := argon2.IDKey(
-		[]byte(password),
-		salt,
-		params.Time,
-		params.Memory,
-		params.Threads,
-		params.KeyLen,
-	)
-
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("create cipher: %w", err)
-	}
-
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("create GCM: %w", err)
-	}
+	// Derive key using Argon2id (nil params falls back to defaults)
+	key := seal.DeriveKey([]byte(password), salt, meta.Argon2Params)
+	defer seal.Wipe(key)
 
 	// Decrypt data
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := seal.Decrypt(key, nonce, ciphertext, nil)
 	if err != nil {
-		return nil, fmt.Errorf("decrypt: %w (wrong password?)", err)
+		return nil, fmt.Errorf("%w (wrong password?)", err)
 	}
 
 	return plaintext, nil
@@ -255,17 +212,11 @@ func SaveEncryptionMetadata(bundleDir string, meta *EncryptionMetadata) error {
 
 // GenerateRandomBytes generates cryptographically secure random bytes.
 func GenerateRandomBytes(n int) ([]byte, error) {
-	b := make([]byte, n)
-	if _, err := io.ReadFull(rand.Reader, b); err != nil {
-		return nil, fmt.Errorf("generate random bytes: %w", err)
-	}
-	return b, nil
+	return seal.RandomBytes(n)
 }
 
 // SecureWipe attempts to overwrite sensitive data in memory.
 // Note: This is best-effort; Go's GC may have copied the data elsewhere.
 func SecureWipe(data []byte) {
-	for i := range data {
-		data[i] = 0
-	}
+	seal.Wipe(data)
 }
@@ -2,6 +2,7 @@ package bundle
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 )
 
@@ -165,7 +167,11 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 			return nil, fmt.Errorf("create dir for %s: %w", f.RelPath, err)
 		}
 
-		if err := copyFileForExport(f.SrcPath, destPath); err != nil {
+		copyFile := copyFileForExport
+		if f.Vault {
+			copyFile = copyVaultFileForExport
+		}
+		if err := copyFile(f.SrcPath, destPath); err != nil {
 			return nil, fmt.Errorf("copy %s: %w", f.RelPath, err)
 		}
 
@@ -232,6 +238,8 @@ func (e *VaultExporter) Export(opts *ExportOptions) (*ExportResult, error) {
 type fileEntry struct {
 	SrcPath string
 	RelPath string
+	// Vault marks vault profile files, which are decrypted on export.
+	Vault bool
 }
 
 // collectFiles gathers all files to include in the bundle.
@@ -362,6 +370,10 @@ func (e *VaultExporter) collectProfileFiles(profilePath, provider, profile strin
 			return err
 		}
 		if d.IsDir() {
+			// Revision history is machine-local.
+			if d.Name() == authfile.HistoryDirName {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		if !d.Type().IsRegular() {
@@ -377,6 +389,7 @@ func (e *VaultExporter) collectProfileFiles(profilePath, provider, profile strin
 		files = append(files, fileEntry{
 			SrcPath: path,
 			RelPath: NormalizePath(bundlePath),
+			Vault:   true,
 		})
 		return nil
 	})
@@ -751,6 +764,22 @@ func copyFileForExport(src, dst string) error {
 	}
 	defer srcFile.Close()
 
+	return writeFileForExport(dst, srcFile)
+}
+
+// copyVaultFileForExport copies a vault file for export, decrypting it if
+// the vault is encrypted: the vault key does not travel with the bundle
+// (encrypt the bundle itself with --encrypt).
+func copyVaultFileForExport(src, dst string) error {
+	data, err := authfile.ReadVaultFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFileForExport(dst, bytes.NewReader(data))
+}
+
+// writeFileForExport writes r to dst atomically (with fsync for durability).
+func writeFileForExport(dst string, r io.Reader) error {
 	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
 		return err
 	}
@@ -761,7 +790,7 @@ func copyFileForExport(src, dst string) error {
 		return err
 	}
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	if _, err := io.Copy(dstFile, r); err != nil {
 		dstFile.Close()
 		os.Remove(tmpPath)
 		return err
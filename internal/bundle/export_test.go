@@ -3,10 +3,13 @@ package bundle
 import (
 	"archive/zip"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 func TestDefaultExportOptions(t *testing.T) {
@@ -955,3 +958,55 @@ func TestVaultExporter_Export_WithOptionalContent(t *testing.T) {
 		t.Error("sync/pool.json should be in bundle")
 	}
 }
+
+func TestVaultExporter_Export_DecryptsEncryptedVault(t *testing.T) {
+	authfile.ForgetKeys()
+	t.Cleanup(authfile.ForgetKeys)
+
+	tmpDir := t.TempDir()
+	vaultDir := filepath.Join(tmpDir, "vault")
+	profileDir := filepath.Join(vaultDir, "codex", "work")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(`{"token":"codex_token"}`)
+	if err := os.WriteFile(filepath.Join(profileDir, "auth.json"), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := authfile.NewVault(vaultDir).EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "output")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	exporter := &VaultExporter{VaultPath: vaultDir, DataPath: tmpDir}
+	result, err := exporter.Export(&ExportOptions{OutputDir: outputDir})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	reader, err := zip.OpenReader(result.OutputPath)
+	if err != nil {
+		t.Fatalf("Should be valid zip: %v", err)
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		if f.Name != "vault/codex/work/auth.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(got) != string(content) {
+			t.Errorf("exported auth.json = %q, want plaintext %q", got, content)
+		}
+		return
+	}
+	t.Error("codex auth should be in bundle")
+}
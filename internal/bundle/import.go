@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
 )
 
@@ -604,7 +605,11 @@ func (i *VaultImporter) importVault(bundleDir string, manifest *ManifestV1, opts
 			bundleProfilePath := filepath.Join(bundleDir, "vault", provider, profile)
 			localProfilePath := filepath.Join(opts.VaultPath, provider, profile)
 
-			if err := copyProfileDirectory(bundleProfilePath, localProfilePath); err != nil {
+			vault := authfile.NewVault(opts.VaultPath)
+			err := vault.TrackRevision(provider, profile, authfile.RevisionImport, func() error {
+				return copyProfileDirectory(bundleProfilePath, localProfilePath)
+			})
+			if err != nil {
 				result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", provider, profile, err))
 				continue
 			}
@@ -658,6 +663,9 @@ func copyProfileDirectory(src, dst string) error {
 		dstPath := filepath.Join(tmpDir, relPath)
 
 		if d.IsDir() {
+			if path != src && d.Name() == authfile.HistoryDirName {
+				return filepath.SkipDir
+			}
 			return os.MkdirAll(dstPath, 0700)
 		}
 
@@ -670,6 +678,16 @@ func copyProfileDirectory(src, dst string) error {
 	_, statErr := os.Stat(dst)
 	dstExists := statErr == nil
 
+	// Carry the local revision history over to the imported profile.
+	if dstExists {
+		history := filepath.Join(dst, authfile.HistoryDirName)
+		if _, err := os.Stat(history); err == nil {
+			if err := copyDirectory(history, filepath.Join(tmpDir, authfile.HistoryDirName)); err != nil {
+				return fmt.Errorf("copy revision history: %w", err)
+			}
+		}
+	}
+
 	// If destination exists, rename it to backup first
 	backupPath := dst + ".bak"
 	if dstExists {
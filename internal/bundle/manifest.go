@@ -12,6 +12,7 @@ import (
 	"encoding/base64"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/version"
 )
 
@@ -152,29 +153,12 @@ type EncryptionMetadata struct {
 }
 
 // Argon2Params contains Argon2id parameters for key derivation.
-type Argon2Params struct {
-	// Time is the number of iterations.
-	Time uint32 `json:"time"`
-
-	// Memory is the memory size in KiB.
-	Memory uint32 `json:"memory"`
-
-	// Threads is the parallelism factor.
-	Threads uint8 `json:"threads"`
-
-	// KeyLen is the derived key length in bytes.
-	KeyLen uint32 `json:"key_len"`
-}
+type Argon2Params = seal.Argon2Params
 
 // DefaultArgon2Params returns recommended Argon2id parameters.
 // These are balanced for security and performance on modern hardware.
 func DefaultArgon2Params() *Argon2Params {
-	return &Argon2Params{
-		Time:    3,         // 3 iterations
-		Memory:  64 * 1024, // 64 MiB
-		Threads: 4,         // 4 threads
-		KeyLen:  32,        // 256-bit key for AES-256
-	}
+	return seal.DefaultArgon2Params()
 }
 
 // NewManifest creates a new ManifestV1 with default values.
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -28,6 +29,7 @@ type SPMConfig struct {
 	RateLimits          RateLimitPatternsConfig      `yaml:"rate_limits"`
 	LoginPatterns       LoginPatternsConfig          `yaml:"login_patterns"`
 	Subscriptions       map[string]SubscriptionConfig `yaml:"subscriptions,omitempty"`
+	Budgets             []BudgetConfig               `yaml:"budgets,omitempty"`
 	Daemon              DaemonConfig                 `yaml:"daemon"`
 	TUI                 TUIConfig                    `yaml:"tui"`
 	CompactionReminder  CompactionReminderConfig     `yaml:"compaction_reminder"`
@@ -129,7 +131,7 @@ type CooldownConfig struct {
 // Varies which accounts are used and when to reduce predictable patterns.
 type RotationConfig struct {
 	Enabled   bool   `yaml:"enabled"`   // Master switch for rotation feature
-	Algorithm string `yaml:"algorithm"` // "smart" | "round_robin" | "random"
+	Algorithm string `yaml:"algorithm"` // "smart" | "round_robin" | "random" | "reset_aware"
 }
 
 // SafetyConfig contains data safety and recovery settings.
@@ -160,6 +162,147 @@ type NotificationConfig struct {
 	Terminal bool   `yaml:"terminal"` // Show alerts in terminal
 	Desktop  bool   `yaml:"desktop"`  // Show desktop notifications (if available)
 	Webhook  string `yaml:"webhook"`  // Optional webhook URL for alerts
+
+	// WebhookSecret signs requests to Webhook with HMAC-SHA256.
+	WebhookSecret string `yaml:"webhook_secret,omitempty"`
+
+	// Channels are named delivery targets. Terminal, Desktop and Webhook
+	// above are available to routes as the channels "terminal", "desktop"
+	// and "webhook" when enabled.
+	Channels map[string]ChannelConfig `yaml:"channels,omitempty"`
+
+	// Routes choose the channels for each alert. Without routes every
+	// alert goes to every channel.
+	Routes []NotificationRoute `yaml:"routes,omitempty"`
+
+	Dedup      Duration `yaml:"dedup,omitempty"`       // Default window in which each alert is sent once
+	QuietHours string   `yaml:"quiet_hours,omitempty"` // Default "HH:MM-HH:MM" when only critical alerts are sent
+}
+
+// ChannelConfig is a named notification channel.
+type ChannelConfig struct {
+	Type     string            `yaml:"type"`               // ntfy, slack, email, exec, webhook, terminal or desktop
+	URL      string            `yaml:"url,omitempty"`      // ntfy topic, Slack incoming webhook or webhook URL
+	Headers  map[string]string `yaml:"headers,omitempty"`  // Extra HTTP headers, e.g. ntfy access tokens
+	SMTP     string            `yaml:"smtp,omitempty"`     // Email: SMTP server as host:port
+	Username string            `yaml:"username,omitempty"` // Email: SMTP login, if required
+	Password string            `yaml:"password,omitempty"` // Email: SMTP password
+	From     string            `yaml:"from,omitempty"`     // Email: sender address
+	To       []string          `yaml:"to,omitempty"`       // Email: recipients
+	Command  []string          `yaml:"command,omitempty"`  // Exec: program and arguments
+	Template string            `yaml:"template,omitempty"` // Go text/template for the message text
+	Secret   string            `yaml:"secret,omitempty"`   // Webhook: HMAC-SHA256 signing key
+}
+
+// NotificationRoute sends alerts matching all of its non-empty conditions
+// to channels. Dedup and QuietHours default to the NotificationConfig ones.
+type NotificationRoute struct {
+	Name       string   `yaml:"name,omitempty"`
+	Levels     []string `yaml:"levels,omitempty"` // info, warning, critical
+	Providers  []string `yaml:"providers,omitempty"`
+	Profiles   []string `yaml:"profiles,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"` // Profile tags ('caam tag')
+	Channels   []string `yaml:"channels"`
+	Dedup      Duration `yaml:"dedup,omitempty"`
+	QuietHours string   `yaml:"quiet_hours,omitempty"` // "off" disables the default quiet hours
+}
+
+// ParseQuietHours parses a "HH:MM-HH:MM" range into offsets from midnight.
+// The range may wrap midnight, e.g. "22:00-07:00". Selection policy rules
+// use it for their hours too.
+func ParseQuietHours(s string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("hours %q: want HH:MM-HH:MM", s)
+	}
+	parse := func(v string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("hours %q: want HH:MM-HH:MM", s)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	if start, err = parse(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// validate checks notification channels and routes.
+func (n NotificationConfig) validate() error {
+	if n.Dedup.Duration() < 0 {
+		return fmt.Errorf("alerts.notifications.dedup cannot be negative")
+	}
+	if n.QuietHours != "" {
+		if _, _, err := ParseQuietHours(n.QuietHours); err != nil {
+			return fmt.Errorf("alerts.notifications.quiet_hours: %w", err)
+		}
+	}
+
+	for name, ch := range n.Channels {
+		prefix := "alerts.notifications.channels." + name
+		switch ch.Type {
+		case "ntfy", "slack", "webhook":
+			if ch.URL == "" {
+				return fmt.Errorf("%s: %s channels need a url", prefix, ch.Type)
+			}
+		case "email":
+			if ch.SMTP == "" || ch.From == "" || len(ch.To) == 0 {
+				return fmt.Errorf("%s: email channels need smtp, from and to", prefix)
+			}
+		case "exec":
+			if len(ch.Command) == 0 {
+				return fmt.Errorf("%s: exec channels need a command", prefix)
+			}
+		case "terminal", "desktop":
+		default:
+			return fmt.Errorf("%s.type must be one of: ntfy, slack, email, exec, webhook, terminal, desktop", prefix)
+		}
+		if ch.Secret != "" && ch.Type != "webhook" {
+			return fmt.Errorf("%s.secret only applies to webhook channels", prefix)
+		}
+		if ch.Template != "" {
+			if _, err := template.New(name).Parse(ch.Template); err != nil {
+				return fmt.Errorf("%s.template: %w", prefix, err)
+			}
+		}
+	}
+
+	for i, r := range n.Routes {
+		prefix := fmt.Sprintf("alerts.notifications.routes[%d]", i)
+		if len(r.Channels) == 0 {
+			return fmt.Errorf("%s needs at least one channel", prefix)
+		}
+		for _, ch := range r.Channels {
+			if _, ok := n.Channels[ch]; ok {
+				continue
+			}
+			switch ch {
+			case "terminal", "desktop", "webhook":
+			default:
+				return fmt.Errorf("%s: unknown channel %q", prefix, ch)
+			}
+		}
+		for _, level := range r.Levels {
+			switch strings.ToLower(level) {
+			case "info", "warning", "critical":
+			default:
+				return fmt.Errorf("%s.levels must be info, warning or critical", prefix)
+			}
+		}
+		if r.Dedup.Duration() < 0 {
+			return fmt.Errorf("%s.dedup cannot be negative", prefix)
+		}
+		if r.QuietHours != "" && r.QuietHours != "off" {
+			if _, _, err := ParseQuietHours(r.QuietHours); err != nil {
+				return fmt.Errorf("%s.quiet_hours: %w", prefix, err)
+			}
+		}
+	}
+	return nil
 }
 
 // HandoffConfig controls smart session handoff behavior.
@@ -196,12 +339,36 @@ type SubscriptionConfig struct {
 	MonthlyCost float64 `yaml:"monthly_cost"` // Monthly cost in USD
 }
 
+// BudgetConfig limits usage within a scope over a calendar period. Scope
+// fields that are set must all match; a budget without any covers all usage.
+// At least one of Tokens, Dollars and Minutes must be set.
+type BudgetConfig struct {
+	Name string `yaml:"name"`
+
+	// Scope
+	Provider string `yaml:"provider,omitempty"`
+	Profile  string `yaml:"profile,omitempty"`
+	Tag      string `yaml:"tag,omitempty"`
+	Project  string `yaml:"project,omitempty"` // Directory; ~ is expanded, subdirectories included
+
+	Period  string  `yaml:"period,omitempty"`  // "day", "week" or "month" (default)
+	Tokens  int64   `yaml:"tokens,omitempty"`  // Tokens from CLI logs
+	Dollars float64 `yaml:"dollars,omitempty"` // API-equivalent cost of those tokens
+	Minutes float64 `yaml:"minutes,omitempty"` // Time in 'caam run' sessions
+
+	WarnAt  []int `yaml:"warn_at,omitempty"` // Alert thresholds in percent (default 80 and 100)
+	Enforce bool  `yaml:"enforce,omitempty"` // Refuse activate/run/exec once exceeded
+}
+
 // DaemonConfig holds daemon-specific settings.
 type DaemonConfig struct {
 	AuthPool         AuthPoolConfig `yaml:"auth_pool"`
 	CheckInterval    Duration       `yaml:"check_interval"`
 	RefreshThreshold Duration       `yaml:"refresh_threshold"`
 	Verbose          bool           `yaml:"verbose"`
+	// VaultKeyTimeout is how long the daemon keeps an unlocked encrypted-vault
+	// key before requiring 'caam vault unlock' again.
+	VaultKeyTimeout  Duration       `yaml:"vault_key_timeout"`
 }
 
 // AuthPoolConfig holds auth pool settings.
@@ -427,6 +594,7 @@ func DefaultSPMConfig() *SPMConfig {
 			CheckInterval:    Duration(5 * time.Minute),
 			RefreshThreshold: Duration(30 * time.Minute),
 			Verbose:          false,
+			VaultKeyTimeout:  Duration(15 * time.Minute),
 		},
 		TUI: TUIConfig{
 			Theme:         "auto",
@@ -587,9 +755,9 @@ func (c *SPMConfig) Validate() error {
 	if c.Stealth.Cooldown.DefaultMinutes < 0 {
 		return fmt.Errorf("stealth.cooldown.default_minutes cannot be negative")
 	}
-	validAlgorithms := map[string]bool{"smart": true, "round_robin": true, "random": true}
+	validAlgorithms := map[string]bool{"smart": true, "round_robin": true, "random": true, "reset_aware": true}
 	if c.Stealth.Rotation.Algorithm != "" && !validAlgorithms[c.Stealth.Rotation.Algorithm] {
-		return fmt.Errorf("stealth.rotation.algorithm must be one of: smart, round_robin, random")
+		return fmt.Errorf("stealth.rotation.algorithm must be one of: smart, round_robin, random, reset_aware")
 	}
 
 	// Safety validation
@@ -627,6 +795,9 @@ func (c *SPMConfig) Validate() error {
 	if c.Daemon.RefreshThreshold.Duration() < 0 {
 		return fmt.Errorf("daemon.refresh_threshold cannot be negative")
 	}
+	if c.Daemon.VaultKeyTimeout.Duration() < 0 {
+		return fmt.Errorf("daemon.vault_key_timeout cannot be negative")
+	}
 	if c.Daemon.AuthPool.MaxConcurrentRefresh < 0 {
 		return fmt.Errorf("daemon.auth_pool.max_concurrent_refresh cannot be negative")
 	}
@@ -644,6 +815,38 @@ func (c *SPMConfig) Validate() error {
 		}
 	}
 
+	if err := c.Alerts.Notifications.validate(); err != nil {
+		return err
+	}
+
+	// Budget validation
+	budgetNames := make(map[string]bool)
+	for i, b := range c.Budgets {
+		if strings.TrimSpace(b.Name) == "" {
+			return fmt.Errorf("budgets[%d].name is required", i)
+		}
+		if budgetNames[b.Name] {
+			return fmt.Errorf("budgets[%d]: duplicate name %q", i, b.Name)
+		}
+		budgetNames[b.Name] = true
+		switch b.Period {
+		case "", "day", "week", "month":
+		default:
+			return fmt.Errorf("budgets.%s.period must be one of: day, week, month", b.Name)
+		}
+		if b.Tokens < 0 || b.Dollars < 0 || b.Minutes < 0 {
+			return fmt.Errorf("budgets.%s limits cannot be negative", b.Name)
+		}
+		if b.Tokens == 0 && b.Dollars == 0 && b.Minutes == 0 {
+			return fmt.Errorf("budgets.%s needs a tokens, dollars or minutes limit", b.Name)
+		}
+		for _, pct := range b.WarnAt {
+			if pct <= 0 || pct > 100 {
+				return fmt.Errorf("budgets.%s.warn_at must be between 1 and 100", b.Name)
+			}
+		}
+	}
+
 	// Pattern validation
 	if err := validatePatterns("rate_limits.claude", c.RateLimits.Claude); err != nil {
 		return err
@@ -741,6 +944,9 @@ func (c *SPMConfig) ApplyEnvOverrides() {
 	if v := os.Getenv("CAAM_ALERTS_NOTIFICATIONS_WEBHOOK"); v != "" {
 		c.Alerts.Notifications.Webhook = v
 	}
+	if v := os.Getenv("CAAM_ALERTS_NOTIFICATIONS_WEBHOOK_SECRET"); v != "" {
+		c.Alerts.Notifications.WebhookSecret = v
+	}
 
 	// Handoff
 	if v := os.Getenv("CAAM_HANDOFF_AUTO_TRIGGER"); v != "" {
@@ -780,6 +986,11 @@ func (c *SPMConfig) ApplyEnvOverrides() {
 			c.Daemon.Verbose = b
 		}
 	}
+	if v := os.Getenv("CAAM_DAEMON_VAULT_KEY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.Daemon.VaultKeyTimeout = Duration(d)
+		}
+	}
 	
 	// Health
 	if v := os.Getenv("CAAM_HEALTH_REFRESH_THRESHOLD"); v != "" {
@@ -492,7 +492,7 @@ stealth:
   rotation:
     algorithm: invalid_algo
 `,
-			wantErr: "stealth.rotation.algorithm must be one of: smart, round_robin, random",
+			wantErr: "stealth.rotation.algorithm must be one of: smart, round_robin, random, reset_aware",
 		},
 		{
 			name: "invalid backup mode",
@@ -1338,6 +1338,74 @@ subscriptions:
 `,
 			wantErr: "monthly_cost cannot be negative",
 		},
+		{
+			name: "notification route to unknown channel",
+			yaml: `
+version: 1
+alerts:
+  notifications:
+    channels:
+      phone:
+        type: ntfy
+        url: https://ntfy.sh/caam
+    routes:
+      - levels: [critical]
+        channels: [phone, pager]
+`,
+			wantErr: `alerts.notifications.routes[0]: unknown channel "pager"`,
+		},
+		{
+			name: "email channel without recipients",
+			yaml: `
+version: 1
+alerts:
+  notifications:
+    channels:
+      mail:
+        type: email
+        smtp: localhost:25
+        from: caam@example.com
+`,
+			wantErr: "email channels need smtp, from and to",
+		},
+		{
+			name: "bad quiet hours",
+			yaml: `
+version: 1
+alerts:
+  notifications:
+    quiet_hours: "10pm-7am"
+`,
+			wantErr: "alerts.notifications.quiet_hours",
+		},
+		{
+			name: "bad channel template",
+			yaml: `
+version: 1
+alerts:
+  notifications:
+    channels:
+      ops:
+        type: slack
+        url: https://hooks.slack.com/x
+        template: "{{.Title"
+`,
+			wantErr: "alerts.notifications.channels.ops.template",
+		},
+		{
+			name: "secret on non-webhook channel",
+			yaml: `
+version: 1
+alerts:
+  notifications:
+    channels:
+      ops:
+        type: slack
+        url: https://hooks.slack.com/x
+        secret: s3cret
+`,
+			wantErr: "alerts.notifications.channels.ops.secret",
+		},
 	}
 
 	for _, tc := range tests {
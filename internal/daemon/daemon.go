@@ -15,8 +15,13 @@ import (
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authpool"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/budget"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/notify"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
 )
 
@@ -47,6 +52,17 @@ type Config struct {
 	// MaxConcurrentRefreshes limits concurrent refresh operations when using AuthPool.
 	// Default: 3
 	MaxConcurrentRefreshes int
+
+	// VaultKeyTimeout is how long the key agent keeps an unlocked vault key.
+	// Only used when the vault is encrypted. Default: 15m
+	VaultKeyTimeout time.Duration
+
+	// KeyAgentSocket overrides the key agent socket path (empty for default).
+	KeyAgentSocket string
+
+	// IngestLogs imports new token usage from CLI logs into caam's database
+	// on every check, keeping cost and burn-rate queries cheap.
+	IngestLogs bool
 }
 
 // DefaultConfig returns the default daemon configuration.
@@ -55,6 +71,7 @@ func DefaultConfig() *Config {
 		CheckInterval:    DefaultCheckInterval,
 		RefreshThreshold: DefaultRefreshThreshold,
 		Verbose:          false,
+		IngestLogs:       true,
 	}
 }
 
@@ -76,6 +93,10 @@ type Daemon struct {
 	// poolMonitor runs the background token monitoring (may be nil if not enabled)
 	poolMonitor *authpool.Monitor
 
+	// keyAgent caches the vault data key for other caam processes
+	// (nil unless the vault is encrypted)
+	keyAgent *authfile.KeyAgent
+
 	ctx           context.Context
 	cancel        context.CancelFunc
 	configChanged chan struct{} // Signal to reload config in runLoop
@@ -142,6 +163,9 @@ func New(vault *authfile.Vault, healthStore *health.Storage, cfg *Config) *Daemo
 	if cfg.RefreshThreshold <= 0 {
 		cfg.RefreshThreshold = DefaultRefreshThreshold
 	}
+	if cfg.VaultKeyTimeout <= 0 {
+		cfg.VaultKeyTimeout = authfile.DefaultKeyTimeout
+	}
 
 	logger := log.New(os.Stdout, "[caam-daemon] ", log.LstdFlags)
 	var logFile *os.File
@@ -260,6 +284,11 @@ func (d *Daemon) Start() error {
 	d.logger.Printf("Starting daemon (check interval: %v, refresh threshold: %v)",
 		d.config.CheckInterval, d.config.RefreshThreshold)
 
+	// Serve the vault key to other caam processes if the vault is encrypted.
+	if d.vault != nil && d.vault.IsEncrypted() {
+		d.startKeyAgent()
+	}
+
 	// Start pool monitor if enabled
 	if d.poolMonitor != nil {
 		// Load profiles from vault into the pool
@@ -302,6 +331,26 @@ func (d *Daemon) Start() error {
 	}
 }
 
+// startKeyAgent runs the vault key agent for the daemon's lifetime. The
+// daemon itself reads sealed profiles through the same agent.
+func (d *Daemon) startKeyAgent() {
+	socketPath := d.config.KeyAgentSocket
+	if socketPath == "" {
+		socketPath = authfile.DefaultKeyAgentSocket()
+	}
+	d.keyAgent = authfile.NewKeyAgent(d.config.VaultKeyTimeout)
+	authfile.SetKeySource(d.keyAgent.Key)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.keyAgent.Serve(d.ctx, socketPath); err != nil {
+			d.logger.Printf("Warning: vault key agent stopped: %v", err)
+		}
+	}()
+	d.logger.Printf("Vault key agent listening on %s (key timeout: %v)", socketPath, d.config.VaultKeyTimeout)
+}
+
 // ReloadConfig reloads the configuration from disk.
 func (d *Daemon) ReloadConfig() {
 	// Load global config
@@ -454,10 +503,14 @@ func (d *Daemon) runLoop() {
 	}
 
 	// Do an initial check immediately
+	d.recoverJournals()
 	if !shouldUsePoolRefresh() {
 		d.checkAndRefresh()
 	}
 	d.checkAndBackup()
+	d.ingestLogs()
+	d.checkBudgets()
+	d.drainWebhooks()
 
 	interval := d.getCheckInterval()
 	if interval <= 0 {
@@ -482,11 +535,15 @@ func (d *Daemon) runLoop() {
 				d.logger.Printf("Updated check interval to %v", interval)
 			}
 		case <-ticker.C:
+			d.recoverJournals()
 			// Check each iteration in case pool monitor state changed
 			if !shouldUsePoolRefresh() {
 				d.checkAndRefresh()
 			}
 			d.checkAndBackup()
+			d.ingestLogs()
+			d.checkBudgets()
+			d.drainWebhooks()
 		}
 	}
 }
@@ -590,6 +647,142 @@ func (d *Daemon) checkAndBackup() {
 	}
 }
 
+// ingestLogs imports token usage appended to CLI logs since the last check.
+func (d *Daemon) ingestLogs() {
+	d.configMu.RLock()
+	enabled := d.config.IngestLogs
+	d.configMu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		d.logger.Printf("Warning: open database for log ingest: %v", err)
+		return
+	}
+	defer db.Close()
+
+	sources := logs.DefaultSources()
+	// Isolated profiles ('caam exec') keep their logs in the profile directory.
+	if all, err := profile.NewStore(profile.DefaultStorePath()).ListAll(); err == nil {
+		for provider, profiles := range all {
+			for _, prof := range profiles {
+				if src, ok := logs.IsolatedSource(provider, prof.Name, prof.HomePath(), prof.CodexHomePath()); ok {
+					sources = append(sources, src)
+				}
+			}
+		}
+	}
+
+	for _, src := range sources {
+		result, err := logs.IngestSource(d.ctx, src, db)
+		label := src.Provider
+		if src.Profile != "" {
+			label += "/" + src.Profile
+		}
+		if err != nil {
+			if d.ctx.Err() == nil {
+				d.logger.Printf("Warning: ingest %s logs: %v", label, err)
+			}
+			continue
+		}
+		if d.isVerbose() && result.Lines > 0 {
+			d.logger.Printf("Ingested %d %s log lines (%d usage entries)", result.Lines, label, result.Entries)
+		}
+	}
+}
+
+// checkBudgets alerts on budget thresholds crossed since the last check.
+func (d *Daemon) checkBudgets() {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil || len(spmCfg.Budgets) == 0 || !spmCfg.Alerts.Enabled {
+		return
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		d.logger.Printf("Warning: open database for budgets: %v", err)
+		return
+	}
+	defer db.Close()
+
+	store := profile.NewStore(profile.DefaultStorePath())
+	tags := func(provider, name string) []string {
+		if prof, err := store.Load(provider, name); err == nil {
+			return prof.Tags
+		}
+		return nil
+	}
+
+	now := time.Now()
+	usage, err := budget.Load(db, spmCfg.Budgets, now, tags)
+	if err != nil {
+		d.logger.Printf("Warning: load budget usage: %v", err)
+		return
+	}
+	alerts, err := budget.Alerts(budget.Evaluate(spmCfg.Budgets, usage, now), db)
+	if err != nil {
+		d.logger.Printf("Warning: record budget alerts: %v", err)
+	}
+
+	notifier := notify.FromConfig(spmCfg.Alerts.Notifications, nil,
+		notify.WithDeduper(db), notify.WithOutbox(db), notify.WithTags(tags))
+	for _, a := range alerts {
+		d.logger.Printf("%s: %s", a.Title, a.Message)
+		if err := notifier.Notify(a); err != nil {
+			d.logger.Printf("Warning: budget alert: %v", err)
+		}
+	}
+}
+
+// drainWebhooks retries webhook deliveries queued in the outbox that are
+// due, and forgets ones delivered over a week ago.
+func (d *Daemon) drainWebhooks() {
+	spmCfg, err := config.LoadSPMConfig()
+	if err != nil {
+		return
+	}
+
+	db, err := caamdb.Open()
+	if err != nil {
+		d.logger.Printf("Warning: open database for webhook outbox: %v", err)
+		return
+	}
+	defer db.Close()
+
+	now := time.Now()
+	res, err := notify.DrainOutbox(db, notify.Channels(spmCfg.Alerts.Notifications, nil), now)
+	if err != nil {
+		d.logger.Printf("Warning: drain webhook outbox: %v", err)
+	}
+	if res.Delivered > 0 || res.Failed > 0 {
+		d.logger.Printf("Webhook outbox: %d delivered, %d retrying, %d failed", res.Delivered, res.Retrying, res.Failed)
+	}
+	if _, err := db.PruneWebhooks(now.AddDate(0, 0, -7)); err != nil {
+		d.logger.Printf("Warning: prune webhook outbox: %v", err)
+	}
+}
+
+// recoverJournals finishes activations torn by a crashed caam process.
+func (d *Daemon) recoverJournals() {
+	if d.vault == nil {
+		return
+	}
+	results, err := d.vault.RecoverJournals()
+	if err != nil {
+		d.logger.Printf("Warning: check activation journal: %v", err)
+		return
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			d.logger.Printf("Warning: repair interrupted activation of %s/%s: %v", r.Journal.Tool, r.Journal.Profile, r.Err)
+			continue
+		}
+		d.logger.Printf("Interrupted activation of %s/%s: %s", r.Journal.Tool, r.Journal.Profile, r.Action)
+	}
+}
+
 // checkAndRefresh checks all profiles and refreshes those that need it.
 func (d *Daemon) checkAndRefresh() {
 	d.mu.Lock()
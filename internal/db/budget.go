@@ -0,0 +1,38 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecordBudgetAlert records that a budget crossed a threshold (in percent)
+// during the period starting at periodStart. It returns false if that was
+// already recorded, so each threshold is alerted on once per period.
+func (d *DB) RecordBudgetAlert(budget string, periodStart time.Time, threshold int) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+
+	budget = strings.TrimSpace(budget)
+	if budget == "" {
+		return false, fmt.Errorf("budget is required")
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT OR IGNORE INTO budget_alerts (budget, period_start, threshold, alerted_at)
+		 VALUES (?, ?, ?, ?)`,
+		budget,
+		formatSQLiteTime(periodStart),
+		threshold,
+		formatSQLiteTime(time.Now()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert budget_alerts: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("budget_alerts rows affected: %w", err)
+	}
+	return n > 0, nil
+}
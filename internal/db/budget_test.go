@@ -0,0 +1,49 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordBudgetAlert(t *testing.T) {
+	db, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	period := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		budget    string
+		period    time.Time
+		threshold int
+		want      bool
+	}{
+		{"work", period, 80, true},
+		{"work", period, 80, false},
+		{"work", period, 100, true},
+		{"work", period.AddDate(0, 1, 0), 80, true},
+		{"personal", period, 80, true},
+	}
+	for _, s := range steps {
+		got, err := db.RecordBudgetAlert(s.budget, s.period, s.threshold)
+		if err != nil {
+			t.Fatalf("RecordBudgetAlert(%s, %v, %d) error = %v", s.budget, s.period, s.threshold, err)
+		}
+		if got != s.want {
+			t.Errorf("RecordBudgetAlert(%s, %v, %d) = %v, want %v", s.budget, s.period, s.threshold, got, s.want)
+		}
+	}
+
+	if err := db.RecordWrapSession(WrapSession{Provider: "claude", ProfileName: "work", ProjectDir: "/src/app"}); err != nil {
+		t.Fatalf("RecordWrapSession() error = %v", err)
+	}
+	sessions, err := db.GetWrapSessions("claude", time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("GetWrapSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ProjectDir != "/src/app" {
+		t.Errorf("GetWrapSessions() = %+v, want one session in /src/app", sessions)
+	}
+}
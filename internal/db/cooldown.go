@@ -207,6 +207,63 @@ func (d *DB) ListActiveCooldowns(now time.Time) ([]CooldownEvent, error) {
 	return out, nil
 }
 
+// ListLimitEvents returns recorded limit hits for a provider since the given
+// time, oldest first.
+func (d *DB) ListLimitEvents(provider string, since time.Time) ([]CooldownEvent, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT id, provider, profile_name, hit_at, cooldown_until, notes
+		   FROM limit_events
+		  WHERE provider = ? AND datetime(hit_at) >= datetime(?)
+		  ORDER BY datetime(hit_at) ASC, id ASC`,
+		provider,
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query limit_events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CooldownEvent
+	for rows.Next() {
+		var (
+			ev               CooldownEvent
+			hitAtStr         string
+			cooldownUntilStr string
+			notes            sql.NullString
+		)
+		if err := rows.Scan(&ev.ID, &ev.Provider, &ev.ProfileName, &hitAtStr, &cooldownUntilStr, &notes); err != nil {
+			return nil, fmt.Errorf("scan limit_events: %w", err)
+		}
+		hitAt, err := parseSQLiteTime(hitAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse hit_at %q: %w", hitAtStr, err)
+		}
+		cooldownUntil, err := parseSQLiteTime(cooldownUntilStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse cooldown_until %q: %w", cooldownUntilStr, err)
+		}
+		ev.HitAt = hitAt
+		ev.CooldownUntil = cooldownUntil
+		if notes.Valid {
+			ev.Notes = notes.String
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate limit_events: %w", err)
+	}
+	return out, nil
+}
+
 // ClearCooldown deletes cooldown history for a specific provider/profile.
 func (d *DB) ClearCooldown(provider, profile string) (int64, error) {
 	if d == nil || d.conn == nil {
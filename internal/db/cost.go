@@ -19,6 +19,7 @@ type WrapSession struct {
 	RateLimitHit      bool
 	EstimatedCostCents int
 	Notes             string
+	ProjectDir        string // Working directory the session ran in
 }
 
 // CostRate represents the cost rate configuration for a provider.
@@ -87,8 +88,8 @@ func (d *DB) RecordWrapSession(session WrapSession) error {
 	}
 
 	_, err := d.conn.Exec(
-		`INSERT INTO wrap_sessions (provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO wrap_sessions (provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes, project_dir)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		provider,
 		profile,
 		formatSQLiteTime(startedAt),
@@ -98,6 +99,7 @@ func (d *DB) RecordWrapSession(session WrapSession) error {
 		rateLimitHit,
 		estimatedCost,
 		session.Notes,
+		nullableString(session.ProjectDir),
 	)
 	if err != nil {
 		return fmt.Errorf("insert wrap_sessions: %w", err)
@@ -221,7 +223,7 @@ func (d *DB) GetWrapSessions(provider string, since time.Time, limit int) ([]Wra
 
 	if provider != "" {
 		rows, err = d.conn.Query(
-			`SELECT id, provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes
+			`SELECT id, provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes, project_dir
 			 FROM wrap_sessions
 			 WHERE provider = ? AND datetime(started_at) >= datetime(?)
 			 ORDER BY started_at DESC
@@ -230,7 +232,7 @@ func (d *DB) GetWrapSessions(provider string, since time.Time, limit int) ([]Wra
 		)
 	} else {
 		rows, err = d.conn.Query(
-			`SELECT id, provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes
+			`SELECT id, provider, profile_name, started_at, ended_at, duration_seconds, exit_code, rate_limit_hit, estimated_cost_cents, notes, project_dir
 			 FROM wrap_sessions
 			 WHERE datetime(started_at) >= datetime(?)
 			 ORDER BY started_at DESC
@@ -249,10 +251,10 @@ func (d *DB) GetWrapSessions(provider string, since time.Time, limit int) ([]Wra
 		var s WrapSession
 		var startedAtStr, endedAtStr string
 		var rateLimitHit int
-		var notes sql.NullString
+		var notes, projectDir sql.NullString
 
 		if err := rows.Scan(&s.ID, &s.Provider, &s.ProfileName, &startedAtStr, &endedAtStr,
-			&s.DurationSeconds, &s.ExitCode, &rateLimitHit, &s.EstimatedCostCents, &notes); err != nil {
+			&s.DurationSeconds, &s.ExitCode, &rateLimitHit, &s.EstimatedCostCents, &notes, &projectDir); err != nil {
 			return nil, fmt.Errorf("scan wrap_sessions: %w", err)
 		}
 
@@ -266,6 +268,7 @@ func (d *DB) GetWrapSessions(provider string, since time.Time, limit int) ([]Wra
 		if notes.Valid {
 			s.Notes = notes.String
 		}
+		s.ProjectDir = projectDir.String
 
 		sessions = append(sessions, s)
 	}
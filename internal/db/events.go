@@ -187,6 +187,56 @@ func (d *DB) GetEvents(provider, profile string, since time.Time, limit int) ([]
 	return out, nil
 }
 
+// ListProviderEvents returns all events for a provider since the given time,
+// oldest first.
+func (d *DB) ListProviderEvents(provider string, since time.Time) ([]Event, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return nil, fmt.Errorf("provider is required")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT timestamp, event_type, provider, profile_name, duration_seconds
+		 FROM activity_log
+		 WHERE provider = ? AND datetime(timestamp) >= datetime(?)
+		 ORDER BY datetime(timestamp) ASC, id ASC`,
+		provider,
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query activity_log: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Event
+	for rows.Next() {
+		var tsStr string
+		var e Event
+		var durationSeconds sql.NullInt64
+		if err := rows.Scan(&tsStr, &e.Type, &e.Provider, &e.ProfileName, &durationSeconds); err != nil {
+			return nil, fmt.Errorf("scan activity_log: %w", err)
+		}
+
+		ts, err := parseSQLiteTime(tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse timestamp %q: %w", tsStr, err)
+		}
+		e.Timestamp = ts
+		if durationSeconds.Valid && durationSeconds.Int64 > 0 {
+			e.Duration = time.Duration(durationSeconds.Int64) * time.Second
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate activity_log: %w", err)
+	}
+	return out, nil
+}
+
 // ListRecentEvents returns recent events across all profiles.
 // Unlike GetEvents, provider and profile are optional filters.
 // If empty, all events are returned.
@@ -352,6 +402,38 @@ func (d *DB) LastActivation(provider, profile string) (time.Time, error) {
 	return ts, nil
 }
 
+// CountActivations returns how many times a provider/profile was activated
+// since the given time.
+func (d *DB) CountActivations(provider, profile string, since time.Time) (int, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	profile = strings.TrimSpace(profile)
+	if provider == "" {
+		return 0, fmt.Errorf("provider is required")
+	}
+	if profile == "" {
+		return 0, fmt.Errorf("profile name is required")
+	}
+
+	var count int
+	err := d.conn.QueryRow(
+		`SELECT COUNT(*)
+		 FROM activity_log
+		 WHERE provider = ? AND profile_name = ? AND event_type = ? AND datetime(timestamp) >= datetime(?)`,
+		provider,
+		profile,
+		EventActivate,
+		formatSQLiteTime(since),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count activations: %w", err)
+	}
+	return count, nil
+}
+
 func updateProfileStats(tx *sql.Tx, eventType, provider, profile, ts string, durationSeconds int64) error {
 	switch eventType {
 	case EventActivate:
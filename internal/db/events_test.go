@@ -221,3 +221,39 @@ func TestDB_ProfileStats_LastErrorIsMonotonic(t *testing.T) {
 		t.Fatalf("LastError = %s, want %s", stats.LastError.Format(time.RFC3339Nano), newer.Format(time.RFC3339Nano))
 	}
 }
+
+func TestDB_CountActivations(t *testing.T) {
+	d, err := OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	now := time.Now()
+	for _, ev := range []Event{
+		{Type: EventActivate, Provider: "claude", ProfileName: "work", Timestamp: now.Add(-2 * time.Hour)},
+		{Type: EventActivate, Provider: "claude", ProfileName: "work", Timestamp: now.Add(-30 * time.Hour)},
+		{Type: EventError, Provider: "claude", ProfileName: "work", Timestamp: now.Add(-time.Hour)},
+		{Type: EventActivate, Provider: "claude", ProfileName: "personal", Timestamp: now.Add(-time.Hour)},
+	} {
+		if err := d.LogEvent(ev); err != nil {
+			t.Fatalf("LogEvent() error = %v", err)
+		}
+	}
+
+	got, err := d.CountActivations("claude", "work", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("CountActivations() error = %v", err)
+	}
+	if got != 1 {
+		t.Errorf("CountActivations(24h) = %d, want 1", got)
+	}
+
+	got, err = d.CountActivations("claude", "work", now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("CountActivations() error = %v", err)
+	}
+	if got != 2 {
+		t.Errorf("CountActivations(7d) = %d, want 2", got)
+	}
+}
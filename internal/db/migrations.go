@@ -94,6 +94,103 @@ VALUES
     ('claude', 5, 0, CURRENT_TIMESTAMP),
     ('codex', 3, 0, CURRENT_TIMESTAMP),
     ('gemini', 2, 0, CURRENT_TIMESTAMP);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "token_usage",
+		Up: `
+-- Token usage ingested from provider CLI logs
+CREATE TABLE IF NOT EXISTS token_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    conversation_id TEXT,
+    message_id TEXT,
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_create_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    source_path TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_usage_provider_timestamp ON token_usage(provider, timestamp);
+CREATE INDEX IF NOT EXISTS idx_token_usage_source ON token_usage(provider, source_path);
+
+-- How far each log file has been ingested
+CREATE TABLE IF NOT EXISTS log_scan_checkpoints (
+    provider TEXT NOT NULL,
+    path TEXT NOT NULL,
+    byte_offset INTEGER NOT NULL DEFAULT 0,
+    size INTEGER NOT NULL DEFAULT 0,
+    mod_time_ns INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, path)
+);
+`,
+	},
+	{
+		Version: 5,
+		Name:    "token_usage_profiles",
+		Up: `
+-- Profile of entries read from an isolated profile's logs; NULL when the
+-- profile is derived from activations instead
+ALTER TABLE token_usage ADD COLUMN profile_name TEXT;
+
+-- Finding the activation in effect at a given time
+CREATE INDEX IF NOT EXISTS idx_activity_provider_type_timestamp ON activity_log(provider, event_type, timestamp);
+`,
+	},
+	{
+		Version: 6,
+		Name:    "budgets",
+		Up: `
+-- Working directory of wrapped sessions, for project budgets
+ALTER TABLE wrap_sessions ADD COLUMN project_dir TEXT;
+
+-- Budget thresholds already alerted on, once per budget period
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    budget TEXT NOT NULL,
+    period_start DATETIME NOT NULL,
+    threshold INTEGER NOT NULL,
+    alerted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (budget, period_start, threshold)
+);
+`,
+	},
+	{
+		Version: 7,
+		Name:    "notification_dedup",
+		Up: `
+-- Last delivery of each alert key, for notification dedup windows
+CREATE TABLE IF NOT EXISTS notification_dedup (
+    alert_key TEXT PRIMARY KEY,
+    sent_at DATETIME NOT NULL
+);
+`,
+	},
+	{
+		Version: 8,
+		Name:    "webhook_outbox",
+		Up: `
+-- Webhook deliveries, kept until delivered so failures can be retried
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    delivery_id TEXT NOT NULL UNIQUE,
+    channel TEXT NOT NULL,
+    url TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    next_attempt_at DATETIME NOT NULL,
+    delivered_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_outbox_status_next ON webhook_outbox(status, next_attempt_at);
 `,
 	},
 }
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AlertSent reports whether an alert with the given key was sent within
+// window before now.
+func (d *DB) AlertSent(key string, window time.Duration, now time.Time) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return false, fmt.Errorf("alert key is required")
+	}
+
+	var n int
+	err := d.conn.QueryRow(
+		`SELECT COUNT(*) FROM notification_dedup
+		 WHERE alert_key = ? AND datetime(sent_at) > datetime(?)`,
+		key,
+		formatSQLiteTime(now.Add(-window)),
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("query notification_dedup: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ClaimAlert reports whether an alert with the given key may be sent at
+// now: it may unless one was sent within window before now. A successful
+// claim records now as the key's last delivery.
+func (d *DB) ClaimAlert(key string, window time.Duration, now time.Time) (bool, error) {
+	if d == nil || d.conn == nil {
+		return false, fmt.Errorf("db is not open")
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return false, fmt.Errorf("alert key is required")
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO notification_dedup (alert_key, sent_at) VALUES (?, ?)
+		 ON CONFLICT(alert_key) DO UPDATE SET sent_at = excluded.sent_at
+		 WHERE datetime(notification_dedup.sent_at) <= datetime(?)`,
+		key,
+		formatSQLiteTime(now),
+		formatSQLiteTime(now.Add(-window)),
+	)
+	if err != nil {
+		return false, fmt.Errorf("upsert notification_dedup: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("notification_dedup rows affected: %w", err)
+	}
+	return n > 0, nil
+}
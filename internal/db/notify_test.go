@@ -0,0 +1,67 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClaimAlert(t *testing.T) {
+	db, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		key  string
+		at   time.Duration
+		want bool
+	}{
+		{"near-limit/work", 0, true},
+		{"near-limit/work", 10 * time.Minute, false},
+		{"near-limit/personal", 10 * time.Minute, true},
+		{"near-limit/work", 59 * time.Minute, false},
+		{"near-limit/work", time.Hour, true},
+		{"near-limit/work", 90 * time.Minute, false}, // The window restarts at the last delivery
+	}
+	for _, s := range steps {
+		got, err := db.ClaimAlert(s.key, time.Hour, start.Add(s.at))
+		if err != nil {
+			t.Fatalf("ClaimAlert(%s, +%v) error = %v", s.key, s.at, err)
+		}
+		if got != s.want {
+			t.Errorf("ClaimAlert(%s, +%v) = %v, want %v", s.key, s.at, got, s.want)
+		}
+	}
+
+	if _, err := db.ClaimAlert(" ", time.Hour, start); err == nil {
+		t.Error("ClaimAlert() with empty key should fail")
+	}
+}
+
+func TestAlertSent(t *testing.T) {
+	db, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if sent, err := db.AlertSent("near-limit/work", time.Hour, start); err != nil || sent {
+		t.Fatalf("AlertSent() before any delivery = %v, %v; want false", sent, err)
+	}
+	if sent, _ := db.AlertSent("near-limit/work", time.Hour, start); sent {
+		t.Fatal("AlertSent() should not record a delivery")
+	}
+	if _, err := db.ClaimAlert("near-limit/work", time.Hour, start); err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := db.AlertSent("near-limit/work", time.Hour, start.Add(59*time.Minute)); err != nil || !sent {
+		t.Errorf("AlertSent() within the window = %v, %v; want true", sent, err)
+	}
+	if sent, err := db.AlertSent("near-limit/work", time.Hour, start.Add(time.Hour)); err != nil || sent {
+		t.Errorf("AlertSent() after the window = %v, %v; want false", sent, err)
+	}
+}
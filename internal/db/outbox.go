@@ -0,0 +1,240 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook outbox statuses.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed" // Gave up; replay with RequeueWebhooks
+)
+
+// WebhookDelivery is a webhook payload in the outbox.
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	DeliveryID    string     `json:"delivery_id"`
+	Channel       string     `json:"channel"`
+	URL           string     `json:"url"`
+	Payload       string     `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// EnqueueWebhook stores a webhook payload for delivery, due immediately.
+func (d *DB) EnqueueWebhook(channel, url, deliveryID string, payload []byte, now time.Time) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	deliveryID = strings.TrimSpace(deliveryID)
+	if deliveryID == "" {
+		return 0, fmt.Errorf("delivery id is required")
+	}
+
+	res, err := d.conn.Exec(
+		`INSERT INTO webhook_outbox (delivery_id, channel, url, payload, status, created_at, next_attempt_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		deliveryID,
+		channel,
+		url,
+		string(payload),
+		WebhookPending,
+		formatSQLiteTime(now),
+		formatSQLiteTime(now),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert webhook_outbox: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("webhook_outbox last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// MarkWebhookDelivered records a successful delivery attempt.
+func (d *DB) MarkWebhookDelivered(id int64, now time.Time) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	_, err := d.conn.Exec(
+		`UPDATE webhook_outbox
+		    SET status = ?, attempts = attempts + 1, last_error = NULL, delivered_at = ?
+		  WHERE id = ?`,
+		WebhookDelivered,
+		formatSQLiteTime(now),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook_outbox: %w", err)
+	}
+	return nil
+}
+
+// MarkWebhookFailed records a failed delivery attempt. The delivery is
+// retried at next, or given up on if giveUp is set.
+func (d *DB) MarkWebhookFailed(id int64, errMsg string, next time.Time, giveUp bool) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	status := WebhookPending
+	if giveUp {
+		status = WebhookFailed
+	}
+	_, err := d.conn.Exec(
+		`UPDATE webhook_outbox
+		    SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		  WHERE id = ?`,
+		status,
+		nullableString(errMsg),
+		formatSQLiteTime(next),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook_outbox: %w", err)
+	}
+	return nil
+}
+
+// DueWebhooks returns pending deliveries due at now, oldest first.
+func (d *DB) DueWebhooks(now time.Time, limit int) ([]WebhookDelivery, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	return d.queryWebhooks(
+		`WHERE status = ? AND datetime(next_attempt_at) <= datetime(?)
+		 ORDER BY datetime(next_attempt_at) ASC, id ASC
+		 LIMIT ?`,
+		WebhookPending, formatSQLiteTime(now), limit,
+	)
+}
+
+// ListWebhooks returns deliveries with the given status, or all deliveries
+// if status is empty, newest first.
+func (d *DB) ListWebhooks(status string, limit int) ([]WebhookDelivery, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	status = strings.TrimSpace(status)
+	if status == "" {
+		return d.queryWebhooks(`ORDER BY id DESC LIMIT ?`, limit)
+	}
+	return d.queryWebhooks(`WHERE status = ? ORDER BY id DESC LIMIT ?`, status, limit)
+}
+
+func (d *DB) queryWebhooks(where string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := d.conn.Query(
+		`SELECT id, delivery_id, channel, url, payload, status, attempts, last_error,
+		        created_at, next_attempt_at, delivered_at
+		   FROM webhook_outbox `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query webhook_outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []WebhookDelivery
+	for rows.Next() {
+		var (
+			w                   WebhookDelivery
+			lastError           sql.NullString
+			createdStr, nextStr string
+			deliveredStr        sql.NullString
+		)
+		if err := rows.Scan(&w.ID, &w.DeliveryID, &w.Channel, &w.URL, &w.Payload, &w.Status, &w.Attempts, &lastError,
+			&createdStr, &nextStr, &deliveredStr); err != nil {
+			return nil, fmt.Errorf("scan webhook_outbox: %w", err)
+		}
+		if w.CreatedAt, err = parseSQLiteTime(createdStr); err != nil {
+			return nil, fmt.Errorf("parse created_at %q: %w", createdStr, err)
+		}
+		if w.NextAttemptAt, err = parseSQLiteTime(nextStr); err != nil {
+			return nil, fmt.Errorf("parse next_attempt_at %q: %w", nextStr, err)
+		}
+		if deliveredStr.Valid {
+			ts, err := parseSQLiteTime(deliveredStr.String)
+			if err != nil {
+				return nil, fmt.Errorf("parse delivered_at %q: %w", deliveredStr.String, err)
+			}
+			w.DeliveredAt = &ts
+		}
+		w.LastError = lastError.String
+		out = append(out, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook_outbox: %w", err)
+	}
+	return out, nil
+}
+
+// RequeueWebhooks makes failed deliveries due again at now: those with the
+// given ids, or all failed ones if ids is empty. Pending deliveries among
+// ids are made due now too. It returns the number of deliveries requeued.
+func (d *DB) RequeueWebhooks(ids []int64, now time.Time) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	query := `UPDATE webhook_outbox SET status = ?, next_attempt_at = ? WHERE status = ?`
+	args := []any{WebhookPending, formatSQLiteTime(now), WebhookFailed}
+	if len(ids) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+		query = `UPDATE webhook_outbox SET status = ?, next_attempt_at = ?
+		          WHERE status IN (?, ?) AND id IN (` + placeholders + `)`
+		args = append(args, WebhookPending)
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	res, err := d.conn.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("requeue webhook_outbox: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("webhook_outbox rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// PruneWebhooks deletes deliveries delivered before the given time.
+func (d *DB) PruneWebhooks(before time.Time) (int64, error) {
+	if d == nil || d.conn == nil {
+		return 0, fmt.Errorf("db is not open")
+	}
+
+	res, err := d.conn.Exec(
+		`DELETE FROM webhook_outbox WHERE status = ? AND datetime(delivered_at) < datetime(?)`,
+		WebhookDelivered,
+		formatSQLiteTime(before),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("delete webhook_outbox: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("webhook_outbox rows affected: %w", err)
+	}
+	return n, nil
+}
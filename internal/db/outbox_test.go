@@ -0,0 +1,71 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	db, err := OpenAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	first, err := db.EnqueueWebhook("webhook", "https://example.com/a", "d1", []byte(`{"n":1}`), now)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook() error = %v", err)
+	}
+	second, err := db.EnqueueWebhook("ops", "https://example.com/b", "d2", []byte(`{"n":2}`), now)
+	if err != nil {
+		t.Fatalf("EnqueueWebhook() error = %v", err)
+	}
+	if _, err := db.EnqueueWebhook("ops", "https://example.com/b", "d2", nil, now); err == nil {
+		t.Error("EnqueueWebhook() with a duplicate delivery id should fail")
+	}
+
+	due, err := db.DueWebhooks(now, 0)
+	if err != nil || len(due) != 2 || due[0].ID != first || due[0].Payload != `{"n":1}` {
+		t.Fatalf("DueWebhooks() = %+v, %v; want both, oldest first", due, err)
+	}
+
+	if err := db.MarkWebhookDelivered(first, now); err != nil {
+		t.Fatalf("MarkWebhookDelivered() error = %v", err)
+	}
+	if err := db.MarkWebhookFailed(second, "503 Service Unavailable", now.Add(time.Minute), false); err != nil {
+		t.Fatalf("MarkWebhookFailed() error = %v", err)
+	}
+	if due, _ := db.DueWebhooks(now.Add(30*time.Second), 0); len(due) != 0 {
+		t.Errorf("DueWebhooks() before the retry time = %d deliveries, want 0", len(due))
+	}
+	due, _ = db.DueWebhooks(now.Add(time.Minute), 0)
+	if len(due) != 1 || due[0].Attempts != 1 || due[0].LastError != "503 Service Unavailable" {
+		t.Fatalf("DueWebhooks() at the retry time = %+v", due)
+	}
+
+	if err := db.MarkWebhookFailed(second, "410 Gone", now.Add(time.Hour), true); err != nil {
+		t.Fatalf("MarkWebhookFailed() error = %v", err)
+	}
+	failed, err := db.ListWebhooks(WebhookFailed, 0)
+	if err != nil || len(failed) != 1 || failed[0].ID != second || failed[0].Attempts != 2 {
+		t.Fatalf("ListWebhooks(failed) = %+v, %v", failed, err)
+	}
+	all, _ := db.ListWebhooks("", 0)
+	if len(all) != 2 || all[0].ID != second || all[1].DeliveredAt == nil {
+		t.Errorf("ListWebhooks() = %+v, want both, newest first", all)
+	}
+
+	later := now.Add(2 * time.Hour)
+	if n, err := db.RequeueWebhooks([]int64{first, second}, later); err != nil || n != 1 {
+		t.Errorf("RequeueWebhooks() = %d, %v; want only the failed delivery", n, err)
+	}
+	if due, _ := db.DueWebhooks(later, 0); len(due) != 1 || due[0].Status != WebhookPending {
+		t.Errorf("DueWebhooks() after requeue = %+v", due)
+	}
+
+	if n, err := db.PruneWebhooks(now.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("PruneWebhooks() = %d, %v; want 1", n, err)
+	}
+}
@@ -0,0 +1,306 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+// Ensure DB can back a logs.StoredScanner.
+var _ logs.Store = (*DB)(nil)
+
+// tokenUsageProfileSQL attributes a token_usage row (aliased t) to a profile:
+// the isolated profile whose logs it came from, or else the profile most
+// recently activated for the provider when it was written. Rows from before
+// any activation get an empty profile.
+const tokenUsageProfileSQL = `COALESCE(t.profile_name, (
+    SELECT a.profile_name FROM activity_log a
+    WHERE a.provider = t.provider AND a.event_type = '` + EventActivate + `' AND a.timestamp <= t.timestamp
+    ORDER BY a.timestamp DESC, a.id DESC
+    LIMIT 1
+), '')`
+
+// ScanCheckpoints returns how far each of a provider's log files has been
+// ingested, keyed by path.
+func (d *DB) ScanCheckpoints(provider string) (map[string]logs.Checkpoint, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT path, byte_offset, size, mod_time_ns
+		 FROM log_scan_checkpoints
+		 WHERE provider = ?`,
+		strings.TrimSpace(provider),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query log_scan_checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]logs.Checkpoint)
+	for rows.Next() {
+		var cp logs.Checkpoint
+		var modNanos int64
+		if err := rows.Scan(&cp.Path, &cp.Offset, &cp.Size, &modNanos); err != nil {
+			return nil, fmt.Errorf("scan log_scan_checkpoints: %w", err)
+		}
+		if modNanos != 0 {
+			cp.ModTime = time.Unix(0, modNanos)
+		}
+		out[cp.Path] = cp
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate log_scan_checkpoints: %w", err)
+	}
+	return out, nil
+}
+
+// RecordScan stores token usage read from a log file and advances the file's
+// checkpoint in one transaction, so an interrupted ingest never stores lines
+// twice. With reset, rows previously ingested from the file are replaced.
+func (d *DB) RecordScan(provider string, cp logs.Checkpoint, entries []*logs.LogEntry, reset bool) error {
+	if d == nil || d.conn == nil {
+		return fmt.Errorf("db is not open")
+	}
+
+	provider = strings.TrimSpace(provider)
+	if provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if cp.Path == "" {
+		return fmt.Errorf("checkpoint path is required")
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if reset {
+		if _, err := tx.Exec(`DELETE FROM token_usage WHERE provider = ? AND source_path = ?`, provider, cp.Path); err != nil {
+			return fmt.Errorf("reset token_usage: %w", err)
+		}
+	}
+
+	if len(entries) > 0 {
+		stmt, err := tx.Prepare(
+			`INSERT INTO token_usage (
+			    provider, timestamp, model, conversation_id, message_id,
+			    input_tokens, output_tokens, cache_read_tokens, cache_create_tokens,
+			    total_tokens, source_path, profile_name
+			 ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		)
+		if err != nil {
+			return fmt.Errorf("prepare insert token_usage: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, e := range entries {
+			if e == nil {
+				continue
+			}
+			total := e.TotalTokens
+			if total == 0 {
+				total = e.CalculateTotalTokens()
+			}
+			if _, err := stmt.Exec(
+				provider,
+				formatSQLiteTime(e.Timestamp),
+				e.Model,
+				nullableString(e.ConversationID),
+				nullableString(e.MessageID),
+				e.InputTokens,
+				e.OutputTokens,
+				e.CacheReadTokens,
+				e.CacheCreateTokens,
+				total,
+				cp.Path,
+				nullableString(e.Profile),
+			); err != nil {
+				return fmt.Errorf("insert token_usage: %w", err)
+			}
+		}
+	}
+
+	var modNanos int64
+	if !cp.ModTime.IsZero() {
+		modNanos = cp.ModTime.UnixNano()
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO log_scan_checkpoints (provider, path, byte_offset, size, mod_time_ns, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(provider, path) DO UPDATE SET
+		    byte_offset = excluded.byte_offset,
+		    size = excluded.size,
+		    mod_time_ns = excluded.mod_time_ns,
+		    updated_at = excluded.updated_at`,
+		provider,
+		cp.Path,
+		cp.Offset,
+		cp.Size,
+		modNanos,
+		formatSQLiteTime(time.Now()),
+	); err != nil {
+		return fmt.Errorf("upsert log_scan_checkpoints: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// TokenUsageEntries returns a provider's stored token usage at or after
+// since, oldest first, attributed to profiles where possible.
+func (d *DB) TokenUsageEntries(provider string, since time.Time) ([]*logs.LogEntry, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT t.timestamp, t.model, t.conversation_id, t.message_id,
+		        t.input_tokens, t.output_tokens, t.cache_read_tokens, t.cache_create_tokens, t.total_tokens,
+		        `+tokenUsageProfileSQL+`
+		 FROM token_usage t
+		 WHERE t.provider = ? AND t.timestamp >= ?
+		 ORDER BY t.timestamp ASC, t.id ASC`,
+		strings.TrimSpace(provider),
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query token_usage: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*logs.LogEntry
+	for rows.Next() {
+		var tsStr string
+		var conversationID, messageID sql.NullString
+		e := &logs.LogEntry{}
+		if err := rows.Scan(&tsStr, &e.Model, &conversationID, &messageID,
+			&e.InputTokens, &e.OutputTokens, &e.CacheReadTokens, &e.CacheCreateTokens, &e.TotalTokens,
+			&e.Profile); err != nil {
+			return nil, fmt.Errorf("scan token_usage: %w", err)
+		}
+		ts, err := parseSQLiteTime(tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse timestamp %q: %w", tsStr, err)
+		}
+		e.Timestamp = ts
+		e.ConversationID = conversationID.String
+		e.MessageID = messageID.String
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate token_usage: %w", err)
+	}
+	return out, nil
+}
+
+// TokenUsageTotals aggregates a provider's stored token usage at or after
+// since, in total and per model.
+func (d *DB) TokenUsageTotals(provider string, since time.Time) (*logs.TokenUsage, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT model,
+		        SUM(input_tokens), SUM(output_tokens),
+		        SUM(cache_read_tokens), SUM(cache_create_tokens)
+		 FROM token_usage
+		 WHERE provider = ? AND timestamp >= ?
+		 GROUP BY model`,
+		strings.TrimSpace(provider),
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query token_usage totals: %w", err)
+	}
+	defer rows.Close()
+
+	usage := logs.NewTokenUsage()
+	for rows.Next() {
+		e := &logs.LogEntry{}
+		if err := rows.Scan(&e.Model, &e.InputTokens, &e.OutputTokens, &e.CacheReadTokens, &e.CacheCreateTokens); err != nil {
+			return nil, fmt.Errorf("scan token_usage totals: %w", err)
+		}
+		usage.Add(e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate token_usage totals: %w", err)
+	}
+	return usage, nil
+}
+
+// TokenUsageByProfile aggregates a provider's stored token usage at or after
+// since per attributed profile. Usage that cannot be attributed is keyed "".
+func (d *DB) TokenUsageByProfile(provider string, since time.Time) (map[string]*logs.TokenUsage, error) {
+	if d == nil || d.conn == nil {
+		return nil, fmt.Errorf("db is not open")
+	}
+
+	rows, err := d.conn.Query(
+		`SELECT profile, model,
+		        SUM(input_tokens), SUM(output_tokens),
+		        SUM(cache_read_tokens), SUM(cache_create_tokens)
+		 FROM (
+		    SELECT `+tokenUsageProfileSQL+` AS profile, t.model,
+		           t.input_tokens, t.output_tokens, t.cache_read_tokens, t.cache_create_tokens
+		    FROM token_usage t
+		    WHERE t.provider = ? AND t.timestamp >= ?
+		 )
+		 GROUP BY profile, model`,
+		strings.TrimSpace(provider),
+		formatSQLiteTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query token_usage by profile: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]*logs.TokenUsage)
+	for rows.Next() {
+		e := &logs.LogEntry{}
+		if err := rows.Scan(&e.Profile, &e.Model, &e.InputTokens, &e.OutputTokens, &e.CacheReadTokens, &e.CacheCreateTokens); err != nil {
+			return nil, fmt.Errorf("scan token_usage by profile: %w", err)
+		}
+		usage, ok := out[e.Profile]
+		if !ok {
+			usage = logs.NewTokenUsage()
+			out[e.Profile] = usage
+		}
+		usage.Add(e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate token_usage by profile: %w", err)
+	}
+	return out, nil
+}
+
+// TokensSince returns the total tokens attributed to a profile at or after
+// since. It backs rotation token caps (rotation.TokenCounter).
+func (d *DB) TokensSince(provider, profile string, since time.Time) (int64, error) {
+	byProfile, err := d.TokenUsageByProfile(provider, since)
+	if err != nil {
+		return 0, err
+	}
+	if usage := byProfile[strings.TrimSpace(profile)]; usage != nil {
+		return usage.TotalTokens, nil
+	}
+	return 0, nil
+}
+
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+func TestTokenUsage_RecordAndQuery(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	modTime := time.Unix(0, 1736510400123456789)
+	cp := logs.Checkpoint{Path: "/logs/a.jsonl", Offset: 120, Size: 150, ModTime: modTime}
+	entries := []*logs.LogEntry{
+		{Timestamp: base, Model: "claude-opus-4", ConversationID: "c1", InputTokens: 100, OutputTokens: 50},
+		{Timestamp: base.Add(time.Hour), Model: "claude-sonnet-4", InputTokens: 10, OutputTokens: 5, CacheReadTokens: 1000},
+		{Timestamp: base.Add(2 * time.Hour), Model: "claude-opus-4", InputTokens: 1, OutputTokens: 1},
+	}
+	if err := d.RecordScan("claude", cp, entries, false); err != nil {
+		t.Fatalf("RecordScan() error = %v", err)
+	}
+
+	checkpoints, err := d.ScanCheckpoints("claude")
+	if err != nil {
+		t.Fatalf("ScanCheckpoints() error = %v", err)
+	}
+	got, ok := checkpoints[cp.Path]
+	if !ok || got.Offset != 120 || got.Size != 150 || !got.ModTime.Equal(modTime) {
+		t.Fatalf("ScanCheckpoints()[%s] = %+v, want %+v", cp.Path, got, cp)
+	}
+	if other, _ := d.ScanCheckpoints("codex"); len(other) != 0 {
+		t.Errorf("ScanCheckpoints(codex) = %d, want 0", len(other))
+	}
+
+	stored, err := d.TokenUsageEntries("claude", base.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("TokenUsageEntries() error = %v", err)
+	}
+	if len(stored) != 2 || stored[0].Model != "claude-sonnet-4" || stored[0].TotalTokens != 1015 {
+		t.Fatalf("TokenUsageEntries() = %d entries, first %+v", len(stored), stored[0])
+	}
+
+	totals, err := d.TokenUsageTotals("claude", time.Time{})
+	if err != nil {
+		t.Fatalf("TokenUsageTotals() error = %v", err)
+	}
+	if totals.TotalTokens != 1167 {
+		t.Errorf("TotalTokens = %d, want 1167", totals.TotalTokens)
+	}
+	if opus := totals.ByModel["claude-opus-4"]; opus == nil || opus.InputTokens != 101 {
+		t.Errorf("ByModel[claude-opus-4] = %+v, want 101 input tokens", opus)
+	}
+
+	// Advancing the checkpoint appends; a reset replaces the file's rows.
+	cp.Offset = 200
+	more := []*logs.LogEntry{{Timestamp: base.Add(3 * time.Hour), Model: "claude-opus-4", InputTokens: 7}}
+	if err := d.RecordScan("claude", cp, more, false); err != nil {
+		t.Fatalf("RecordScan() append error = %v", err)
+	}
+	if stored, _ := d.TokenUsageEntries("claude", time.Time{}); len(stored) != 4 {
+		t.Errorf("entries after append = %d, want 4", len(stored))
+	}
+
+	cp.Offset = 40
+	if err := d.RecordScan("claude", cp, more, true); err != nil {
+		t.Fatalf("RecordScan() reset error = %v", err)
+	}
+	if stored, _ := d.TokenUsageEntries("claude", time.Time{}); len(stored) != 1 {
+		t.Errorf("entries after reset = %d, want 1", len(stored))
+	}
+	if checkpoints, _ := d.ScanCheckpoints("claude"); checkpoints[cp.Path].Offset != 40 {
+		t.Errorf("checkpoint offset after reset = %d, want 40", checkpoints[cp.Path].Offset)
+	}
+}
+
+func TestTokenUsage_AttributesProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	d, err := OpenAt(filepath.Join(tmpDir, "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	base := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	for _, ev := range []Event{
+		{Type: EventActivate, Provider: "claude", ProfileName: "work", Timestamp: base.Add(time.Hour)},
+		{Type: EventActivate, Provider: "claude", ProfileName: "personal", Timestamp: base.Add(3 * time.Hour)},
+		{Type: EventActivate, Provider: "codex", ProfileName: "other", Timestamp: base},
+	} {
+		if err := d.LogEvent(ev); err != nil {
+			t.Fatalf("LogEvent() error = %v", err)
+		}
+	}
+
+	shared := []*logs.LogEntry{
+		{Timestamp: base.Add(30 * time.Minute), Model: "m", InputTokens: 1},   // before any activation
+		{Timestamp: base.Add(time.Hour), Model: "m", InputTokens: 10},         // at the activation
+		{Timestamp: base.Add(2 * time.Hour), Model: "m", InputTokens: 100},    // work
+		{Timestamp: base.Add(4 * time.Hour), Model: "m", InputTokens: 1000},   // personal
+		{Timestamp: base.Add(5 * time.Hour), Model: "n", OutputTokens: 10000}, // personal
+	}
+	if err := d.RecordScan("claude", logs.Checkpoint{Path: "/home/.claude/a.jsonl"}, shared, false); err != nil {
+		t.Fatalf("RecordScan() error = %v", err)
+	}
+	// Entries from an isolated profile's logs keep their profile.
+	isolated := []*logs.LogEntry{{Timestamp: base.Add(4 * time.Hour), Model: "m", InputTokens: 5, Profile: "sandbox"}}
+	if err := d.RecordScan("claude", logs.Checkpoint{Path: "/profiles/sandbox/a.jsonl"}, isolated, false); err != nil {
+		t.Fatalf("RecordScan() error = %v", err)
+	}
+
+	byProfile, err := d.TokenUsageByProfile("claude", time.Time{})
+	if err != nil {
+		t.Fatalf("TokenUsageByProfile() error = %v", err)
+	}
+	want := map[string]int64{"": 1, "work": 110, "personal": 11000, "sandbox": 5}
+	if len(byProfile) != len(want) {
+		t.Fatalf("TokenUsageByProfile() = %d profiles, want %d", len(byProfile), len(want))
+	}
+	for profile, total := range want {
+		if u := byProfile[profile]; u == nil || u.TotalTokens != total {
+			t.Errorf("TokenUsageByProfile()[%q] = %+v, want %d tokens", profile, u, total)
+		}
+	}
+	if len(byProfile["personal"].ByModel) != 2 {
+		t.Errorf("personal models = %d, want 2", len(byProfile["personal"].ByModel))
+	}
+
+	if n, err := d.TokensSince("claude", "personal", time.Time{}); err != nil || n != 11000 {
+		t.Errorf("TokensSince(personal) = %d, %v; want 11000", n, err)
+	}
+	if n, err := d.TokensSince("claude", "unknown", time.Time{}); err != nil || n != 0 {
+		t.Errorf("TokensSince(unknown) = %d, %v; want 0", n, err)
+	}
+
+	entries, err := d.TokenUsageEntries("claude", base.Add(4*time.Hour))
+	if err != nil {
+		t.Fatalf("TokenUsageEntries() error = %v", err)
+	}
+	profiles := map[string]bool{}
+	for _, e := range entries {
+		profiles[e.Profile] = true
+	}
+	if len(entries) != 3 || !profiles["personal"] || !profiles["sandbox"] {
+		t.Errorf("TokenUsageEntries() profiles = %v, want personal and sandbox", profiles)
+	}
+}
@@ -42,7 +42,7 @@ stealth:
 		dir := filepath.Join(vaultDir, "claude", name)
 		require.NoError(t, os.MkdirAll(dir, 0755))
 		// Mock auth file
-		require.NoError(t, os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(fmt.Sprintf(`{"token":"%s"}`, name)), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(fmt.Sprintf(`{"oauthAccount":{"emailAddress":"%s@example.com"}}`, name)), 0600))
 	}
 	
 	createProfile("p1")
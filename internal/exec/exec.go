@@ -222,6 +222,7 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 	defer signal.Stop(sigChan)
 
 	// Run command
+	startedAt := time.Now()
 	runErr := cmd.Run()
 	if stdoutObserver != nil {
 		stdoutObserver.Flush()
@@ -237,6 +238,9 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 			opts.Profile.LastSessionID = sessionID
 			opts.Profile.LastSessionTS = now.UTC()
 		}
+	} else if sessionID := captureDiskSession(opts.Provider.ID(), providerEnv, opts.WorkDir, startedAt); sessionID != "" {
+		opts.Profile.LastSessionID = sessionID
+		opts.Profile.LastSessionTS = now.UTC()
 	}
 	if err := opts.Profile.Save(); err != nil {
 		// Don't hide the original process exit code, but do surface metadata issues
@@ -81,6 +81,10 @@ func (m *mockProvider) ValidateToken(_ context.Context, _ *profile.Profile, _ bo
 	return &provider.ValidationResult{Provider: m.id, Valid: true, Method: "passive"}, nil
 }
 
+func (m *mockProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{IsolatedProfiles: true}
+}
+
 // =============================================================================
 // NewRunner Tests
 // =============================================================================
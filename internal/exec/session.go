@@ -0,0 +1,247 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is a resumable conversation transcript found on disk.
+//
+// Claude Code and Gemini CLI keep one transcript per session, grouped by
+// working directory, and can only resume a session whose transcript is in
+// their own data dir. Codex session IDs are captured from output instead
+// (see codex_session.go).
+type Session struct {
+	Tool    string    `json:"tool"`
+	ID      string    `json:"id"`
+	Path    string    `json:"path"` // transcript file
+	Root    string    `json:"root"` // session root the transcript was found under
+	ModTime time.Time `json:"mod_time"`
+}
+
+// SessionRoots returns the directories where tool keeps session transcripts
+// when run with env (HOME and tool overrides; missing keys fall back to the
+// process environment).
+//
+//	claude: $CLAUDE_CONFIG_DIR/projects, ~/.claude/projects
+//	gemini: ~/.gemini/tmp
+func SessionRoots(tool string, env map[string]string) []string {
+	get := func(key string) string {
+		if v, ok := env[key]; ok {
+			return v
+		}
+		if key == "HOME" {
+			home, _ := os.UserHomeDir()
+			return home
+		}
+		return os.Getenv(key)
+	}
+
+	switch tool {
+	case "claude":
+		var roots []string
+		if dir := get("CLAUDE_CONFIG_DIR"); dir != "" {
+			roots = append(roots, filepath.Join(dir, "projects"))
+		}
+		return append(roots, filepath.Join(get("HOME"), ".claude", "projects"))
+	case "gemini":
+		return []string{filepath.Join(get("HOME"), ".gemini", "tmp")}
+	}
+	return nil
+}
+
+// SupportsSessionDiscovery reports whether tool's sessions can be found on
+// disk with FindSessions.
+func SupportsSessionDiscovery(tool string) bool {
+	return tool == "claude" || tool == "gemini"
+}
+
+// ResumeArgs returns the CLI arguments that resume sessionID.
+func ResumeArgs(tool, sessionID string) ([]string, error) {
+	switch tool {
+	case "codex":
+		return []string{"resume", sessionID}, nil
+	case "claude", "gemini":
+		return []string{"--resume", sessionID}, nil
+	}
+	return nil, fmt.Errorf("resume is not supported for %s", tool)
+}
+
+var claudeProjectDirRe = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// SessionDir returns the directory under root holding tool's sessions for
+// workDir.
+func SessionDir(tool, root, workDir string) string {
+	switch tool {
+	case "claude":
+		// Claude Code names project dirs after the path with every
+		// non-alphanumeric character replaced by '-'.
+		return filepath.Join(root, claudeProjectDirRe.ReplaceAllString(workDir, "-"))
+	case "gemini":
+		// Gemini CLI hashes the project root.
+		sum := sha256.Sum256([]byte(workDir))
+		return filepath.Join(root, hex.EncodeToString(sum[:]), "chats")
+	}
+	return ""
+}
+
+// FindSessions returns tool's sessions for workDir under roots, newest first.
+func FindSessions(tool string, roots []string, workDir string) ([]Session, error) {
+	if !SupportsSessionDiscovery(tool) {
+		return nil, fmt.Errorf("session discovery is not supported for %s", tool)
+	}
+
+	var sessions []Session
+	for _, root := range roots {
+		dir := SessionDir(tool, root, workDir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			id := sessionIDFromFile(tool, path)
+			if id == "" {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			sessions = append(sessions, Session{
+				Tool:    tool,
+				ID:      id,
+				Path:    path,
+				Root:    root,
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].ModTime.After(sessions[j].ModTime)
+	})
+	return sessions, nil
+}
+
+// LatestSession returns the most recent session for workDir, or nil.
+func LatestSession(tool string, roots []string, workDir string) (*Session, error) {
+	sessions, err := FindSessions(tool, roots, workDir)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// sessionIDFromFile returns the session ID a transcript belongs to, or ""
+// if path is not a resumable transcript.
+func sessionIDFromFile(tool, path string) string {
+	name := filepath.Base(path)
+	switch tool {
+	case "claude":
+		// <session-id>.jsonl; agent-*.jsonl are subagent sidechains.
+		if !strings.HasSuffix(name, ".jsonl") || strings.HasPrefix(name, "agent-") {
+			return ""
+		}
+		return strings.TrimSuffix(name, ".jsonl")
+	case "gemini":
+		// session-<timestamp>-<short-id>.json holding the full sessionId.
+		if !strings.HasPrefix(name, "session-") || !strings.HasSuffix(name, ".json") {
+			return ""
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return ""
+		}
+		defer f.Close()
+		var header struct {
+			SessionID string `json:"sessionId"`
+		}
+		if err := json.NewDecoder(f).Decode(&header); err != nil {
+			return ""
+		}
+		return header.SessionID
+	}
+	return ""
+}
+
+// CopySession copies a transcript into root (the session root of another
+// profile) so the tool can resume it there. An existing copy is replaced
+// only if it is older.
+func CopySession(s Session, root string) (string, error) {
+	rel, err := filepath.Rel(s.Root, s.Path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("session %s is not under %s", s.ID, s.Root)
+	}
+	dst := filepath.Join(root, rel)
+	if dst == s.Path {
+		return dst, nil
+	}
+	if info, err := os.Stat(dst); err == nil && !info.ModTime().Before(s.ModTime) {
+		return dst, nil
+	}
+
+	in, err := os.Open(s.Path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp.*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	// Keep the source mtime so "most recent" stays meaningful.
+	_ = os.Chtimes(dst, s.ModTime, s.ModTime)
+	return dst, nil
+}
+
+// captureDiskSession records the newest session the tool wrote for workDir
+// since start. It is the Claude/Gemini counterpart of codexSessionCapture.
+func captureDiskSession(tool string, env map[string]string, workDir string, start time.Time) string {
+	if !SupportsSessionDiscovery(tool) {
+		return ""
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return ""
+	}
+	s, err := LatestSession(tool, SessionRoots(tool, env), workDir)
+	// Allow for coarse filesystem timestamps.
+	if err != nil || s == nil || s.ModTime.Before(start.Add(-time.Second)) {
+		return ""
+	}
+	return s.ID
+}
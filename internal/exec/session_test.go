@@ -0,0 +1,127 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTranscript(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionRoots(t *testing.T) {
+	env := map[string]string{"HOME": "/p/home", "CLAUDE_CONFIG_DIR": "/p/xdg/claude-code"}
+
+	claude := SessionRoots("claude", env)
+	if len(claude) != 2 || claude[0] != "/p/xdg/claude-code/projects" || claude[1] != "/p/home/.claude/projects" {
+		t.Errorf("claude roots = %v", claude)
+	}
+	gemini := SessionRoots("gemini", env)
+	if len(gemini) != 1 || gemini[0] != "/p/home/.gemini/tmp" {
+		t.Errorf("gemini roots = %v", gemini)
+	}
+}
+
+func TestFindSessions_Claude(t *testing.T) {
+	root := t.TempDir()
+	workDir := "/work/my.app"
+	dir := filepath.Join(root, "-work-my-app")
+	now := time.Now()
+
+	writeTranscript(t, filepath.Join(dir, "old-id.jsonl"), "{}\n", now.Add(-time.Hour))
+	writeTranscript(t, filepath.Join(dir, "new-id.jsonl"), "{}\n", now)
+	writeTranscript(t, filepath.Join(dir, "agent-123.jsonl"), "{}\n", now.Add(time.Hour))
+	writeTranscript(t, filepath.Join(root, "-other", "x.jsonl"), "{}\n", now.Add(time.Hour))
+
+	sessions, err := FindSessions("claude", []string{root}, workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "new-id" || sessions[1].ID != "old-id" {
+		t.Fatalf("FindSessions() = %+v", sessions)
+	}
+}
+
+func TestFindSessions_Gemini(t *testing.T) {
+	root := t.TempDir()
+	workDir := "/work/app"
+	dir := SessionDir("gemini", root, workDir)
+
+	writeTranscript(t, filepath.Join(dir, "session-2026-01-01T10-00-abcd1234.json"),
+		`{"sessionId":"abcd1234-0000-4000-8000-000000000000","messages":[]}`, time.Now())
+	writeTranscript(t, filepath.Join(dir, "notes.json"), `{"sessionId":"nope"}`, time.Now())
+
+	s, err := LatestSession("gemini", []string{root}, workDir)
+	if err != nil || s == nil {
+		t.Fatalf("LatestSession() = %v, %v", s, err)
+	}
+	if s.ID != "abcd1234-0000-4000-8000-000000000000" {
+		t.Errorf("ID = %q", s.ID)
+	}
+}
+
+func TestCopySession(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	mtime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	path := filepath.Join(src, "-work", "abc.jsonl")
+	writeTranscript(t, path, "line\n", mtime)
+
+	s := Session{Tool: "claude", ID: "abc", Path: path, Root: src, ModTime: mtime}
+	copied, err := CopySession(s, dst)
+	if err != nil {
+		t.Fatalf("CopySession() error = %v", err)
+	}
+	if copied != filepath.Join(dst, "-work", "abc.jsonl") {
+		t.Errorf("copied to %q", copied)
+	}
+
+	found, err := LatestSession("claude", []string{dst}, "/work")
+	if err != nil || found == nil || found.ID != "abc" || !found.ModTime.Equal(mtime) {
+		t.Errorf("LatestSession(dst) = %+v, %v", found, err)
+	}
+}
+
+func TestCaptureDiskSession(t *testing.T) {
+	home := t.TempDir()
+	workDir := t.TempDir()
+	env := map[string]string{"HOME": home, "CLAUDE_CONFIG_DIR": ""}
+	start := time.Now()
+
+	dir := SessionDir("claude", filepath.Join(home, ".claude", "projects"), workDir)
+	writeTranscript(t, filepath.Join(dir, "stale.jsonl"), "{}\n", start.Add(-time.Hour))
+	if got := captureDiskSession("claude", env, workDir, start); got != "" {
+		t.Errorf("captured stale session %q", got)
+	}
+
+	writeTranscript(t, filepath.Join(dir, "fresh.jsonl"), "{}\n", start.Add(time.Second))
+	if got := captureDiskSession("claude", env, workDir, start); got != "fresh" {
+		t.Errorf("captureDiskSession() = %q, want fresh", got)
+	}
+	if got := captureDiskSession("codex", env, workDir, start); got != "" {
+		t.Errorf("codex should not use disk capture, got %q", got)
+	}
+}
+
+func TestResumeArgs(t *testing.T) {
+	if args, _ := ResumeArgs("claude", "id"); len(args) != 2 || args[0] != "--resume" {
+		t.Errorf("claude ResumeArgs = %v", args)
+	}
+	if args, _ := ResumeArgs("codex", "id"); len(args) != 2 || args[0] != "resume" {
+		t.Errorf("codex ResumeArgs = %v", args)
+	}
+	if _, err := ResumeArgs("opencode", "id"); err == nil {
+		t.Error("expected error for unsupported tool")
+	}
+}
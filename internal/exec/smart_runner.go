@@ -157,6 +157,7 @@ func (r *SmartRunner) Run(ctx context.Context, opts RunOptions) (err error) {
 				DurationSeconds: int(duration.Seconds()),
 				ExitCode:        finalCode,
 				RateLimitHit:    r.handoffCount > 0,
+				ProjectDir:      opts.WorkDir,
 			}
 			if r.handoffCount > 0 {
 				session.Notes = fmt.Sprintf("handoffs: %d", r.handoffCount)
@@ -214,6 +215,7 @@ func (r *SmartRunner) Run(ctx context.Context, opts RunOptions) (err error) {
 	defer ctrl.Close()
 
 	// Start the PTY (this executes the command)
+	startedAt := time.Now()
 	if err := ctrl.Start(); err != nil {
 		return fmt.Errorf("start pty: %w", err)
 	}
@@ -250,6 +252,9 @@ func (r *SmartRunner) Run(ctx context.Context, opts RunOptions) (err error) {
 			opts.Profile.LastSessionID = sessionID
 			opts.Profile.LastSessionTS = now.UTC()
 		}
+	} else if sessionID := captureDiskSession(opts.Provider.ID(), providerEnv, opts.WorkDir, startedAt); sessionID != "" {
+		opts.Profile.LastSessionID = sessionID
+		opts.Profile.LastSessionTS = now.UTC()
 	}
 	if saveErr := opts.Profile.Save(); saveErr != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to save profile metadata: %v\n", saveErr)
@@ -225,3 +225,4 @@ func (m *MockProvider) ValidateProfile(ctx context.Context, p *profile.Profile)
 func (m *MockProvider) DetectExistingAuth() (*provider.AuthDetection, error) { return nil, nil }
 func (m *MockProvider) ImportAuth(ctx context.Context, s string, p *profile.Profile) ([]string, error) { return nil, nil }
 func (m *MockProvider) ValidateToken(ctx context.Context, p *profile.Profile, passive bool) (*provider.ValidationResult, error) { return nil, nil }
+func (m *MockProvider) Capabilities() provider.Capabilities { return provider.Capabilities{} }
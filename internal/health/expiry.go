@@ -14,6 +14,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 // ErrNoExpiry indicates that expiry information could not be determined.
@@ -173,7 +175,7 @@ type claudeOAuthJSON struct {
 
 // parseClaudeCredentialsFile parses the Claude Code credentials file format.
 func parseClaudeCredentialsFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -351,7 +353,7 @@ type oauthJSON struct {
 
 // parseOAuthFile reads an OAuth token file and extracts expiry info.
 func parseOAuthFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +426,7 @@ type adcJSON struct {
 // parseADCFile reads Google ADC credentials.
 // ADC files don't contain expiry - they contain refresh tokens.
 func parseADCFile(path string) (*ExpiryInfo, error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return nil, err
 	}
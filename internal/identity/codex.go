@@ -3,12 +3,11 @@ package identity
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 )
 
 // ExtractFromCodexAuth reads a Codex auth.json file and extracts identity from the JWT.
 func ExtractFromCodexAuth(path string) (*Identity, error) {
-	data, err := os.ReadFile(path)
+	data, err := readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read codex auth.json: %w", err)
 	}
@@ -3,12 +3,11 @@ package identity
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 )
 
 // ExtractFromGeminiConfig reads Gemini/Google auth config and extracts identity.
 func ExtractFromGeminiConfig(path string) (*Identity, error) {
-	data, err := os.ReadFile(path)
+	data, err := readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read gemini config: %w", err)
 	}
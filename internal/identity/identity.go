@@ -1,7 +1,11 @@
 // Package identity extracts account identity details from provider auth artifacts.
 package identity
 
-import "time"
+import (
+	"os"
+	"sync"
+	"time"
+)
 
 // Identity captures account metadata extracted from auth files.
 type Identity struct {
@@ -11,4 +15,33 @@ type Identity struct {
 	AccountID    string    `json:"account_id,omitempty"`
 	ExpiresAt    time.Time `json:"expires_at,omitempty"`
 	Provider     string    `json:"provider"`
+
+	// Providers lists the upstream providers a multi-provider tool (OpenCode)
+	// holds credentials for.
+	Providers []string `json:"providers,omitempty"`
+}
+
+var fileReader = struct {
+	sync.RWMutex
+	read func(path string) ([]byte, error)
+}{read: os.ReadFile}
+
+// SetFileReader replaces the function the extractors read auth files with.
+// authfile installs one that decrypts sealed vault copies, so identities
+// can be read from an encrypted vault. Pass nil to restore os.ReadFile.
+func SetFileReader(read func(path string) ([]byte, error)) {
+	if read == nil {
+		read = os.ReadFile
+	}
+	fileReader.Lock()
+	defer fileReader.Unlock()
+	fileReader.read = read
+}
+
+// readFile reads an auth file with the installed reader.
+func readFile(path string) ([]byte, error) {
+	fileReader.RLock()
+	read := fileReader.read
+	fileReader.RUnlock()
+	return read(path)
 }
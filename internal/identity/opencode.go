@@ -0,0 +1,74 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ExtractFromOpenCodeAuth reads an OpenCode auth.json and extracts identity.
+//
+// OpenCode keeps credentials for every upstream provider in one file, keyed
+// by provider ID:
+//
+//	{
+//	  "anthropic":  {"type": "oauth", "access": "...", "refresh": "...", "expires": 1767225600000},
+//	  "openai":     {"type": "oauth", "access": "<jwt>", "accountId": "..."},
+//	  "openrouter": {"type": "api", "key": "sk-or-..."}
+//	}
+//
+// Providers lists every entry. Email, plan and account come from the first
+// OAuth access token that is a JWT (in provider order); ExpiresAt is the
+// earliest OAuth expiry, since that is when the profile first needs a refresh.
+func ExtractFromOpenCodeAuth(path string) (*Identity, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read opencode auth.json: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("parse opencode auth.json: %w", err)
+	}
+
+	identity := &Identity{Provider: "opencode"}
+	for name, raw := range root {
+		if _, ok := raw.(map[string]interface{}); ok {
+			identity.Providers = append(identity.Providers, name)
+		}
+	}
+	sort.Strings(identity.Providers)
+
+	for _, name := range identity.Providers {
+		entry := root[name].(map[string]interface{})
+		if valueAsString(entry["type"]) != "oauth" {
+			continue
+		}
+
+		if exp, ok := parseEpoch(entry["expires"]); ok {
+			if identity.ExpiresAt.IsZero() || exp.Before(identity.ExpiresAt) {
+				identity.ExpiresAt = exp
+			}
+		}
+		if identity.AccountID == "" {
+			identity.AccountID = pickString(entry, "accountId", "account_id")
+		}
+		if identity.Email != "" {
+			continue
+		}
+		if claims, err := ExtractFromJWT(valueAsString(entry["access"])); err == nil {
+			identity.Email = claims.Email
+			identity.PlanType = claims.PlanType
+			identity.Organization = claims.Organization
+			if claims.AccountID != "" && identity.AccountID == "" {
+				identity.AccountID = claims.AccountID
+			}
+		}
+	}
+
+	return identity, nil
+}
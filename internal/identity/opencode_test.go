@@ -0,0 +1,91 @@
+package identity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeOpenCodeFile(t *testing.T, content map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestExtractFromOpenCodeAuth_MultiProvider(t *testing.T) {
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(24 * time.Hour)
+	path := writeOpenCodeFile(t, map[string]interface{}{
+		"anthropic": map[string]interface{}{
+			"type":    "oauth",
+			"access":  "opaque-token",
+			"refresh": "r1",
+			"expires": late.UnixMilli(),
+		},
+		"openai": map[string]interface{}{
+			"type":      "oauth",
+			"access":    buildTestJWT(map[string]interface{}{"email": "dev@example.com", "plan_type": "pro"}),
+			"expires":   early.UnixMilli(),
+			"accountId": "acct-42",
+		},
+		"openrouter": map[string]interface{}{"type": "api", "key": "sk-or-1"},
+		"$schema":    "ignored",
+	})
+
+	id, err := ExtractFromOpenCodeAuth(path)
+	if err != nil {
+		t.Fatalf("ExtractFromOpenCodeAuth error: %v", err)
+	}
+	if id.Provider != "opencode" {
+		t.Errorf("Provider = %q, want opencode", id.Provider)
+	}
+	if want := []string{"anthropic", "openai", "openrouter"}; !reflect.DeepEqual(id.Providers, want) {
+		t.Errorf("Providers = %v, want %v", id.Providers, want)
+	}
+	if id.Email != "dev@example.com" || id.PlanType != "pro" {
+		t.Errorf("Email/PlanType = %q/%q", id.Email, id.PlanType)
+	}
+	if id.AccountID != "acct-42" {
+		t.Errorf("AccountID = %q, want acct-42", id.AccountID)
+	}
+	if !id.ExpiresAt.Equal(early) {
+		t.Errorf("ExpiresAt = %v, want earliest %v", id.ExpiresAt, early)
+	}
+}
+
+func TestExtractFromOpenCodeAuth_APIKeyOnly(t *testing.T) {
+	path := writeOpenCodeFile(t, map[string]interface{}{
+		"anthropic": map[string]interface{}{"type": "api", "key": "sk-ant-1"},
+	})
+
+	id, err := ExtractFromOpenCodeAuth(path)
+	if err != nil {
+		t.Fatalf("ExtractFromOpenCodeAuth error: %v", err)
+	}
+	if id.Email != "" || !id.ExpiresAt.IsZero() {
+		t.Errorf("unexpected identity fields: %+v", id)
+	}
+	if len(id.Providers) != 1 || id.Providers[0] != "anthropic" {
+		t.Errorf("Providers = %v", id.Providers)
+	}
+}
+
+func TestExtractFromOpenCodeAuth_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractFromOpenCodeAuth(path); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
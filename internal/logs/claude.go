@@ -48,14 +48,7 @@ func (s *ClaudeScanner) Scan(ctx context.Context, logDir string, since time.Time
 		Entries:  make([]*LogEntry, 0),
 	}
 
-	// Check if directory exists
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		// No logs directory = no logs, not an error
-		return result, nil
-	}
-
-	// Find all .jsonl files
-	files, err := filepath.Glob(filepath.Join(logDir, "*.jsonl"))
+	files, err := s.LogFiles(logDir)
 	if err != nil {
 		return result, err
 	}
@@ -89,6 +82,15 @@ func (s *ClaudeScanner) Scan(ctx context.Context, logDir string, since time.Time
 	return result, nil
 }
 
+// LogFiles returns the JSONL files in logDir.
+// A missing directory means no logs, not an error.
+func (s *ClaudeScanner) LogFiles(logDir string) ([]string, error) {
+	if _, err := os.Stat(logDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return filepath.Glob(filepath.Join(logDir, "*.jsonl"))
+}
+
 // scanFile parses a single JSONL file.
 func (s *ClaudeScanner) scanFile(ctx context.Context, filePath string, since time.Time, result *ScanResult) error {
 	f, err := os.Open(filePath)
@@ -147,6 +149,11 @@ type claudeUsage struct {
 	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
 }
 
+// ParseLine parses a single JSONL line into a LogEntry.
+func (s *ClaudeScanner) ParseLine(line []byte) (*LogEntry, error) {
+	return s.parseLine(line)
+}
+
 // parseLine parses a single JSONL line into a LogEntry.
 func (s *ClaudeScanner) parseLine(line []byte) (*LogEntry, error) {
 	var raw claudeLogEntry
@@ -184,5 +191,8 @@ func (s *ClaudeScanner) parseLine(line []byte) (*LogEntry, error) {
 	return entry, nil
 }
 
-// Ensure ClaudeScanner implements Scanner interface.
-var _ Scanner = (*ClaudeScanner)(nil)
+// Ensure ClaudeScanner implements Scanner and FileScanner interfaces.
+var (
+	_ Scanner     = (*ClaudeScanner)(nil)
+	_ FileScanner = (*ClaudeScanner)(nil)
+)
@@ -52,32 +52,19 @@ func (s *CodexScanner) Scan(ctx context.Context, logDir string, since time.Time)
 		Entries:  make([]*LogEntry, 0),
 	}
 
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		return result, nil
-	}
-
-	entries, err := os.ReadDir(logDir)
+	files, err := s.LogFiles(logDir)
 	if err != nil {
 		return result, err
 	}
 
-	for _, entry := range entries {
+	for _, path := range files {
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
 		default:
 		}
 
-		if entry.IsDir() {
-			continue
-		}
-
-		name := entry.Name()
-		if !strings.HasSuffix(name, ".jsonl") {
-			continue
-		}
-
-		info, err := entry.Info()
+		info, err := os.Stat(path)
 		if err != nil {
 			result.ParseErrors++
 			continue
@@ -87,7 +74,6 @@ func (s *CodexScanner) Scan(ctx context.Context, logDir string, since time.Time)
 			continue
 		}
 
-		path := filepath.Join(logDir, name)
 		if err := s.scanFile(ctx, path, since, result); err != nil {
 			result.ParseErrors++
 		}
@@ -96,6 +82,27 @@ func (s *CodexScanner) Scan(ctx context.Context, logDir string, since time.Time)
 	return result, nil
 }
 
+// LogFiles returns the JSONL log files in logDir.
+// A missing directory means no logs, not an error.
+func (s *CodexScanner) LogFiles(logDir string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		files = append(files, filepath.Join(logDir, entry.Name()))
+	}
+	return files, nil
+}
+
 func (s *CodexScanner) scanFile(ctx context.Context, filePath string, since time.Time, result *ScanResult) error {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -134,6 +141,11 @@ func (s *CodexScanner) scanFile(ctx context.Context, filePath string, since time
 	return scanner.Err()
 }
 
+// ParseLine parses a single JSONL line into a LogEntry.
+func (s *CodexScanner) ParseLine(line []byte) (*LogEntry, error) {
+	return s.parseLine(line)
+}
+
 func (s *CodexScanner) parseLine(line []byte) (*LogEntry, error) {
 	var raw map[string]any
 	if err := json.Unmarshal(line, &raw); err != nil {
@@ -334,5 +346,8 @@ func asCodexMap(value any) (map[string]any, bool) {
 	return m, ok
 }
 
-// Ensure CodexScanner implements Scanner interface.
-var _ Scanner = (*CodexScanner)(nil)
+// Ensure CodexScanner implements Scanner and FileScanner interfaces.
+var (
+	_ Scanner     = (*CodexScanner)(nil)
+	_ FileScanner = (*CodexScanner)(nil)
+)
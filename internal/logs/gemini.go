@@ -51,27 +51,19 @@ func (s *GeminiScanner) Scan(ctx context.Context, logDir string, since time.Time
 		Entries:  make([]*LogEntry, 0),
 	}
 
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		return result, nil
-	}
-
-	entries, err := os.ReadDir(logDir)
+	files, err := s.LogFiles(logDir)
 	if err != nil {
 		return result, err
 	}
 
-	for _, entry := range entries {
+	for _, path := range files {
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
 		default:
 		}
 
-		if entry.IsDir() {
-			continue
-		}
-
-		info, err := entry.Info()
+		info, err := os.Stat(path)
 		if err != nil {
 			result.ParseErrors++
 			continue
@@ -81,7 +73,6 @@ func (s *GeminiScanner) Scan(ctx context.Context, logDir string, since time.Time
 			continue
 		}
 
-		path := filepath.Join(logDir, entry.Name())
 		if err := s.scanFile(ctx, path, since, result); err != nil {
 			result.ParseErrors++
 		}
@@ -90,6 +81,27 @@ func (s *GeminiScanner) Scan(ctx context.Context, logDir string, since time.Time
 	return result, nil
 }
 
+// LogFiles returns the log files in logDir.
+// A missing directory means no logs, not an error.
+func (s *GeminiScanner) LogFiles(logDir string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		files = append(files, filepath.Join(logDir, entry.Name()))
+	}
+	return files, nil
+}
+
 func (s *GeminiScanner) scanFile(ctx context.Context, filePath string, since time.Time, result *ScanResult) error {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -128,6 +140,11 @@ func (s *GeminiScanner) scanFile(ctx context.Context, filePath string, since tim
 	return scanner.Err()
 }
 
+// ParseLine parses a single JSONL line into a LogEntry.
+func (s *GeminiScanner) ParseLine(line []byte) (*LogEntry, error) {
+	return s.parseLine(line)
+}
+
 func (s *GeminiScanner) parseLine(line []byte) (*LogEntry, error) {
 	var raw map[string]any
 	if err := json.Unmarshal(line, &raw); err != nil {
@@ -330,5 +347,8 @@ func asMap(value any) (map[string]any, bool) {
 	return m, ok
 }
 
-// Ensure GeminiScanner implements Scanner interface.
-var _ Scanner = (*GeminiScanner)(nil)
+// Ensure GeminiScanner implements Scanner and FileScanner interfaces.
+var (
+	_ Scanner     = (*GeminiScanner)(nil)
+	_ FileScanner = (*GeminiScanner)(nil)
+)
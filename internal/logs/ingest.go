@@ -0,0 +1,274 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileScanner is a Scanner whose logs are append-only JSONL files, which lets
+// them be ingested incrementally.
+type FileScanner interface {
+	Scanner
+
+	// LogFiles lists the log files in logDir.
+	LogFiles(logDir string) ([]string, error)
+
+	// ParseLine parses a single log line.
+	ParseLine(line []byte) (*LogEntry, error)
+}
+
+// Checkpoint records how far a log file has been ingested.
+type Checkpoint struct {
+	Path    string
+	Offset  int64 // Bytes consumed; always at a line boundary
+	Size    int64 // File size when last read
+	ModTime time.Time
+}
+
+// Store persists ingested log entries with per-file checkpoints.
+type Store interface {
+	// ScanCheckpoints returns the checkpoints of a provider's log files by path.
+	ScanCheckpoints(provider string) (map[string]Checkpoint, error)
+
+	// RecordScan stores entries read from cp.Path and advances its checkpoint
+	// in one transaction. With reset, entries previously stored for the file
+	// are replaced.
+	RecordScan(provider string, cp Checkpoint, entries []*LogEntry, reset bool) error
+
+	// TokenUsageEntries returns stored entries at or after since, oldest
+	// first, with Profile set where the entry can be attributed.
+	TokenUsageEntries(provider string, since time.Time) ([]*LogEntry, error)
+}
+
+// Source is a set of log files to ingest: a provider's default logs, or the
+// logs written inside an isolated profile, which all belong to that profile.
+type Source struct {
+	Provider string
+	Profile  string // Set for isolated profiles
+	Scanner  FileScanner
+}
+
+// DefaultSources returns the default log locations of all supported providers.
+func DefaultSources() []Source {
+	return []Source{
+		{Provider: "claude", Scanner: NewClaudeScanner()},
+		{Provider: "codex", Scanner: NewCodexScanner()},
+		{Provider: "gemini", Scanner: NewGeminiScanner()},
+	}
+}
+
+// IsolatedSource returns where a provider CLI writes logs when run in an
+// isolated profile, i.e. with HOME (and for codex, CODEX_HOME) pointing into
+// the profile directory. It returns false for providers without log support.
+func IsolatedSource(provider, profile, home, codexHome string) (Source, bool) {
+	src := Source{Provider: provider, Profile: profile}
+	switch provider {
+	case "claude":
+		src.Scanner = NewClaudeScannerWithDir(filepath.Join(home, ".local", "share", "claude", "logs"))
+	case "codex":
+		src.Scanner = NewCodexScannerWithDir(filepath.Join(codexHome, "logs"))
+	case "gemini":
+		src.Scanner = NewGeminiScannerWithDir(filepath.Join(home, ".gemini", "logs"))
+	default:
+		return Source{}, false
+	}
+	return src, true
+}
+
+// IngestResult summarizes an Ingest run.
+type IngestResult struct {
+	Provider    string
+	Profile     string
+	Files       int // Files with new lines
+	Lines       int // New lines read
+	Entries     int // Entries stored
+	ParseErrors int
+}
+
+// Ingest stores the lines appended to a provider's log files since their last
+// checkpoint. Only entries that carry a timestamp and token counts are kept.
+// A file that shrank is treated as replaced and read again from the start; a
+// trailing line without a newline is left for the next run, since the CLI may
+// still be writing it.
+func Ingest(ctx context.Context, provider string, s FileScanner, store Store) (*IngestResult, error) {
+	return IngestSource(ctx, Source{Provider: provider, Scanner: s}, store)
+}
+
+// IngestSource is like Ingest, but attributes the entries of an isolated
+// profile's source to that profile.
+func IngestSource(ctx context.Context, src Source, store Store) (*IngestResult, error) {
+	provider, s := src.Provider, src.Scanner
+	result := &IngestResult{Provider: provider, Profile: src.Profile}
+
+	files, err := s.LogFiles(s.LogDir())
+	if err != nil {
+		return result, err
+	}
+	if len(files) == 0 {
+		return result, nil
+	}
+
+	checkpoints, err := store.ScanCheckpoints(provider)
+	if err != nil {
+		return result, err
+	}
+
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			result.ParseErrors++
+			continue
+		}
+
+		cp, seen := checkpoints[path]
+		if seen && info.Size() == cp.Size && info.ModTime().Equal(cp.ModTime) {
+			continue
+		}
+
+		reset := seen && info.Size() < cp.Offset
+		offset := cp.Offset
+		if !seen || reset {
+			offset = 0
+		}
+
+		entries, next, lines, parseErrors, err := readFrom(ctx, s, path, offset, src.Profile)
+		result.ParseErrors += parseErrors
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.ParseErrors++
+			continue
+		}
+
+		newCP := Checkpoint{Path: path, Offset: next, Size: info.Size(), ModTime: info.ModTime()}
+		if err := store.RecordScan(provider, newCP, entries, reset); err != nil {
+			return result, err
+		}
+		if lines > 0 {
+			result.Files++
+		}
+		result.Lines += lines
+		result.Entries += len(entries)
+	}
+
+	return result, nil
+}
+
+// readFrom parses complete lines of path starting at offset. It returns the
+// entries worth storing and the offset after the last complete line.
+func readFrom(ctx context.Context, s FileScanner, path string, offset int64, profile string) ([]*LogEntry, int64, int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, 0, 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, 0, 0, err
+	}
+
+	var (
+		entries     []*LogEntry
+		lines       int
+		parseErrors int
+	)
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, offset, lines, parseErrors, err
+		}
+
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Incomplete trailing line: pick it up once it is finished.
+			break
+		}
+		if err != nil {
+			return nil, offset, lines, parseErrors, err
+		}
+		offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+
+		entry, err := s.ParseLine(line)
+		if err != nil {
+			parseErrors++
+			continue
+		}
+		if entry.TotalTokens == 0 {
+			entry.TotalTokens = entry.CalculateTotalTokens()
+		}
+		if entry.Timestamp.IsZero() || entry.TotalTokens == 0 {
+			continue
+		}
+		entry.Raw = nil
+		entry.Profile = profile
+		entries = append(entries, entry)
+	}
+
+	return entries, offset, lines, parseErrors, nil
+}
+
+// StoredScanner is a Scanner that answers from a Store, ingesting new log
+// lines first. Scans of a directory other than the default one bypass the
+// store.
+type StoredScanner struct {
+	provider string
+	files    FileScanner
+	store    Store
+}
+
+// NewStoredScanner wraps a provider's file scanner with a store.
+func NewStoredScanner(provider string, files FileScanner, store Store) *StoredScanner {
+	return &StoredScanner{provider: provider, files: files, store: store}
+}
+
+// LogDir returns the wrapped scanner's log directory.
+func (s *StoredScanner) LogDir() string {
+	return s.files.LogDir()
+}
+
+// Scan ingests new log lines and returns stored entries since the given time.
+// If ingesting fails, it falls back to scanning the files directly.
+func (s *StoredScanner) Scan(ctx context.Context, logDir string, since time.Time) (*ScanResult, error) {
+	if logDir != "" && logDir != s.files.LogDir() {
+		return s.files.Scan(ctx, logDir, since)
+	}
+
+	ingested, err := Ingest(ctx, s.provider, s.files, s.store)
+	if err != nil {
+		return s.files.Scan(ctx, logDir, since)
+	}
+	entries, err := s.store.TokenUsageEntries(s.provider, since)
+	if err != nil {
+		return s.files.Scan(ctx, logDir, since)
+	}
+
+	return &ScanResult{
+		Provider:      s.provider,
+		TotalEntries:  len(entries),
+		ParsedEntries: len(entries),
+		ParseErrors:   ingested.ParseErrors,
+		Since:         since,
+		Until:         time.Now(),
+		Entries:       entries,
+	}, nil
+}
+
+// Ensure StoredScanner implements Scanner interface.
+var _ Scanner = (*StoredScanner)(nil)
@@ -0,0 +1,215 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	checkpoints map[string]Checkpoint
+	entries     map[string][]*LogEntry // by path
+	recordErr   error
+}
+
+func newMemStore() *memStore {
+	return &memStore{checkpoints: make(map[string]Checkpoint), entries: make(map[string][]*LogEntry)}
+}
+
+func (m *memStore) ScanCheckpoints(provider string) (map[string]Checkpoint, error) {
+	out := make(map[string]Checkpoint, len(m.checkpoints))
+	for k, v := range m.checkpoints {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (m *memStore) RecordScan(provider string, cp Checkpoint, entries []*LogEntry, reset bool) error {
+	if m.recordErr != nil {
+		return m.recordErr
+	}
+	if reset {
+		delete(m.entries, cp.Path)
+	}
+	m.entries[cp.Path] = append(m.entries[cp.Path], entries...)
+	m.checkpoints[cp.Path] = cp
+	return nil
+}
+
+func (m *memStore) TokenUsageEntries(provider string, since time.Time) ([]*LogEntry, error) {
+	var out []*LogEntry
+	for _, entries := range m.entries {
+		for _, e := range entries {
+			if !e.Timestamp.Before(since) {
+				out = append(out, e)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) count() int {
+	n := 0
+	for _, entries := range m.entries {
+		n += len(entries)
+	}
+	return n
+}
+
+func codexLine(ts string, in, out int) string {
+	return fmt.Sprintf(`{"timestamp":%q,"event":"response","model":"gpt-4o","usage":{"prompt_tokens":%d,"completion_tokens":%d}}`, ts, in, out)
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestIngest_Incremental(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "session.jsonl")
+	scanner := NewCodexScannerWithDir(tmpDir)
+	store := newMemStore()
+	ctx := context.Background()
+
+	// A trailing line without a newline is still being written.
+	appendFile(t, path, codexLine("2025-01-10T12:00:00Z", 100, 200)+"\n"+
+		"not json\n"+
+		codexLine("2025-01-10T12:01:00Z", 10, 20))
+
+	result, err := Ingest(ctx, "codex", scanner, store)
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if result.Lines != 2 || result.Entries != 1 || result.ParseErrors != 1 {
+		t.Errorf("first Ingest() = %+v, want 2 lines, 1 entry, 1 parse error", result)
+	}
+
+	// Unchanged files are skipped.
+	result, err = Ingest(ctx, "codex", scanner, store)
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if result.Lines != 0 || store.count() != 1 {
+		t.Errorf("unchanged Ingest() = %+v, stored %d, want nothing new", result, store.count())
+	}
+
+	// Finishing the partial line and appending another picks up both.
+	appendFile(t, path, "\n"+codexLine("2025-01-10T12:02:00Z", 1, 2)+"\n")
+	result, err = Ingest(ctx, "codex", scanner, store)
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if result.Entries != 2 || store.count() != 3 {
+		t.Errorf("append Ingest() = %+v, stored %d, want 2 new of 3", result, store.count())
+	}
+
+	info, _ := os.Stat(path)
+	if cp := store.checkpoints[path]; cp.Offset != info.Size() {
+		t.Errorf("checkpoint offset = %d, want %d", cp.Offset, info.Size())
+	}
+}
+
+func TestIngest_ResetOnShrink(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "session.jsonl")
+	scanner := NewCodexScannerWithDir(tmpDir)
+	store := newMemStore()
+	ctx := context.Background()
+
+	appendFile(t, path, codexLine("2025-01-10T12:00:00Z", 100, 200)+"\n"+codexLine("2025-01-10T12:01:00Z", 1, 2)+"\n")
+	if _, err := Ingest(ctx, "codex", scanner, store); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+
+	// A rotated file starts over; its old rows are replaced.
+	if err := os.WriteFile(path, []byte(codexLine("2025-01-11T08:00:00Z", 5, 5)+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := Ingest(ctx, "codex", scanner, store); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if store.count() != 1 || store.entries[path][0].TotalTokens != 10 {
+		t.Errorf("stored %d entries after shrink, want the 1 new entry", store.count())
+	}
+}
+
+func TestStoredScanner(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "session.jsonl")
+	appendFile(t, path, codexLine("2025-01-10T12:00:00Z", 100, 200)+"\n"+codexLine("2025-01-12T12:00:00Z", 1, 2)+"\n")
+
+	store := newMemStore()
+	scanner := NewStoredScanner("codex", NewCodexScannerWithDir(tmpDir), store)
+	if scanner.LogDir() != tmpDir {
+		t.Errorf("LogDir() = %q, want %q", scanner.LogDir(), tmpDir)
+	}
+
+	since := time.Date(2025, 1, 11, 0, 0, 0, 0, time.UTC)
+	result, err := scanner.Scan(context.Background(), "", since)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].TotalTokens != 3 {
+		t.Errorf("Scan() entries = %d, want the 1 entry since %v", len(result.Entries), since)
+	}
+	if store.count() != 2 {
+		t.Errorf("stored %d entries, want 2", store.count())
+	}
+
+	// A failing store falls back to reading the files.
+	failing := newMemStore()
+	failing.recordErr = fmt.Errorf("disk full")
+	scanner = NewStoredScanner("codex", NewCodexScannerWithDir(tmpDir), failing)
+	result, err = scanner.Scan(context.Background(), "", time.Time{})
+	if err != nil {
+		t.Fatalf("Scan() fallback error = %v", err)
+	}
+	if len(result.Entries) != 2 {
+		t.Errorf("fallback Scan() entries = %d, want 2", len(result.Entries))
+	}
+}
+
+func TestIngestSource_IsolatedProfile(t *testing.T) {
+	codexHome := t.TempDir()
+	src, ok := IsolatedSource("codex", "sandbox", t.TempDir(), codexHome)
+	if !ok {
+		t.Fatal("IsolatedSource(codex) not supported")
+	}
+	if src.Scanner.LogDir() != filepath.Join(codexHome, "logs") {
+		t.Errorf("LogDir() = %q, want CODEX_HOME/logs", src.Scanner.LogDir())
+	}
+	if _, ok := IsolatedSource("opencode", "x", "/h", "/c"); ok {
+		t.Error("IsolatedSource(opencode) should not be supported")
+	}
+
+	if err := os.MkdirAll(src.Scanner.LogDir(), 0700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	appendFile(t, filepath.Join(src.Scanner.LogDir(), "s.jsonl"), codexLine("2025-01-10T12:00:00Z", 1, 2)+"\n")
+
+	store := newMemStore()
+	result, err := IngestSource(context.Background(), src, store)
+	if err != nil {
+		t.Fatalf("IngestSource() error = %v", err)
+	}
+	if result.Profile != "sandbox" || result.Entries != 1 {
+		t.Errorf("IngestSource() = %+v, want 1 sandbox entry", result)
+	}
+	for _, entries := range store.entries {
+		if entries[0].Profile != "sandbox" {
+			t.Errorf("entry profile = %q, want sandbox", entries[0].Profile)
+		}
+	}
+}
@@ -35,6 +35,9 @@ type LogEntry struct {
 	CacheCreateTokens int64
 	TotalTokens       int64
 
+	// Profile is the caam profile the entry is attributed to, if known
+	Profile string
+
 	// Raw contains provider-specific fields not in the common schema
 	Raw map[string]any
 }
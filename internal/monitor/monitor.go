@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"sync"
 	"time"
 
@@ -88,7 +87,7 @@ func NewMonitor(opts ...MonitorOption) *Monitor {
 	m := &Monitor{
 		interval:  30 * time.Second,
 		providers: []string{"claude", "codex", "gemini"},
-		fetcher:   usage.NewMultiProfileFetcher(),
+		fetcher:   usage.NewMultiProfileFetcher(usage.WithVaultDir(authfile.DefaultVaultPath())),
 		vault:     authfile.NewVault(authfile.DefaultVaultPath()),
 		health:    health.NewStorage(""),
 		state: &MonitorState{
@@ -290,27 +289,8 @@ func (m *Monitor) readAccessToken(provider, name string) (string, error) {
 		return "", fmt.Errorf("vault is nil")
 	}
 
-	switch provider {
-	case "claude":
-		profilePath := m.vault.ProfilePath(provider, name)
-		creds := filepath.Join(profilePath, ".credentials.json")
-		token, _, err := usage.ReadClaudeCredentials(creds)
-		if err != nil {
-			oldPath := filepath.Join(profilePath, ".claude.json")
-			token, _, err = usage.ReadClaudeCredentials(oldPath)
-			if err != nil {
-				authPath := filepath.Join(profilePath, "auth.json")
-				token, _, err = usage.ReadClaudeCredentials(authPath)
-			}
-		}
-		return token, err
-	case "codex":
-		authPath := filepath.Join(m.vault.ProfilePath(provider, name), "auth.json")
-		token, _, err := usage.ReadCodexCredentials(authPath)
-		return token, err
-	default:
-		return "", fmt.Errorf("usage fetch unsupported for provider %s", provider)
-	}
+	token, _, err := usage.ReadProfileToken(m.vault.ProfilePath(provider, name), provider)
+	return token, err
 }
 
 func (m *Monitor) buildProfileState(provider, name string, info *usage.UsageInfo, cooldowns map[string]time.Time) *ProfileState {
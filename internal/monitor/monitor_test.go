@@ -102,12 +102,12 @@ func TestMonitorRefreshBuildsState(t *testing.T) {
 func TestMonitorRefreshUnsupportedProvider(t *testing.T) {
 	tmpDir := t.TempDir()
 	vault := authfile.NewVault(tmpDir)
-	writeProfileDir(t, vault, "gemini", "carol")
+	writeProfileDir(t, vault, "opencode", "carol")
 
 	mon := NewMonitor(
 		WithVault(vault),
 		WithFetcher(&fakeFetcher{}),
-		WithProviders([]string{"gemini"}),
+		WithProviders([]string{"opencode"}),
 		WithHealthStore(nil),
 	)
 
@@ -116,9 +116,9 @@ func TestMonitorRefreshUnsupportedProvider(t *testing.T) {
 	}
 
 	state := mon.GetState()
-	profile := state.Profiles["gemini/carol"]
+	profile := state.Profiles["opencode/carol"]
 	if profile == nil || profile.Usage == nil || profile.Usage.Error == "" {
-		t.Fatalf("expected usage error for gemini profile, got %+v", profile)
+		t.Fatalf("expected usage error for opencode profile, got %+v", profile)
 	}
 }
 
@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Text renders the body of an alert: tmpl applied to the alert, or if tmpl
+// is nil the message, profile and suggested action.
+func Text(alert *Alert, tmpl *template.Template) (string, error) {
+	if tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, alert); err != nil {
+			return "", fmt.Errorf("render template: %w", err)
+		}
+		return buf.String(), nil
+	}
+
+	text := alert.Message
+	if alert.Profile != "" {
+		text += fmt.Sprintf(" (%s)", alert.Profile)
+	}
+	if alert.Action != "" {
+		text += "\nAction: " + alert.Action
+	}
+	return text, nil
+}
+
+func postHTTP(url, contentType string, body []byte, headers map[string]string, timeout time.Duration) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request failed with status: %s", resp.Status)
+	}
+	return nil
+}
+
+// NtfyNotifier publishes alerts to an ntfy topic URL as plain-text push
+// messages, with the title and priority in headers.
+type NtfyNotifier struct {
+	URL      string
+	Headers  map[string]string
+	Template *template.Template
+	Timeout  time.Duration
+}
+
+func NewNtfyNotifier(url string) *NtfyNotifier {
+	return &NtfyNotifier{
+		URL:     url,
+		Headers: make(map[string]string),
+		Timeout: 5 * time.Second,
+	}
+}
+
+func (n *NtfyNotifier) Name() string {
+	return "ntfy"
+}
+
+func (n *NtfyNotifier) Available() bool {
+	return n.URL != ""
+}
+
+func (n *NtfyNotifier) Notify(alert *Alert) error {
+	text, err := Text(alert, n.Template)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Title": alert.Title}
+	switch alert.Level {
+	case Critical:
+		headers["Priority"] = "urgent"
+		headers["Tags"] = "rotating_light"
+	case Warning:
+		headers["Priority"] = "high"
+		headers["Tags"] = "warning"
+	default:
+		headers["Priority"] = "default"
+	}
+	for k, v := range n.Headers {
+		headers[k] = v
+	}
+	return postHTTP(n.URL, "text/plain; charset=utf-8", []byte(text), headers, n.Timeout)
+}
+
+// SlackNotifier posts alerts to a Slack-compatible incoming webhook.
+type SlackNotifier struct {
+	URL      string
+	Headers  map[string]string
+	Template *template.Template
+	Timeout  time.Duration
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		URL:     url,
+		Headers: make(map[string]string),
+		Timeout: 5 * time.Second,
+	}
+}
+
+func (n *SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (n *SlackNotifier) Available() bool {
+	return n.URL != ""
+}
+
+func (n *SlackNotifier) Notify(alert *Alert) error {
+	text, err := Text(alert, n.Template)
+	if err != nil {
+		return err
+	}
+	if n.Template == nil {
+		text = fmt.Sprintf("*[%s] %s*\n%s", alert.Level, alert.Title, text)
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	return postHTTP(n.URL, "application/json", body, n.Headers, n.Timeout)
+}
+
+// EmailNotifier sends alerts as plain-text email over SMTP. STARTTLS is
+// used when the server offers it.
+type EmailNotifier struct {
+	Addr     string // SMTP server as host:port
+	Username string // Optional; enables PLAIN auth
+	Password string
+	From     string
+	To       []string
+	Template *template.Template
+}
+
+func NewEmailNotifier(addr, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{Addr: addr, From: from, To: to}
+}
+
+func (n *EmailNotifier) Name() string {
+	return "email"
+}
+
+func (n *EmailNotifier) Available() bool {
+	return n.Addr != "" && n.From != "" && len(n.To) > 0
+}
+
+func (n *EmailNotifier) Notify(alert *Alert) error {
+	text, err := Text(alert, n.Template)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("[caam %s] %s", alert.Level, alert.Title)
+	ts := alert.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", ts.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return fmt.Errorf("smtp address %q: %w", n.Addr, err)
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+	if err := smtp.SendMail(n.Addr, auth, n.From, n.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// ExecNotifier runs a program for each alert. The rendered text is written
+// to its standard input and the alert fields are passed in CAAM_ALERT_*
+// environment variables.
+type ExecNotifier struct {
+	Command  []string
+	Template *template.Template
+	Timeout  time.Duration
+}
+
+func NewExecNotifier(command []string) *ExecNotifier {
+	return &ExecNotifier{Command: command, Timeout: 30 * time.Second}
+}
+
+func (n *ExecNotifier) Name() string {
+	return "exec"
+}
+
+func (n *ExecNotifier) Available() bool {
+	return len(n.Command) > 0
+}
+
+func (n *ExecNotifier) Notify(alert *Alert) error {
+	if !n.Available() {
+		return nil
+	}
+	text, err := Text(alert, n.Template)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, n.Command[0], n.Command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Env = append(os.Environ(),
+		"CAAM_ALERT_LEVEL="+alert.Level.String(),
+		"CAAM_ALERT_TITLE="+alert.Title,
+		"CAAM_ALERT_MESSAGE="+alert.Message,
+		"CAAM_ALERT_PROVIDER="+alert.Provider,
+		"CAAM_ALERT_PROFILE="+alert.Profile,
+		"CAAM_ALERT_ACTION="+alert.Action,
+		"CAAM_ALERT_KEY="+alert.DedupKey(),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("run %s: %w: %s", n.Command[0], err, msg)
+		}
+		return fmt.Errorf("run %s: %w", n.Command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestText(t *testing.T) {
+	alert := &Alert{Level: Warning, Title: "Near limit", Message: "80% used", Provider: "claude", Profile: "work", Action: "caam next claude"}
+
+	got, err := Text(alert, nil)
+	if err != nil || got != "80% used (work)\nAction: caam next claude" {
+		t.Errorf("Text(nil) = %q, %v", got, err)
+	}
+
+	tmpl := template.Must(template.New("t").Parse("{{.Level}} {{.Provider}}/{{.Profile}}: {{.Message}}"))
+	got, err = Text(alert, tmpl)
+	if err != nil || got != "WARNING claude/work: 80% used" {
+		t.Errorf("Text(tmpl) = %q, %v", got, err)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	var body string
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, header = string(b), r.Header
+	}))
+	defer server.Close()
+
+	n := NewNtfyNotifier(server.URL + "/caam")
+	n.Headers["Authorization"] = "Bearer tk_test"
+	if err := n.Notify(&Alert{Level: Critical, Title: "Limit hit", Message: "work is rate-limited"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if body != "work is rate-limited" {
+		t.Errorf("body = %q", body)
+	}
+	if header.Get("Title") != "Limit hit" || header.Get("Priority") != "urgent" || header.Get("Authorization") != "Bearer tk_test" {
+		t.Errorf("headers = %v", header)
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier(server.URL)
+	if err := n.Notify(&Alert{Level: Warning, Title: "Budget acme at 80%", Message: "$160.00 of $200.00"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if payload["text"] != "*[WARNING] Budget acme at 80%*\n$160.00 of $200.00" {
+		t.Errorf("text = %q", payload["text"])
+	}
+
+	n.Template = template.Must(template.New("t").Parse(":warning: {{.Title}}"))
+	if err := n.Notify(&Alert{Title: "Budget acme at 80%"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if payload["text"] != ":warning: Budget acme at 80%" {
+		t.Errorf("templated text = %q", payload["text"])
+	}
+}
+
+// smtpStandIn accepts one message and returns what it received.
+func smtpStandIn(t *testing.T) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		var transcript strings.Builder
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					transcript.WriteString(l)
+				}
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				ch <- transcript.String()
+				return
+			default:
+				transcript.WriteString(line)
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, received := smtpStandIn(t)
+
+	n := NewEmailNotifier(addr, "caam@example.com", []string{"ops@example.com", "me@example.com"})
+	alert := &Alert{Level: Critical, Title: "All profiles low", Message: "No claude profile has quota left", Action: "Wait for reset"}
+	if err := n.Notify(alert); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	got := <-received
+	for _, want := range []string{
+		"MAIL FROM:<caam@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"RCPT TO:<me@example.com>",
+		"Subject: [caam CRITICAL] All profiles low\r\n",
+		"To: ops@example.com, me@example.com\r\n",
+		"No claude profile has quota left\r\nAction: Wait for reset\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestExecNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := filepath.Join(dir, "alert.sh")
+	content := "#!/bin/sh\n{ echo \"$CAAM_ALERT_LEVEL $CAAM_ALERT_PROVIDER/$CAAM_ALERT_PROFILE\"; cat; } > " + out + "\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	n := NewExecNotifier([]string{script})
+	if err := n.Notify(&Alert{Level: Warning, Title: "Near limit", Message: "85% used", Provider: "codex", Profile: "work"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "WARNING codex/work\n85% used (work)" {
+		t.Errorf("script saw %q", data)
+	}
+
+	failing := NewExecNotifier([]string{"/bin/sh", "-c", "echo boom >&2; exit 3"})
+	if err := failing.Notify(&Alert{}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Notify() error = %v, want script output", err)
+	}
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"io"
+	"sort"
+	"text/template"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+// FromConfig returns a router over the channels in cfg: the terminal,
+// desktop and webhook settings when enabled, and the named channels.
+// Terminal alerts are written to terminal, and skipped if it is nil.
+func FromConfig(cfg config.NotificationConfig, terminal io.Writer, opts ...RouterOption) *Router {
+	return NewRouter(cfg, Channels(cfg, terminal), opts...)
+}
+
+// Channels builds the notifiers for the channels in cfg, by name.
+func Channels(cfg config.NotificationConfig, terminal io.Writer) map[string]Notifier {
+	channels := make(map[string]Notifier)
+	if cfg.Terminal && terminal != nil {
+		channels["terminal"] = NewTerminalNotifier(terminal, true)
+	}
+	if cfg.Desktop {
+		channels["desktop"] = NewDesktopNotifier()
+	}
+	if cfg.Webhook != "" {
+		n := NewWebhookNotifier(cfg.Webhook)
+		n.Secret = cfg.WebhookSecret
+		channels["webhook"] = n
+	}
+
+	names := make([]string, 0, len(cfg.Channels))
+	for name := range cfg.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if n := newChannel(name, cfg.Channels[name], terminal); n != nil {
+			channels[name] = n
+		}
+	}
+	return channels
+}
+
+// newChannel builds the notifier for a channel. Channels that cannot be
+// built, such as terminal channels without a terminal, yield nil.
+func newChannel(name string, c config.ChannelConfig, terminal io.Writer) Notifier {
+	var tmpl *template.Template
+	if c.Template != "" {
+		if t, err := template.New(name).Parse(c.Template); err == nil {
+			tmpl = t
+		}
+	}
+
+	switch c.Type {
+	case "ntfy":
+		n := NewNtfyNotifier(c.URL)
+		n.Template = tmpl
+		for k, v := range c.Headers {
+			n.Headers[k] = v
+		}
+		return n
+	case "slack":
+		n := NewSlackNotifier(c.URL)
+		n.Template = tmpl
+		for k, v := range c.Headers {
+			n.Headers[k] = v
+		}
+		return n
+	case "webhook":
+		n := NewWebhookNotifier(c.URL)
+		n.Template = tmpl
+		n.Secret = c.Secret
+		n.Channel = name
+		for k, v := range c.Headers {
+			n.Headers[k] = v
+		}
+		return n
+	case "email":
+		n := NewEmailNotifier(c.SMTP, c.From, c.To)
+		n.Username, n.Password = c.Username, c.Password
+		n.Template = tmpl
+		return n
+	case "exec":
+		n := NewExecNotifier(c.Command)
+		n.Template = tmpl
+		return n
+	case "terminal":
+		if terminal == nil {
+			return nil
+		}
+		return NewTerminalNotifier(terminal, true)
+	case "desktop":
+		return NewDesktopNotifier()
+	default:
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package notify
 
 import (
+	"strings"
 	"time"
 )
 
@@ -31,9 +32,22 @@ type Alert struct {
 	Level     AlertLevel
 	Title     string
 	Message   string
+	Provider  string
 	Profile   string
 	Timestamp time.Time
 	Action    string // Suggested action for the user
+
+	// Key identifies repeats of the same alert for deduplication. If empty,
+	// alerts with the same level, provider, profile and title are repeats.
+	Key string
+}
+
+// DedupKey returns the key identifying repeats of the alert.
+func (a *Alert) DedupKey() string {
+	if a.Key != "" {
+		return a.Key
+	}
+	return strings.Join([]string{a.Level.String(), a.Provider, a.Profile, a.Title}, "/")
 }
 
 // Notifier defines the interface for delivering notifications.
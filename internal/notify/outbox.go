@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+// MaxOutboxAttempts is how many times the outbox tries a delivery before
+// giving up on it.
+const MaxOutboxAttempts = 10
+
+// OutboxBackoff returns how long to wait after the given number of failed
+// outbox attempts: a minute, doubling each time, at most six hours.
+func OutboxBackoff(attempts int) time.Duration {
+	wait := time.Minute
+	for i := 1; i < attempts && wait < 6*time.Hour; i++ {
+		wait *= 2
+	}
+	if wait > 6*time.Hour {
+		wait = 6 * time.Hour
+	}
+	return wait
+}
+
+// DrainResult counts what happened to the deliveries in a drain.
+type DrainResult struct {
+	Delivered int `json:"delivered"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+}
+
+// DrainOutbox makes one attempt at each outbox delivery due at now. Each
+// goes to the current URL and secret of its channel in channels, so fixing
+// the configuration fixes queued deliveries; those whose channel is gone
+// are given up on.
+func DrainOutbox(db *caamdb.DB, channels map[string]Notifier, now time.Time) (DrainResult, error) {
+	var res DrainResult
+	due, err := db.DueWebhooks(now, 0)
+	if err != nil {
+		return res, err
+	}
+
+	for _, d := range due {
+		attempts := d.Attempts + 1
+		n, ok := channels[d.Channel].(*WebhookNotifier)
+		if !ok || !n.Available() {
+			if err := db.MarkWebhookFailed(d.ID, fmt.Sprintf("webhook channel %q is not configured", d.Channel), now, true); err != nil {
+				return res, err
+			}
+			res.Failed++
+			continue
+		}
+
+		retry, sendErr := n.Post(d.DeliveryID, []byte(d.Payload))
+		switch {
+		case sendErr == nil:
+			err = db.MarkWebhookDelivered(d.ID, now)
+			res.Delivered++
+		case retry && attempts < MaxOutboxAttempts:
+			err = db.MarkWebhookFailed(d.ID, sendErr.Error(), now.Add(OutboxBackoff(attempts)), false)
+			res.Retrying++
+		default:
+			err = db.MarkWebhookFailed(d.ID, sendErr.Error(), now, true)
+			res.Failed++
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
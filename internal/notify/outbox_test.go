@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	const secret = "s3cret"
+	var verified atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+			t.Errorf("bad timestamp header %q", r.Header.Get(HeaderTimestamp))
+		}
+		if r.Header.Get(HeaderDelivery) == "" {
+			t.Error("missing delivery id header")
+		}
+		got := r.Header.Get(HeaderSignature)
+		verified.Store(hmac.Equal([]byte(got), []byte(Sign(secret, ts, body))))
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL)
+	n.Secret = secret
+	if err := n.Notify(&Alert{Level: Warning, Title: "Near limit", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if !verified.Load() {
+		t.Error("signature did not verify")
+	}
+
+	const want = "sha256=97926816e98fbb41ccb1673225ff29a2f35369099990e1b1561651e7bd097ebf"
+	if got := Sign(secret, 1700000000, []byte(`{}`)); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL)
+	n.Backoff = time.Millisecond
+	if err := n.Notify(&Alert{Title: "flaky"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server saw %d requests, want 3", calls.Load())
+	}
+
+	calls.Store(0)
+	n.Retries = 1
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if err := n.Notify(&Alert{Title: "down"}); err == nil {
+		t.Error("Notify() should fail once retries are used up")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server saw %d requests, want 2", calls.Load())
+	}
+}
+
+func TestWebhookOutbox(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	defer db.Close()
+
+	status := atomic.Int32{}
+	status.Store(http.StatusBadGateway)
+	var lastDelivery atomic.Value
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		lastDelivery.Store(r.Header.Get(HeaderDelivery))
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL)
+	n.Channel = "ops"
+	n.Outbox = db
+	err = n.Notify(&Alert{Level: Critical, Title: "Limit hit"})
+	if err == nil || !strings.Contains(err.Error(), "queued for retry") {
+		t.Fatalf("Notify() error = %v, want queued for retry", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server saw %d requests, want 1: the outbox retries, not Notify", calls.Load())
+	}
+
+	queued, _ := db.ListWebhooks(caamdb.WebhookPending, 0)
+	if len(queued) != 1 || queued[0].Channel != "ops" || queued[0].Attempts != 1 || !strings.Contains(queued[0].Payload, "Limit hit") {
+		t.Fatalf("outbox = %+v", queued)
+	}
+	deliveryID := queued[0].DeliveryID
+
+	channels := map[string]Notifier{"ops": n}
+	now := time.Now()
+	if res, _ := DrainOutbox(db, channels, now); res != (DrainResult{}) {
+		t.Errorf("DrainOutbox() before the retry time = %+v, want nothing due", res)
+	}
+
+	later := now.Add(OutboxBackoff(1))
+	if res, err := DrainOutbox(db, channels, later); err != nil || res.Retrying != 1 {
+		t.Fatalf("DrainOutbox() = %+v, %v; want one retrying", res, err)
+	}
+
+	status.Store(http.StatusOK)
+	later = later.Add(OutboxBackoff(2))
+	if res, err := DrainOutbox(db, channels, later); err != nil || res.Delivered != 1 {
+		t.Fatalf("DrainOutbox() = %+v, %v; want one delivered", res, err)
+	}
+	if lastDelivery.Load() != deliveryID {
+		t.Errorf("retry used delivery id %v, want %s", lastDelivery.Load(), deliveryID)
+	}
+
+	// Once the endpoint has failed, later alerts are queued without trying.
+	calls.Store(0)
+	if err := n.Notify(&Alert{Title: "Still down"}); err == nil || !strings.Contains(err.Error(), "queued") {
+		t.Errorf("Notify() error = %v, want queued", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server saw %d requests after a failure, want 0", calls.Load())
+	}
+	if res, err := DrainOutbox(db, channels, now); err != nil || res.Delivered != 1 {
+		t.Fatalf("DrainOutbox() = %+v, %v; want the skipped alert delivered", res, err)
+	}
+
+	// Client errors are not retried, and neither are deliveries for
+	// channels that are gone.
+	n = NewWebhookNotifier(server.URL)
+	n.Channel = "ops"
+	n.Outbox = db
+	status.Store(http.StatusNotFound)
+	if err := n.Notify(&Alert{Title: "gone"}); err == nil || !strings.Contains(err.Error(), "kept as outbox delivery") {
+		t.Errorf("Notify() error = %v, want kept", err)
+	}
+	status.Store(http.StatusInternalServerError)
+	_ = n.Notify(&Alert{Title: "orphaned"})
+	if res, _ := DrainOutbox(db, map[string]Notifier{}, later.Add(time.Hour)); res.Failed != 1 {
+		t.Errorf("DrainOutbox() without the channel = %+v, want one failed", res)
+	}
+	if failed, _ := db.ListWebhooks(caamdb.WebhookFailed, 0); len(failed) != 2 {
+		t.Errorf("got %d failed deliveries, want 2", len(failed))
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 9: 256 * time.Minute, 10: 6 * time.Hour, 50: 6 * time.Hour}
+	for attempts, want := range tests {
+		if got := OutboxBackoff(attempts); got != want {
+			t.Errorf("OutboxBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
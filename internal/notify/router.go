@@ -0,0 +1,291 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+// Deduper remembers when alerts were sent, so repeats within a window can
+// be dropped. *db.DB implements it.
+type Deduper interface {
+	// AlertSent reports whether an alert with key was sent within window
+	// before now.
+	AlertSent(key string, window time.Duration, now time.Time) (bool, error)
+
+	// ClaimAlert records a delivery of an alert with key at now, unless one
+	// was already recorded within window, and reports whether it did.
+	ClaimAlert(key string, window time.Duration, now time.Time) (bool, error)
+}
+
+// MemoryDeduper is a Deduper for a single process.
+type MemoryDeduper struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func NewMemoryDeduper() *MemoryDeduper {
+	return &MemoryDeduper{sent: make(map[string]time.Time)}
+}
+
+func (d *MemoryDeduper) AlertSent(key string, window time.Duration, now time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.sent[key]
+	return ok && now.Sub(last) < window, nil
+}
+
+func (d *MemoryDeduper) ClaimAlert(key string, window time.Duration, now time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.sent[key]; ok && now.Sub(last) < window {
+		return false, nil
+	}
+	d.sent[key] = now
+	return true, nil
+}
+
+// Router delivers alerts to named channels chosen by routing rules. Repeats
+// of an alert within a route's dedup window are dropped, and so are alerts
+// below Critical during its quiet hours.
+type Router struct {
+	channels map[string]Notifier
+	routes   []route
+	dedup    Deduper
+	tags     func(provider, profile string) []string
+	now      func() time.Time
+	outbox   Outbox
+}
+
+type route struct {
+	config.NotificationRoute
+	quiet                bool
+	quietFrom, quietTill time.Duration
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithDeduper keeps dedup state in d instead of in memory, so that it is
+// shared between processes.
+func WithDeduper(d Deduper) RouterOption {
+	return func(r *Router) {
+		if d != nil {
+			r.dedup = d
+		}
+	}
+}
+
+// WithOutbox keeps failed webhook deliveries in o for later retries.
+func WithOutbox(o Outbox) RouterOption {
+	return func(r *Router) { r.outbox = o }
+}
+
+// WithTags sets how profile tags are looked up for tag conditions.
+func WithTags(tags func(provider, profile string) []string) RouterOption {
+	return func(r *Router) { r.tags = tags }
+}
+
+// WithClock sets the clock used for dedup windows and quiet hours.
+func WithClock(now func() time.Time) RouterOption {
+	return func(r *Router) { r.now = now }
+}
+
+// NewRouter returns a router over the given channels. Without routes, every
+// alert goes to every channel, subject to the config's dedup window and
+// quiet hours.
+func NewRouter(cfg config.NotificationConfig, channels map[string]Notifier, opts ...RouterOption) *Router {
+	r := &Router{
+		channels: channels,
+		dedup:    NewMemoryDeduper(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.outbox != nil {
+		for _, n := range channels {
+			if w, ok := n.(*WebhookNotifier); ok {
+				w.Outbox = r.outbox
+			}
+		}
+	}
+
+	routes := cfg.Routes
+	if len(routes) == 0 {
+		all := make([]string, 0, len(channels))
+		for name := range channels {
+			all = append(all, name)
+		}
+		sort.Strings(all)
+		routes = []config.NotificationRoute{{Name: "default", Channels: all}}
+	}
+	for i, rc := range routes {
+		if rc.Name == "" {
+			rc.Name = fmt.Sprintf("routes[%d]", i)
+		}
+		if rc.Dedup == 0 {
+			rc.Dedup = cfg.Dedup
+		}
+		if rc.QuietHours == "" {
+			rc.QuietHours = cfg.QuietHours
+		}
+		rt := route{NotificationRoute: rc}
+		if rc.QuietHours != "" && rc.QuietHours != "off" {
+			if from, till, err := config.ParseQuietHours(rc.QuietHours); err == nil {
+				rt.quiet, rt.quietFrom, rt.quietTill = true, from, till
+			}
+		}
+		r.routes = append(r.routes, rt)
+	}
+	return r
+}
+
+func (r *Router) Name() string {
+	return "router"
+}
+
+func (r *Router) Available() bool {
+	for _, n := range r.channels {
+		if n.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends an alert to the channels of every route it matches, each
+// channel at most once. A route's dedup window starts only once one of its
+// channels has delivered the alert, so a failed send is not deduplicated.
+func (r *Router) Notify(alert *Alert) error {
+	if alert == nil {
+		return nil
+	}
+	now := r.now()
+	if alert.Timestamp.IsZero() {
+		a := *alert
+		a.Timestamp = now
+		alert = &a
+	}
+
+	var errs []error
+	selected := make(map[string]bool)
+	var claims []route
+	for _, rt := range r.routes {
+		if !r.matches(rt, alert) {
+			continue
+		}
+		if rt.quiet && alert.Level < Critical && inQuietHours(now, rt.quietFrom, rt.quietTill) {
+			continue
+		}
+		if window := rt.Dedup.Duration(); window > 0 {
+			sent, err := r.dedup.AlertSent(r.dedupKey(rt, alert), window, now)
+			if err != nil {
+				// Better a repeat than a lost alert.
+				errs = append(errs, fmt.Errorf("dedup: %w", err))
+			} else if sent {
+				continue
+			}
+			claims = append(claims, rt)
+		}
+		for _, name := range rt.Channels {
+			selected[name] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	delivered := make(map[string]bool)
+	for name := range selected {
+		n, ok := r.channels[name]
+		if !ok || !n.Available() {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, n Notifier) {
+			defer wg.Done()
+			err := n.Notify(alert)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			delivered[name] = true
+		}(name, n)
+	}
+	wg.Wait()
+
+	for _, rt := range claims {
+		for _, name := range rt.Channels {
+			if !delivered[name] {
+				continue
+			}
+			if _, err := r.dedup.ClaimAlert(r.dedupKey(rt, alert), rt.Dedup.Duration(), now); err != nil {
+				errs = append(errs, fmt.Errorf("dedup: %w", err))
+			}
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Router) dedupKey(rt route, alert *Alert) string {
+	return rt.Name + "|" + alert.DedupKey()
+}
+
+func (r *Router) matches(rt route, alert *Alert) bool {
+	if len(rt.Levels) > 0 && !containsFold(rt.Levels, alert.Level.String()) {
+		return false
+	}
+	if len(rt.Providers) > 0 && !containsFold(rt.Providers, alert.Provider) {
+		return false
+	}
+	if len(rt.Profiles) > 0 && !containsFold(rt.Profiles, alert.Profile) {
+		return false
+	}
+	if len(rt.Tags) > 0 {
+		if r.tags == nil || alert.Profile == "" {
+			return false
+		}
+		found := false
+		for _, tag := range r.tags(alert.Provider, alert.Profile) {
+			if containsFold(rt.Tags, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// inQuietHours reports whether now's time of day falls in [from, till),
+// which wraps midnight if till is not after from.
+func inQuietHours(now time.Time, from, till time.Duration) bool {
+	tod := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if from < till {
+		return tod >= from && tod < till
+	}
+	return tod >= from || tod < till
+}
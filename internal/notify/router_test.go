@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+type recordingNotifier struct {
+	name   string
+	alerts []*Alert
+}
+
+func (n *recordingNotifier) Notify(alert *Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+func (n *recordingNotifier) Name() string    { return n.name }
+func (n *recordingNotifier) Available() bool { return true }
+
+func TestRouterRoutes(t *testing.T) {
+	pager := &recordingNotifier{name: "pager"}
+	chat := &recordingNotifier{name: "chat"}
+	cfg := config.NotificationConfig{
+		Routes: []config.NotificationRoute{
+			{Name: "critical", Levels: []string{"critical"}, Channels: []string{"pager", "chat"}},
+			{Name: "clients", Tags: []string{"client"}, Channels: []string{"chat"}},
+			{Name: "codex", Providers: []string{"codex"}, Profiles: []string{"ci"}, Channels: []string{"pager"}},
+		},
+	}
+	tags := func(provider, profile string) []string {
+		if profile == "acme" {
+			return []string{"Client"}
+		}
+		return nil
+	}
+	r := NewRouter(cfg, map[string]Notifier{"pager": pager, "chat": chat}, WithTags(tags))
+
+	alerts := []*Alert{
+		{Level: Critical, Title: "down", Provider: "claude", Profile: "work"},       // pager, chat (once)
+		{Level: Warning, Title: "near limit", Provider: "claude", Profile: "acme"},  // chat
+		{Level: Critical, Title: "near limit", Provider: "claude", Profile: "acme"}, // pager, chat (once)
+		{Level: Info, Title: "switched", Provider: "codex", Profile: "ci"},          // pager
+		{Level: Info, Title: "switched", Provider: "codex", Profile: "dev"},         // nowhere
+	}
+	for _, a := range alerts {
+		if err := r.Notify(a); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+	if len(pager.alerts) != 3 || len(chat.alerts) != 3 {
+		t.Errorf("pager got %d alerts, chat got %d; want 3 and 3", len(pager.alerts), len(chat.alerts))
+	}
+	if chat.alerts[1].Title != "near limit" || chat.alerts[1].Level != Warning {
+		t.Errorf("chat.alerts[1] = %+v, want the warning for acme", chat.alerts[1])
+	}
+	if chat.alerts[0].Timestamp.IsZero() {
+		t.Error("router should timestamp alerts")
+	}
+}
+
+func TestRouterDedupAndQuietHours(t *testing.T) {
+	ch := &recordingNotifier{name: "push"}
+	now := time.Date(2026, 1, 15, 21, 0, 0, 0, time.UTC)
+	cfg := config.NotificationConfig{
+		Dedup:      config.Duration(time.Hour),
+		QuietHours: "22:00-07:00",
+	}
+	r := NewRouter(cfg, map[string]Notifier{"push": ch}, WithClock(func() time.Time { return now }))
+
+	near := &Alert{Level: Warning, Title: "Rate limit approaching", Provider: "claude", Profile: "work"}
+	send := func(a *Alert, at time.Time) {
+		t.Helper()
+		now = at
+		if err := r.Notify(a); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	send(near, now)                     // Sent
+	send(near, now.Add(10*time.Minute)) // Repeat within the window
+	other := *near
+	other.Profile = "personal"
+	send(&other, now.Add(20*time.Minute)) // Different key
+	if len(ch.alerts) != 2 {
+		t.Fatalf("got %d alerts within the dedup window, want 2", len(ch.alerts))
+	}
+
+	night := time.Date(2026, 1, 15, 23, 30, 0, 0, time.UTC)
+	send(near, night) // Quiet hours
+	send(&Alert{Level: Critical, Title: "All profiles low"}, night)
+	if len(ch.alerts) != 3 || ch.alerts[2].Level != Critical {
+		t.Fatalf("quiet hours: got %d alerts, want only the critical one added", len(ch.alerts))
+	}
+
+	// Warnings are delivered again once quiet hours end.
+	send(near, time.Date(2026, 1, 16, 7, 0, 0, 0, time.UTC))
+	if len(ch.alerts) != 4 {
+		t.Errorf("got %d alerts after quiet hours, want 4", len(ch.alerts))
+	}
+
+	// A route can lift the default quiet hours.
+	cfg.Routes = []config.NotificationRoute{{Channels: []string{"push"}, QuietHours: "off"}}
+	r = NewRouter(cfg, map[string]Notifier{"push": ch}, WithClock(func() time.Time { return now }))
+	send(&Alert{Level: Info, Title: "Profile switched"}, night)
+	if len(ch.alerts) != 5 {
+		t.Errorf("got %d alerts with quiet hours off, want 5", len(ch.alerts))
+	}
+}
+
+type failingNotifier struct {
+	recordingNotifier
+	fail bool
+}
+
+func (n *failingNotifier) Notify(alert *Alert) error {
+	if n.fail {
+		return errors.New("unreachable")
+	}
+	return n.recordingNotifier.Notify(alert)
+}
+
+func TestRouterDedupClaimsOnlyDelivered(t *testing.T) {
+	ch := &failingNotifier{recordingNotifier: recordingNotifier{name: "push"}, fail: true}
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	cfg := config.NotificationConfig{Dedup: config.Duration(time.Hour)}
+	r := NewRouter(cfg, map[string]Notifier{"push": ch}, WithClock(func() time.Time { return now }))
+
+	near := &Alert{Level: Warning, Title: "Rate limit approaching", Provider: "claude", Profile: "work"}
+	if err := r.Notify(near); err == nil {
+		t.Fatal("Notify() error = nil, want the channel's error")
+	}
+
+	// The failed send does not start the dedup window.
+	ch.fail = false
+	now = now.Add(time.Minute)
+	if err := r.Notify(near); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := r.Notify(near); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(ch.alerts) != 1 {
+		t.Errorf("got %d alerts, want 1 after the failed send", len(ch.alerts))
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 1, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		from, till time.Duration
+		now        time.Time
+		want       bool
+	}{
+		{22 * time.Hour, 7 * time.Hour, at(23, 0), true},
+		{22 * time.Hour, 7 * time.Hour, at(6, 59), true},
+		{22 * time.Hour, 7 * time.Hour, at(7, 0), false},
+		{12 * time.Hour, 13 * time.Hour, at(12, 30), true},
+		{12 * time.Hour, 13 * time.Hour, at(21, 0), false},
+	}
+	for _, tt := range tests {
+		if got := inQuietHours(tt.now, tt.from, tt.till); got != tt.want {
+			t.Errorf("inQuietHours(%v, %v-%v) = %v, want %v", tt.now.Format("15:04"), tt.from, tt.till, got, tt.want)
+		}
+	}
+}
+
+func TestChannelsFromConfig(t *testing.T) {
+	cfg := config.NotificationConfig{
+		Desktop: true,
+		Webhook: "https://example.com/hook",
+		Channels: map[string]config.ChannelConfig{
+			"phone": {Type: "ntfy", URL: "https://ntfy.sh/caam"},
+			"ops":   {Type: "slack", URL: "https://hooks.slack.com/x"},
+			"mail":  {Type: "email", SMTP: "localhost:25", From: "caam@example.com", To: []string{"me@example.com"}},
+			"hook":  {Type: "exec", Command: []string{"/bin/true"}},
+			"tty":   {Type: "terminal"},
+		},
+	}
+	channels := Channels(cfg, nil)
+	var names []string
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"desktop", "hook", "mail", "ops", "phone", "webhook"}
+	if len(names) != len(want) {
+		t.Fatalf("Channels() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Channels() = %v, want %v", names, want)
+		}
+	}
+	if channels["phone"].Name() != "ntfy" || channels["mail"].Name() != "email" {
+		t.Errorf("unexpected channel types: %s, %s", channels["phone"].Name(), channels["mail"].Name())
+	}
+}
@@ -2,17 +2,62 @@ package notify
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
+// Webhook request headers. When a secret is configured, X-Caam-Signature is
+// "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and the
+// body. Receivers should recompute it and reject stale timestamps.
+const (
+	HeaderDelivery  = "X-Caam-Delivery"
+	HeaderTimestamp = "X-Caam-Timestamp"
+	HeaderSignature = "X-Caam-Signature"
+)
+
+// Outbox persists webhook deliveries that failed, so they can be retried
+// later. *db.DB implements it.
+type Outbox interface {
+	EnqueueWebhook(channel, url, deliveryID string, payload []byte, now time.Time) (int64, error)
+	MarkWebhookFailed(id int64, errMsg string, next time.Time, giveUp bool) error
+}
+
 // WebhookNotifier delivers alerts via HTTP POST.
 type WebhookNotifier struct {
 	URL     string
 	Timeout time.Duration
 	Headers map[string]string
+
+	// Template, if set, renders the payload's message field.
+	Template *template.Template
+
+	// Secret, if set, signs each request.
+	Secret string
+
+	// Retries is how many times a failed request is retried, waiting
+	// Backoff and then twice as long each time. With an Outbox, Notify does
+	// not retry in process.
+	Retries int
+	Backoff time.Duration
+
+	// Outbox, if set, keeps deliveries that fail for later retries (by the
+	// daemon), under the name Channel. A failed request is queued at once
+	// rather than retried, and once one has failed with an error worth
+	// retrying, later alerts are queued without trying, so callers are not
+	// held up by an unreachable endpoint.
+	Outbox  Outbox
+	Channel string
+
+	down atomic.Bool
 }
 
 func NewWebhookNotifier(url string) *WebhookNotifier {
@@ -20,6 +65,9 @@ func NewWebhookNotifier(url string) *WebhookNotifier {
 		URL:     url,
 		Timeout: 5 * time.Second,
 		Headers: make(map[string]string),
+		Retries: 3,
+		Backoff: 500 * time.Millisecond,
+		Channel: "webhook",
 	}
 }
 
@@ -36,10 +84,20 @@ func (n *WebhookNotifier) Notify(alert *Alert) error {
 		return nil
 	}
 
+	message := alert.Message
+	if n.Template != nil {
+		text, err := Text(alert, n.Template)
+		if err != nil {
+			return err
+		}
+		message = text
+	}
+
 	payload := map[string]interface{}{
 		"level":     alert.Level.String(),
 		"title":     alert.Title,
-		"message":   alert.Message,
+		"message":   message,
+		"provider":  alert.Provider,
 		"profile":   alert.Profile,
 		"timestamp": alert.Timestamp.Format(time.RFC3339),
 		"action":    alert.Action,
@@ -50,15 +108,73 @@ func (n *WebhookNotifier) Notify(alert *Alert) error {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", n.URL, bytes.NewBuffer(body))
+	deliveryID := newDeliveryID()
+	if n.Outbox == nil {
+		_, err := n.Send(deliveryID, body)
+		return err
+	}
+
+	now := time.Now()
+	if n.down.Load() {
+		id, err := n.Outbox.EnqueueWebhook(n.Channel, n.URL, deliveryID, body, now)
+		if err != nil {
+			return fmt.Errorf("webhook unreachable; not queued: %w", err)
+		}
+		return fmt.Errorf("webhook unreachable; queued as outbox delivery %d", id)
+	}
+
+	retry, err := n.Post(deliveryID, body)
+	if err == nil {
+		return nil
+	}
+	if retry {
+		n.down.Store(true)
+	}
+	id, qerr := n.Outbox.EnqueueWebhook(n.Channel, n.URL, deliveryID, body, now)
+	if qerr != nil {
+		return fmt.Errorf("%w (not queued: %v)", err, qerr)
+	}
+	if qerr := n.Outbox.MarkWebhookFailed(id, err.Error(), now.Add(OutboxBackoff(1)), !retry); qerr != nil {
+		return fmt.Errorf("%w (not queued: %v)", err, qerr)
+	}
+	if !retry {
+		return fmt.Errorf("%w (kept as outbox delivery %d)", err, id)
+	}
+	return fmt.Errorf("%w (queued for retry as outbox delivery %d)", err, id)
+}
+
+// Send posts body, retrying transient failures. If it fails, retry reports
+// whether a later attempt might succeed.
+func (n *WebhookNotifier) Send(deliveryID string, body []byte) (retry bool, err error) {
+	wait := n.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err = n.Post(deliveryID, body)
+		if err == nil || !retry || attempt >= n.Retries {
+			return retry, err
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// Post makes a single delivery attempt. Network errors, 5xx responses,
+// 408 and 429 are reported as worth retrying; other failures are not.
+func (n *WebhookNotifier) Post(deliveryID string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest("POST", n.URL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return false, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	for k, v := range n.Headers {
 		req.Header.Set(k, v)
 	}
+	ts := time.Now().Unix()
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	if n.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(n.Secret, ts, body))
+	}
 
 	client := &http.Client{
 		Timeout: n.Timeout,
@@ -66,13 +182,32 @@ func (n *WebhookNotifier) Notify(alert *Alert) error {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return true, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook failed with status: %s", resp.Status)
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook failed with status: %s", resp.Status)
 	}
 
-	return nil
+	return false, nil
+}
+
+// Sign returns the X-Caam-Signature value for a request body sent at
+// timestamp (Unix seconds).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
 }
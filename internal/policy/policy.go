@@ -0,0 +1,462 @@
+// Package policy implements rule-based profile selection.
+//
+// A policy file holds an ordered list of rules such as "between 09:00 and
+// 18:00 on weekdays prefer tag:work" or "never use tag:personal inside
+// ~/clients/*". Rules are evaluated before rotation scoring, so every
+// command that picks a profile (activate --auto, run, next, the auth agent)
+// narrows its candidates the same way and can explain why.
+//
+// The file lives at ~/.caam/policy.yaml:
+//
+//	rules:
+//	  - name: work-hours
+//	    when:
+//	      days: [weekdays]
+//	      hours: "09:00-18:00"
+//	    prefer: tag:work
+//	  - name: no-personal-for-clients
+//	    when:
+//	      dir: ~/clients/*
+//	    deny: tag:personal
+//	  - name: opus-on-max
+//	    when:
+//	      model: opus
+//	    prefer: plan:max
+//
+// Deny rules are applied first and remove matching profiles outright.
+// Prefer rules are then applied in file order; each narrows the candidates
+// to the profiles it matches, unless it matches none of them. Rotation only
+// hands over profiles that are out of cooldown and under their caps (when
+// there are any), so a prefer rule whose profiles cannot be used falls back
+// to the rest.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+)
+
+// DefaultPath returns the policy file path (~/.caam/policy.yaml).
+func DefaultPath() string {
+	if caamHome := os.Getenv("CAAM_HOME"); caamHome != "" {
+		return filepath.Join(caamHome, "policy.yaml")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".caam", "policy.yaml")
+	}
+	return filepath.Join(homeDir, ".caam", "policy.yaml")
+}
+
+// Policy is an ordered set of selection rules.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule narrows profile selection when all of its conditions hold.
+type Rule struct {
+	Name   string    `yaml:"name"`
+	When   Condition `yaml:"when"`
+	Prefer List      `yaml:"prefer,omitempty"` // Narrow to matching profiles
+	Deny   List      `yaml:"deny,omitempty"`   // Never use matching profiles
+}
+
+// Condition describes when a rule applies. Empty fields always match.
+type Condition struct {
+	Providers List   `yaml:"provider,omitempty"` // Tool names
+	Days      List   `yaml:"days,omitempty"`     // mon..sun, weekdays, weekends
+	Hours     string `yaml:"hours,omitempty"`    // "HH:MM-HH:MM", may wrap midnight
+	Dirs      List   `yaml:"dir,omitempty"`      // Directory globs; ~ is expanded
+	Models    List   `yaml:"model,omitempty"`    // Substring or glob of the model
+}
+
+// List is a YAML value that may be written as a scalar or a sequence.
+type List []string
+
+// UnmarshalYAML accepts both "a" and ["a", "b"].
+func (l *List) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = List{value.Value}
+		return nil
+	}
+	var items []string
+	if err := value.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// Profile is what rules can match against.
+type Profile struct {
+	Name  string
+	Tags  []string
+	Plans []string // Plan names the profile is known by, e.g. "max" and "pro"
+}
+
+// Context is the situation a profile is being selected for.
+type Context struct {
+	Provider string
+	Dir      string // Working directory; dir conditions never match when empty
+	Model    string // Requested model; model conditions never match when empty
+	Now      time.Time
+}
+
+// Step records how one rule was evaluated.
+type Step struct {
+	Rule    string `json:"rule"`
+	Matched bool   `json:"matched"`
+	Effect  string `json:"effect"`
+}
+
+// Decision is the outcome of evaluating a policy.
+type Decision struct {
+	// Profiles are the remaining candidates, in input order.
+	Profiles []string `json:"profiles"`
+
+	// Denied maps each removed profile to the rule that denied it.
+	Denied map[string]string `json:"denied,omitempty"`
+
+	// Steps lists every rule in evaluation order.
+	Steps []Step `json:"steps"`
+}
+
+// Load reads a policy file. A missing file yields an empty policy.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Policy{}, nil
+		}
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates policy YAML.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks every rule and fills in missing rule names.
+func (p *Policy) Validate() error {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if len(r.Prefer) == 0 && len(r.Deny) == 0 {
+			return fmt.Errorf("rule %s: needs prefer or deny", r.Name)
+		}
+		for _, sel := range append(append(List{}, r.Prefer...), r.Deny...) {
+			if _, _, err := parseSelector(sel); err != nil {
+				return fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+		}
+		for _, d := range r.When.Days {
+			if _, ok := dayNames[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("rule %s: unknown day %q", r.Name, d)
+			}
+		}
+		if r.When.Hours != "" {
+			if _, _, err := config.ParseQuietHours(r.When.Hours); err != nil {
+				return fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+		}
+		for _, d := range r.When.Dirs {
+			if _, err := path.Match(d, ""); err != nil {
+				return fmt.Errorf("rule %s: bad dir pattern %q", r.Name, d)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate applies the policy to candidate profiles.
+func (p *Policy) Evaluate(ctx Context, profiles []Profile) *Decision {
+	d := &Decision{Denied: make(map[string]string)}
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+
+	var rules []Rule
+	if p != nil {
+		rules = p.Rules
+	}
+
+	matched := make([]bool, len(rules))
+	for i, r := range rules {
+		matched[i] = r.When.matches(ctx)
+	}
+
+	// Deny rules first, so a prefer rule never narrows to profiles that are
+	// later removed.
+	remaining := profiles
+	for i, r := range rules {
+		if len(r.Deny) == 0 {
+			continue
+		}
+		if !matched[i] {
+			d.Steps = append(d.Steps, Step{Rule: r.Name, Effect: "conditions not met"})
+			continue
+		}
+		var kept []Profile
+		var denied []string
+		for _, prof := range remaining {
+			if r.Deny.matches(prof) {
+				denied = append(denied, prof.Name)
+				d.Denied[prof.Name] = r.Name
+				continue
+			}
+			kept = append(kept, prof)
+		}
+		remaining = kept
+		effect := "denied no profiles"
+		if len(denied) > 0 {
+			effect = "denied " + strings.Join(denied, ", ")
+		}
+		d.Steps = append(d.Steps, Step{Rule: r.Name, Matched: true, Effect: effect})
+	}
+
+	for i, r := range rules {
+		if len(r.Prefer) == 0 {
+			continue
+		}
+		if !matched[i] {
+			d.Steps = append(d.Steps, Step{Rule: r.Name, Effect: "conditions not met"})
+			continue
+		}
+		var preferred []Profile
+		for _, prof := range remaining {
+			if r.Prefer.matches(prof) {
+				preferred = append(preferred, prof)
+			}
+		}
+		if len(preferred) == 0 {
+			d.Steps = append(d.Steps, Step{Rule: r.Name, Matched: true, Effect: "no candidate matches " + strings.Join(r.Prefer, ", ")})
+			continue
+		}
+		remaining = preferred
+		d.Steps = append(d.Steps, Step{Rule: r.Name, Matched: true, Effect: "preferred " + strings.Join(names(preferred), ", ")})
+	}
+
+	d.Profiles = names(remaining)
+	return d
+}
+
+func names(profiles []Profile) []string {
+	out := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+// Format renders a decision for humans.
+func Format(d *Decision) string {
+	if d == nil || len(d.Steps) == 0 {
+		return "No selection policy rules"
+	}
+	var sb strings.Builder
+	sb.WriteString("Policy:\n")
+	for _, s := range d.Steps {
+		marker := " "
+		if s.Matched {
+			marker = "*"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s: %s\n", marker, s.Rule, s.Effect))
+	}
+	if len(d.Profiles) == 0 {
+		sb.WriteString("  => no profiles allowed")
+	} else {
+		sb.WriteString("  => candidates: " + strings.Join(d.Profiles, ", "))
+	}
+	return sb.String()
+}
+
+// matches reports whether a profile matches any selector in the list.
+func (l List) matches(p Profile) bool {
+	for _, sel := range l {
+		kind, value, err := parseSelector(sel)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case "tag":
+			for _, t := range p.Tags {
+				if strings.EqualFold(t, value) {
+					return true
+				}
+			}
+		case "plan":
+			if containsFold(p.Plans, value) {
+				return true
+			}
+		case "name":
+			if ok, _ := path.Match(value, p.Name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSelector splits "tag:work", "plan:max" or "name:glob". A bare value
+// is a profile name glob.
+func parseSelector(sel string) (kind, value string, err error) {
+	sel = strings.TrimSpace(sel)
+	kind, value, found := strings.Cut(sel, ":")
+	if !found {
+		kind, value = "name", sel
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", fmt.Errorf("empty selector %q", sel)
+	}
+	switch kind {
+	case "tag", "plan":
+	case "name":
+		if _, err := path.Match(value, ""); err != nil {
+			return "", "", fmt.Errorf("bad name pattern %q", value)
+		}
+	default:
+		return "", "", fmt.Errorf("unknown selector %q (use tag:, plan: or name:)", sel)
+	}
+	return kind, value, nil
+}
+
+var dayNames = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+func (c Condition) matches(ctx Context) bool {
+	if len(c.Providers) > 0 && !containsFold(c.Providers, ctx.Provider) {
+		return false
+	}
+
+	if len(c.Days) > 0 {
+		ok := false
+		for _, d := range c.Days {
+			for _, wd := range dayNames[strings.ToLower(d)] {
+				if ctx.Now.Weekday() == wd {
+					ok = true
+				}
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if c.Hours != "" {
+		start, end, err := config.ParseQuietHours(c.Hours)
+		if err != nil {
+			return false
+		}
+		now := time.Duration(ctx.Now.Hour())*time.Hour + time.Duration(ctx.Now.Minute())*time.Minute
+		var in bool
+		if start <= end {
+			in = now >= start && now < end
+		} else {
+			in = now >= start || now < end
+		}
+		if !in {
+			return false
+		}
+	}
+
+	if len(c.Dirs) > 0 {
+		if ctx.Dir == "" {
+			return false
+		}
+		ok := false
+		for _, pattern := range c.Dirs {
+			if dirMatches(expandHome(pattern), ctx.Dir) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(c.Models) > 0 {
+		if ctx.Model == "" {
+			return false
+		}
+		ok := false
+		model := strings.ToLower(ctx.Model)
+		for _, pattern := range c.Models {
+			pattern = strings.ToLower(pattern)
+			if strings.ContainsAny(pattern, "*?[") {
+				ok, _ = path.Match(pattern, model)
+			} else {
+				ok = strings.Contains(model, pattern)
+			}
+			if ok {
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dirMatches reports whether dir or one of its parents matches pattern.
+func dirMatches(pattern, dir string) bool {
+	pattern = filepath.Clean(pattern)
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if ok, _ := filepath.Match(pattern, d); ok {
+			return true
+		}
+		if parent := filepath.Dir(d); parent == d {
+			return false
+		}
+	}
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const examplePolicy = `
+rules:
+  - name: work-hours
+    when:
+      days: [weekdays]
+      hours: "09:00-18:00"
+    prefer: tag:work
+  - name: no-personal-for-clients
+    when:
+      dir: /home/u/clients/*
+    deny: tag:personal
+  - name: opus-on-max
+    when:
+      provider: claude
+      model: opus
+    prefer: [plan:max]
+`
+
+var testProfiles = []Profile{
+	{Name: "alice", Tags: []string{"work"}, Plans: []string{"pro"}},
+	{Name: "bob", Tags: []string{"personal"}, Plans: []string{"max", "pro"}},
+	{Name: "carol", Tags: []string{"work"}, Plans: []string{"max", "pro"}},
+}
+
+// monday returns a Monday at the given local clock time.
+func monday(hour, minute int) time.Time {
+	return time.Date(2026, 1, 5, hour, minute, 0, 0, time.Local)
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(examplePolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(p.Rules) != 3 {
+		t.Fatalf("len(Rules) = %d, want 3", len(p.Rules))
+	}
+	if got := p.Rules[2].Prefer; !reflect.DeepEqual(got, List{"plan:max"}) {
+		t.Errorf("Prefer = %v", got)
+	}
+	if got := p.Rules[1].When.Dirs; !reflect.DeepEqual(got, List{"/home/u/clients/*"}) {
+		t.Errorf("Dirs = %v", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no action":    "rules:\n  - name: x\n    when: {days: [mon]}\n",
+		"bad selector": "rules:\n  - prefer: color:blue\n",
+		"bad day":      "rules:\n  - when: {days: [funday]}\n    prefer: tag:x\n",
+		"bad hours":    "rules:\n  - when: {hours: \"9-5\"}\n    prefer: tag:x\n",
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(doc)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), "policy.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(p.Rules) != 0 {
+		t.Errorf("expected empty policy, got %d rules", len(p.Rules))
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(examplePolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  Context
+		want []string
+	}{
+		{
+			name: "work hours prefer work",
+			ctx:  Context{Provider: "claude", Now: monday(10, 0)},
+			want: []string{"alice", "carol"},
+		},
+		{
+			name: "evening keeps everyone",
+			ctx:  Context{Provider: "claude", Now: monday(20, 0)},
+			want: []string{"alice", "bob", "carol"},
+		},
+		{
+			name: "weekend keeps everyone",
+			ctx:  Context{Provider: "claude", Now: monday(10, 0).AddDate(0, 0, 5)},
+			want: []string{"alice", "bob", "carol"},
+		},
+		{
+			name: "client dir denies personal",
+			ctx:  Context{Provider: "claude", Dir: "/home/u/clients/acme/src", Now: monday(20, 0)},
+			want: []string{"alice", "carol"},
+		},
+		{
+			name: "opus narrows work profiles to max",
+			ctx:  Context{Provider: "claude", Model: "claude-opus-4", Now: monday(10, 0)},
+			want: []string{"carol"},
+		},
+		{
+			name: "opus rule is claude only",
+			ctx:  Context{Provider: "codex", Model: "opus", Now: monday(20, 0)},
+			want: []string{"alice", "bob", "carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(tt.ctx, testProfiles)
+			if !reflect.DeepEqual(d.Profiles, tt.want) {
+				t.Errorf("Profiles = %v, want %v\n%s", d.Profiles, tt.want, Format(d))
+			}
+		})
+	}
+}
+
+func TestEvaluate_DenyBeforePrefer(t *testing.T) {
+	p, err := Parse([]byte(`
+rules:
+  - name: prefer-max
+    prefer: plan:max
+  - name: no-bob
+    deny: bob
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	d := p.Evaluate(Context{Provider: "claude"}, testProfiles)
+	if !reflect.DeepEqual(d.Profiles, []string{"carol"}) {
+		t.Errorf("Profiles = %v, want [carol]", d.Profiles)
+	}
+	if d.Denied["bob"] != "no-bob" {
+		t.Errorf("Denied = %v", d.Denied)
+	}
+	if len(d.Steps) != 2 || d.Steps[0].Rule != "no-bob" {
+		t.Errorf("Steps = %+v, want deny rule first", d.Steps)
+	}
+}
+
+func TestEvaluate_PreferWithoutMatchIsNoop(t *testing.T) {
+	p, err := Parse([]byte("rules:\n  - prefer: tag:nobody\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	d := p.Evaluate(Context{Provider: "claude"}, testProfiles)
+	if len(d.Profiles) != 3 {
+		t.Errorf("Profiles = %v, want all", d.Profiles)
+	}
+	if !strings.Contains(Format(d), "no candidate matches tag:nobody") {
+		t.Errorf("Format() = %q", Format(d))
+	}
+}
+
+func TestConditionHours_WrapsMidnight(t *testing.T) {
+	c := Condition{Hours: "22:00-06:00"}
+	for hour, want := range map[int]bool{23: true, 3: true, 6: false, 12: false, 22: true} {
+		if got := c.matches(Context{Now: monday(hour, 0)}); got != want {
+			t.Errorf("hour %d: matches = %v, want %v", hour, got, want)
+		}
+	}
+}
+
+func TestConditionDir_ExpandsHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	c := Condition{Dirs: List{"~/clients/*"}}
+	if !c.matches(Context{Dir: filepath.Join(home, "clients", "acme", "api")}) {
+		t.Error("expected nested client directory to match")
+	}
+	if c.matches(Context{Dir: filepath.Join(home, "personal")}) {
+		t.Error("expected other directory not to match")
+	}
+	if c.matches(Context{}) {
+		t.Error("expected empty directory not to match")
+	}
+}
@@ -163,6 +163,7 @@ func ToNotifyAlert(a *Alert) *notify.Alert {
 		Level:     level,
 		Title:     title,
 		Message:   a.Message,
+		Provider:  a.Provider,
 		Profile:   a.Profile,
 		Action:    a.SuggestedAction,
 		Timestamp: time.Now(),
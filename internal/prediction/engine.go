@@ -6,6 +6,7 @@ package prediction
 import (
 	"context"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
@@ -56,6 +57,15 @@ type Prediction struct {
 	// TimeToDepletion is the duration until depletion.
 	TimeToDepletion time.Duration `json:"time_to_depletion"`
 
+	// EarliestTime and LatestTime bound PredictedTime with an 80% confidence
+	// interval. LatestTime is zero if the quota may never run out.
+	EarliestTime time.Time `json:"earliest_time,omitempty"`
+	LatestTime   time.Time `json:"latest_time,omitempty"`
+
+	// Seasonal is true when the profile's learned hour-of-week usage
+	// pattern shaped the forecast.
+	Seasonal bool `json:"seasonal,omitempty"`
+
 	// Confidence is how reliable the prediction is (0-1).
 	Confidence float64 `json:"confidence"`
 
@@ -63,7 +73,7 @@ type Prediction struct {
 	Warning WarningLevel `json:"warning"`
 
 	// DataSources lists where burn rate data came from.
-	// Values: "session", "logs", "api"
+	// Values: "session", "logs", "api", "history"
 	DataSources []string `json:"data_sources"`
 
 	// Error contains any error message from prediction.
@@ -88,11 +98,32 @@ type PredictionEngine struct {
 	logScanner     logs.Scanner
 	sessionTracker *usage.SessionTracker
 
+	// Stored usage history for seasonality (may be nil)
+	history         HistorySource
+	historyLookback time.Duration
+	historyMu       sync.Mutex
+	historyCache    map[string][]*logs.LogEntry // By provider
+
 	// Configuration
 	logWindow     time.Duration // How far back to look in logs
 	sessionWindow time.Duration // Window for session burn rate
+	location      *time.Location
+	now           func() time.Time
 }
 
+const (
+	// DefaultHistoryLookback is how much history seasonality is learned from.
+	DefaultHistoryLookback = 28 * 24 * time.Hour
+
+	// liveRateDecay is how quickly a forecast moves from the live burn rate
+	// to the seasonal pattern: bursts fade, and quiet spells end, on about
+	// this time scale.
+	liveRateDecay = time.Hour
+
+	// seasonalHorizon is how far ahead seasonal forecasts are integrated.
+	seasonalHorizon = 7 * 24 * time.Hour
+)
+
 // EngineOption configures a PredictionEngine.
 type EngineOption func(*PredictionEngine)
 
@@ -124,11 +155,41 @@ func WithSessionWindow(d time.Duration) EngineOption {
 	}
 }
 
+// WithHistory sets the stored usage from which each profile's hour-of-week
+// usage pattern is learned, looking back the given duration (0 for
+// DefaultHistoryLookback).
+func WithHistory(h HistorySource, lookback time.Duration) EngineOption {
+	return func(e *PredictionEngine) {
+		e.history = h
+		if lookback > 0 {
+			e.historyLookback = lookback
+		}
+	}
+}
+
+// WithLocation sets the time zone usage patterns are learned in
+// (default: local time).
+func WithLocation(loc *time.Location) EngineOption {
+	return func(e *PredictionEngine) {
+		e.location = loc
+	}
+}
+
+// WithClock sets the engine's clock. Useful for testing.
+func WithClock(now func() time.Time) EngineOption {
+	return func(e *PredictionEngine) {
+		e.now = now
+	}
+}
+
 // NewPredictionEngine creates a new prediction engine.
 func NewPredictionEngine(opts ...EngineOption) *PredictionEngine {
 	e := &PredictionEngine{
-		logWindow:     2 * time.Hour,    // Default: look back 2 hours
-		sessionWindow: 30 * time.Minute, // Default: 30 min window for session
+		logWindow:       2 * time.Hour,    // Default: look back 2 hours
+		sessionWindow:   30 * time.Minute, // Default: 30 min window for session
+		historyLookback: DefaultHistoryLookback,
+		location:        time.Local,
+		now:             time.Now,
 	}
 
 	for _, opt := range opts {
@@ -173,7 +234,7 @@ func (e *PredictionEngine) Predict(ctx context.Context, usageInfo *usage.UsageIn
 	// Already at limit?
 	if pred.CurrentPercent >= 100 {
 		pred.Warning = WarningImminent
-		pred.PredictedTime = time.Now()
+		pred.PredictedTime = e.now()
 		pred.TimeToDepletion = 0
 		pred.DataSources = append(pred.DataSources, "current_usage")
 		pred.Confidence = 1.0 // Certain - already depleted
@@ -194,7 +255,7 @@ func (e *PredictionEngine) Predict(ctx context.Context, usageInfo *usage.UsageIn
 
 	// Priority 2: Historical log data
 	if burnRate == nil && e.logScanner != nil {
-		if result, err := e.logScanner.Scan(ctx, "", time.Now().Add(-e.logWindow)); err == nil && len(result.Entries) > 0 {
+		if result, err := e.logScanner.Scan(ctx, "", e.now().Add(-e.logWindow)); err == nil && len(result.Entries) > 0 {
 			logRate := usage.CalculateBurnRate(result.Entries, e.logWindow, nil)
 			if logRate != nil && logRate.PercentPerHour > 0 {
 				burnRate = logRate
@@ -217,16 +278,23 @@ func (e *PredictionEngine) Predict(ctx context.Context, usageInfo *usage.UsageIn
 
 	pred.BurnRate = burnRate
 
-	// Calculate time to depletion
-	remainingPercent := 100.0 - pred.CurrentPercent
-	hoursUntilDepletion := remainingPercent / burnRate.PercentPerHour
-	pred.TimeToDepletion = time.Duration(hoursUntilDepletion * float64(time.Hour))
-	pred.PredictedTime = time.Now().Add(pred.TimeToDepletion)
+	// Calculate time to depletion, following the profile's usual pattern
+	// when its history is known
+	now := e.now()
+	if seasonal, scale := e.seasonality(usageInfo, window, burnRate, pred.CurrentPercent, now); seasonal != nil {
+		pred.Seasonal = true
+		pred.DataSources = append(pred.DataSources, "history")
+		e.forecastSeasonal(pred, burnRate, seasonal, scale, now)
+	} else {
+		e.forecastLinear(pred, burnRate, now)
+	}
 
 	// Determine warning level
 	// We only warn if depletion happens BEFORE the window resets.
 	// If the window resets first, we are safe (quota is restored).
-	if !window.ResetsAt.IsZero() && window.ResetsAt.Before(pred.PredictedTime) {
+	if pred.PredictedTime.IsZero() {
+		pred.Warning = WarningNone
+	} else if !window.ResetsAt.IsZero() && window.ResetsAt.Before(pred.PredictedTime) {
 		pred.Warning = WarningNone
 	} else if pred.TimeToDepletion > 0 && pred.TimeToDepletion < 10*time.Minute {
 		pred.Warning = WarningImminent
@@ -281,15 +349,13 @@ func (e *PredictionEngine) PredictWithBurnRate(usageInfo *usage.UsageInfo, burnR
 	remainingPercent := 100.0 - pred.CurrentPercent
 	if remainingPercent <= 0 {
 		pred.Warning = WarningImminent
-		pred.PredictedTime = time.Now()
+		pred.PredictedTime = e.now()
 		pred.TimeToDepletion = 0
 		pred.Confidence = 1.0 // Certain - already depleted
 		return pred
 	}
 
-	hoursUntilDepletion := remainingPercent / burnRate.PercentPerHour
-	pred.TimeToDepletion = time.Duration(hoursUntilDepletion * float64(time.Hour))
-	pred.PredictedTime = time.Now().Add(pred.TimeToDepletion)
+	e.forecastLinear(pred, burnRate, e.now())
 
 	// Warning level
 	if !window.ResetsAt.IsZero() && window.ResetsAt.Before(pred.PredictedTime) {
@@ -307,6 +373,131 @@ func (e *PredictionEngine) PredictWithBurnRate(usageInfo *usage.UsageInfo, burnR
 	return pred
 }
 
+// rateUncertainty is the relative error assumed for a burn rate when
+// bounding forecasts.
+func rateUncertainty(burnRate *usage.BurnRateInfo) float64 {
+	return math.Max(0.1, math.Min(0.5, 1-burnRate.Confidence))
+}
+
+// forecastLinear extrapolates the burn rate as constant.
+func (e *PredictionEngine) forecastLinear(pred *Prediction, burnRate *usage.BurnRateInfo, now time.Time) {
+	remaining := 100.0 - pred.CurrentPercent
+	at := func(rate float64) time.Time {
+		return now.Add(time.Duration(remaining / rate * float64(time.Hour)))
+	}
+
+	u := rateUncertainty(burnRate)
+	pred.PredictedTime = at(burnRate.PercentPerHour)
+	pred.TimeToDepletion = pred.PredictedTime.Sub(now)
+	pred.EarliestTime = at(burnRate.PercentPerHour * (1 + u))
+	pred.LatestTime = at(burnRate.PercentPerHour * (1 - u))
+}
+
+// forecastSeasonal integrates a rate that starts at the live burn rate and
+// relaxes towards the profile's usual usage for each upcoming hour. The
+// interval combines the burn rate's uncertainty with the spread of past
+// usage in each hour.
+func (e *PredictionEngine) forecastSeasonal(pred *Prediction, burnRate *usage.BurnRateInfo, seasonal *Seasonality, scale float64, now time.Time) {
+	remaining := 100.0 - pred.CurrentPercent
+	u := rateUncertainty(burnRate)
+
+	path := func(z float64) depletionPath {
+		live := burnRate.PercentPerHour * (1 + z/intervalZ*u)
+		return func(t time.Time) float64 {
+			w := math.Exp(-float64(t.Sub(now)) / float64(liveRateDecay))
+			mean, stddev := seasonal.Expected(t)
+			return w*live + (1-w)*math.Max(0, mean+z*stddev)*scale
+		}
+	}
+	tail := seasonal.Average() * scale
+	at := func(z float64) time.Time {
+		d := timeToDeplete(now, remaining, path(z), seasonalHorizon, tail)
+		if d <= 0 {
+			return time.Time{}
+		}
+		return now.Add(d)
+	}
+
+	pred.PredictedTime = at(0)
+	if !pred.PredictedTime.IsZero() {
+		pred.TimeToDepletion = pred.PredictedTime.Sub(now)
+	}
+	pred.EarliestTime = at(intervalZ)
+	pred.LatestTime = at(-intervalZ)
+}
+
+// seasonality returns the learned usage pattern of the predicted profile and
+// the quota percent used per token, or nil if either is unknown. The scale
+// is calibrated on the tokens the profile used in the current window, or
+// failing that on the burn rate.
+func (e *PredictionEngine) seasonality(info *usage.UsageInfo, window *usage.UsageWindow, burnRate *usage.BurnRateInfo, currentPercent float64, now time.Time) (*Seasonality, float64) {
+	if e.history == nil || info.ProfileName == "" {
+		return nil, 0
+	}
+	entries := e.historyEntries(info.Provider, now)
+	if len(entries) == 0 {
+		return nil, 0
+	}
+
+	var own []*logs.LogEntry
+	for _, entry := range entries {
+		if entry.Profile == info.ProfileName {
+			own = append(own, entry)
+		}
+	}
+	// Learn from when the history starts, so that the time before caam
+	// recorded anything doesn't count as idle.
+	from := entries[0].Timestamp
+	if lookback := now.Add(-e.historyLookback); from.Before(lookback) {
+		from = lookback
+	}
+	seasonal := LearnSeasonality(own, from, now, e.location)
+	if seasonal == nil {
+		return nil, 0
+	}
+
+	var scale float64
+	if window.WindowDuration > 0 && !window.ResetsAt.IsZero() && currentPercent > 0 {
+		start := window.ResetsAt.Add(-window.WindowDuration)
+		var tokens int64
+		for _, entry := range own {
+			if !entry.Timestamp.Before(start) && !entry.Timestamp.After(now) {
+				tokens += entryTokens(entry)
+			}
+		}
+		if tokens > 0 {
+			scale = currentPercent / float64(tokens)
+		}
+	}
+	if scale == 0 && burnRate.TokensPerHour > 0 {
+		scale = burnRate.PercentPerHour / burnRate.TokensPerHour
+	}
+	if scale == 0 {
+		return nil, 0
+	}
+	return seasonal, scale
+}
+
+// historyEntries returns a provider's stored usage over the lookback,
+// oldest first, loading it once per engine.
+func (e *PredictionEngine) historyEntries(provider string, now time.Time) []*logs.LogEntry {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	if entries, ok := e.historyCache[provider]; ok {
+		return entries
+	}
+	entries, err := e.history.TokenUsageEntries(provider, now.Add(-e.historyLookback))
+	if err != nil {
+		entries = nil
+	}
+	if e.historyCache == nil {
+		e.historyCache = make(map[string][]*logs.LogEntry)
+	}
+	e.historyCache[provider] = entries
+	return entries
+}
+
 // calculateConfidence determines prediction reliability based on data quality.
 func (e *PredictionEngine) calculateConfidence(burnRate *usage.BurnRateInfo, sourceCount int) float64 {
 	if burnRate == nil {
@@ -0,0 +1,173 @@
+package prediction
+
+import (
+	"math"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+// HistorySource supplies stored token usage, oldest first and attributed to
+// profiles where known. *db.DB implements it.
+type HistorySource interface {
+	TokenUsageEntries(provider string, since time.Time) ([]*logs.LogEntry, error)
+}
+
+const (
+	hoursPerWeek = 7 * 24
+
+	// minSeasonalSpan is the least history worth learning a pattern from.
+	minSeasonalSpan = 3 * 24 * time.Hour
+
+	// seasonalPrior is how many observations of its hour of day an
+	// hour-of-week slot's estimate is shrunk towards, so that a slot seen
+	// only once or twice borrows from the same hour on similar days
+	// (weekdays or weekends).
+	seasonalPrior = 2.0
+
+	// intervalZ is the normal quantile of the 80% confidence interval.
+	intervalZ = 1.2816
+)
+
+// Seasonality is a profile's typical token usage by hour of the week,
+// learned from history.
+type Seasonality struct {
+	// Span is how much history the pattern was learned from.
+	Span time.Duration
+
+	mean    [hoursPerWeek]float64 // Tokens per hour
+	stddev  [hoursPerWeek]float64
+	average float64 // Tokens per hour over all hours
+	loc     *time.Location
+}
+
+// LearnSeasonality learns the hour-of-week usage pattern of entries written
+// between from and to, with hours taken in loc. It returns nil if the span is
+// too short or contains no usage.
+func LearnSeasonality(entries []*logs.LogEntry, from, to time.Time, loc *time.Location) *Seasonality {
+	if loc == nil {
+		loc = time.Local
+	}
+	from = from.Truncate(time.Hour)
+	to = to.Truncate(time.Hour)
+	if to.Sub(from) < minSeasonalSpan {
+		return nil
+	}
+
+	hours := int(to.Sub(from) / time.Hour)
+	totals := make([]float64, hours)
+	var sum float64
+	for _, e := range entries {
+		if e == nil || e.Timestamp.Before(from) || !e.Timestamp.Before(to) {
+			continue
+		}
+		tokens := float64(entryTokens(e))
+		totals[int(e.Timestamp.Sub(from)/time.Hour)] += tokens
+		sum += tokens
+	}
+	if sum == 0 {
+		return nil
+	}
+
+	// Collect every observed hour into its hour-of-week and hour-of-day
+	// buckets, idle hours included.
+	var slotN, slotSum, slotSq [hoursPerWeek]float64
+	var dayN, daySum, daySq [2 * 24]float64
+	for i, v := range totals {
+		t := from.Add(time.Duration(i) * time.Hour).In(loc)
+		slot, day := hourOfWeek(t), hourOfDay(t.Weekday(), t.Hour())
+		slotN[slot]++
+		slotSum[slot] += v
+		slotSq[slot] += v * v
+		dayN[day]++
+		daySum[day] += v
+		daySq[day] += v * v
+	}
+
+	s := &Seasonality{
+		Span:    to.Sub(from),
+		average: sum / float64(hours),
+		loc:     loc,
+	}
+	for slot := 0; slot < hoursPerWeek; slot++ {
+		day := hourOfDay(time.Weekday(slot/24), slot%24)
+		dayMean, dayVar := meanVar(dayN[day], daySum[day], daySq[day])
+		slotMean, slotVar := meanVar(slotN[slot], slotSum[slot], slotSq[slot])
+
+		w := slotN[slot] / (slotN[slot] + seasonalPrior)
+		s.mean[slot] = w*slotMean + (1-w)*dayMean
+		s.stddev[slot] = math.Sqrt(w*slotVar + (1-w)*dayVar)
+	}
+	return s
+}
+
+// Expected returns the typical tokens per hour at t and its standard
+// deviation.
+func (s *Seasonality) Expected(t time.Time) (mean, stddev float64) {
+	slot := hourOfWeek(t.In(s.loc))
+	return s.mean[slot], s.stddev[slot]
+}
+
+// Average returns the mean tokens per hour over the learned span.
+func (s *Seasonality) Average() float64 {
+	return s.average
+}
+
+func entryTokens(e *logs.LogEntry) int64 {
+	if e.TotalTokens != 0 {
+		return e.TotalTokens
+	}
+	return e.CalculateTotalTokens()
+}
+
+// hourOfWeek numbers the hours of the week from Sunday 00:00.
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// hourOfDay numbers the hours of weekdays from 0 and those of weekends
+// from 24.
+func hourOfDay(weekday time.Weekday, hour int) int {
+	if weekday == time.Saturday || weekday == time.Sunday {
+		return 24 + hour
+	}
+	return hour
+}
+
+func meanVar(n, sum, sq float64) (float64, float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	mean := sum / n
+	return mean, math.Max(0, sq/n-mean*mean)
+}
+
+// depletionPath describes how fast quota is expected to be used at each
+// moment of a forecast.
+type depletionPath func(t time.Time) float64 // Percent per hour
+
+// forecastStep is the integration step of seasonal forecasts.
+const forecastStep = 15 * time.Minute
+
+// timeToDeplete integrates rate from now until remaining percent is used up.
+// Past horizon the rate is assumed to stay at tail. It returns 0 if the quota
+// is never used up.
+func timeToDeplete(now time.Time, remaining float64, rate depletionPath, horizon time.Duration, tail float64) time.Duration {
+	if remaining <= 0 {
+		return 0
+	}
+	var elapsed time.Duration
+	for elapsed < horizon {
+		r := rate(now.Add(elapsed))
+		used := r * forecastStep.Hours()
+		if used >= remaining {
+			return elapsed + time.Duration(remaining/r*float64(time.Hour))
+		}
+		remaining -= used
+		elapsed += forecastStep
+	}
+	if tail <= 0 {
+		return 0
+	}
+	return elapsed + time.Duration(remaining/tail*float64(time.Hour))
+}
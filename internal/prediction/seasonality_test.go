@@ -0,0 +1,175 @@
+package prediction
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
+)
+
+// mockHistory implements HistorySource for testing.
+type mockHistory struct {
+	entries []*logs.LogEntry
+	calls   int
+}
+
+func (m *mockHistory) TokenUsageEntries(provider string, since time.Time) ([]*logs.LogEntry, error) {
+	m.calls++
+	var out []*logs.LogEntry
+	for _, e := range m.entries {
+		if !e.Timestamp.Before(since) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// officeHours builds history in which "work" uses 100k tokens in each hour
+// from 09:00 to 12:00 on weekdays, from start until end.
+func officeHours(start, end time.Time) []*logs.LogEntry {
+	var entries []*logs.LogEntry
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			continue
+		}
+		if t.Hour() >= 9 && t.Hour() < 12 {
+			entries = append(entries, &logs.LogEntry{Timestamp: t.Add(30 * time.Minute), Profile: "work", InputTokens: 100000})
+		}
+	}
+	return entries
+}
+
+func TestLearnSeasonality(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC) // Monday
+	end := start.Add(21 * 24 * time.Hour)
+	s := LearnSeasonality(officeHours(start, end), start, end, time.UTC)
+	if s == nil {
+		t.Fatal("LearnSeasonality() = nil")
+	}
+
+	tuesday10 := time.Date(2026, 1, 27, 10, 15, 0, 0, time.UTC)
+	if mean, stddev := s.Expected(tuesday10); mean < 90000 || stddev > 10000 {
+		t.Errorf("Expected(Tue 10:15) = %.0f ± %.0f, want about 100k", mean, stddev)
+	}
+	if mean, _ := s.Expected(time.Date(2026, 1, 27, 3, 0, 0, 0, time.UTC)); mean != 0 {
+		t.Errorf("Expected(Tue 03:00) = %.0f, want 0", mean)
+	}
+	// Weekends keep their own pattern.
+	if mean, _ := s.Expected(time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)); mean != 0 {
+		t.Errorf("Expected(Sat 10:00) = %.0f, want 0", mean)
+	}
+	if want := 45.0 * 100000 / (21 * 24); s.Average() != want {
+		t.Errorf("Average() = %.1f, want %.1f", s.Average(), want)
+	}
+
+	if LearnSeasonality(officeHours(start, end), start, start.Add(24*time.Hour), time.UTC) != nil {
+		t.Error("expected nil for a span under three days")
+	}
+	if LearnSeasonality(nil, start, end, time.UTC) != nil {
+		t.Error("expected nil without usage")
+	}
+}
+
+func TestTimeToDeplete(t *testing.T) {
+	now := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	constant := func(time.Time) float64 { return 10 }
+	if got := timeToDeplete(now, 50, constant, 24*time.Hour, 10); got != 5*time.Hour {
+		t.Errorf("timeToDeplete(constant) = %v, want 5h", got)
+	}
+	// Past the horizon the tail rate applies.
+	if got := timeToDeplete(now, 50, func(time.Time) float64 { return 0 }, 2*time.Hour, 5); got != 12*time.Hour {
+		t.Errorf("timeToDeplete(tail) = %v, want 12h", got)
+	}
+	if got := timeToDeplete(now, 50, func(time.Time) float64 { return 0 }, 2*time.Hour, 0); got != 0 {
+		t.Errorf("timeToDeplete(never) = %v, want 0", got)
+	}
+}
+
+func TestPredictionEngine_Predict_Seasonal(t *testing.T) {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 1, 26, 8, 0, 0, 0, time.UTC) // Monday, before office hours
+	history := &mockHistory{entries: officeHours(start, now)}
+	// 10k tokens so far in the current window calibrate 10% to 10k tokens.
+	history.entries = append(history.entries, &logs.LogEntry{Timestamp: now.Add(-30 * time.Minute), Profile: "work", InputTokens: 10000})
+
+	info := func(rate float64) *usage.UsageInfo {
+		return &usage.UsageInfo{
+			Provider:    "claude",
+			ProfileName: "work",
+			PrimaryWindow: &usage.UsageWindow{
+				UsedPercent:    10,
+				ResetsAt:       now.Add(4 * time.Hour),
+				WindowDuration: 5 * time.Hour,
+			},
+			BurnRate: &usage.BurnRateInfo{PercentPerHour: rate, Confidence: 0.8, SampleSize: 20},
+		}
+	}
+
+	seasonal := NewPredictionEngine(WithHistory(history, 0), WithLocation(time.UTC), WithClock(func() time.Time { return now }))
+	linear := NewPredictionEngine(WithClock(func() time.Time { return now }))
+
+	// A quiet start to the morning: the linear forecast sees 90h of quota
+	// left, but office hours use it up before noon.
+	pred := seasonal.Predict(context.Background(), info(1))
+	if !pred.Seasonal {
+		t.Fatalf("Seasonal = false, sources %v", pred.DataSources)
+	}
+	if pred.TimeToDepletion < time.Hour || pred.TimeToDepletion > 3*time.Hour {
+		t.Errorf("seasonal TimeToDepletion = %v, want between 1h and 3h", pred.TimeToDepletion)
+	}
+	if !pred.EarliestTime.Before(pred.PredictedTime) || !pred.LatestTime.After(pred.PredictedTime) {
+		t.Errorf("interval [%v, %v] does not bracket %v", pred.EarliestTime, pred.LatestTime, pred.PredictedTime)
+	}
+	if lin := linear.Predict(context.Background(), info(1)); lin.TimeToDepletion < 80*time.Hour || lin.Seasonal {
+		t.Errorf("linear TimeToDepletion = %v, want about 90h", lin.TimeToDepletion)
+	}
+
+	// A burst in the middle of the night fades instead of running on.
+	night := time.Date(2026, 1, 25, 2, 0, 0, 0, time.UTC) // Sunday
+	burst := NewPredictionEngine(WithHistory(history, 0), WithLocation(time.UTC), WithClock(func() time.Time { return night }))
+	nightInfo := info(60)
+	// Without a window to calibrate on, the burn rate converts tokens to quota.
+	nightInfo.PrimaryWindow.ResetsAt = time.Time{}
+	nightInfo.BurnRate.TokensPerHour = 60000
+	pred = burst.Predict(context.Background(), nightInfo)
+	if !pred.Seasonal {
+		t.Fatalf("Seasonal = false, sources %v", pred.DataSources)
+	}
+	if pred.TimeToDepletion < 24*time.Hour {
+		t.Errorf("burst TimeToDepletion = %v, want after the weekend", pred.TimeToDepletion)
+	}
+
+	// Other profiles have no pattern and fall back to the linear forecast.
+	other := info(10)
+	other.ProfileName = "personal"
+	if pred := seasonal.Predict(context.Background(), other); pred.Seasonal || pred.TimeToDepletion != 9*time.Hour {
+		t.Errorf("personal prediction = %v (seasonal %v), want linear 9h", pred.TimeToDepletion, pred.Seasonal)
+	}
+	if history.calls != 2 {
+		t.Errorf("history loaded %d times, want once per engine", history.calls)
+	}
+}
+
+func TestPredictionEngine_Predict_LinearInterval(t *testing.T) {
+	now := time.Date(2026, 1, 26, 8, 0, 0, 0, time.UTC)
+	e := NewPredictionEngine(WithClock(func() time.Time { return now }))
+	pred := e.Predict(context.Background(), &usage.UsageInfo{
+		Provider:      "codex",
+		PrimaryWindow: &usage.UsageWindow{UsedPercent: 50},
+		BurnRate:      &usage.BurnRateInfo{PercentPerHour: 10, Confidence: 0.8},
+	})
+
+	if pred.PredictedTime != now.Add(5*time.Hour) {
+		t.Errorf("PredictedTime = %v, want now+5h", pred.PredictedTime)
+	}
+	// 20% rate uncertainty either way.
+	remaining := 50.0
+	if want := now.Add(time.Duration(remaining / 12 * float64(time.Hour))); !pred.EarliestTime.Equal(want) {
+		t.Errorf("EarliestTime = %v, want %v", pred.EarliestTime, want)
+	}
+	if want := now.Add(time.Duration(remaining / 8 * float64(time.Hour))); !pred.LatestTime.Equal(want) {
+		t.Errorf("LatestTime = %v, want %v", pred.LatestTime, want)
+	}
+}
@@ -3,6 +3,7 @@ package pricing
 import (
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
 )
@@ -42,32 +43,16 @@ func NormalizeModelName(model string) string {
 	return name
 }
 
-// PriceFor returns the token price for a provider+model combination.
+// PriceFor returns the current token price for a provider+model
+// combination, including overrides from the pricing file.
 func PriceFor(provider, model string) (TokenPrice, bool) {
-	provider = normalizeProvider(provider)
-	if provider == "" {
-		return TokenPrice{}, false
-	}
-
-	normalized := normalizeModelForProvider(provider, model)
-
-	switch provider {
-	case "claude":
-		price, ok := ClaudePricing[normalized]
-		return price, ok
-	case "codex", "openai":
-		price, ok := OpenAIPricing[normalized]
-		return price, ok
-	case "gemini":
-		price, ok := GeminiPricing[normalized]
-		return price, ok
-	default:
-		return TokenPrice{}, false
-	}
+	return Default().PriceAt(provider, model, time.Now())
 }
 
-// CalculateCost computes the total cost for token usage.
+// CalculateCost computes the total cost for token usage at current prices.
 // If model is empty, it will sum costs for all known models in usage.ByModel.
+// Aggregated usage has no timestamps; use EntriesCost to price usage at the
+// rates in effect when it happened.
 func CalculateCost(usage *logs.TokenUsage, model, provider string) float64 {
 	if usage == nil {
 		return 0
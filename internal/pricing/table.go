@@ -0,0 +1,317 @@
+package pricing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+// BuiltinSource is the Source of rates compiled into caam.
+const BuiltinSource = "built-in"
+
+// Rate is the price of a model from EffectiveFrom until the next rate for
+// the same model takes effect. A zero EffectiveFrom applies from the start.
+type Rate struct {
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	InputPer1M       float64   `json:"input_per_1m"`
+	OutputPer1M      float64   `json:"output_per_1m"`
+	CacheReadPer1M   float64   `json:"cache_read_per_1m"`
+	CacheCreatePer1M float64   `json:"cache_create_per_1m"`
+	EffectiveFrom    time.Time `json:"effective_from,omitempty"`
+	Source           string    `json:"source"`
+}
+
+// Price returns the rate's per-1M token prices.
+func (r Rate) Price() TokenPrice {
+	return TokenPrice{
+		InputPer1M:       r.InputPer1M,
+		OutputPer1M:      r.OutputPer1M,
+		CacheReadPer1M:   r.CacheReadPer1M,
+		CacheCreatePer1M: r.CacheCreatePer1M,
+	}
+}
+
+// Table holds dated rates per provider and model.
+type Table struct {
+	rates map[string][]Rate // provider/model -> rates, oldest first
+}
+
+// BuiltinTable returns the prices compiled into caam.
+func BuiltinTable() *Table {
+	t := &Table{rates: make(map[string][]Rate)}
+	for provider, prices := range map[string]map[string]TokenPrice{
+		"claude": ClaudePricing,
+		"codex":  OpenAIPricing,
+		"gemini": GeminiPricing,
+	} {
+		for model, p := range prices {
+			t.set(Rate{
+				Provider:         provider,
+				Model:            model,
+				InputPer1M:       p.InputPer1M,
+				OutputPer1M:      p.OutputPer1M,
+				CacheReadPer1M:   p.CacheReadPer1M,
+				CacheCreatePer1M: p.CacheCreatePer1M,
+				Source:           BuiltinSource,
+			})
+		}
+	}
+	return t
+}
+
+// DefaultPath returns the pricing file path (~/.caam/pricing.yaml). A
+// pricing.json is used instead if it exists and pricing.yaml does not.
+func DefaultPath() string {
+	dir := os.Getenv("CAAM_HOME")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			dir = ".caam"
+		} else {
+			dir = filepath.Join(homeDir, ".caam")
+		}
+	}
+	path := filepath.Join(dir, "pricing.yaml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(dir, "pricing.json")); err == nil {
+			return filepath.Join(dir, "pricing.json")
+		}
+	}
+	return path
+}
+
+// pricingFile is the layout of a pricing file. JSON files use the same
+// field names.
+type pricingFile struct {
+	Models []struct {
+		Provider         string   `yaml:"provider"`
+		Model            string   `yaml:"model"`
+		InputPer1M       *float64 `yaml:"input_per_1m"`
+		OutputPer1M      *float64 `yaml:"output_per_1m"`
+		CacheReadPer1M   *float64 `yaml:"cache_read_per_1m"`
+		CacheCreatePer1M *float64 `yaml:"cache_create_per_1m"`
+		EffectiveFrom    string   `yaml:"effective_from"`
+	} `yaml:"models"`
+}
+
+// LoadTable returns the built-in prices merged with a pricing file. A
+// missing file yields the built-in prices.
+//
+// Each file entry adds a model or a dated rate for one. An entry without
+// effective_from replaces the model's built-in price; with one, it applies
+// to usage from that date on and earlier usage keeps its earlier price.
+// Prices left out of a dated entry carry over from the rate before it.
+func LoadTable(path string) (*Table, error) {
+	t := BuiltinTable()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return nil, fmt.Errorf("read pricing: %w", err)
+	}
+
+	var f pricingFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse pricing %s: %w", path, err)
+	}
+	for i, m := range f.Models {
+		provider := canonicalProvider(m.Provider)
+		model := normalizeModelForProvider(provider, m.Model)
+		if provider == "" || model == "" {
+			return nil, fmt.Errorf("pricing %s: models[%d]: provider and model are required", path, i)
+		}
+		var from time.Time
+		if s := strings.TrimSpace(m.EffectiveFrom); s != "" {
+			if from, err = parseEffectiveFrom(s); err != nil {
+				return nil, fmt.Errorf("pricing %s: models[%d]: effective_from %q: want YYYY-MM-DD or RFC 3339", path, i, s)
+			}
+		}
+
+		r := Rate{Provider: provider, Model: model, EffectiveFrom: from, Source: path}
+		if prev, ok := t.rateAt(provider, model, from); ok {
+			r.InputPer1M, r.OutputPer1M = prev.InputPer1M, prev.OutputPer1M
+			r.CacheReadPer1M, r.CacheCreatePer1M = prev.CacheReadPer1M, prev.CacheCreatePer1M
+		}
+		for _, v := range []struct {
+			src *float64
+			dst *float64
+		}{
+			{m.InputPer1M, &r.InputPer1M},
+			{m.OutputPer1M, &r.OutputPer1M},
+			{m.CacheReadPer1M, &r.CacheReadPer1M},
+			{m.CacheCreatePer1M, &r.CacheCreatePer1M},
+		} {
+			if v.src == nil {
+				continue
+			}
+			if *v.src < 0 {
+				return nil, fmt.Errorf("pricing %s: models[%d]: prices must not be negative", path, i)
+			}
+			*v.dst = *v.src
+		}
+		t.set(r)
+	}
+	return t, nil
+}
+
+func parseEffectiveFrom(s string) (time.Time, error) {
+	if ts, err := time.Parse("2006-01-02", s); err == nil {
+		return ts, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// set adds a rate, replacing any for the same model and effective date.
+func (t *Table) set(r Rate) {
+	key := r.Provider + "/" + r.Model
+	rates := t.rates[key]
+	for i := range rates {
+		if rates[i].EffectiveFrom.Equal(r.EffectiveFrom) {
+			rates[i] = r
+			return
+		}
+	}
+	rates = append(rates, r)
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].EffectiveFrom.Before(rates[j].EffectiveFrom)
+	})
+	t.rates[key] = rates
+}
+
+func (t *Table) rateAt(provider, model string, at time.Time) (Rate, bool) {
+	rates := t.rates[provider+"/"+model]
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !rates[i].EffectiveFrom.After(at) {
+			return rates[i], true
+		}
+	}
+	return Rate{}, false
+}
+
+// PriceAt returns the price of a model in effect at the given time.
+func (t *Table) PriceAt(provider, model string, at time.Time) (TokenPrice, bool) {
+	if t == nil {
+		return TokenPrice{}, false
+	}
+	provider = canonicalProvider(provider)
+	r, ok := t.rateAt(provider, normalizeModelForProvider(provider, model), at)
+	if !ok {
+		return TokenPrice{}, false
+	}
+	return r.Price(), true
+}
+
+// Rates returns every rate, ordered by provider, model and effective date.
+func (t *Table) Rates() []Rate {
+	if t == nil {
+		return nil
+	}
+	var out []Rate
+	for _, rates := range t.rates {
+		out = append(out, rates...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].EffectiveFrom.Before(out[j].EffectiveFrom)
+	})
+	return out
+}
+
+// EntryCost prices a log entry, including its cache tokens, at the rate in
+// effect when it was written. Entries without a known model cost nothing.
+func (t *Table) EntryCost(entry *logs.LogEntry, provider string) float64 {
+	if entry == nil || entry.Model == "" {
+		return 0
+	}
+	price, ok := t.PriceAt(provider, entry.Model, entry.Timestamp)
+	if !ok {
+		return 0
+	}
+	return costFromTokens(entry.InputTokens, entry.OutputTokens, entry.CacheReadTokens, entry.CacheCreateTokens, price)
+}
+
+// EntriesCost prices log entries with the default table, each at the rate
+// in effect when it was written.
+func EntriesCost(entries []*logs.LogEntry, provider string) float64 {
+	t := Default()
+	total := 0.0
+	for _, e := range entries {
+		total += t.EntryCost(e, provider)
+	}
+	return total
+}
+
+var defaultTable struct {
+	sync.Mutex
+	table   *Table
+	err     error
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// LoadDefault returns the built-in prices merged with the pricing file at
+// DefaultPath, reloading it when the file changes. If the file cannot be
+// loaded, it returns the built-in prices together with the error.
+func LoadDefault() (*Table, error) {
+	t, err, _ := loadDefault()
+	return t, err
+}
+
+// Default is LoadDefault for callers that only need prices. A pricing file
+// that cannot be loaded is reported on stderr once per change to the file,
+// and the built-in prices are used.
+func Default() *Table {
+	t, err, reloaded := loadDefault()
+	if err != nil && reloaded {
+		fmt.Fprintf(os.Stderr, "Warning: %v (using built-in prices)\n", err)
+	}
+	return t
+}
+
+func loadDefault() (t *Table, err error, reloaded bool) {
+	path := DefaultPath()
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	defaultTable.Lock()
+	defer defaultTable.Unlock()
+	if defaultTable.table != nil && defaultTable.path == path &&
+		defaultTable.modTime.Equal(modTime) && defaultTable.size == size {
+		return defaultTable.table, defaultTable.err, false
+	}
+	t, err = LoadTable(path)
+	if err != nil {
+		t = BuiltinTable()
+	}
+	defaultTable.table, defaultTable.err, defaultTable.path = t, err, path
+	defaultTable.modTime, defaultTable.size = modTime, size
+	return t, err, true
+}
+
+// canonicalProvider maps provider names to the one rates are kept under.
+func canonicalProvider(provider string) string {
+	provider = normalizeProvider(provider)
+	if provider == "openai" {
+		return "codex"
+	}
+	return provider
+}
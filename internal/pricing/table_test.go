@@ -0,0 +1,155 @@
+package pricing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
+)
+
+func writePricingFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadTable(t *testing.T) {
+	path := writePricingFile(t, "pricing.yaml", `models:
+  - provider: claude
+    model: claude-sonnet-4-20250514
+    input_per_1m: 3
+    output_per_1m: 15
+  - provider: claude
+    model: claude-3-opus
+    input_per_1m: 12
+    effective_from: 2026-11-01
+  - provider: openai
+    model: gpt-4o
+    input_per_1m: 2.5
+    output_per_1m: 10
+`)
+	table, err := LoadTable(path)
+	if err != nil {
+		t.Fatalf("LoadTable() error = %v", err)
+	}
+
+	before := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+	after := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	price, ok := table.PriceAt("claude", "claude-3-opus-20240229", before)
+	if !ok || price.InputPer1M != 15 {
+		t.Errorf("PriceAt(opus, before) = %+v, %v; want built-in $15 input", price, ok)
+	}
+	price, ok = table.PriceAt("claude", "claude-3-opus", after)
+	if !ok || price.InputPer1M != 12 || price.OutputPer1M != 75 || price.CacheReadPer1M != 1.5 {
+		t.Errorf("PriceAt(opus, after) = %+v, %v; want $12 input with other prices carried over", price, ok)
+	}
+
+	// Added models are normalized like the models in logs.
+	if price, ok := table.PriceAt("claude", "claude-sonnet-4-20250514", before); !ok || price.OutputPer1M != 15 {
+		t.Errorf("PriceAt(sonnet-4) = %+v, %v; want added model", price, ok)
+	}
+	// Undated entries replace the built-in price; openai and codex share rates.
+	if price, ok := table.PriceAt("codex", "gpt-4o", before); !ok || price.InputPer1M != 2.5 {
+		t.Errorf("PriceAt(gpt-4o) = %+v, %v; want override", price, ok)
+	}
+
+	var sources []string
+	for _, r := range table.Rates() {
+		if r.Provider == "claude" && r.Model == "claude-3-opus" {
+			sources = append(sources, r.Source)
+		}
+	}
+	if len(sources) != 2 || sources[0] != BuiltinSource || sources[1] != path {
+		t.Errorf("claude-3-opus sources = %v, want built-in then %s", sources, path)
+	}
+
+	entries := []*logs.LogEntry{
+		{Timestamp: before, Model: "claude-3-opus", InputTokens: 1_000_000},
+		{Timestamp: after, Model: "claude-3-opus", InputTokens: 1_000_000, CacheReadTokens: 1_000_000},
+		{Timestamp: after, InputTokens: 1_000_000},
+	}
+	total := 0.0
+	for _, e := range entries {
+		total += table.EntryCost(e, "claude")
+	}
+	if math.Abs(total-(15+12+1.5)) > 1e-9 {
+		t.Errorf("EntryCost total = %v, want 28.5", total)
+	}
+}
+
+func TestLoadTableJSON(t *testing.T) {
+	path := writePricingFile(t, "pricing.json",
+		`{"models": [{"provider": "gemini", "model": "gemini-2.5-pro", "input_per_1m": 1.25, "output_per_1m": 10, "effective_from": "2025-06-17T00:00:00Z"}]}`)
+	table, err := LoadTable(path)
+	if err != nil {
+		t.Fatalf("LoadTable() error = %v", err)
+	}
+	if _, ok := table.PriceAt("gemini", "gemini-2.5-pro", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("PriceAt() before effective_from should find no price")
+	}
+	if price, ok := table.PriceAt("gemini", "gemini-2.5-pro", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)); !ok || price.InputPer1M != 1.25 {
+		t.Errorf("PriceAt() = %+v, %v; want $1.25 input", price, ok)
+	}
+}
+
+func TestLoadTableErrors(t *testing.T) {
+	if table, err := LoadTable(filepath.Join(t.TempDir(), "missing.yaml")); err != nil || len(table.Rates()) != len(BuiltinTable().Rates()) {
+		t.Errorf("LoadTable(missing) = %v; want built-in prices", err)
+	}
+
+	for name, content := range map[string]string{
+		"no model":       "models:\n  - provider: claude\n    input_per_1m: 1\n",
+		"bad date":       "models:\n  - provider: claude\n    model: x\n    effective_from: next week\n",
+		"negative price": "models:\n  - provider: claude\n    model: x\n    output_per_1m: -1\n",
+		"not yaml":       "models: [",
+	} {
+		if _, err := LoadTable(writePricingFile(t, "pricing.yaml", content)); err == nil {
+			t.Errorf("LoadTable(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestDefaultReloads(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CAAM_HOME", dir)
+
+	if price, _ := PriceFor("claude", "claude-3-opus"); price.InputPer1M != 15 {
+		t.Fatalf("PriceFor() input = %v, want built-in 15", price.InputPer1M)
+	}
+	content := "models:\n  - provider: claude\n    model: claude-3-opus\n    input_per_1m: 9\n"
+	if err := os.WriteFile(filepath.Join(dir, "pricing.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if price, _ := PriceFor("claude", "claude-3-opus"); price.InputPer1M != 9 {
+		t.Errorf("PriceFor() after edit input = %v, want 9", price.InputPer1M)
+	}
+}
+
+func TestLoadDefaultReportsMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CAAM_HOME", dir)
+	if err := os.WriteFile(filepath.Join(dir, "pricing.yaml"), []byte("models: ["), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	table, err := LoadDefault()
+	if err == nil {
+		t.Fatal("LoadDefault() error = nil, want parse error")
+	}
+	if len(table.Rates()) != len(BuiltinTable().Rates()) {
+		t.Errorf("LoadDefault() table has %d rates, want built-in prices", len(table.Rates()))
+	}
+	if _, err := LoadDefault(); err == nil {
+		t.Error("LoadDefault() from cache error = nil, want parse error")
+	}
+	if price, _ := PriceFor("claude", "claude-3-opus"); price.InputPer1M != 15 {
+		t.Errorf("PriceFor() input = %v, want built-in 15", price.InputPer1M)
+	}
+}
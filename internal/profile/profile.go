@@ -55,8 +55,9 @@ type Profile struct {
 	// LastUsedAt is when this profile was last used.
 	LastUsedAt time.Time `json:"last_used_at,omitempty"`
 
-	// LastSessionID is the most recent Codex session ID observed for this profile.
-	// This enables 'caam resume codex <profile>' to resume without manually copy/pasting.
+	// LastSessionID is the most recent session ID observed for this profile
+	// (captured from Codex output, or from Claude/Gemini session transcripts).
+	// This enables 'caam resume <tool> <profile>' to resume without manually copy/pasting.
 	LastSessionID string `json:"last_session_id,omitempty"`
 
 	// LastSessionTS is when LastSessionID was last observed.
@@ -83,6 +84,60 @@ type Profile struct {
 	// Examples: "Work Google", "Personal GitHub"
 	// Used for display purposes only.
 	BrowserProfileName string `json:"browser_profile_name,omitempty"`
+
+	// Rotation tunes how rotation algorithms treat this profile.
+	Rotation *RotationPolicy `json:"rotation,omitempty"`
+}
+
+// RotationPolicy holds per-profile weights, caps and the reserve flag
+// honored by rotation. Caps are rolling: daily means the last 24 hours,
+// weekly the last 7 days. Zero values mean "no limit".
+type RotationPolicy struct {
+	// Weight is the profile's relative share of activations (default 1).
+	Weight float64 `json:"weight,omitempty"`
+
+	// DailyActivations and WeeklyActivations cap how often the profile
+	// may be selected.
+	DailyActivations  int `json:"daily_activations,omitempty"`
+	WeeklyActivations int `json:"weekly_activations,omitempty"`
+
+	// DailyTokens and WeeklyTokens cap tokens attributed to the profile.
+	DailyTokens  int64 `json:"daily_tokens,omitempty"`
+	WeeklyTokens int64 `json:"weekly_tokens,omitempty"`
+
+	// Reserve keeps the profile out of rotation unless no other profile
+	// is eligible.
+	Reserve bool `json:"reserve,omitempty"`
+}
+
+// EffectiveWeight returns the policy weight, defaulting to 1.
+func (r *RotationPolicy) EffectiveWeight() float64 {
+	if r == nil || r.Weight <= 0 {
+		return 1
+	}
+	return r.Weight
+}
+
+// IsZero reports whether the policy has no settings.
+func (r *RotationPolicy) IsZero() bool {
+	return r == nil || *r == RotationPolicy{}
+}
+
+// Validate checks the policy for negative values.
+func (r *RotationPolicy) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Weight < 0 {
+		return fmt.Errorf("weight cannot be negative")
+	}
+	if r.DailyActivations < 0 || r.WeeklyActivations < 0 {
+		return fmt.Errorf("activation caps cannot be negative")
+	}
+	if r.DailyTokens < 0 || r.WeeklyTokens < 0 {
+		return fmt.Errorf("token caps cannot be negative")
+	}
+	return nil
 }
 
 // HomePath returns the pseudo-HOME directory for this profile.
@@ -97,6 +152,12 @@ func (p *Profile) XDGConfigPath() string {
 	return filepath.Join(p.BasePath, "xdg_config")
 }
 
+// XDGDataPath returns the pseudo-XDG_DATA_HOME directory.
+// Used by tools like OpenCode that keep credentials under XDG data.
+func (p *Profile) XDGDataPath() string {
+	return filepath.Join(p.BasePath, "xdg_data")
+}
+
 // CodexHomePath returns the CODEX_HOME directory for this profile.
 // Codex CLI specifically uses this for auth.json.
 func (p *Profile) CodexHomePath() string {
@@ -139,6 +200,10 @@ func (p *Profile) LoadIdentity() {
 			candidates = append(candidates, filepath.Join(p.BasePath, "gcloud", "application_default_credentials.json"))
 		}
 		id = loadIdentityFromPaths(candidates, identity.ExtractFromGeminiConfig)
+	case "opencode":
+		id = loadIdentityFromPaths([]string{
+			filepath.Join(p.XDGDataPath(), "opencode", "auth.json"),
+		}, identity.ExtractFromOpenCodeAuth)
 	}
 
 	if id != nil {
@@ -1393,3 +1393,37 @@ func TestStoreAllTags(t *testing.T) {
 		}
 	}
 }
+
+func TestRotationPolicy(t *testing.T) {
+	var nilPolicy *RotationPolicy
+	if !nilPolicy.IsZero() {
+		t.Error("nil policy should be zero")
+	}
+	if w := nilPolicy.EffectiveWeight(); w != 1 {
+		t.Errorf("nil EffectiveWeight() = %v, want 1", w)
+	}
+	if err := (&RotationPolicy{DailyActivations: -1}).Validate(); err == nil {
+		t.Error("expected error for negative cap")
+	}
+
+	store := NewStore(t.TempDir())
+	p, err := store.Create("claude", "work", "oauth")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	p.Rotation = &RotationPolicy{Weight: 0.7, DailyActivations: 5, Reserve: true}
+	if err := p.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load("claude", "work")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Rotation == nil || *loaded.Rotation != *p.Rotation {
+		t.Errorf("Rotation = %+v, want %+v", loaded.Rotation, p.Rotation)
+	}
+	if w := loaded.Rotation.EffectiveWeight(); w != 0.7 {
+		t.Errorf("EffectiveWeight() = %v, want 0.7", w)
+	}
+}
@@ -76,6 +76,19 @@ func (p *Provider) SupportedAuthModes() []provider.AuthMode {
 	}
 }
 
+// Capabilities reports the optional features caam supports for Claude Code.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		TokenRefresh:     true, // via internal/refresh (claudeAiOauth refresh token)
+		UsageAPI:         true,
+		DeviceCode:       false,
+		SessionResume:    true,
+		IsolatedProfiles: true,
+		ActiveValidation: true,
+		LoginHandoff:     true,
+	}
+}
+
 // xdgConfigHome returns the XDG config directory.
 func xdgConfigHome() string {
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
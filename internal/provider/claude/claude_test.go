@@ -81,6 +81,16 @@ func TestSupportedAuthModes(t *testing.T) {
 	}
 }
 
+func TestCapabilities(t *testing.T) {
+	caps := New().Capabilities()
+
+	// caam refreshes Claude OAuth tokens itself (internal/refresh), so the
+	// TUI refresh action must be available.
+	if !caps.Has(provider.CapTokenRefresh) {
+		t.Error("Capabilities() should include token_refresh")
+	}
+}
+
 // =============================================================================
 // Auth Files Tests
 // =============================================================================
@@ -67,6 +67,19 @@ func (p *Provider) SupportedAuthModes() []provider.AuthMode {
 	}
 }
 
+// Capabilities reports the optional features caam supports for Codex.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		TokenRefresh:     true,
+		UsageAPI:         true,
+		DeviceCode:       true,
+		SessionResume:    true, // session IDs captured from 'caam exec'
+		IsolatedProfiles: true,
+		ActiveValidation: true,
+		LoginHandoff:     true,
+	}
+}
+
 // codexHome returns the Codex home directory.
 func codexHome() string {
 	if home := os.Getenv("CODEX_HOME"); home != "" {
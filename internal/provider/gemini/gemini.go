@@ -68,6 +68,19 @@ func (p *Provider) SupportedAuthModes() []provider.AuthMode {
 	}
 }
 
+// Capabilities reports the optional features caam supports for Gemini CLI.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		TokenRefresh:     true,
+		UsageAPI:         true,
+		DeviceCode:       false,
+		SessionResume:    true,
+		IsolatedProfiles: true,
+		ActiveValidation: true,
+		LoginHandoff:     true,
+	}
+}
+
 // geminiHome returns the Gemini home directory.
 func geminiHome() string {
 	if home := os.Getenv("GEMINI_HOME"); home != "" {
@@ -0,0 +1,429 @@
+// Package opencode implements the provider adapter for OpenCode.
+//
+// Authentication mechanics:
+//   - OpenCode is multi-provider: `opencode auth login` adds credentials for
+//     Anthropic, OpenAI, GitHub Copilot, or any API-key provider.
+//   - All of them live in one store, $XDG_DATA_HOME/opencode/auth.json
+//     (default ~/.local/share/opencode/auth.json), keyed by provider ID. OAuth
+//     entries carry access/refresh/expires; API entries carry a key.
+//   - Configuration is read from $XDG_CONFIG_HOME/opencode/.
+//
+// Context isolation for caam:
+//   - Set XDG_DATA_HOME (auth store and sessions), XDG_CONFIG_HOME and HOME
+//     to the profile's directories.
+//
+// Auth file swapping:
+//   - Backing up auth.json captures every provider login at once, so one caam
+//     profile is one complete OpenCode account set.
+package opencode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+// Provider implements the OpenCode adapter.
+type Provider struct{}
+
+// New creates a new OpenCode provider.
+func New() *Provider {
+	return &Provider{}
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return "opencode"
+}
+
+// DisplayName returns the human-friendly name.
+func (p *Provider) DisplayName() string {
+	return "OpenCode"
+}
+
+// DefaultBin returns the default binary name.
+func (p *Provider) DefaultBin() string {
+	return "opencode"
+}
+
+// SupportedAuthModes returns the authentication modes supported by OpenCode.
+func (p *Provider) SupportedAuthModes() []provider.AuthMode {
+	return []provider.AuthMode{
+		provider.AuthModeOAuth,  // Claude Pro/Max, ChatGPT, Copilot logins
+		provider.AuthModeAPIKey, // Any API-key provider
+	}
+}
+
+// Capabilities reports the optional features caam supports for OpenCode.
+// Tokens belong to the upstream providers, so caam can only isolate them.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		IsolatedProfiles: true,
+	}
+}
+
+// dataHome returns the XDG data directory OpenCode uses.
+func dataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share")
+}
+
+// authPath returns the OpenCode auth store below a data home.
+func authPath(dataHome string) string {
+	return filepath.Join(dataHome, "opencode", "auth.json")
+}
+
+// profileAuthPath returns the auth store inside an isolated profile.
+func profileAuthPath(prof *profile.Profile) string {
+	return authPath(prof.XDGDataPath())
+}
+
+// AuthFiles returns the auth file specifications for OpenCode.
+func (p *Provider) AuthFiles() []provider.AuthFileSpec {
+	return []provider.AuthFileSpec{
+		{
+			Path:        authPath(dataHome()),
+			Description: "OpenCode provider credentials (OAuth tokens and API keys)",
+			Required:    true,
+		},
+	}
+}
+
+// PrepareProfile sets up the profile directory structure.
+func (p *Provider) PrepareProfile(ctx context.Context, prof *profile.Profile) error {
+	for _, dir := range []string{
+		filepath.Join(prof.XDGDataPath(), "opencode"),
+		filepath.Join(prof.XDGConfigPath(), "opencode"),
+	} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("create %s: %w", filepath.Base(filepath.Dir(dir)), err)
+		}
+	}
+
+	homePath := prof.HomePath()
+	if err := os.MkdirAll(homePath, 0700); err != nil {
+		return fmt.Errorf("create home: %w", err)
+	}
+
+	mgr, err := passthrough.NewManager()
+	if err != nil {
+		return fmt.Errorf("create passthrough manager: %w", err)
+	}
+	if err := mgr.SetupPassthroughs(homePath); err != nil {
+		return fmt.Errorf("setup passthroughs: %w", err)
+	}
+
+	return nil
+}
+
+// Env returns the environment variables for running OpenCode in this profile's context.
+func (p *Provider) Env(ctx context.Context, prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{
+		"HOME":            prof.HomePath(),
+		"XDG_DATA_HOME":   prof.XDGDataPath(),
+		"XDG_CONFIG_HOME": prof.XDGConfigPath(),
+	}
+	return env, nil
+}
+
+// Login runs `opencode auth login`, which asks which provider to sign in to.
+// Run it again to add more providers to the same profile.
+func (p *Provider) Login(ctx context.Context, prof *profile.Profile) error {
+	env, err := p.Env(ctx, prof)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "opencode", "auth", "login")
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	fmt.Println("Starting OpenCode login...")
+	fmt.Println("Pick a provider; run 'caam login opencode' again to add another.")
+
+	return cmd.Run()
+}
+
+// Logout clears the profile's auth store (all providers).
+func (p *Provider) Logout(ctx context.Context, prof *profile.Profile) error {
+	if err := os.Remove(profileAuthPath(prof)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove auth.json: %w", err)
+	}
+	return nil
+}
+
+// Status checks the current authentication state.
+func (p *Provider) Status(ctx context.Context, prof *profile.Profile) (*provider.ProfileStatus, error) {
+	status := &provider.ProfileStatus{
+		HasLockFile: prof.IsLocked(),
+	}
+
+	path := profileAuthPath(prof)
+	entries, err := readAuthStore(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			status.Error = err.Error()
+		}
+		return status, nil
+	}
+	status.LoggedIn = len(entries) > 0
+
+	if id, err := identity.ExtractFromOpenCodeAuth(path); err == nil {
+		status.AccountID = id.Email
+		if status.AccountID == "" {
+			status.AccountID = strings.Join(id.Providers, ",")
+		}
+		if !id.ExpiresAt.IsZero() {
+			status.ExpiresAt = id.ExpiresAt.Format(time.RFC3339)
+		}
+	}
+
+	return status, nil
+}
+
+// ValidateProfile checks if the profile is correctly configured.
+func (p *Provider) ValidateProfile(ctx context.Context, prof *profile.Profile) error {
+	if _, err := os.Stat(prof.HomePath()); os.IsNotExist(err) {
+		return fmt.Errorf("home directory missing")
+	}
+	if _, err := os.Stat(prof.XDGDataPath()); os.IsNotExist(err) {
+		return fmt.Errorf("xdg_data directory missing")
+	}
+	return nil
+}
+
+// DetectExistingAuth detects an existing OpenCode auth store.
+// Locations checked:
+//   - $XDG_DATA_HOME/opencode/auth.json (if XDG_DATA_HOME is set)
+//   - ~/.local/share/opencode/auth.json (default location)
+func (p *Provider) DetectExistingAuth() (*provider.AuthDetection, error) {
+	detection := &provider.AuthDetection{
+		Provider:  p.ID(),
+		Locations: []provider.AuthLocation{},
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("get home dir: %w", err)
+	}
+
+	paths := []string{authPath(filepath.Join(homeDir, ".local", "share"))}
+	if custom := authPath(dataHome()); custom != paths[0] {
+		paths = append([]string{custom}, paths...)
+	}
+
+	for _, path := range paths {
+		loc := provider.AuthLocation{
+			Path:        path,
+			Description: "OpenCode provider credentials",
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				loc.ValidationError = fmt.Sprintf("stat error: %v", err)
+			}
+			detection.Locations = append(detection.Locations, loc)
+			continue
+		}
+
+		loc.Exists = true
+		loc.LastModified = info.ModTime()
+		loc.FileSize = info.Size()
+
+		entries, err := readAuthStore(path)
+		switch {
+		case err != nil:
+			loc.ValidationError = err.Error()
+		case len(entries) == 0:
+			loc.ValidationError = "no provider credentials in auth.json"
+		default:
+			loc.IsValid = true
+		}
+		detection.Locations = append(detection.Locations, loc)
+
+		if loc.IsValid && detection.Primary == nil {
+			detection.Found = true
+			locCopy := loc
+			detection.Primary = &locCopy
+		}
+	}
+
+	return detection, nil
+}
+
+// ImportAuth imports a detected auth.json into the profile's data directory.
+func (p *Provider) ImportAuth(ctx context.Context, sourcePath string, prof *profile.Profile) ([]string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source auth file not found: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("source path is a directory, not a file")
+	}
+	if _, err := readAuthStore(sourcePath); err != nil {
+		return nil, err
+	}
+
+	targetPath := profileAuthPath(prof)
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0700); err != nil {
+		return nil, fmt.Errorf("create opencode data dir: %w", err)
+	}
+	if err := copyFile(sourcePath, targetPath); err != nil {
+		return nil, fmt.Errorf("copy auth.json: %w", err)
+	}
+	return []string{targetPath}, nil
+}
+
+// ValidateToken validates the profile's stored credentials.
+//
+// OpenCode refreshes OAuth access tokens itself, so an expired access token
+// with a refresh token is still valid. There is no single endpoint covering
+// every upstream provider, so active validation falls back to the passive
+// check.
+func (p *Provider) ValidateToken(ctx context.Context, prof *profile.Profile, passive bool) (*provider.ValidationResult, error) {
+	result := &provider.ValidationResult{
+		Provider:  p.ID(),
+		Profile:   prof.Name,
+		Method:    "passive",
+		CheckedAt: time.Now(),
+	}
+
+	entries, err := readAuthStore(profileAuthPath(prof))
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Error = "auth.json not found"
+		} else {
+			result.Error = err.Error()
+		}
+		return result, nil
+	}
+
+	var usable, expired []string
+	for _, name := range sortedKeys(entries) {
+		e := entries[name]
+		switch {
+		case e.Key != "" || e.Refresh != "":
+			usable = append(usable, name)
+		case e.Access != "" && (e.Expires == 0 || time.UnixMilli(e.Expires).After(time.Now())):
+			usable = append(usable, name)
+			if exp := time.UnixMilli(e.Expires); e.Expires != 0 && (result.ExpiresAt.IsZero() || exp.Before(result.ExpiresAt)) {
+				result.ExpiresAt = exp
+			}
+		default:
+			expired = append(expired, name)
+		}
+	}
+
+	if len(usable) == 0 {
+		if len(expired) > 0 {
+			result.Error = "credentials expired: " + strings.Join(expired, ", ")
+		} else {
+			result.Error = "no provider credentials in auth.json"
+		}
+		return result, nil
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// authEntry is one provider's credentials in auth.json.
+type authEntry struct {
+	Type    string `json:"type"`
+	Key     string `json:"key,omitempty"`
+	Access  string `json:"access,omitempty"`
+	Refresh string `json:"refresh,omitempty"`
+	Expires int64  `json:"expires,omitempty"` // unix milliseconds
+}
+
+// readAuthStore parses an OpenCode auth.json. Non-object members (such as
+// "$schema") are ignored.
+func readAuthStore(path string) (map[string]authEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid auth.json: %w", err)
+	}
+
+	entries := make(map[string]authEntry, len(raw))
+	for name, msg := range raw {
+		var e authEntry
+		if err := json.Unmarshal(msg, &e); err != nil {
+			continue
+		}
+		if e.Type == "" && e.Key == "" && e.Access == "" && e.Refresh == "" {
+			continue
+		}
+		entries[name] = e
+	}
+	return entries, nil
+}
+
+func sortedKeys(m map[string]authEntry) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyFile copies src to dst atomically with 0600 permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
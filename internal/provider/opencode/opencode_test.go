@@ -0,0 +1,183 @@
+package opencode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+func testProfile(t *testing.T) *profile.Profile {
+	t.Helper()
+	return &profile.Profile{
+		Name:     "work",
+		Provider: "opencode",
+		BasePath: filepath.Join(t.TempDir(), "opencode", "work"),
+	}
+}
+
+func writeAuth(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProviderInterface(t *testing.T) {
+	var _ provider.Provider = New()
+
+	p := New()
+	if p.ID() != "opencode" || p.DefaultBin() != "opencode" {
+		t.Errorf("ID/DefaultBin = %q/%q", p.ID(), p.DefaultBin())
+	}
+}
+
+func TestAuthFiles_XDGDataHome(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	files := New().AuthFiles()
+	if len(files) != 1 {
+		t.Fatalf("AuthFiles() = %d files, want 1", len(files))
+	}
+	if want := filepath.Join(dataHome, "opencode", "auth.json"); files[0].Path != want {
+		t.Errorf("Path = %q, want %q", files[0].Path, want)
+	}
+	if !files[0].Required {
+		t.Error("auth.json should be required")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	prof := testProfile(t)
+	env, err := New().Env(context.Background(), prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"HOME":            prof.HomePath(),
+		"XDG_DATA_HOME":   prof.XDGDataPath(),
+		"XDG_CONFIG_HOME": prof.XDGConfigPath(),
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("env[%s] = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func TestPrepareProfile(t *testing.T) {
+	prof := testProfile(t)
+	if err := New().PrepareProfile(context.Background(), prof); err != nil {
+		t.Fatalf("PrepareProfile() error = %v", err)
+	}
+	for _, dir := range []string{prof.HomePath(), filepath.Join(prof.XDGDataPath(), "opencode")} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s not created: %v", dir, err)
+		}
+	}
+	if err := New().ValidateProfile(context.Background(), prof); err != nil {
+		t.Errorf("ValidateProfile() error = %v", err)
+	}
+}
+
+func TestStatusAndLogout(t *testing.T) {
+	ctx := context.Background()
+	p := New()
+	prof := testProfile(t)
+
+	status, err := p.Status(ctx, prof)
+	if err != nil || status.LoggedIn {
+		t.Fatalf("Status() before login = %+v, %v", status, err)
+	}
+
+	writeAuth(t, profileAuthPath(prof), `{"openrouter":{"type":"api","key":"sk-or-1"},"anthropic":{"type":"api","key":"sk-ant-1"}}`)
+	status, err = p.Status(ctx, prof)
+	if err != nil || !status.LoggedIn {
+		t.Fatalf("Status() after login = %+v, %v", status, err)
+	}
+	if status.AccountID != "anthropic,openrouter" {
+		t.Errorf("AccountID = %q, want provider list", status.AccountID)
+	}
+
+	if err := p.Logout(ctx, prof); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(profileAuthPath(prof)); !os.IsNotExist(err) {
+		t.Error("Logout() left auth.json")
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	ctx := context.Background()
+	p := New()
+	past := time.Now().Add(-time.Hour).UnixMilli()
+	future := time.Now().Add(time.Hour).UnixMilli()
+
+	tests := []struct {
+		name  string
+		auth  string
+		valid bool
+	}{
+		{"api key", `{"openai":{"type":"api","key":"sk-1"}}`, true},
+		{"refreshable oauth", `{"anthropic":{"type":"oauth","access":"a","refresh":"r","expires":1}}`, true},
+		{"live access token", `{"copilot":{"type":"oauth","access":"a","expires":` + itoa(future) + `}}`, true},
+		{"expired access token", `{"copilot":{"type":"oauth","access":"a","expires":` + itoa(past) + `}}`, false},
+		{"schema only", `{"$schema":"https://opencode.ai/auth.json"}`, false},
+		{"invalid json", `{`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prof := testProfile(t)
+			writeAuth(t, profileAuthPath(prof), tt.auth)
+			res, err := p.ValidateToken(ctx, prof, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Valid != tt.valid {
+				t.Errorf("Valid = %v, want %v (error %q)", res.Valid, tt.valid, res.Error)
+			}
+		})
+	}
+}
+
+func TestDetectAndImport(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	src := filepath.Join(dataHome, "opencode", "auth.json")
+	writeAuth(t, src, `{"anthropic":{"type":"api","key":"sk-ant-1"}}`)
+
+	p := New()
+	det, err := p.DetectExistingAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !det.Found || det.Primary == nil || det.Primary.Path != src {
+		t.Fatalf("DetectExistingAuth() = %+v", det)
+	}
+
+	prof := testProfile(t)
+	copied, err := p.ImportAuth(context.Background(), src, prof)
+	if err != nil {
+		t.Fatalf("ImportAuth() error = %v", err)
+	}
+	if len(copied) != 1 || copied[0] != profileAuthPath(prof) {
+		t.Errorf("ImportAuth() = %v", copied)
+	}
+	info, err := os.Stat(profileAuthPath(prof))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("imported auth.json: %v, %v", info, err)
+	}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
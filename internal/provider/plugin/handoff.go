@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/pty"
+)
+
+// LoginHandler implements handoff.LoginHandler from a spec's login patterns.
+type LoginHandler struct {
+	spec     *Spec
+	progress []*regexp.Regexp
+	success  []*regexp.Regexp
+	failure  []*regexp.Regexp
+}
+
+// NewLoginHandler compiles the spec's login patterns. Specs are validated on
+// load, so patterns always compile.
+func NewLoginHandler(spec *Spec) *LoginHandler {
+	return &LoginHandler{
+		spec:     spec,
+		progress: mustCompileAll(spec.Login.ProgressPatterns),
+		success:  mustCompileAll(spec.Login.SuccessPatterns),
+		failure:  mustCompileAll(spec.Login.FailurePatterns),
+	}
+}
+
+// Provider returns the provider ID.
+func (h *LoginHandler) Provider() string {
+	return h.spec.ID
+}
+
+// LoginCommand returns the command typed into a running session.
+func (h *LoginHandler) LoginCommand() string {
+	return h.spec.Login.PTYCommand
+}
+
+// TriggerLogin injects the login command into the PTY.
+func (h *LoginHandler) TriggerLogin(ctrl pty.Controller) error {
+	return ctrl.InjectCommand(h.spec.Login.PTYCommand)
+}
+
+// IsLoginInProgress checks the output against the progress patterns.
+func (h *LoginHandler) IsLoginInProgress(output string) bool {
+	return matchAny(h.progress, output) != ""
+}
+
+// IsLoginComplete checks the output against the success patterns.
+func (h *LoginHandler) IsLoginComplete(output string) bool {
+	return matchAny(h.success, output) != ""
+}
+
+// IsLoginFailed checks the output against the failure patterns and returns
+// the matching text as the message.
+func (h *LoginHandler) IsLoginFailed(output string) (bool, string) {
+	if m := matchAny(h.failure, output); m != "" {
+		return true, strings.TrimSpace(m)
+	}
+	return false, ""
+}
+
+// ExpectedPatterns returns the login patterns joined per state.
+func (h *LoginHandler) ExpectedPatterns() map[string]string {
+	return map[string]string{
+		"progress": joinPatterns(h.spec.Login.ProgressPatterns),
+		"success":  joinPatterns(h.spec.Login.SuccessPatterns),
+		"failure":  joinPatterns(h.spec.Login.FailurePatterns),
+	}
+}
+
+func mustCompileAll(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		compiled = append(compiled, regexp.MustCompile(p))
+	}
+	return compiled
+}
+
+// matchAny returns the first match of any pattern in s, or "".
+func matchAny(patterns []*regexp.Regexp, s string) string {
+	for _, re := range patterns {
+		if m := re.FindString(s); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+func joinPatterns(patterns []string) string {
+	if len(patterns) == 0 {
+		return ""
+	}
+	return "(?:" + strings.Join(patterns, ")|(?:") + ")"
+}
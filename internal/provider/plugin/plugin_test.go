@@ -0,0 +1,265 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/handoff"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/ratelimit"
+)
+
+const aiderSpec = `
+id: aider
+display_name: Aider
+auth_modes: [api-key]
+auth_files:
+  - path: ~/.aider/oauth-keys.env
+    description: Aider API keys
+    required: true
+  - path: ${AIDER_CONFIG_DIR:-~/.aider}/settings.json
+env:
+  HOME: "{home}"
+login:
+  command: [aider, --login]
+  pty_command: /login
+  progress_patterns: ['(?i)open this url']
+  success_patterns: ['(?i)logged in as \S+']
+  failure_patterns: ['(?i)invalid key[^\n]*']
+rate_limit_patterns: ['(?i)aider quota exhausted']
+account_url: https://aider.chat/
+`
+
+func writeSpec(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, dir, "aider.yaml", aiderSpec)
+	writeSpec(t, dir, "bad-id.yaml", "id: Not Valid\nauth_files: [{path: ~/x, required: true}]\n")
+	writeSpec(t, dir, "builtin.yml", "id: claude\nauth_files: [{path: ~/x, required: true}]\n")
+	writeSpec(t, dir, "typo.yaml", "id: typo\nauth_file: [{path: ~/x}]\n")
+	writeSpec(t, dir, "README.md", "not a spec")
+
+	specs, errs := LoadDir(dir)
+	if len(specs) != 1 || specs[0].ID != "aider" {
+		t.Fatalf("LoadDir() specs = %v, want [aider]", specs)
+	}
+	if len(errs) != 3 {
+		t.Errorf("LoadDir() errs = %v, want 3", errs)
+	}
+
+	spec := specs[0]
+	if spec.Bin != "aider" {
+		t.Errorf("Bin = %q, want default %q", spec.Bin, "aider")
+	}
+	if spec.Login.PTYCommand != "/login" {
+		t.Errorf("PTYCommand = %q", spec.Login.PTYCommand)
+	}
+}
+
+func TestLoadDir_MissingDir(t *testing.T) {
+	specs, errs := LoadDir(filepath.Join(t.TempDir(), "nope"))
+	if specs != nil || errs != nil {
+		t.Errorf("LoadDir(missing) = %v, %v; want nothing", specs, errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"no files", Spec{ID: "x"}, "auth_files"},
+		{"none required", Spec{ID: "x", AuthFiles: []AuthFileSpec{{Path: "~/a"}}}, "required"},
+		{"duplicate base", Spec{ID: "x", AuthFiles: []AuthFileSpec{{Path: "~/a/k", Required: true}, {Path: "~/b/k"}}}, "duplicate"},
+		{"bad mode", Spec{ID: "x", AuthModes: []string{"magic"}, AuthFiles: []AuthFileSpec{{Path: "~/a", Required: true}}}, "auth mode"},
+		{"bad pattern", Spec{ID: "x", RateLimitPatterns: []string{"("}, AuthFiles: []AuthFileSpec{{Path: "~/a", Required: true}}}, "pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	env := map[string]string{"HOME": "/home/u", "XDG": "/xdg"}
+	lookup := func(k string) string { return env[k] }
+
+	tests := map[string]string{
+		"~/.tool/auth.json":           "/home/u/.tool/auth.json",
+		"$XDG/tool/auth.json":         "/xdg/tool/auth.json",
+		"${XDG}/tool":                 "/xdg/tool",
+		"${UNSET:-~/.tool}/auth.json": "/home/u/.tool/auth.json",
+		"${XDG:-/other}/t":            "/xdg/t",
+	}
+	for in, want := range tests {
+		if got := expandPath(in, lookup); got != want {
+			t.Errorf("expandPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProvider_ProfileEnvAndStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, dir, "aider.yaml", aiderSpec)
+	spec, err := LoadFile(filepath.Join(dir, "aider.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(spec)
+	prof := &profile.Profile{Name: "work", Provider: "aider", BasePath: filepath.Join(dir, "profiles", "aider", "work")}
+	ctx := context.Background()
+
+	env, err := p.Env(ctx, prof)
+	if err != nil || env["HOME"] != prof.HomePath() {
+		t.Fatalf("Env() = %v, %v; want HOME=%s", env, err, prof.HomePath())
+	}
+
+	status, _ := p.Status(ctx, prof)
+	if status.LoggedIn {
+		t.Error("Status() logged in before auth files exist")
+	}
+
+	keyPath := filepath.Join(prof.HomePath(), ".aider", "oauth-keys.env")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("KEY=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	status, _ = p.Status(ctx, prof)
+	if !status.LoggedIn {
+		t.Error("Status() not logged in with required file in profile home")
+	}
+	res, _ := p.ValidateToken(ctx, prof, true)
+	if !res.Valid {
+		t.Errorf("ValidateToken() = %+v", res)
+	}
+
+	if err := p.Logout(ctx, prof); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Error("Logout() left the auth file")
+	}
+}
+
+func TestProvider_EnvWithoutHomeStaysIsolated(t *testing.T) {
+	dir := t.TempDir()
+	writeSpec(t, dir, "tool.yaml", `
+id: tool
+auth_files:
+  - path: ~/.tool/key
+    required: true
+  - path: ${TOOL_SHARED_DIR}/token
+env:
+  TOOL_CONFIG: "{xdg_config}/tool"
+login:
+  command: [tool, login]
+`)
+	spec, err := LoadFile(filepath.Join(dir, "tool.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(spec)
+	prof := &profile.Profile{Name: "work", Provider: "tool", BasePath: filepath.Join(dir, "profiles", "tool", "work")}
+	ctx := context.Background()
+
+	env, _ := p.Env(ctx, prof)
+	if env["HOME"] != prof.HomePath() || env["TOOL_CONFIG"] != filepath.Join(prof.XDGConfigPath(), "tool") {
+		t.Fatalf("Env() = %v, want HOME=%s plus the spec's env", env, prof.HomePath())
+	}
+	paths, err := p.profilePaths(prof)
+	if err != nil || paths[0] != filepath.Join(prof.HomePath(), ".tool", "key") {
+		t.Fatalf("profilePaths() = %v, %v; want ~ in the profile home", paths, err)
+	}
+
+	// A path that resolves outside the profile is never removed.
+	shared := t.TempDir()
+	t.Setenv("TOOL_SHARED_DIR", shared)
+	token := filepath.Join(shared, "token")
+	if err := os.WriteFile(token, []byte("t"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Logout(ctx, prof); err == nil || !strings.Contains(err.Error(), "outside profile") {
+		t.Errorf("Logout() error = %v, want refusal", err)
+	}
+	if _, err := os.Stat(token); err != nil {
+		t.Errorf("Logout() removed a file outside the profile: %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	writeSpec(t, dir, "aider.yaml", aiderSpec)
+
+	reg := provider.NewRegistry()
+	specs, errs := LoadAndRegister(reg, dir)
+	if len(specs) != 1 || len(errs) != 0 {
+		t.Fatalf("LoadAndRegister() = %v, %v", specs, errs)
+	}
+	t.Cleanup(func() { handoff.DefaultRegistry.Clear("aider") })
+
+	if _, ok := reg.Get("aider"); !ok {
+		t.Error("provider not in registry")
+	}
+
+	set, ok := authfile.GetAuthFileSet("aider")
+	if !ok || len(set.Files) != 2 {
+		t.Fatalf("GetAuthFileSet(aider) = %+v, %v", set, ok)
+	}
+	if want := filepath.Join(dir, ".aider", "oauth-keys.env"); set.Files[0].Path != want {
+		t.Errorf("auth file path = %q, want %q", set.Files[0].Path, want)
+	}
+
+	if got := ratelimit.ProviderFromString("aider"); got != "aider" {
+		t.Errorf("ProviderFromString(aider) = %q", got)
+	}
+	det, err := ratelimit.NewDetector(ratelimit.ProviderFromString("aider"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !det.Check("Error: Aider quota exhausted, try later") {
+		t.Error("detector did not match plugin rate limit pattern")
+	}
+
+	h := handoff.DefaultRegistry.Get("aider")
+	if h == nil {
+		t.Fatal("no handoff handler registered")
+	}
+	caps, _ := reg.Capabilities("aider")
+	if !caps.LoginHandoff || !caps.IsolatedProfiles || caps.TokenRefresh || caps.UsageAPI {
+		t.Errorf("Capabilities() = %+v, want isolated profiles and login handoff only", caps)
+	}
+	if !h.IsLoginComplete("Logged in as me@example.com") {
+		t.Error("IsLoginComplete() = false")
+	}
+	if failed, msg := h.IsLoginFailed("error: Invalid key provided"); !failed || msg != "Invalid key provided" {
+		t.Errorf("IsLoginFailed() = %v, %q", failed, msg)
+	}
+
+	if meta, ok := provider.GetProviderMeta("aider"); !ok || meta.AccountURL != "https://aider.chat/" {
+		t.Errorf("GetProviderMeta(aider) = %+v, %v", meta, ok)
+	}
+
+	// A second registration into the same registry is rejected.
+	if err := Register(reg, specs[0]); err == nil {
+		t.Error("Register() duplicate succeeded")
+	}
+}
@@ -0,0 +1,362 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/passthrough"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+// Provider adapts a Spec to the provider.Provider interface.
+type Provider struct {
+	spec *Spec
+}
+
+// New creates a provider from a validated spec.
+func New(spec *Spec) *Provider {
+	return &Provider{spec: spec}
+}
+
+// Spec returns the definition the provider was built from.
+func (p *Provider) Spec() *Spec {
+	return p.spec
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return p.spec.ID
+}
+
+// DisplayName returns the human-friendly name.
+func (p *Provider) DisplayName() string {
+	return p.spec.DisplayName
+}
+
+// DefaultBin returns the default binary name.
+func (p *Provider) DefaultBin() string {
+	return p.spec.Bin
+}
+
+// SupportedAuthModes returns the declared authentication modes.
+func (p *Provider) SupportedAuthModes() []provider.AuthMode {
+	modes := make([]provider.AuthMode, 0, len(p.spec.AuthModes))
+	for _, m := range p.spec.AuthModes {
+		modes = append(modes, provider.AuthMode(m))
+	}
+	return modes
+}
+
+// Capabilities reports the optional features a declarative provider gets:
+// isolated profiles always, and login handoff when a PTY command is declared.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		IsolatedProfiles: true,
+		LoginHandoff:     p.spec.Login.PTYCommand != "",
+	}
+}
+
+// AuthFiles returns the declared auth files at their system locations.
+func (p *Provider) AuthFiles() []provider.AuthFileSpec {
+	specs := make([]provider.AuthFileSpec, 0, len(p.spec.AuthFiles))
+	for _, f := range p.spec.AuthFiles {
+		specs = append(specs, provider.AuthFileSpec{
+			Path:        expandPath(f.Path, systemLookup),
+			Description: p.describe(f),
+			Required:    f.Required,
+		})
+	}
+	return specs
+}
+
+func (p *Provider) describe(f AuthFileSpec) string {
+	if f.Description != "" {
+		return f.Description
+	}
+	return p.spec.DisplayName + " " + filepath.Base(f.Path)
+}
+
+// profilePaths returns the declared auth file paths inside an isolated
+// profile, expanded against the profile's environment. "~" and $HOME always
+// mean the profile's pseudo-home, never the user's.
+func (p *Provider) profilePaths(prof *profile.Profile) ([]string, error) {
+	env, err := p.Env(context.Background(), prof)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(name string) string {
+		if name == "HOME" {
+			return prof.HomePath()
+		}
+		if v, ok := env[name]; ok {
+			return v
+		}
+		return systemLookup(name)
+	}
+
+	paths := make([]string, 0, len(p.spec.AuthFiles))
+	for _, f := range p.spec.AuthFiles {
+		paths = append(paths, expandPath(f.Path, lookup))
+	}
+	return paths, nil
+}
+
+// PrepareProfile creates the pseudo-home and passthrough symlinks.
+func (p *Provider) PrepareProfile(ctx context.Context, prof *profile.Profile) error {
+	homePath := prof.HomePath()
+	if err := os.MkdirAll(homePath, 0700); err != nil {
+		return fmt.Errorf("create home: %w", err)
+	}
+
+	mgr, err := passthrough.NewManager()
+	if err != nil {
+		return fmt.Errorf("create passthrough manager: %w", err)
+	}
+	if err := mgr.SetupPassthroughs(homePath); err != nil {
+		return fmt.Errorf("setup passthroughs: %w", err)
+	}
+	return nil
+}
+
+// Env returns the environment for running the tool in this profile's
+// context: HOME is the profile's pseudo-home, and the spec's env section is
+// applied on top. Values may reference {home}, {xdg_config} and
+// {profile_dir}.
+func (p *Provider) Env(ctx context.Context, prof *profile.Profile) (map[string]string, error) {
+	env := map[string]string{"HOME": prof.HomePath()}
+	replacer := strings.NewReplacer(
+		"{home}", prof.HomePath(),
+		"{xdg_config}", prof.XDGConfigPath(),
+		"{profile_dir}", prof.BasePath,
+	)
+	for k, v := range p.spec.Env {
+		env[k] = replacer.Replace(v)
+	}
+	return env, nil
+}
+
+// Login runs the declared login command with the profile's environment.
+func (p *Provider) Login(ctx context.Context, prof *profile.Profile) error {
+	if len(p.spec.Login.Command) == 0 {
+		return fmt.Errorf("%s: no login command defined in %s", p.spec.ID, p.spec.Source)
+	}
+	env, err := p.Env(ctx, prof)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, p.spec.Login.Command[0], p.spec.Login.Command[1:]...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	fmt.Printf("Starting %s login...\n", p.spec.DisplayName)
+	return cmd.Run()
+}
+
+// Logout removes the profile's auth files. Paths that resolve outside the
+// profile directory (through a variable from the user's environment) are
+// refused rather than removed.
+func (p *Provider) Logout(ctx context.Context, prof *profile.Profile) error {
+	paths, err := p.profilePaths(prof)
+	if err != nil {
+		return err
+	}
+	base := filepath.Clean(prof.BasePath)
+	for _, path := range paths {
+		if rel, err := filepath.Rel(base, path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to remove %s: outside profile %s", path, base)
+		}
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// Status reports the profile as logged in when every required auth file
+// exists.
+func (p *Provider) Status(ctx context.Context, prof *profile.Profile) (*provider.ProfileStatus, error) {
+	status := &provider.ProfileStatus{
+		HasLockFile: prof.IsLocked(),
+	}
+	paths, err := p.profilePaths(prof)
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	status.LoggedIn = true
+	for i, f := range p.spec.AuthFiles {
+		if !f.Required {
+			continue
+		}
+		if _, err := os.Stat(paths[i]); err != nil {
+			status.LoggedIn = false
+			break
+		}
+	}
+	return status, nil
+}
+
+// ValidateProfile checks that the profile's home directory exists.
+func (p *Provider) ValidateProfile(ctx context.Context, prof *profile.Profile) error {
+	if _, err := os.Stat(prof.HomePath()); os.IsNotExist(err) {
+		return fmt.Errorf("home directory missing")
+	}
+	return nil
+}
+
+// DetectExistingAuth checks the declared auth file locations.
+func (p *Provider) DetectExistingAuth() (*provider.AuthDetection, error) {
+	detection := &provider.AuthDetection{
+		Provider:  p.ID(),
+		Locations: []provider.AuthLocation{},
+	}
+
+	for _, spec := range p.AuthFiles() {
+		loc := provider.AuthLocation{
+			Path:        spec.Path,
+			Description: spec.Description,
+		}
+		info, err := os.Stat(spec.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				loc.ValidationError = fmt.Sprintf("stat error: %v", err)
+			}
+			detection.Locations = append(detection.Locations, loc)
+			continue
+		}
+
+		loc.Exists = true
+		loc.LastModified = info.ModTime()
+		loc.FileSize = info.Size()
+		if err := validateAuthFile(spec.Path); err != nil {
+			loc.ValidationError = err.Error()
+		} else {
+			loc.IsValid = true
+		}
+		detection.Locations = append(detection.Locations, loc)
+
+		if loc.IsValid && spec.Required {
+			detection.Found = true
+			if detection.Primary == nil {
+				locCopy := loc
+				detection.Primary = &locCopy
+			}
+		}
+	}
+	return detection, nil
+}
+
+// ImportAuth copies a detected auth file to the matching location inside
+// the profile.
+func (p *Provider) ImportAuth(ctx context.Context, sourcePath string, prof *profile.Profile) ([]string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source auth file not found: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("source path is a directory, not a file")
+	}
+
+	paths, err := p.profilePaths(prof)
+	if err != nil {
+		return nil, err
+	}
+	basename := filepath.Base(sourcePath)
+	for _, target := range paths {
+		if filepath.Base(target) != basename {
+			continue
+		}
+		if err := copyFile(sourcePath, target); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", basename, err)
+		}
+		return []string{target}, nil
+	}
+	return nil, fmt.Errorf("%s is not an auth file of %s", basename, p.spec.ID)
+}
+
+// ValidateToken checks that the required auth files exist and, for JSON
+// files, parse. Plugins have no API to call, so validation is always passive.
+func (p *Provider) ValidateToken(ctx context.Context, prof *profile.Profile, passive bool) (*provider.ValidationResult, error) {
+	result := &provider.ValidationResult{
+		Provider:  p.ID(),
+		Profile:   prof.Name,
+		Method:    "passive",
+		CheckedAt: time.Now(),
+	}
+	paths, err := p.profilePaths(prof)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range p.spec.AuthFiles {
+		if !f.Required {
+			continue
+		}
+		if err := validateAuthFile(paths[i]); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// validateAuthFile checks that path exists, is non-empty and, if it looks
+// like JSON, parses.
+func validateAuthFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s not found", filepath.Base(path))
+		}
+		return fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" {
+		return fmt.Errorf("%s is empty", filepath.Base(path))
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") || strings.HasPrefix(trimmed, "{") {
+		if !json.Valid([]byte(trimmed)) {
+			return fmt.Errorf("%s is not valid JSON", filepath.Base(path))
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
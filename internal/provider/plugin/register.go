@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/handoff"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/ratelimit"
+)
+
+// AuthFileSet returns the vault file set for the spec's tool.
+func (s *Spec) AuthFileSet() authfile.AuthFileSet {
+	set := authfile.AuthFileSet{Tool: s.ID}
+	for _, f := range s.AuthFiles {
+		set.Files = append(set.Files, authfile.AuthFileSpec{
+			Tool:        s.ID,
+			Path:        expandPath(f.Path, systemLookup),
+			Description: f.Description,
+			Required:    f.Required,
+			JSONKeys:    f.JSONKeys,
+		})
+	}
+	return set
+}
+
+// Register makes a plugin provider available everywhere built-in providers
+// are looked up: the provider registry, vault file sets, rate limit
+// detection, login handoff and provider metadata.
+func Register(reg *provider.Registry, spec *Spec) error {
+	if reg != nil {
+		if _, exists := reg.Get(spec.ID); exists {
+			return fmt.Errorf("%s: provider %q is already registered", spec.Source, spec.ID)
+		}
+		reg.Register(New(spec))
+	}
+
+	authfile.RegisterAuthFileSet(spec.ID, spec.AuthFileSet)
+
+	if len(spec.RateLimitPatterns) > 0 {
+		if err := ratelimit.RegisterPatterns(ratelimit.Provider(spec.ID), spec.RateLimitPatterns); err != nil {
+			return fmt.Errorf("%s: %w", spec.Source, err)
+		}
+	}
+
+	if spec.Login.PTYCommand != "" {
+		handoff.DefaultRegistry.Register(NewLoginHandler(spec))
+	}
+
+	provider.RegisterProviderMeta(provider.ProviderMeta{
+		ID:          spec.ID,
+		DisplayName: spec.DisplayName,
+		AccountURL:  spec.AccountURL,
+		Description: spec.AccountDescription,
+	})
+	return nil
+}
+
+// LoadAndRegister loads every plugin in dir and registers it. Plugins that
+// fail to load or register are skipped and reported in errs.
+func LoadAndRegister(reg *provider.Registry, dir string) (specs []*Spec, errs []error) {
+	loaded, errs := LoadDir(dir)
+	for _, spec := range loaded {
+		if err := Register(reg, spec); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	return specs, errs
+}
@@ -0,0 +1,241 @@
+// Package plugin loads declarative provider definitions from YAML.
+//
+// A file in providers.d (next to config.yaml, e.g. ~/.caam/providers.d/aider.yaml)
+// describes a CLI tool well enough for caam to back up, activate, run and
+// hand off its accounts without a compiled-in adapter:
+//
+//	id: aider
+//	display_name: Aider
+//	bin: aider
+//	auth_modes: [api-key]
+//	auth_files:
+//	  - path: ~/.aider/oauth-keys.env
+//	    description: Aider API keys
+//	    required: true
+//	env:                      # isolated profile overrides
+//	  HOME: "{home}"
+//	login:
+//	  command: [aider, --login]
+//	  pty_command: /login     # injected into a running session
+//	  success_patterns: ['(?i)logged in']
+//	  failure_patterns: ['(?i)invalid key']
+//	rate_limit_patterns: ['(?i)rate.?limit', '\b429\b']
+//	account_url: https://aider.chat/
+//
+// Auth file paths expand "~" and $VAR / ${VAR} / ${VAR:-default}. For an
+// isolated profile the same paths are expanded against the profile's env, so
+// "~/.aider/oauth-keys.env" lands in the profile's pseudo-HOME.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/config"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
+)
+
+// DirName is the providers directory name, next to config.yaml.
+const DirName = "providers.d"
+
+// builtinIDs are providers with compiled-in adapters; plugins cannot
+// replace them.
+var builtinIDs = map[string]bool{"codex": true, "claude": true, "gemini": true, "opencode": true}
+
+var idPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// Spec is a declarative provider definition.
+type Spec struct {
+	ID                 string            `yaml:"id"`
+	DisplayName        string            `yaml:"display_name"`
+	Bin                string            `yaml:"bin"`
+	AuthModes          []string          `yaml:"auth_modes"`
+	AuthFiles          []AuthFileSpec    `yaml:"auth_files"`
+	Env                map[string]string `yaml:"env"`
+	Login              LoginSpec         `yaml:"login"`
+	RateLimitPatterns  []string          `yaml:"rate_limit_patterns"`
+	AccountURL         string            `yaml:"account_url"`
+	AccountDescription string            `yaml:"account_description"`
+
+	// Source is the file the spec was loaded from.
+	Source string `yaml:"-"`
+}
+
+// AuthFileSpec declares one auth file of the tool.
+type AuthFileSpec struct {
+	Path        string   `yaml:"path"`
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	JSONKeys    []string `yaml:"json_keys"`
+}
+
+// LoginSpec declares how to log in and how to read login output.
+type LoginSpec struct {
+	// Command runs an interactive login for an isolated profile.
+	Command []string `yaml:"command"`
+
+	// PTYCommand is typed into a running session to re-login (handoff).
+	// Defaults to Command joined with spaces.
+	PTYCommand string `yaml:"pty_command"`
+
+	ProgressPatterns []string `yaml:"progress_patterns"`
+	SuccessPatterns  []string `yaml:"success_patterns"`
+	FailurePatterns  []string `yaml:"failure_patterns"`
+}
+
+// DefaultDir returns the providers.d directory (e.g., ~/.caam/providers.d).
+func DefaultDir() string {
+	return filepath.Join(filepath.Dir(config.SPMConfigPath()), DirName)
+}
+
+// LoadFile parses and validates a single provider definition.
+func LoadFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec Spec
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("%s: parse: %w", path, err)
+	}
+	spec.Source = path
+
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// LoadDir loads every *.yaml / *.yml file in dir, sorted by name. Invalid
+// files are reported in errs and skipped; a missing dir is not an error.
+func LoadDir(dir string) (specs []*Spec, errs []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+
+	var names []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string)
+	for _, name := range names {
+		spec, err := LoadFile(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if prev, ok := seen[spec.ID]; ok {
+			errs = append(errs, fmt.Errorf("%s: provider %q already defined in %s", spec.Source, spec.ID, prev))
+			continue
+		}
+		seen[spec.ID] = spec.Source
+		specs = append(specs, spec)
+	}
+	return specs, errs
+}
+
+// Validate checks the spec and fills defaults.
+func (s *Spec) Validate() error {
+	s.ID = strings.ToLower(strings.TrimSpace(s.ID))
+	if !idPattern.MatchString(s.ID) {
+		return fmt.Errorf("invalid id %q (lowercase letters, digits, '-' and '_')", s.ID)
+	}
+	if builtinIDs[s.ID] {
+		return fmt.Errorf("id %q is a built-in provider", s.ID)
+	}
+	if s.DisplayName == "" {
+		s.DisplayName = s.ID
+	}
+	if s.Bin == "" {
+		s.Bin = s.ID
+	}
+	if len(s.AuthModes) == 0 {
+		s.AuthModes = []string{string(provider.AuthModeOAuth)}
+	}
+	for _, mode := range s.AuthModes {
+		switch provider.AuthMode(mode) {
+		case provider.AuthModeOAuth, provider.AuthModeAPIKey, provider.AuthModeDeviceCode, provider.AuthModeVertexADC:
+		default:
+			return fmt.Errorf("unknown auth mode %q", mode)
+		}
+	}
+
+	if len(s.AuthFiles) == 0 {
+		return fmt.Errorf("auth_files is required")
+	}
+	names := make(map[string]bool)
+	required := false
+	for i, f := range s.AuthFiles {
+		if strings.TrimSpace(f.Path) == "" {
+			return fmt.Errorf("auth_files[%d]: path is required", i)
+		}
+		// The vault stores files by base name.
+		base := filepath.Base(f.Path)
+		if names[base] {
+			return fmt.Errorf("auth_files[%d]: duplicate file name %q", i, base)
+		}
+		names[base] = true
+		required = required || f.Required
+	}
+	if !required {
+		return fmt.Errorf("at least one auth file must be required")
+	}
+
+	for _, group := range [][]string{s.RateLimitPatterns, s.Login.ProgressPatterns, s.Login.SuccessPatterns, s.Login.FailurePatterns} {
+		for _, p := range group {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	if s.Login.PTYCommand == "" && len(s.Login.Command) > 0 {
+		s.Login.PTYCommand = strings.Join(s.Login.Command, " ")
+	}
+	return nil
+}
+
+// expandPath expands "~" and environment references in path. lookup
+// resolves variables (including HOME for "~").
+func expandPath(path string, lookup func(string) string) string {
+	expanded := os.Expand(path, func(name string) string {
+		if key, def, ok := strings.Cut(name, ":-"); ok {
+			if v := lookup(key); v != "" {
+				return v
+			}
+			return def
+		}
+		return lookup(name)
+	})
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") {
+		expanded = filepath.Join(lookup("HOME"), strings.TrimPrefix(expanded, "~"))
+	}
+	return filepath.Clean(expanded)
+}
+
+// systemLookup resolves variables from the process environment.
+func systemLookup(name string) string {
+	if name == "HOME" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home
+		}
+	}
+	return os.Getenv(name)
+}
@@ -3,6 +3,8 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
@@ -67,6 +69,98 @@ type DeviceCodeProvider interface {
 	LoginWithDeviceCode(ctx context.Context, p *profile.Profile) error
 }
 
+// Capability names an optional feature a provider may support.
+type Capability string
+
+const (
+	CapTokenRefresh     Capability = "token_refresh"     // caam can refresh OAuth tokens itself
+	CapUsageAPI         Capability = "usage_api"         // rate limit usage can be fetched from the provider
+	CapDeviceCode       Capability = "device_code"       // OAuth device code login (headless)
+	CapSessionResume    Capability = "session_resume"    // chat sessions can be resumed on another profile
+	CapIsolatedProfiles Capability = "isolated_profiles" // profiles can run in isolated HOME/XDG dirs
+	CapActiveValidation Capability = "active_validation" // tokens can be checked with a live API call
+	CapLoginHandoff     Capability = "login_handoff"     // login can be completed by a remote handoff handler
+)
+
+// AllCapabilities lists every capability in display order.
+var AllCapabilities = []Capability{
+	CapTokenRefresh,
+	CapUsageAPI,
+	CapDeviceCode,
+	CapSessionResume,
+	CapIsolatedProfiles,
+	CapActiveValidation,
+	CapLoginHandoff,
+}
+
+// Capabilities describes which optional features a provider supports.
+// Commands, the TUI and the API consult it instead of checking provider IDs.
+type Capabilities struct {
+	TokenRefresh     bool `json:"token_refresh"`
+	UsageAPI         bool `json:"usage_api"`
+	DeviceCode       bool `json:"device_code"`
+	SessionResume    bool `json:"session_resume"`
+	IsolatedProfiles bool `json:"isolated_profiles"`
+	ActiveValidation bool `json:"active_validation"`
+	LoginHandoff     bool `json:"login_handoff"`
+}
+
+// Has reports whether the capability is supported.
+func (c Capabilities) Has(capability Capability) bool {
+	switch capability {
+	case CapTokenRefresh:
+		return c.TokenRefresh
+	case CapUsageAPI:
+		return c.UsageAPI
+	case CapDeviceCode:
+		return c.DeviceCode
+	case CapSessionResume:
+		return c.SessionResume
+	case CapIsolatedProfiles:
+		return c.IsolatedProfiles
+	case CapActiveValidation:
+		return c.ActiveValidation
+	case CapLoginHandoff:
+		return c.LoginHandoff
+	}
+	return false
+}
+
+// List returns the supported capabilities in display order.
+func (c Capabilities) List() []Capability {
+	var result []Capability
+	for _, capability := range AllCapabilities {
+		if c.Has(capability) {
+			result = append(result, capability)
+		}
+	}
+	return result
+}
+
+// UnsupportedError is returned when an action needs a capability the
+// provider does not have. It matches errors.ErrUnsupported.
+type UnsupportedError struct {
+	Provider   string     `json:"provider"`
+	Capability Capability `json:"capability"`
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s does not support %s", e.Provider, e.Capability)
+}
+
+// Is makes errors.Is(err, errors.ErrUnsupported) true.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == errors.ErrUnsupported
+}
+
+// Require returns an *UnsupportedError unless p supports capability.
+func Require(p Provider, capability Capability) error {
+	if p.Capabilities().Has(capability) {
+		return nil
+	}
+	return &UnsupportedError{Provider: p.ID(), Capability: capability}
+}
+
 // ProfileStatus represents the current authentication state of a profile.
 type ProfileStatus struct {
 	LoggedIn    bool   // Whether the profile has valid auth credentials
@@ -135,6 +229,9 @@ type Provider interface {
 	// If passive=false, makes a minimal API call to verify the token is valid.
 	// Active validation may incur minimal API costs.
 	ValidateToken(ctx context.Context, p *profile.Profile, passive bool) (*ValidationResult, error)
+
+	// Capabilities reports which optional features this provider supports.
+	Capabilities() Capabilities
 }
 
 // Registry holds all registered providers.
@@ -169,6 +266,25 @@ func (r *Registry) All() []Provider {
 	return result
 }
 
+// Capabilities returns the capabilities of a registered provider.
+func (r *Registry) Capabilities(id string) (Capabilities, bool) {
+	p, ok := r.providers[id]
+	if !ok {
+		return Capabilities{}, false
+	}
+	return p.Capabilities(), true
+}
+
+// Require returns an error unless provider id is registered and supports
+// capability.
+func (r *Registry) Require(id string, capability Capability) error {
+	p, ok := r.providers[id]
+	if !ok {
+		return fmt.Errorf("unknown provider: %s", id)
+	}
+	return Require(p, capability)
+}
+
 // IDs returns the IDs of all registered providers.
 func (r *Registry) IDs() []string {
 	result := make([]string, 0, len(r.providers))
@@ -208,6 +324,21 @@ var providerMetaRegistry = map[string]ProviderMeta{
 		AccountURL:  "https://aistudio.google.com/",
 		Description: "Google AI Studio dashboard",
 	},
+	"opencode": {
+		ID:          "opencode",
+		DisplayName: "OpenCode",
+		AccountURL:  "https://opencode.ai/",
+		Description: "OpenCode (provider accounts are managed upstream)",
+	},
+}
+
+// RegisterProviderMeta adds metadata for a provider that is not built in
+// (e.g., a declarative provider plugin). Built-in entries are not replaced.
+func RegisterProviderMeta(meta ProviderMeta) {
+	if _, ok := providerMetaRegistry[meta.ID]; ok {
+		return
+	}
+	providerMetaRegistry[meta.ID] = meta
 }
 
 // GetProviderMeta returns metadata for a provider by ID.
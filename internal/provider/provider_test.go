@@ -3,6 +3,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ type testProvider struct {
 	id          string
 	displayName string
 	defaultBin  string
+	caps        Capabilities
 }
 
 func (p *testProvider) ID() string                     { return p.id }
@@ -50,6 +52,7 @@ func (p *testProvider) ValidateToken(ctx context.Context, prof *profile.Profile,
 		CheckedAt: time.Now(),
 	}, nil
 }
+func (p *testProvider) Capabilities() Capabilities { return p.caps }
 
 func TestAuthModeConstants(t *testing.T) {
 	tests := []struct {
@@ -333,6 +336,62 @@ func TestProviderInterfaceMethods(t *testing.T) {
 
 // ProviderMeta tests
 
+func TestCapabilitiesHasAndList(t *testing.T) {
+	caps := Capabilities{SessionResume: true, IsolatedProfiles: true}
+
+	if !caps.Has(CapSessionResume) || !caps.Has(CapIsolatedProfiles) {
+		t.Errorf("Has() missed a supported capability: %+v", caps)
+	}
+	if caps.Has(CapTokenRefresh) || caps.Has(Capability("bogus")) {
+		t.Error("Has() reported an unsupported capability")
+	}
+
+	got := caps.List()
+	if len(got) != 2 || got[0] != CapSessionResume || got[1] != CapIsolatedProfiles {
+		t.Errorf("List() = %v, want [session_resume isolated_profiles]", got)
+	}
+
+	// Every capability must map to a field.
+	all := Capabilities{true, true, true, true, true, true, true}
+	for _, c := range AllCapabilities {
+		if !all.Has(c) {
+			t.Errorf("Has(%q) not wired to a field", c)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	p := &testProvider{id: "test", caps: Capabilities{UsageAPI: true}}
+
+	if err := Require(p, CapUsageAPI); err != nil {
+		t.Errorf("Require(usage_api) = %v, want nil", err)
+	}
+
+	err := Require(p, CapSessionResume)
+	var unsup *UnsupportedError
+	if !errors.As(err, &unsup) {
+		t.Fatalf("Require(session_resume) = %v, want *UnsupportedError", err)
+	}
+	if unsup.Provider != "test" || unsup.Capability != CapSessionResume {
+		t.Errorf("UnsupportedError = %+v", unsup)
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Error("UnsupportedError should match errors.ErrUnsupported")
+	}
+
+	reg := NewRegistry()
+	reg.Register(p)
+	if err := reg.Require("test", CapUsageAPI); err != nil {
+		t.Errorf("Registry.Require() = %v", err)
+	}
+	if err := reg.Require("missing", CapUsageAPI); err == nil || errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Registry.Require(missing) = %v, want unknown provider error", err)
+	}
+	if caps, ok := reg.Capabilities("test"); !ok || !caps.UsageAPI {
+		t.Errorf("Registry.Capabilities() = %+v, %v", caps, ok)
+	}
+}
+
 func TestGetProviderMeta(t *testing.T) {
 	tests := []struct {
 		id          string
@@ -370,8 +429,8 @@ func TestGetProviderMeta(t *testing.T) {
 func TestAllProviderMeta(t *testing.T) {
 	all := AllProviderMeta()
 
-	if len(all) != 3 {
-		t.Errorf("AllProviderMeta() len = %d, want 3", len(all))
+	if len(all) != 4 {
+		t.Errorf("AllProviderMeta() len = %d, want 4", len(all))
 	}
 
 	// Verify all known providers are present
@@ -390,7 +449,7 @@ func TestAllProviderMeta(t *testing.T) {
 		}
 	}
 
-	for _, expected := range []string{"codex", "claude", "gemini"} {
+	for _, expected := range []string{"codex", "claude", "gemini", "opencode"} {
 		if !ids[expected] {
 			t.Errorf("AllProviderMeta() missing %q", expected)
 		}
@@ -400,8 +459,8 @@ func TestAllProviderMeta(t *testing.T) {
 func TestKnownProviderIDs(t *testing.T) {
 	ids := KnownProviderIDs()
 
-	if len(ids) != 3 {
-		t.Errorf("KnownProviderIDs() len = %d, want 3", len(ids))
+	if len(ids) != 4 {
+		t.Errorf("KnownProviderIDs() len = %d, want 4", len(ids))
 	}
 
 	// Verify all expected IDs are present
@@ -410,7 +469,7 @@ func TestKnownProviderIDs(t *testing.T) {
 		idMap[id] = true
 	}
 
-	for _, expected := range []string{"codex", "claude", "gemini"} {
+	for _, expected := range []string{"codex", "claude", "gemini", "opencode"} {
 		if !idMap[expected] {
 			t.Errorf("KnownProviderIDs() missing %q", expected)
 		}
@@ -23,6 +23,9 @@ const (
 
 	// ProviderGemini is Google's Gemini CLI.
 	ProviderGemini Provider = "gemini"
+
+	// ProviderOpenCode is the OpenCode CLI (multi-provider).
+	ProviderOpenCode Provider = "opencode"
 )
 
 // DefaultPatterns returns the default rate limit patterns for each provider.
@@ -52,6 +55,18 @@ func DefaultPatterns() map[Provider][]string {
 			`\b429\b`,
 			`(?i)too.?many.?requests`,
 		},
+		// OpenCode relays errors from whichever upstream provider is in use.
+		ProviderOpenCode: {
+			`(?i)rate.?limit`,
+			`(?i)usage.?limit`,
+			`(?i)RESOURCE_EXHAUSTED`,
+			`(?i)quota.?exceeded`,
+			`(?i)exceeded.*quota`,
+			`(?i)insufficient.?quota`,
+			`\b429\b`,
+			`(?i)too.?many.?requests`,
+			`(?i)overloaded`,
+		},
 	}
 }
 
@@ -78,6 +93,29 @@ func initDefaults() {
 	}
 }
 
+var (
+	registeredMu       sync.RWMutex
+	registeredPatterns = make(map[Provider][]*regexp.Regexp)
+)
+
+// RegisterPatterns adds default rate limit patterns for a provider that is
+// not built in (e.g., a declarative provider plugin).
+func RegisterPatterns(provider Provider, patterns []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, re)
+	}
+
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registeredPatterns[provider] = compiled
+	return nil
+}
+
 // Detector monitors output for rate limit patterns.
 type Detector struct {
 	mu       sync.RWMutex
@@ -98,7 +136,13 @@ func NewDetector(provider Provider, customPatterns []string) (*Detector, error)
 	if len(customPatterns) == 0 {
 		initDefaultsOnce.Do(initDefaults)
 		// Use pre-compiled defaults
-		if patterns, ok := defaultCompiledPatterns[provider]; ok {
+		patterns, ok := defaultCompiledPatterns[provider]
+		if !ok {
+			registeredMu.RLock()
+			patterns, ok = registeredPatterns[provider]
+			registeredMu.RUnlock()
+		}
+		if ok {
 			// Copy patterns to avoid sharing backing array, ensuring thread safety if Detector is modified
 			d.patterns = make([]*regexp.Regexp, len(patterns))
 			copy(d.patterns, patterns)
@@ -270,7 +314,8 @@ func (w *ObservingWriter) Flush() {
 }
 
 // ProviderFromString converts a string to a Provider, returning ProviderClaude
-// as default for unknown providers.
+// as default for unknown providers. Providers with registered patterns are
+// returned as-is.
 func ProviderFromString(s string) Provider {
 	switch strings.ToLower(s) {
 	case "claude":
@@ -279,7 +324,15 @@ func ProviderFromString(s string) Provider {
 		return ProviderCodex
 	case "gemini":
 		return ProviderGemini
+	case "opencode":
+		return ProviderOpenCode
 	default:
+		registeredMu.RLock()
+		_, ok := registeredPatterns[Provider(strings.ToLower(s))]
+		registeredMu.RUnlock()
+		if ok {
+			return Provider(strings.ToLower(s))
+		}
 		return ProviderClaude
 	}
 }
@@ -278,6 +278,7 @@ func TestProviderFromString(t *testing.T) {
 		{"Codex", ProviderCodex},
 		{"gemini", ProviderGemini},
 		{"Gemini", ProviderGemini},
+		{"opencode", ProviderOpenCode},
 		{"unknown", ProviderClaude}, // default
 		{"", ProviderClaude},        // default
 	}
@@ -305,4 +306,7 @@ func TestDefaultPatterns(t *testing.T) {
 	if len(patterns[ProviderGemini]) == 0 {
 		t.Error("No default patterns for Gemini")
 	}
+	if len(patterns[ProviderOpenCode]) == 0 {
+		t.Error("No default patterns for OpenCode")
+	}
 }
@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 // Claude Constants
@@ -84,7 +85,7 @@ var RefreshClaudeToken = func(ctx context.Context, refreshToken string) (*TokenR
 // UpdateClaudeAuth updates the auth files with the new token.
 func UpdateClaudeAuth(path string, resp *TokenResponse) error {
 	// Read existing file to preserve other fields
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return fmt.Errorf("read auth file: %w", err)
 	}
@@ -169,38 +170,16 @@ func claudeExpiryFromResponse(resp *TokenResponse) time.Time {
 	return time.Time{}
 }
 
+// writeAuthFile atomically rewrites an auth file. Vault files that are sealed
+// (encrypted vault mode) are re-sealed with the same key.
 func writeAuthFile(path string, auth map[string]interface{}) error {
 	updatedData, err := json.MarshalIndent(auth, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal updated auth: %w", err)
 	}
 
-	tmpPath := path + ".tmp"
-	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-
-	if _, err := f.Write(updatedData); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("write temp file: %w", err)
-	}
-
-	if err := f.Sync(); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("sync temp file: %w", err)
-	}
-
-	if err := f.Close(); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, path); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("rename file: %w", err)
+	if err := authfile.WriteVaultFile(path, updatedData); err != nil {
+		return fmt.Errorf("write auth file: %w", err)
 	}
 
 	return nil
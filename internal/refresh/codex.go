@@ -6,8 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 // Codex Constants
@@ -75,7 +76,7 @@ var RefreshCodexToken = func(ctx context.Context, refreshToken string) (*TokenRe
 // UpdateCodexAuth updates the auth file with the new token.
 func UpdateCodexAuth(path string, resp *TokenResponse) error {
 	// Read existing file
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return fmt.Errorf("read auth file: %w", err)
 	}
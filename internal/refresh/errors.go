@@ -39,3 +39,9 @@ func (e *UnsupportedError) Error() string {
 func (e *UnsupportedError) Unwrap() error {
 	return ErrUnsupported
 }
+
+// Is makes errors.Is(err, errors.ErrUnsupported) true, matching the
+// provider capability errors.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == errors.ErrUnsupported
+}
@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
 )
 
@@ -85,7 +85,7 @@ var RefreshGeminiToken = func(ctx context.Context, clientID, clientSecret, refre
 
 // ReadADC reads the ADC file to get credentials.
 func ReadADC(path string) (*ADC, error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read ADC file: %w", err)
 	}
@@ -104,7 +104,7 @@ func ReadADC(path string) (*ADC, error) {
 
 // UpdateGeminiAuth updates Gemini auth settings with a refreshed access token and expiry.
 func UpdateGeminiAuth(path string, resp *GoogleTokenResponse) error {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return fmt.Errorf("read auth file: %w", err)
 	}
@@ -133,40 +133,7 @@ func UpdateGeminiAuth(path string, resp *GoogleTokenResponse) error {
 		updateGeminiTokenMap(auth, resp)
 	}
 
-	updatedData, err := json.MarshalIndent(auth, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal updated auth: %w", err)
-	}
-
-	tmpPath := path + ".tmp"
-	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-
-	if _, err := f.Write(updatedData); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("write temp file: %w", err)
-	}
-
-	if err := f.Sync(); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("sync temp file: %w", err)
-	}
-
-	if err := f.Close(); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, path); err != nil {
-		_ = os.Remove(tmpPath)
-		return fmt.Errorf("rename file: %w", err)
-	}
-
-	return nil
+	return writeAuthFile(path, auth)
 }
 
 func updateGeminiTokenMap(m map[string]interface{}, resp *GoogleTokenResponse) {
@@ -57,18 +57,22 @@ func RefreshProfile(ctx context.Context, provider, profile string, vault *authfi
 
 	vaultPath := vault.ProfilePath(provider, profile)
 
-	var err error
+	var refreshFn func() error
 	switch provider {
 	case "claude":
-		err = refreshClaude(ctx, vaultPath)
+		refreshFn = func() error { return refreshClaude(ctx, vaultPath) }
 	case "codex":
-		err = refreshCodex(ctx, vaultPath)
+		refreshFn = func() error { return refreshCodex(ctx, vaultPath) }
 	case "gemini":
-		err = refreshGemini(ctx, provider, profile, store, vaultPath)
+		refreshFn = func() error { return refreshGemini(ctx, provider, profile, store, vaultPath) }
 	default:
 		return &UnsupportedError{Provider: provider, Reason: "provider not supported"}
 	}
 
+	// Keep the pre-refresh tokens in the profile's revision history so a bad
+	// refresh can be rolled back with 'caam restore-revision'.
+	err := vault.TrackRevision(provider, profile, authfile.RevisionRefresh, refreshFn)
+
 	if err != nil {
 		return err
 	}
@@ -186,7 +190,7 @@ func refreshGemini(ctx context.Context, provider, profile string, store *health.
 // getRefreshTokenFromJSON reads a JSON file and extracts the refresh_token field.
 // Supports snake_case and camelCase.
 func getRefreshTokenFromJSON(path string) (string, error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return "", err
 	}
@@ -277,7 +281,7 @@ func snapshotMatchesProfile(fileSet authfile.AuthFileSet, vault *authfile.Vault,
 			continue
 		}
 		backupPath := vault.BackupPath(fileSet.Tool, profile, filepath.Base(spec.Path))
-		backupData, err := os.ReadFile(backupPath)
+		backupData, err := authfile.ReadVaultFile(backupPath)
 		if err != nil {
 			return false
 		}
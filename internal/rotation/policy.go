@@ -0,0 +1,183 @@
+package rotation
+
+import (
+	"fmt"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+// Scores used for profiles that are excluded from selection. Both are below
+// -9000, the threshold the selectors treat as "not selectable".
+const (
+	cooldownScore = -10000
+	cappedScore   = -9500
+
+	// reservePenalty pushes reserve profiles below every regular candidate
+	// while keeping them selectable.
+	reservePenalty = 1000
+
+	// shareWindow is the activation history used to compare actual load
+	// against profile weights.
+	shareWindow = 7 * 24 * time.Hour
+)
+
+// TokenCounter reports tokens attributed to a profile since a point in time.
+// It backs the daily/weekly token caps of a RotationPolicy.
+type TokenCounter interface {
+	TokensSince(provider, profile string, since time.Time) (int64, error)
+}
+
+// The usage database counts tokens from ingested logs.
+var _ TokenCounter = (*caamdb.DB)(nil)
+
+// SetPolicies sets per-profile rotation policies (weights, caps, reserve).
+func (s *Selector) SetPolicies(policies map[string]*profile.RotationPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = policies
+}
+
+// SetTokenCounter sets the source used to enforce token caps. Without one,
+// token caps are reported but not enforced.
+func (s *Selector) SetTokenCounter(c TokenCounter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = c
+}
+
+func (s *Selector) policy(name string) *profile.RotationPolicy {
+	if s.policies == nil {
+		return nil
+	}
+	return s.policies[name]
+}
+
+// checkCaps reports whether a profile has reached one of its caps, with a
+// reason for each cap that was evaluated.
+func (s *Selector) checkCaps(tool, name string, now time.Time) (bool, []Reason) {
+	pol := s.policy(name)
+	if pol.IsZero() {
+		return false, nil
+	}
+
+	var reasons []Reason
+	capped := false
+
+	activationCap := func(label string, limit int, window time.Duration) {
+		if limit <= 0 || s.db == nil {
+			return
+		}
+		n, err := s.db.CountActivations(tool, name, now.Add(-window))
+		if err != nil {
+			return
+		}
+		if n >= limit {
+			capped = true
+			reasons = append(reasons, Reason{
+				Text:     fmt.Sprintf("Over %s activation cap (%d/%d)", label, n, limit),
+				Positive: false,
+			})
+		}
+	}
+	activationCap("daily", pol.DailyActivations, 24*time.Hour)
+	activationCap("weekly", pol.WeeklyActivations, 7*24*time.Hour)
+
+	tokenCap := func(label string, limit int64, window time.Duration) {
+		if limit <= 0 {
+			return
+		}
+		if s.tokens == nil {
+			reasons = append(reasons, Reason{
+				Text:     fmt.Sprintf("Token cap (%s) not enforced: no per-profile token data", label),
+				Positive: false,
+			})
+			return
+		}
+		n, err := s.tokens.TokensSince(tool, name, now.Add(-window))
+		if err != nil {
+			return
+		}
+		if n >= limit {
+			capped = true
+			reasons = append(reasons, Reason{
+				Text:     fmt.Sprintf("Over %s token cap (%d/%d)", label, n, limit),
+				Positive: false,
+			})
+		}
+	}
+	tokenCap("daily", pol.DailyTokens, 24*time.Hour)
+	tokenCap("weekly", pol.WeeklyTokens, 7*24*time.Hour)
+
+	return capped, reasons
+}
+
+// isReserve reports whether a profile is held in reserve.
+func (s *Selector) isReserve(name string) bool {
+	pol := s.policy(name)
+	return pol != nil && pol.Reserve
+}
+
+// loadShare compares a profile's weight with its share of recent activations.
+type loadShare struct {
+	target float64 // Weight share among candidates (0-1)
+	actual float64 // Share of activations in shareWindow (0-1)
+}
+
+// weightShares returns load shares for profiles, or nil if no profile has a
+// non-default weight.
+func (s *Selector) weightShares(tool string, profiles []string, now time.Time) map[string]loadShare {
+	weighted := false
+	var totalWeight float64
+	for _, p := range profiles {
+		w := s.policy(p).EffectiveWeight()
+		if w != 1 {
+			weighted = true
+		}
+		totalWeight += w
+	}
+	if !weighted || totalWeight == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int, len(profiles))
+	total := 0
+	if s.db != nil {
+		for _, p := range profiles {
+			if n, err := s.db.CountActivations(tool, p, now.Add(-shareWindow)); err == nil {
+				counts[p] = n
+				total += n
+			}
+		}
+	}
+
+	shares := make(map[string]loadShare, len(profiles))
+	for _, p := range profiles {
+		share := loadShare{target: s.policy(p).EffectiveWeight() / totalWeight}
+		if total > 0 {
+			share.actual = float64(counts[p]) / float64(total)
+		}
+		shares[p] = share
+	}
+	return shares
+}
+
+// weightReason explains a profile's load share.
+func weightReason(share loadShare, pol *profile.RotationPolicy) Reason {
+	return Reason{
+		Text: fmt.Sprintf("Weight %g: %.0f%% of recent activations (target %.0f%%)",
+			pol.EffectiveWeight(), share.actual*100, share.target*100),
+		Positive: share.actual <= share.target,
+	}
+}
+
+// noEligibleError explains why no profile could be selected.
+func noEligibleError(tool string, scores []ProfileScore) error {
+	for _, ps := range scores {
+		if ps.Score == cappedScore {
+			return fmt.Errorf("all profiles for %s are in cooldown or over their rotation caps", tool)
+		}
+	}
+	return fmt.Errorf("all profiles for %s are in cooldown", tool)
+}
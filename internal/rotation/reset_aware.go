@@ -0,0 +1,191 @@
+package rotation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// reserveRiskPerHour is the at-risk quota rate (percent per hour) below which
+// a profile is considered to be held in reserve rather than spent.
+const reserveRiskPerHour = 1.0
+
+// minResetHorizon bounds the time-to-reset used for scoring so a window that
+// is about to reset does not produce an unbounded score.
+const minResetHorizon = 5 * time.Minute
+
+// selectResetAware picks the profile whose remaining quota would otherwise be
+// lost soonest.
+//
+// Quota left in a window when it resets is wasted, so throughput across all
+// accounts is highest when the account with the earliest reset is drained
+// first. Each profile is scored by the share of its quota that expires per
+// hour until the window resets (the "at-risk" rate). When a burn rate is
+// known, the score is capped at it: quota beyond what can be consumed before
+// the reset is lost whichever profile is picked. Ties go to the earlier reset.
+func (s *Selector) selectResetAware(tool string, profiles []string) (*Result, error) {
+	now := s.clock()
+
+	sorted := make([]string, len(profiles))
+	copy(sorted, profiles)
+	sort.Strings(sorted)
+
+	var scores []ProfileScore
+	resets := make(map[string]time.Time, len(sorted))
+
+	for _, p := range sorted {
+		score := ProfileScore{Name: p}
+
+		if s.isInCooldown(tool, p, now) {
+			remaining := s.cooldownRemaining(tool, p, now)
+			score.Score = cooldownScore
+			score.Reasons = append(score.Reasons, Reason{
+				Text:     fmt.Sprintf("In cooldown (%s remaining)", formatDuration(remaining)),
+				Positive: false,
+			})
+			scores = append(scores, score)
+			continue
+		}
+		if capped, reasons := s.checkCaps(tool, p, now); capped {
+			score.Score = cappedScore
+			score.Reasons = reasons
+			scores = append(scores, score)
+			continue
+		}
+
+		var u *UsageInfo
+		if s.usageData != nil {
+			u = s.usageData[p]
+		}
+		switch {
+		case u == nil:
+			score.Reasons = append(score.Reasons, Reason{Text: "No usage data (kept in reserve)", Positive: false})
+		case u.Error != "":
+			score.Score = -10
+			score.Reasons = append(score.Reasons, Reason{Text: "Usage data unavailable", Positive: false})
+		default:
+			score.Score, score.Reasons, resets[p] = resetAwareScore(u, now)
+		}
+		if s.isReserve(p) {
+			score.Score -= reservePenalty
+			score.Reasons = append(score.Reasons, Reason{
+				Text:     "Reserve profile (used when no other profile is eligible)",
+				Positive: false,
+			})
+		}
+		scores = append(scores, score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		ri, rj := resets[scores[i].Name], resets[scores[j].Name]
+		if ri.IsZero() || rj.IsZero() {
+			return !ri.IsZero() && rj.IsZero()
+		}
+		return ri.Before(rj)
+	})
+
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("no profiles available for %s", tool)
+	}
+	if scores[0].Score < -9000 {
+		return nil, noEligibleError(tool, scores)
+	}
+
+	return &Result{
+		Selected:     scores[0].Name,
+		Algorithm:    AlgorithmResetAware,
+		Alternatives: scores,
+	}, nil
+}
+
+// resetAwareScore scores one profile's usage and returns the reset time of
+// the window that determined the score.
+func resetAwareScore(u *UsageInfo, now time.Time) (float64, []Reason, time.Time) {
+	type window struct {
+		name     string
+		used     int
+		resetsAt time.Time
+	}
+	windows := []window{
+		{"primary", u.PrimaryPercent, u.PrimaryResetsAt},
+		{"secondary", u.SecondaryPercent, u.SecondaryResetsAt},
+	}
+	if u.Model != "" {
+		windows = append(windows, window{u.Model, u.ModelPercent, u.ModelResetsAt})
+	}
+
+	// The tightest window bounds what can be used in any window.
+	left := 100 - max(u.PrimaryPercent, u.SecondaryPercent, u.ModelPercent)
+	if left <= 0 {
+		var resetsAt time.Time
+		for _, w := range windows {
+			if w.used >= 100 && w.resetsAt.After(resetsAt) {
+				resetsAt = w.resetsAt
+			}
+		}
+		text := "Limit reached"
+		if resetsAt.After(now) {
+			text = fmt.Sprintf("Limit reached (resets in %s)", formatDuration(resetsAt.Sub(now)))
+		}
+		return -5000, []Reason{{Text: text, Positive: false}}, resetsAt
+	}
+
+	var (
+		best     window
+		bestLeft int
+		bestRisk = -1.0
+	)
+	for _, w := range windows {
+		if !w.resetsAt.After(now) {
+			continue
+		}
+		wLeft := min(100-w.used, left)
+		hours := max(w.resetsAt.Sub(now), minResetHorizon).Hours()
+		if risk := float64(wLeft) / hours; risk > bestRisk {
+			best, bestLeft, bestRisk = w, wLeft, risk
+		}
+	}
+	if bestRisk < 0 {
+		return 0, []Reason{{
+			Text:     fmt.Sprintf("%d%% quota left, reset time unknown (kept in reserve)", left),
+			Positive: false,
+		}}, time.Time{}
+	}
+
+	untilReset := best.resetsAt.Sub(now)
+	var reasons []Reason
+	if bestRisk < reserveRiskPerHour {
+		reasons = append(reasons, Reason{
+			Text:     fmt.Sprintf("%d%% %s quota left, resets in %s (kept in reserve)", bestLeft, best.name, formatDuration(untilReset)),
+			Positive: false,
+		})
+	} else {
+		reasons = append(reasons, Reason{
+			Text:     fmt.Sprintf("%d%% %s quota expires unused in %s", bestLeft, best.name, formatDuration(untilReset)),
+			Positive: true,
+		})
+	}
+
+	score := bestRisk
+	if u.BurnRate > 0 {
+		usable := u.BurnRate * untilReset.Hours()
+		if usable < float64(bestLeft) {
+			score = min(score, u.BurnRate)
+			reasons = append(reasons, Reason{
+				Text:     fmt.Sprintf("~%.0f%% would be wasted even at %.1f%%/h", float64(bestLeft)-usable, u.BurnRate),
+				Positive: true,
+			})
+		} else {
+			depletion := time.Duration(float64(left) / u.BurnRate * float64(time.Hour))
+			reasons = append(reasons, Reason{
+				Text:     fmt.Sprintf("Depletes in %s at %.1f%%/h, before reset", formatDuration(depletion), u.BurnRate),
+				Positive: false,
+			})
+		}
+	}
+
+	return score, reasons, best.resetsAt
+}
@@ -3,10 +3,11 @@
 // Instead of always selecting profiles in predictable patterns, rotation algorithms
 // help vary which accounts are used and when, reducing detectable patterns.
 //
-// Three algorithms are available:
+// Four algorithms are available:
 //   - smart: Multi-factor scoring based on health, cooldown, recency, and usage balance
 //   - round_robin: Simple sequential rotation through available profiles
 //   - random: Random selection (least predictable but may cluster)
+//   - reset_aware: Uses the quota that would otherwise expire unused at the next window reset
 package rotation
 
 import (
@@ -19,6 +20,8 @@ import (
 
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/health"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
 
 // Algorithm identifies a rotation algorithm.
@@ -33,8 +36,16 @@ const (
 
 	// AlgorithmRandom selects a profile at random.
 	AlgorithmRandom Algorithm = "random"
+
+	// AlgorithmResetAware prefers the profile whose remaining quota resets
+	// soonest, keeping profiles with distant resets in reserve.
+	AlgorithmResetAware Algorithm = "reset_aware"
 )
 
+// modelExhaustedPenalty puts profiles whose window for the requested model is
+// used up behind every profile with quota left for that model.
+const modelExhaustedPenalty = 500
+
 // Reason explains why a profile was or wasn't selected.
 type Reason struct {
 	Text     string // Human-readable explanation
@@ -57,11 +68,60 @@ type Result struct {
 
 // UsageInfo represents real-time rate limit usage for a profile.
 type UsageInfo struct {
-	ProfileName      string
-	PrimaryPercent   int     // Primary window usage (0-100)
-	SecondaryPercent int     // Secondary window usage (0-100)
-	AvailScore       int     // Availability score (0-100, higher is better)
-	Error            string  // Error message if fetch failed
+	ProfileName       string
+	PrimaryPercent    int       // Primary window usage (0-100)
+	SecondaryPercent  int       // Secondary window usage (0-100)
+	PrimaryResetsAt   time.Time // When the primary window resets (zero if unknown)
+	SecondaryResetsAt time.Time // When the secondary window resets (zero if unknown)
+	BurnRate          float64   // Percent of the primary window consumed per hour (0 if unknown)
+	AvailScore        int       // Availability score (0-100, higher is better)
+	Error             string    // Error message if fetch failed
+
+	// Model-specific window, when usage was evaluated for a model that has
+	// its own limit (e.g. Opus on Claude).
+	Model         string    // Model the window applies to ("" if none)
+	ModelPercent  int       // Model window usage (0-100)
+	ModelResetsAt time.Time // When the model window resets (zero if unknown)
+}
+
+// UsageFromInfo converts fetched provider usage into selector input.
+// burnRate is the expected consumption in percent per hour, or 0 if unknown.
+func UsageFromInfo(name string, info *usage.UsageInfo, burnRate float64) *UsageInfo {
+	if info == nil {
+		return nil
+	}
+	u := &UsageInfo{
+		ProfileName: name,
+		AvailScore:  info.AvailabilityScore(),
+		BurnRate:    burnRate,
+		Error:       info.Error,
+	}
+	if info.PrimaryWindow != nil {
+		u.PrimaryPercent = info.PrimaryWindow.UsedPercent
+		u.PrimaryResetsAt = info.PrimaryWindow.ResetsAt
+	}
+	if info.SecondaryWindow != nil {
+		u.SecondaryPercent = info.SecondaryWindow.UsedPercent
+		u.SecondaryResetsAt = info.SecondaryWindow.ResetsAt
+	}
+	return u
+}
+
+// UsageForModel is UsageFromInfo for a session that will use model. Windows
+// that only limit other models are ignored, and the model's own window, if
+// any, is reported in the Model fields. An empty model is UsageFromInfo.
+func UsageForModel(name string, info *usage.UsageInfo, model string, burnRate float64) *UsageInfo {
+	scoped := info.ForModel(model)
+	u := UsageFromInfo(name, scoped, burnRate)
+	if u == nil || model == "" {
+		return u
+	}
+	if w := scoped.TertiaryWindow; w != nil {
+		u.Model = model
+		u.ModelPercent = w.UsedPercent
+		u.ModelResetsAt = w.ResetsAt
+	}
+	return u
 }
 
 // Selector performs profile selection based on configured algorithm.
@@ -73,6 +133,10 @@ type Selector struct {
 	rng         *rand.Rand
 	avoidRecent time.Duration // Don't select profiles used within this duration
 	usageData   map[string]*UsageInfo // Real-time usage data by profile name
+	policies    map[string]*profile.RotationPolicy
+	tokens      TokenCounter // Optional source for token caps
+	filter      Filter       // Optional candidate filter (selection policy)
+	clock       func() time.Time
 }
 
 // NewSelector creates a new profile selector.
@@ -83,9 +147,28 @@ func NewSelector(algorithm Algorithm, healthStore *health.Storage, db *caamdb.DB
 		db:          db,
 		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
 		avoidRecent: 30 * time.Minute, // Default: avoid profiles used in last 30 min
+		clock:       time.Now,
 	}
 }
 
+// Filter narrows the candidate profiles before any algorithm scores them.
+type Filter func(tool string, profiles []string) ([]string, error)
+
+// SetFilter sets a filter applied to candidates on every Select.
+func (s *Selector) SetFilter(f Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = f
+}
+
+// SetClock sets the time source used for cooldowns, caps and recency (useful
+// for simulation and testing).
+func (s *Selector) SetClock(clock func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
 // SetRNG sets a custom random number generator (useful for testing).
 func (s *Selector) SetRNG(rng *rand.Rand) {
 	s.mu.Lock()
@@ -129,8 +212,28 @@ func (s *Selector) Select(tool string, profiles []string, currentProfile string)
 		return nil, fmt.Errorf("no user profiles available for %s (only system profiles found)", tool)
 	}
 
-	// If only one profile, return it
-	if len(available) == 1 {
+	now := s.clock()
+	if s.filter != nil {
+		// The policy chooses among profiles that can be used right now, so
+		// a prefer rule whose profiles are all in cooldown or over their
+		// caps falls back to the others instead of failing. If none can be
+		// used, it sees them all and the algorithm reports why.
+		candidates := available
+		if eligible := s.usable(tool, available, now); len(eligible) > 0 {
+			candidates = eligible
+		}
+		filtered, err := s.filter(tool, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("no profiles for %s are allowed by the selection policy", tool)
+		}
+		available = filtered
+	}
+
+	// If only one usable profile, return it
+	if len(available) == 1 && len(s.usable(tool, available, now)) == 1 {
 		return &Result{
 			Selected:  available[0],
 			Algorithm: s.algorithm,
@@ -147,6 +250,8 @@ func (s *Selector) Select(tool string, profiles []string, currentProfile string)
 		return s.selectRandom(tool, available)
 	case AlgorithmRoundRobin:
 		return s.selectRoundRobin(tool, available, currentProfile)
+	case AlgorithmResetAware:
+		return s.selectResetAware(tool, available)
 	default:
 		return s.selectSmart(tool, available)
 	}
@@ -154,26 +259,34 @@ func (s *Selector) Select(tool string, profiles []string, currentProfile string)
 
 // selectRandom picks a profile at random.
 func (s *Selector) selectRandom(tool string, profiles []string) (*Result, error) {
-	// Filter out profiles in cooldown
-	var eligible []string
-	var inCooldown []ProfileScore
+	// Filter out profiles in cooldown or over their caps
+	var eligible, reserve []string
+	var excluded []ProfileScore
 
-	now := time.Now()
+	now := s.clock()
 	for _, p := range profiles {
 		if s.isInCooldown(tool, p, now) {
 			remaining := s.cooldownRemaining(tool, p, now)
-			inCooldown = append(inCooldown, ProfileScore{
+			excluded = append(excluded, ProfileScore{
 				Name:    p,
-				Score:   -10000,
+				Score:   cooldownScore,
 				Reasons: []Reason{{Text: fmt.Sprintf("In cooldown (%s remaining)", formatDuration(remaining)), Positive: false}},
 			})
+		} else if capped, reasons := s.checkCaps(tool, p, now); capped {
+			excluded = append(excluded, ProfileScore{Name: p, Score: cappedScore, Reasons: reasons})
+		} else if s.isReserve(p) {
+			reserve = append(reserve, p)
 		} else {
 			eligible = append(eligible, p)
 		}
 	}
 
+	// Reserve profiles are only drawn when nothing else is eligible.
+	if len(eligible) == 0 {
+		eligible, reserve = reserve, nil
+	}
 	if len(eligible) == 0 {
-		return nil, fmt.Errorf("all profiles for %s are in cooldown", tool)
+		return nil, noEligibleError(tool, excluded)
 	}
 
 	idx := s.rng.Intn(len(eligible))
@@ -189,7 +302,14 @@ func (s *Selector) selectRandom(tool string, profiles []string) (*Result, error)
 		}
 		alternatives = append(alternatives, ProfileScore{Name: p, Score: score, Reasons: reasons})
 	}
-	alternatives = append(alternatives, inCooldown...)
+	for _, p := range reserve {
+		alternatives = append(alternatives, ProfileScore{
+			Name:    p,
+			Score:   100 - reservePenalty,
+			Reasons: []Reason{{Text: "Reserve profile (used when no other profile is eligible)", Positive: false}},
+		})
+	}
+	alternatives = append(alternatives, excluded...)
 
 	return &Result{
 		Selected:     selected,
@@ -199,6 +319,9 @@ func (s *Selector) selectRandom(tool string, profiles []string) (*Result, error)
 }
 
 // selectRoundRobin picks the next profile in sequence.
+//
+// When profiles carry weights, the next profile is the one furthest below its
+// target share of recent activations instead; ties keep sequence order.
 func (s *Selector) selectRoundRobin(tool string, profiles []string, currentProfile string) (*Result, error) {
 	// Sort profiles for consistent ordering
 	sorted := make([]string, len(profiles))
@@ -214,63 +337,116 @@ func (s *Selector) selectRoundRobin(tool string, profiles []string, currentProfi
 		}
 	}
 
-	// Filter out profiles in cooldown, find next available
-	now := time.Now()
+	// Filter out profiles in cooldown or over their caps
+	now := s.clock()
 	var alternatives []ProfileScore
+	excluded := make(map[string]bool)
 
 	for _, p := range sorted {
 		if s.isInCooldown(tool, p, now) {
 			remaining := s.cooldownRemaining(tool, p, now)
+			excluded[p] = true
 			alternatives = append(alternatives, ProfileScore{
 				Name:    p,
-				Score:   -10000,
+				Score:   cooldownScore,
 				Reasons: []Reason{{Text: fmt.Sprintf("In cooldown (%s remaining)", formatDuration(remaining)), Positive: false}},
 			})
+		} else if capped, reasons := s.checkCaps(tool, p, now); capped {
+			excluded[p] = true
+			alternatives = append(alternatives, ProfileScore{Name: p, Score: cappedScore, Reasons: reasons})
 		}
 	}
 
+	// Reserve profiles are only used when every other profile is excluded.
+	useReserve := true
+	for _, p := range sorted {
+		if !excluded[p] && !s.isReserve(p) {
+			useReserve = false
+			break
+		}
+	}
+	isCandidate := func(p string) bool {
+		return !excluded[p] && (useReserve || !s.isReserve(p))
+	}
+
+	// Weighted rotation needs activation history to measure shares.
+	var shares map[string]loadShare
+	if s.db != nil {
+		shares = s.weightShares(tool, sorted, now)
+	}
+
 	// Try each profile starting from the one after current
+	var candidate string
+	bestDeficit := 0.0
 	for i := 0; i < len(sorted); i++ {
 		nextIdx := (currentIdx + 1 + i) % len(sorted)
-		candidate := sorted[nextIdx]
-
-		if !s.isInCooldown(tool, candidate, now) {
-			// Add all non-cooldown profiles to alternatives
-			for j, p := range sorted {
-				if !s.isInCooldown(tool, p, now) {
-					position := (j - currentIdx + len(sorted)) % len(sorted)
-					reasons := []Reason{{Text: fmt.Sprintf("Position %d in rotation", position), Positive: true}}
-					if p == candidate {
-						reasons = append(reasons, Reason{Text: "Next in sequence", Positive: true})
-					}
-					alternatives = append(alternatives, ProfileScore{
-						Name:    p,
-						Score:   float64(len(sorted) - position),
-						Reasons: reasons,
-					})
-				}
-			}
+		p := sorted[nextIdx]
+		if !isCandidate(p) {
+			continue
+		}
+		if shares == nil {
+			candidate = p
+			break
+		}
+		// Only stay on the current profile if nothing else is eligible.
+		if p == currentProfile && candidate != "" {
+			continue
+		}
+		if deficit := shares[p].target - shares[p].actual; candidate == "" || deficit > bestDeficit+1e-9 {
+			candidate, bestDeficit = p, deficit
+		}
+	}
 
-			// Sort by score desc
-			sort.Slice(alternatives, func(i, j int) bool {
-				return alternatives[i].Score > alternatives[j].Score
-			})
+	if candidate == "" {
+		return nil, noEligibleError(tool, alternatives)
+	}
 
-			return &Result{
-				Selected:     candidate,
-				Algorithm:    AlgorithmRoundRobin,
-				Alternatives: alternatives,
-			}, nil
+	// Add all non-excluded profiles to alternatives
+	for j, p := range sorted {
+		if excluded[p] {
+			continue
+		}
+		position := (j - currentIdx + len(sorted)) % len(sorted)
+		reasons := []Reason{{Text: fmt.Sprintf("Position %d in rotation", position), Positive: true}}
+		score := float64(len(sorted) - position)
+		if shares != nil {
+			reasons = append(reasons, weightReason(shares[p], s.policy(p)))
 		}
+		if !isCandidate(p) {
+			score -= reservePenalty
+			reasons = append(reasons, Reason{Text: "Reserve profile (used when no other profile is eligible)", Positive: false})
+		}
+		if p == candidate {
+			if shares != nil {
+				reasons = append(reasons, Reason{Text: "Furthest below its target share", Positive: true})
+			} else {
+				reasons = append(reasons, Reason{Text: "Next in sequence", Positive: true})
+			}
+		}
+		alternatives = append(alternatives, ProfileScore{
+			Name:    p,
+			Score:   score,
+			Reasons: reasons,
+		})
 	}
 
-	return nil, fmt.Errorf("all profiles for %s are in cooldown", tool)
+	// Sort by score desc
+	sort.Slice(alternatives, func(i, j int) bool {
+		return alternatives[i].Score > alternatives[j].Score
+	})
+
+	return &Result{
+		Selected:     candidate,
+		Algorithm:    AlgorithmRoundRobin,
+		Alternatives: alternatives,
+	}, nil
 }
 
 // selectSmart uses multi-factor scoring to select the best profile.
 func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error) {
-	now := time.Now()
+	now := s.clock()
 	var scores []ProfileScore
+	shares := s.weightShares(tool, profiles, now)
 
 	for _, p := range profiles {
 		score := ProfileScore{Name: p, Score: 0}
@@ -278,7 +454,7 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 		// Factor 1: Cooldown (disqualifying)
 		if s.isInCooldown(tool, p, now) {
 			remaining := s.cooldownRemaining(tool, p, now)
-			score.Score = -10000
+			score.Score = cooldownScore
 			score.Reasons = append(score.Reasons, Reason{
 				Text:     fmt.Sprintf("In cooldown (%s remaining)", formatDuration(remaining)),
 				Positive: false,
@@ -287,6 +463,15 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 			continue
 		}
 
+		// Factor 1b: Rotation caps (disqualifying)
+		capped, capReasons := s.checkCaps(tool, p, now)
+		score.Reasons = append(score.Reasons, capReasons...)
+		if capped {
+			score.Score = cappedScore
+			scores = append(scores, score)
+			continue
+		}
+
 		// Factor 2: Health status
 		if s.healthStore != nil {
 			h, err := s.healthStore.GetProfile(tool, p)
@@ -365,7 +550,7 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 		// Factor 3: Recency (prefer profiles not used recently)
 		lastUsed := s.getLastActivation(tool, p)
 		if !lastUsed.IsZero() {
-			since := time.Since(lastUsed)
+			since := now.Sub(lastUsed)
 			if since < s.avoidRecent {
 				penalty := float64(s.avoidRecent-since) / float64(time.Hour) * 50
 				score.Score -= penalty
@@ -424,6 +609,28 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 						Positive: false,
 					})
 				}
+
+				if usage.Model != "" {
+					switch {
+					case usage.ModelPercent >= 100:
+						score.Score -= modelExhaustedPenalty
+						score.Reasons = append(score.Reasons, Reason{
+							Text:     fmt.Sprintf("%s limit reached", usage.Model),
+							Positive: false,
+						})
+					case usage.ModelPercent >= 80:
+						score.Score -= 30
+						score.Reasons = append(score.Reasons, Reason{
+							Text:     fmt.Sprintf("%s limit %d%% used (near limit)", usage.Model, usage.ModelPercent),
+							Positive: false,
+						})
+					default:
+						score.Reasons = append(score.Reasons, Reason{
+							Text:     fmt.Sprintf("%s limit %d%% used", usage.Model, usage.ModelPercent),
+							Positive: true,
+						})
+					}
+				}
 			} else if usage != nil && usage.Error != "" {
 				// Fetching failed - slight penalty but don't disqualify
 				score.Score -= 10
@@ -434,7 +641,22 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 			}
 		}
 
-		// Factor 5: Small random jitter to break ties
+		// Factor 5: Weight (favor profiles below their target share of load)
+		if share, ok := shares[p]; ok {
+			score.Score += (share.target - share.actual) * 200
+			score.Reasons = append(score.Reasons, weightReason(share, s.policy(p)))
+		}
+
+		// Factor 6: Reserve profiles only win when nothing else is eligible
+		if s.isReserve(p) {
+			score.Score -= reservePenalty
+			score.Reasons = append(score.Reasons, Reason{
+				Text:     "Reserve profile (used when no other profile is eligible)",
+				Positive: false,
+			})
+		}
+
+		// Factor 7: Small random jitter to break ties
 		jitter := s.rng.Float64() * 5
 		score.Score += jitter
 
@@ -452,7 +674,7 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 	}
 
 	if scores[0].Score < -9000 {
-		return nil, fmt.Errorf("all profiles for %s are in cooldown", tool)
+		return nil, noEligibleError(tool, scores)
 	}
 
 	return &Result{
@@ -462,6 +684,22 @@ func (s *Selector) selectSmart(tool string, profiles []string) (*Result, error)
 	}, nil
 }
 
+// usable returns the profiles that are neither in cooldown nor over their
+// rotation caps.
+func (s *Selector) usable(tool string, profiles []string, now time.Time) []string {
+	var out []string
+	for _, p := range profiles {
+		if s.isInCooldown(tool, p, now) {
+			continue
+		}
+		if capped, _ := s.checkCaps(tool, p, now); capped {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
 // isInCooldown checks if a profile is currently in cooldown.
 func (s *Selector) isInCooldown(tool, profile string, now time.Time) bool {
 	if s.db == nil {
@@ -559,7 +797,7 @@ func FormatResult(r *Result) string {
 		}
 	}
 
-	// Show alternatives (excluding selected and cooldown profiles)
+	// Show alternatives (excluding selected, cooldown and capped profiles)
 	var alts []ProfileScore
 	for _, ps := range r.Alternatives {
 		if ps.Name != r.Selected && ps.Score > -9000 {
@@ -590,9 +828,12 @@ func FormatResult(r *Result) string {
 	}
 
 	// Show cooldown profiles
-	var cooldowns []ProfileScore
+	var cooldowns, capped []ProfileScore
 	for _, ps := range r.Alternatives {
-		if ps.Score <= -9000 {
+		switch {
+		case ps.Score == cappedScore:
+			capped = append(capped, ps)
+		case ps.Score <= -9000:
 			cooldowns = append(cooldowns, ps)
 		}
 	}
@@ -608,5 +849,51 @@ func FormatResult(r *Result) string {
 		}
 	}
 
+	if len(capped) > 0 {
+		sb.WriteString("\nExcluded by rotation caps:\n")
+		for _, ps := range capped {
+			sb.WriteString(fmt.Sprintf("  %s", ps.Name))
+			if len(ps.Reasons) > 0 {
+				sb.WriteString(fmt.Sprintf(" - %s", ps.Reasons[0].Text))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// FormatExplanation returns every candidate with its score and all reasons,
+// including profiles excluded by cooldowns or rotation caps.
+func FormatExplanation(r *Result) string {
+	if r == nil {
+		return "No selection result"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Selected: %s (algorithm: %s)\n", r.Selected, r.Algorithm))
+
+	for _, ps := range r.Alternatives {
+		status := fmt.Sprintf("score %.1f", ps.Score)
+		switch {
+		case ps.Score == cappedScore:
+			status = "excluded: over rotation cap"
+		case ps.Score <= -9000:
+			status = "excluded: in cooldown"
+		}
+		marker := " "
+		if ps.Name == r.Selected {
+			marker = "*"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s (%s)\n", marker, ps.Name, status))
+		for _, reason := range ps.Reasons {
+			prefix := "    + "
+			if !reason.Positive {
+				prefix = "    - "
+			}
+			sb.WriteString(prefix + reason.Text + "\n")
+		}
+	}
+
 	return sb.String()
 }
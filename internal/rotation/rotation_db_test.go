@@ -3,10 +3,12 @@ package rotation
 import (
 	"math/rand"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 )
 
 func TestSelectSmart_UsesLastActivationFromDB(t *testing.T) {
@@ -104,3 +106,104 @@ func TestSelectSmart_LastActivationNotLostWhenManyNonActivateEventsExist(t *test
 		t.Fatalf("Selected = %q, want %q", result.Selected, "b")
 	}
 }
+
+func logActivations(t *testing.T, db *caamdb.DB, name string, n int, at time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := db.LogEvent(caamdb.Event{
+			Type:        caamdb.EventActivate,
+			Provider:    "codex",
+			ProfileName: name,
+			Timestamp:   at.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatalf("LogEvent(%s) error = %v", name, err)
+		}
+	}
+}
+
+func TestSelectSmart_ExcludesProfileOverActivationCap(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("db.OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// "a" was used long ago but has hit its daily cap; "b" was just used.
+	logActivations(t, db, "a", 3, now.Add(-20*time.Hour))
+	logActivations(t, db, "b", 1, now.Add(-1*time.Minute))
+
+	s := NewSelector(AlgorithmSmart, nil, db)
+	s.SetRNG(rand.New(rand.NewSource(1)))
+	s.SetPolicies(map[string]*profile.RotationPolicy{"a": {DailyActivations: 3}})
+
+	result, err := s.Select("codex", []string{"a", "b"}, "")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Selected != "b" {
+		t.Fatalf("Selected = %q, want %q", result.Selected, "b")
+	}
+
+	var found bool
+	for _, ps := range result.Alternatives {
+		if ps.Name == "a" {
+			found = true
+			if ps.Score != cappedScore {
+				t.Errorf("a score = %v, want %v", ps.Score, float64(cappedScore))
+			}
+			if len(ps.Reasons) == 0 || ps.Reasons[0].Text != "Over daily activation cap (3/3)" {
+				t.Errorf("a reasons = %+v", ps.Reasons)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("capped profile missing from alternatives")
+	}
+
+	// Once every profile is capped, selection fails with a cap-specific error.
+	s.SetPolicies(map[string]*profile.RotationPolicy{
+		"a": {DailyActivations: 3},
+		"b": {WeeklyActivations: 1},
+	})
+	_, err = s.Select("codex", []string{"a", "b"}, "")
+	if err == nil || !strings.Contains(err.Error(), "rotation caps") {
+		t.Fatalf("Select() error = %v, want rotation caps error", err)
+	}
+}
+
+func TestSelectRoundRobin_Weighted(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("db.OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Equal history, but "a" should carry three times the load of "b".
+	logActivations(t, db, "a", 2, now.Add(-2*time.Hour))
+	logActivations(t, db, "b", 2, now.Add(-3*time.Hour))
+
+	s := NewSelector(AlgorithmRoundRobin, nil, db)
+	s.SetPolicies(map[string]*profile.RotationPolicy{"a": {Weight: 3}})
+
+	result, err := s.Select("codex", []string{"a", "b"}, "b")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Selected != "a" {
+		t.Fatalf("Selected = %q, want %q", result.Selected, "a")
+	}
+
+	// "a" is current, but it is still the only profile below target; rotation
+	// moves off it rather than repeating.
+	result, err = s.Select("codex", []string{"a", "b"}, "a")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Selected != "b" {
+		t.Fatalf("Selected = %q, want %q", result.Selected, "b")
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/usage"
 )
 
 func TestNewSelector(t *testing.T) {
@@ -321,6 +324,147 @@ func TestFormatResult(t *testing.T) {
 	})
 }
 
+func TestFormatExplanation(t *testing.T) {
+	result := &Result{
+		Selected:  "work",
+		Algorithm: AlgorithmSmart,
+		Alternatives: []ProfileScore{
+			{Name: "work", Score: 150, Reasons: []Reason{{Text: "Healthy", Positive: true}}},
+			{Name: "capped", Score: cappedScore, Reasons: []Reason{{Text: "Over daily activation cap (5/5)", Positive: false}}},
+			{Name: "blocked", Score: cooldownScore, Reasons: []Reason{{Text: "In cooldown (2h remaining)", Positive: false}}},
+		},
+	}
+
+	output := FormatExplanation(result)
+	for _, want := range []string{
+		"* work (score 150.0)",
+		"capped (excluded: over rotation cap)",
+		"Over daily activation cap (5/5)",
+		"blocked (excluded: in cooldown)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q: %q", want, output)
+		}
+	}
+
+	if output := FormatResult(result); !strings.Contains(output, "Excluded by rotation caps:") {
+		t.Errorf("FormatResult missing caps section: %q", output)
+	}
+}
+
+func TestSelect_ReserveProfiles(t *testing.T) {
+	policies := map[string]*profile.RotationPolicy{"backup": {Reserve: true}}
+
+	for _, algo := range []Algorithm{AlgorithmSmart, AlgorithmRoundRobin, AlgorithmRandom} {
+		t.Run(string(algo), func(t *testing.T) {
+			s := NewSelector(algo, nil, nil)
+			s.SetRNG(rand.New(rand.NewSource(1)))
+			s.SetPolicies(policies)
+
+			for i := 0; i < 10; i++ {
+				result, err := s.Select("claude", []string{"backup", "main"}, "")
+				if err != nil {
+					t.Fatalf("Select() error = %v", err)
+				}
+				if result.Selected != "main" {
+					t.Fatalf("Selected = %q, want %q", result.Selected, "main")
+				}
+			}
+
+			// With nothing else available the reserve is used.
+			result, err := s.Select("claude", []string{"backup"}, "")
+			if err != nil {
+				t.Fatalf("Select(reserve only) error = %v", err)
+			}
+			if result.Selected != "backup" {
+				t.Errorf("Selected = %q, want %q", result.Selected, "backup")
+			}
+		})
+	}
+}
+
+func TestCheckCaps_TokenCapWithoutCounter(t *testing.T) {
+	s := NewSelector(AlgorithmSmart, nil, nil)
+	s.SetPolicies(map[string]*profile.RotationPolicy{"a": {DailyTokens: 1000}})
+
+	capped, reasons := s.checkCaps("claude", "a", time.Now())
+	if capped {
+		t.Error("expected token cap not to exclude without a token counter")
+	}
+	if len(reasons) != 1 || !strings.Contains(reasons[0].Text, "not enforced") {
+		t.Errorf("reasons = %+v, want a not-enforced reason", reasons)
+	}
+
+	s.SetTokenCounter(fakeTokenCounter{"a": 1500})
+	capped, reasons = s.checkCaps("claude", "a", time.Now())
+	if !capped {
+		t.Error("expected profile over its token cap to be excluded")
+	}
+	if len(reasons) != 1 || reasons[0].Text != "Over daily token cap (1500/1000)" {
+		t.Errorf("reasons = %+v", reasons)
+	}
+}
+
+func TestSelect_Filter(t *testing.T) {
+	s := NewSelector(AlgorithmRoundRobin, nil, nil)
+	s.SetFilter(func(tool string, profiles []string) ([]string, error) {
+		var out []string
+		for _, p := range profiles {
+			if p != "a" {
+				out = append(out, p)
+			}
+		}
+		return out, nil
+	})
+
+	result, err := s.Select("claude", []string{"a", "b"}, "")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Selected != "b" {
+		t.Errorf("Selected = %q, want %q", result.Selected, "b")
+	}
+
+	if _, err := s.Select("claude", []string{"a"}, ""); err == nil || !strings.Contains(err.Error(), "selection policy") {
+		t.Errorf("Select() error = %v, want selection policy error", err)
+	}
+}
+
+func TestSelect_FilterFallsBackWhenPreferredUnusable(t *testing.T) {
+	s := NewSelector(AlgorithmRoundRobin, nil, nil)
+	s.SetPolicies(map[string]*profile.RotationPolicy{"a": {DailyTokens: 1000}})
+	s.SetTokenCounter(fakeTokenCounter{"a": 1500})
+	// Prefer "a" whenever it is a candidate, like a prefer rule.
+	s.SetFilter(func(tool string, profiles []string) ([]string, error) {
+		for _, p := range profiles {
+			if p == "a" {
+				return []string{"a"}, nil
+			}
+		}
+		return profiles, nil
+	})
+
+	result, err := s.Select("claude", []string{"a", "b"}, "")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Selected != "b" {
+		t.Errorf("Selected = %q, want %q (preferred profile is over its cap)", result.Selected, "b")
+	}
+
+	// A lone profile over its cap is not returned by the single-profile
+	// shortcut.
+	if _, err := s.Select("claude", []string{"a"}, ""); err == nil || !strings.Contains(err.Error(), "caps") {
+		t.Errorf("Select(capped only) error = %v, want caps error", err)
+	}
+}
+
+type fakeTokenCounter map[string]int64
+
+func (f fakeTokenCounter) TokensSince(provider, name string, since time.Time) (int64, error) {
+	return f[name], nil
+}
+
 func TestAlgorithmConstants(t *testing.T) {
 	// Ensure algorithm constants have expected values
 	if AlgorithmSmart != "smart" {
@@ -332,6 +476,167 @@ func TestAlgorithmConstants(t *testing.T) {
 	if AlgorithmRandom != "random" {
 		t.Errorf("AlgorithmRandom = %q, expected 'random'", AlgorithmRandom)
 	}
+	if AlgorithmResetAware != "reset_aware" {
+		t.Errorf("AlgorithmResetAware = %q, expected 'reset_aware'", AlgorithmResetAware)
+	}
+}
+
+func TestSelectResetAware(t *testing.T) {
+	now := time.Now()
+
+	findScore := func(r *Result, name string) *ProfileScore {
+		for i := range r.Alternatives {
+			if r.Alternatives[i].Name == name {
+				return &r.Alternatives[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("prefers quota that resets soonest", func(t *testing.T) {
+		s := NewSelector(AlgorithmResetAware, nil, nil)
+		s.SetUsageData(map[string]*UsageInfo{
+			"soon":      {PrimaryPercent: 60, PrimaryResetsAt: now.Add(time.Hour)},
+			"later":     {PrimaryPercent: 10, PrimaryResetsAt: now.Add(5 * 24 * time.Hour)},
+			"exhausted": {PrimaryPercent: 100, PrimaryResetsAt: now.Add(2 * time.Hour)},
+		})
+
+		result, err := s.Select("claude", []string{"later", "exhausted", "soon"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Algorithm != AlgorithmResetAware {
+			t.Errorf("expected algorithm %q, got %q", AlgorithmResetAware, result.Algorithm)
+		}
+		if result.Selected != "soon" {
+			t.Fatalf("expected 'soon', got %q", result.Selected)
+		}
+		if got := result.Alternatives[len(result.Alternatives)-1].Name; got != "exhausted" {
+			t.Errorf("expected exhausted profile last, got %q", got)
+		}
+
+		later := findScore(result, "later")
+		if later == nil || len(later.Reasons) == 0 || !strings.Contains(later.Reasons[0].Text, "reserve") {
+			t.Errorf("expected 'later' to be kept in reserve, got %+v", later)
+		}
+		exhausted := findScore(result, "exhausted")
+		if exhausted == nil || !strings.Contains(exhausted.Reasons[0].Text, "Limit reached") {
+			t.Errorf("expected limit reason for 'exhausted', got %+v", exhausted)
+		}
+	})
+
+	t.Run("secondary limit bounds usable quota", func(t *testing.T) {
+		s := NewSelector(AlgorithmResetAware, nil, nil)
+		s.SetUsageData(map[string]*UsageInfo{
+			"weekly-capped": {PrimaryPercent: 0, PrimaryResetsAt: now.Add(time.Hour), SecondaryPercent: 98, SecondaryResetsAt: now.Add(72 * time.Hour)},
+			"open":          {PrimaryPercent: 50, PrimaryResetsAt: now.Add(2 * time.Hour), SecondaryPercent: 20, SecondaryResetsAt: now.Add(72 * time.Hour)},
+		})
+
+		result, err := s.Select("claude", []string{"weekly-capped", "open"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Selected != "open" {
+			t.Errorf("expected 'open', got %q", result.Selected)
+		}
+	})
+
+	t.Run("burn rate caps quota that cannot be used before reset", func(t *testing.T) {
+		usage := map[string]*UsageInfo{
+			"short": {PrimaryPercent: 80, PrimaryResetsAt: now.Add(time.Hour)},
+			"long":  {PrimaryPercent: 50, PrimaryResetsAt: now.Add(2 * time.Hour)},
+		}
+
+		s := NewSelector(AlgorithmResetAware, nil, nil)
+		s.SetUsageData(usage)
+		result, err := s.Select("claude", []string{"short", "long"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Selected != "long" {
+			t.Fatalf("without burn rate expected 'long', got %q", result.Selected)
+		}
+
+		// At 10%/h neither window can be drained before it resets, so the
+		// earlier reset wins.
+		usage["short"].BurnRate = 10
+		usage["long"].BurnRate = 10
+		result, err = s.Select("claude", []string{"short", "long"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Selected != "short" {
+			t.Fatalf("with burn rate expected 'short', got %q", result.Selected)
+		}
+		short := findScore(result, "short")
+		if short == nil || len(short.Reasons) < 2 || !strings.Contains(short.Reasons[1].Text, "wasted") {
+			t.Errorf("expected wasted-quota reason, got %+v", short)
+		}
+	})
+
+	t.Run("works without usage data", func(t *testing.T) {
+		s := NewSelector(AlgorithmResetAware, nil, nil)
+		result, err := s.Select("claude", []string{"beta", "alpha"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Selected != "alpha" {
+			t.Errorf("expected deterministic 'alpha', got %q", result.Selected)
+		}
+	})
+}
+
+func TestSelect_ModelWindow(t *testing.T) {
+	resets := time.Now().Add(3 * time.Hour)
+	infos := map[string]*usage.UsageInfo{
+		// "a" is fresh overall but out of Opus quota.
+		"a": {
+			Provider:       "claude",
+			PrimaryWindow:  &usage.UsageWindow{Utilization: 0.1, UsedPercent: 10, ResetsAt: resets},
+			TertiaryWindow: &usage.UsageWindow{Utilization: 1, UsedPercent: 100, ResetsAt: resets},
+		},
+		"b": {
+			Provider:       "claude",
+			PrimaryWindow:  &usage.UsageWindow{Utilization: 0.5, UsedPercent: 50, ResetsAt: resets},
+			TertiaryWindow: &usage.UsageWindow{Utilization: 0.2, UsedPercent: 20, ResetsAt: resets},
+		},
+	}
+
+	tests := []struct {
+		algo  Algorithm
+		model string
+		want  string
+	}{
+		{AlgorithmSmart, "sonnet", "a"},
+		{AlgorithmSmart, "claude-opus-4-1", "b"},
+		{AlgorithmResetAware, "sonnet", "a"},
+		{AlgorithmResetAware, "opus", "b"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.algo)+"/"+tt.model, func(t *testing.T) {
+			data := make(map[string]*UsageInfo)
+			for name, info := range infos {
+				data[name] = UsageForModel(name, info, tt.model, 0)
+			}
+			s := NewSelector(tt.algo, nil, nil)
+			s.SetUsageData(data)
+
+			result, err := s.Select("claude", []string{"a", "b"}, "")
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if result.Selected != tt.want {
+				t.Errorf("Selected = %q, want %q\n%s", result.Selected, tt.want, FormatExplanation(result))
+			}
+		})
+	}
+
+	if u := UsageForModel("a", infos["a"], "sonnet", 0); u.Model != "" {
+		t.Errorf("sonnet usage has model window %q", u.Model)
+	}
+	if u := UsageForModel("a", infos["a"], "opus", 0); u.Model != "opus" || u.ModelPercent != 100 {
+		t.Errorf("opus usage = %+v, want Opus window at 100%%", u)
+	}
 }
 
 func TestSetAvoidRecent(t *testing.T) {
@@ -0,0 +1,403 @@
+package rotation
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+)
+
+// AlgorithmRecorded labels the simulation row that replays the profiles that
+// were actually used.
+const AlgorithmRecorded Algorithm = "recorded"
+
+// DefaultSimWindow is the quota window assumed by simulations (Claude and
+// Codex use 5-hour windows).
+const DefaultSimWindow = 5 * time.Hour
+
+// SimSession is one recorded period of work.
+type SimSession struct {
+	Start    time.Time
+	Duration time.Duration
+	Profile  string // Profile that was actually used
+}
+
+// SimHistory is the recorded usage of one provider, replayed by Simulate.
+type SimHistory struct {
+	Provider string
+	Profiles []string     // Candidate profiles
+	Sessions []SimSession // Oldest first
+	Limits   []caamdb.CooldownEvent
+}
+
+// LoadSimHistory reads wrap_sessions, activity_log and limit_events for a
+// provider. Wrap sessions describe demand; activity_log events with a
+// duration (deactivations) fill in when no wrap sessions were recorded.
+func LoadSimHistory(db *caamdb.DB, provider string, since time.Time) (*SimHistory, error) {
+	h := &SimHistory{Provider: provider}
+	seen := make(map[string]bool)
+	addProfile := func(name string) {
+		if name != "" && name[0] != '_' && !seen[name] {
+			seen[name] = true
+			h.Profiles = append(h.Profiles, name)
+		}
+	}
+
+	wraps, err := db.GetWrapSessions(provider, since, 1<<30)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range wraps {
+		d := time.Duration(w.DurationSeconds) * time.Second
+		if d <= 0 && !w.EndedAt.IsZero() {
+			d = w.EndedAt.Sub(w.StartedAt)
+		}
+		if d <= 0 {
+			continue
+		}
+		h.Sessions = append(h.Sessions, SimSession{Start: w.StartedAt, Duration: d, Profile: w.ProfileName})
+		addProfile(w.ProfileName)
+	}
+
+	events, err := db.ListProviderEvents(provider, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		addProfile(e.ProfileName)
+		if len(wraps) == 0 && e.Type == caamdb.EventDeactivate && e.Duration > 0 {
+			h.Sessions = append(h.Sessions, SimSession{Start: e.Timestamp.Add(-e.Duration), Duration: e.Duration, Profile: e.ProfileName})
+		}
+	}
+
+	if h.Limits, err = db.ListLimitEvents(provider, since); err != nil {
+		return nil, err
+	}
+	for _, l := range h.Limits {
+		addProfile(l.ProfileName)
+	}
+
+	sort.SliceStable(h.Sessions, func(i, j int) bool { return h.Sessions[i].Start.Before(h.Sessions[j].Start) })
+	sort.Strings(h.Profiles)
+	return h, nil
+}
+
+// AddProfiles adds candidate profiles that may not appear in the history.
+func (h *SimHistory) AddProfiles(names []string) {
+	seen := make(map[string]bool, len(h.Profiles))
+	for _, p := range h.Profiles {
+		seen[p] = true
+	}
+	for _, name := range names {
+		if name != "" && name[0] != '_' && !seen[name] {
+			seen[name] = true
+			h.Profiles = append(h.Profiles, name)
+		}
+	}
+	sort.Strings(h.Profiles)
+}
+
+// InferCapacity estimates how much session time one profile absorbs per
+// quota window: the median usage in the window before each recorded limit
+// hit. It returns 0 when no limit hit has usage before it.
+func (h *SimHistory) InferCapacity(window time.Duration) time.Duration {
+	var samples []time.Duration
+	for _, l := range h.Limits {
+		from := l.HitAt.Add(-window)
+		var used time.Duration
+		for _, s := range h.Sessions {
+			if s.Profile != l.ProfileName {
+				continue
+			}
+			start, end := s.Start, s.Start.Add(s.Duration)
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(l.HitAt) {
+				end = l.HitAt
+			}
+			if end.After(start) {
+				used += end.Sub(start)
+			}
+		}
+		if used > 0 {
+			samples = append(samples, used)
+		}
+	}
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2]
+}
+
+// SimConfig configures a simulation.
+type SimConfig struct {
+	Window   time.Duration // Quota window length (default DefaultSimWindow)
+	Capacity time.Duration // Session time per profile per window; 0 = unlimited
+	Policies map[string]*profile.RotationPolicy
+	Seed     int64
+}
+
+// SimReport summarizes how an algorithm performed over the history.
+type SimReport struct {
+	Algorithm Algorithm
+
+	// LimitHits counts profiles exhausting their quota.
+	LimitHits int
+
+	// Switches counts changes of active profile.
+	Switches int
+
+	// Blocked is demand that found every profile exhausted.
+	Blocked time.Duration
+
+	// IdleQuota is the share of capacity in started quota windows that
+	// reset unused (0-1). Negative when capacity is unknown.
+	IdleQuota float64
+
+	// Load is the session time served by each profile.
+	Load map[string]time.Duration
+}
+
+// simProfile is the quota state of one profile during a simulation.
+type simProfile struct {
+	windowStart time.Time
+	used        time.Duration
+}
+
+// simulation replays sessions against a quota model.
+type simulation struct {
+	h      *SimHistory
+	cfg    SimConfig
+	state  map[string]*simProfile
+	report *SimReport
+
+	openedWindows int
+	unused        time.Duration
+}
+
+func newSimulation(h *SimHistory, cfg SimConfig, algo Algorithm) *simulation {
+	sim := &simulation{
+		h:     h,
+		cfg:   cfg,
+		state: make(map[string]*simProfile, len(h.Profiles)),
+		report: &SimReport{
+			Algorithm: algo,
+			Load:      make(map[string]time.Duration),
+		},
+	}
+	for _, p := range h.Profiles {
+		sim.state[p] = &simProfile{}
+	}
+	return sim
+}
+
+// expire closes a profile's window if it has reset by t.
+func (sim *simulation) expire(p string, t time.Time) {
+	st := sim.state[p]
+	if st == nil || st.windowStart.IsZero() || t.Before(st.windowStart.Add(sim.cfg.Window)) {
+		return
+	}
+	sim.closeWindow(st)
+}
+
+func (sim *simulation) closeWindow(st *simProfile) {
+	if sim.cfg.Capacity > 0 && st.used < sim.cfg.Capacity {
+		sim.unused += sim.cfg.Capacity - st.used
+	}
+	sim.openedWindows++
+	st.windowStart = time.Time{}
+	st.used = 0
+}
+
+// exhausted reports whether p has no quota left at t.
+func (sim *simulation) exhausted(p string, t time.Time) bool {
+	sim.expire(p, t)
+	st := sim.state[p]
+	return st != nil && sim.cfg.Capacity > 0 && st.used >= sim.cfg.Capacity
+}
+
+// consume serves up to left of demand from p starting at t. It returns the
+// time served and whether p hit its limit.
+func (sim *simulation) consume(p string, t time.Time, left time.Duration) (time.Duration, bool) {
+	sim.expire(p, t)
+	st := sim.state[p]
+	if st == nil {
+		st = &simProfile{}
+		sim.state[p] = st
+	}
+	if st.windowStart.IsZero() {
+		st.windowStart = t
+	}
+
+	chunk := left
+	if untilReset := st.windowStart.Add(sim.cfg.Window).Sub(t); untilReset < chunk {
+		chunk = untilReset
+	}
+	hit := false
+	if sim.cfg.Capacity > 0 {
+		if remaining := sim.cfg.Capacity - st.used; remaining <= chunk {
+			chunk = remaining
+			hit = true
+		}
+	}
+	st.used += chunk
+	sim.report.Load[p] += chunk
+	return chunk, hit
+}
+
+// finish closes all open windows and computes idle quota.
+func (sim *simulation) finish() *SimReport {
+	for _, p := range sim.h.Profiles {
+		if st := sim.state[p]; st != nil && !st.windowStart.IsZero() {
+			sim.closeWindow(st)
+		}
+	}
+	sim.report.IdleQuota = -1
+	if sim.cfg.Capacity > 0 && sim.openedWindows > 0 {
+		sim.report.IdleQuota = float64(sim.unused) / float64(sim.cfg.Capacity*time.Duration(sim.openedWindows))
+	}
+	return sim.report
+}
+
+// usageData reports the simulated quota state as rotation usage data.
+func (sim *simulation) usageData(t time.Time) map[string]*UsageInfo {
+	if sim.cfg.Capacity <= 0 {
+		return nil
+	}
+	data := make(map[string]*UsageInfo, len(sim.state))
+	for p, st := range sim.state {
+		sim.expire(p, t)
+		u := &UsageInfo{ProfileName: p}
+		if !st.windowStart.IsZero() {
+			u.PrimaryPercent = int(100 * st.used / sim.cfg.Capacity)
+			u.PrimaryResetsAt = st.windowStart.Add(sim.cfg.Window)
+			u.AvailScore = 100 - u.PrimaryPercent
+		} else {
+			u.AvailScore = 100
+		}
+		data[p] = u
+	}
+	return data
+}
+
+func (cfg *SimConfig) defaults() {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultSimWindow
+	}
+}
+
+// Simulate replays the history through a rotation algorithm.
+//
+// Each recorded session is treated as a rotation decision: the algorithm
+// picks a profile when the session starts, and picks again whenever the
+// chosen profile exhausts its quota mid-session. Quota follows a simple
+// model: a window of cfg.Window opens on first use, and each profile can
+// serve cfg.Capacity of session time per window. Selections, switches and
+// limit hits are recorded in a scratch database so cooldowns, recency and
+// rotation caps behave as they would have at the time.
+func Simulate(h *SimHistory, algo Algorithm, cfg SimConfig) (*SimReport, error) {
+	cfg.defaults()
+	if algo == AlgorithmRecorded {
+		return simulateRecorded(h, cfg), nil
+	}
+
+	dir, err := os.MkdirTemp("", "caam-simulate-*")
+	if err != nil {
+		return nil, fmt.Errorf("create scratch dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	scratch, err := caamdb.OpenAt(filepath.Join(dir, "caam.db"))
+	if err != nil {
+		return nil, fmt.Errorf("open scratch db: %w", err)
+	}
+	defer scratch.Close()
+
+	var now time.Time
+	selector := NewSelector(algo, nil, scratch)
+	selector.SetClock(func() time.Time { return now })
+	selector.SetRNG(rand.New(rand.NewSource(cfg.Seed)))
+	selector.SetPolicies(cfg.Policies)
+
+	sim := newSimulation(h, cfg, algo)
+	current := ""
+
+	pick := func(t time.Time) string {
+		now = t
+		selector.SetUsageData(sim.usageData(t))
+		result, err := selector.Select(h.Provider, h.Profiles, current)
+		if err != nil || result == nil || sim.exhausted(result.Selected, t) {
+			return ""
+		}
+		if result.Selected != current {
+			if current != "" {
+				sim.report.Switches++
+			}
+			current = result.Selected
+			_ = scratch.LogEvent(caamdb.Event{
+				Type:        caamdb.EventActivate,
+				Provider:    h.Provider,
+				ProfileName: current,
+				Timestamp:   t,
+			})
+		}
+		return current
+	}
+
+	for _, s := range h.Sessions {
+		t, left := s.Start, s.Duration
+		p := pick(t)
+		for left > 0 {
+			if p == "" {
+				sim.report.Blocked += left
+				break
+			}
+			served, hit := sim.consume(p, t, left)
+			t, left = t.Add(served), left-served
+			if hit {
+				sim.report.LimitHits++
+				st := sim.state[p]
+				until := st.windowStart.Add(cfg.Window)
+				_, _ = scratch.SetCooldown(h.Provider, p, t, until.Sub(t), "simulated")
+				p = pick(t)
+			}
+		}
+	}
+
+	return sim.finish(), nil
+}
+
+// simulateRecorded replays the profiles that were actually used. Limit hits
+// and switches come from the history itself.
+func simulateRecorded(h *SimHistory, cfg SimConfig) *SimReport {
+	sim := newSimulation(h, cfg, AlgorithmRecorded)
+	sim.report.LimitHits = len(h.Limits)
+
+	current := ""
+	for _, s := range h.Sessions {
+		if current != "" && s.Profile != current {
+			sim.report.Switches++
+		}
+		current = s.Profile
+
+		t, left := s.Start, s.Duration
+		for left > 0 {
+			served, _ := sim.consume(s.Profile, t, left)
+			if served <= 0 {
+				// Over the modeled capacity: the profile kept working in
+				// reality, so count the rest without modeling quota.
+				sim.report.Load[s.Profile] += left
+				break
+			}
+			t, left = t.Add(served), left-served
+		}
+	}
+	return sim.finish()
+}
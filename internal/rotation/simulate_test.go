@@ -0,0 +1,153 @@
+package rotation
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	caamdb "github.com/Dicklesworthstone/coding_agent_account_manager/internal/db"
+)
+
+func simTestHistory(start time.Time) *SimHistory {
+	h := &SimHistory{Provider: "claude", Profiles: []string{"a", "b"}}
+	for i := 0; i < 8; i++ {
+		h.Sessions = append(h.Sessions, SimSession{
+			Start:    start.Add(time.Duration(i) * time.Hour),
+			Duration: time.Hour,
+			Profile:  "a",
+		})
+	}
+	h.Limits = []caamdb.CooldownEvent{{
+		Provider:    "claude",
+		ProfileName: "a",
+		HitAt:       start.Add(2 * time.Hour),
+	}}
+	return h
+}
+
+func TestSimHistory_InferCapacity(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	h := simTestHistory(start)
+
+	if got := h.InferCapacity(5 * time.Hour); got != 2*time.Hour {
+		t.Errorf("InferCapacity() = %v, want 2h", got)
+	}
+
+	h.Limits = nil
+	if got := h.InferCapacity(5 * time.Hour); got != 0 {
+		t.Errorf("InferCapacity() without limits = %v, want 0", got)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	h := simTestHistory(start)
+	cfg := SimConfig{Window: 5 * time.Hour, Capacity: 2 * time.Hour, Seed: 1}
+	demand := 8 * time.Hour
+
+	recorded, err := Simulate(h, AlgorithmRecorded, cfg)
+	if err != nil {
+		t.Fatalf("Simulate(recorded) error = %v", err)
+	}
+	if recorded.LimitHits != 1 || recorded.Switches != 0 {
+		t.Errorf("recorded = %+v, want 1 limit hit and no switches", recorded)
+	}
+	if recorded.Load["a"] != demand {
+		t.Errorf("recorded load = %v, want all on a", recorded.Load)
+	}
+
+	reports := make(map[Algorithm]*SimReport)
+	for _, algo := range []Algorithm{AlgorithmSmart, AlgorithmRoundRobin, AlgorithmRandom, AlgorithmResetAware} {
+		r, err := Simulate(h, algo, cfg)
+		if err != nil {
+			t.Fatalf("Simulate(%s) error = %v", algo, err)
+		}
+		reports[algo] = r
+
+		var served time.Duration
+		for _, d := range r.Load {
+			served += d
+		}
+		if served+r.Blocked != demand {
+			t.Errorf("%s: served %v + blocked %v != demand %v", algo, served, r.Blocked, demand)
+		}
+		// Two profiles with 2h per 5h window cannot serve 5h of
+		// back-to-back demand without exhausting both.
+		if r.LimitHits < 2 {
+			t.Errorf("%s: LimitHits = %d, want >= 2", algo, r.LimitHits)
+		}
+		if r.IdleQuota < 0 || r.IdleQuota > 1 {
+			t.Errorf("%s: IdleQuota = %v, want 0-1", algo, r.IdleQuota)
+		}
+	}
+
+	// reset_aware keeps draining the open window instead of alternating.
+	if rr, ra := reports[AlgorithmRoundRobin], reports[AlgorithmResetAware]; ra.Switches > rr.Switches {
+		t.Errorf("reset_aware switches = %d, want <= round_robin's %d", ra.Switches, rr.Switches)
+	}
+
+	// Runs are reproducible for a fixed seed.
+	again, err := Simulate(h, AlgorithmRandom, cfg)
+	if err != nil {
+		t.Fatalf("Simulate(random) error = %v", err)
+	}
+	if again.Switches != reports[AlgorithmRandom].Switches || again.LimitHits != reports[AlgorithmRandom].LimitHits {
+		t.Errorf("random run not reproducible: %+v vs %+v", again, reports[AlgorithmRandom])
+	}
+}
+
+func TestSimulate_UnlimitedCapacity(t *testing.T) {
+	h := simTestHistory(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC))
+
+	r, err := Simulate(h, AlgorithmSmart, SimConfig{})
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if r.LimitHits != 0 || r.Blocked != 0 {
+		t.Errorf("report = %+v, want no limits without a capacity", r)
+	}
+	if r.IdleQuota >= 0 {
+		t.Errorf("IdleQuota = %v, want unknown (<0)", r.IdleQuota)
+	}
+}
+
+func TestLoadSimHistory(t *testing.T) {
+	db, err := caamdb.OpenAt(filepath.Join(t.TempDir(), "caam.db"))
+	if err != nil {
+		t.Fatalf("db.OpenAt() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, name := range []string{"b", "a"} {
+		if err := db.RecordWrapSession(caamdb.WrapSession{
+			Provider:        "claude",
+			ProfileName:     name,
+			StartedAt:       now.Add(-time.Duration(3-i) * time.Hour),
+			EndedAt:         now.Add(-time.Duration(2-i) * time.Hour),
+			DurationSeconds: 3600,
+		}); err != nil {
+			t.Fatalf("RecordWrapSession() error = %v", err)
+		}
+	}
+	if _, err := db.SetCooldown("claude", "a", now.Add(-time.Hour), time.Hour, ""); err != nil {
+		t.Fatalf("SetCooldown() error = %v", err)
+	}
+	if err := db.LogEvent(caamdb.Event{Type: caamdb.EventActivate, Provider: "claude", ProfileName: "c", Timestamp: now}); err != nil {
+		t.Fatalf("LogEvent() error = %v", err)
+	}
+
+	h, err := LoadSimHistory(db, "claude", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("LoadSimHistory() error = %v", err)
+	}
+	if len(h.Sessions) != 2 || h.Sessions[0].Profile != "b" || h.Sessions[1].Profile != "a" {
+		t.Errorf("Sessions = %+v, want b then a", h.Sessions)
+	}
+	if len(h.Limits) != 1 {
+		t.Errorf("Limits = %+v, want 1", h.Limits)
+	}
+	if want := []string{"a", "b", "c"}; len(h.Profiles) != 3 || h.Profiles[0] != want[0] || h.Profiles[2] != want[2] {
+		t.Errorf("Profiles = %v, want %v", h.Profiles, want)
+	}
+}
@@ -0,0 +1,117 @@
+// Package seal provides the password-based encryption primitives shared by
+// encrypted export bundles and the encrypted vault: Argon2id key derivation
+// and AES-256-GCM authenticated encryption.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// NonceSize is the size of the GCM nonce in bytes.
+const NonceSize = 12
+
+// SaltSize is the size of the salt in bytes.
+const SaltSize = 32
+
+// KeySize is the size of an AES-256 key in bytes.
+const KeySize = 32
+
+// Argon2Params contains Argon2id parameters for key derivation.
+type Argon2Params struct {
+	// Time is the number of iterations.
+	Time uint32 `json:"time"`
+
+	// Memory is the memory size in KiB.
+	Memory uint32 `json:"memory"`
+
+	// Threads is the parallelism factor.
+	Threads uint8 `json:"threads"`
+
+	// KeyLen is the derived key length in bytes.
+	KeyLen uint32 `json:"key_len"`
+}
+
+// DefaultArgon2Params returns recommended Argon2id parameters.
+// These are balanced for security and performance on modern hardware.
+func DefaultArgon2Params() *Argon2Params {
+	return &Argon2Params{
+		Time:    3,         // 3 iterations
+		Memory:  64 * 1024, // 64 MiB
+		Threads: 4,         // 4 threads
+		KeyLen:  KeySize,   // 256-bit key for AES-256
+	}
+}
+
+// DeriveKey derives a key from a password using Argon2id.
+// A nil params uses DefaultArgon2Params.
+func DeriveKey(password, salt []byte, params *Argon2Params) []byte {
+	if params == nil {
+		params = DefaultArgon2Params()
+	}
+	return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+}
+
+// Encrypt seals plaintext with AES-256-GCM. additionalData is authenticated
+// but not encrypted and may be nil.
+func Encrypt(key, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(nonce))
+	}
+	return gcm.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt opens ciphertext produced by Encrypt with the same key, nonce and
+// additional data.
+func Decrypt(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(nonce))
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// RandomBytes generates cryptographically secure random bytes.
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, fmt.Errorf("generate random bytes: %w", err)
+	}
+	return b, nil
+}
+
+// Wipe attempts to overwrite sensitive data in memory.
+// Note: This is best-effort; Go's GC may have copied the data elsewhere.
+func Wipe(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return gcm, nil
+}
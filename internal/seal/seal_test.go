@@ -0,0 +1,55 @@
+package seal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	salt, err := RandomBytes(SaltSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := RandomBytes(NonceSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := DeriveKey([]byte("password"), salt, nil)
+	if len(key) != KeySize {
+		t.Fatalf("DeriveKey() len = %d, want %d", len(key), KeySize)
+	}
+
+	plaintext := []byte("refresh-token")
+	aad := []byte("header")
+	ciphertext, err := Encrypt(key, nonce, plaintext, aad)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if bytes.Contains(ciphertext, plaintext) {
+		t.Fatal("ciphertext contains plaintext")
+	}
+
+	got, err := Decrypt(key, nonce, ciphertext, aad)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+	}
+
+	if _, err := Decrypt(key, nonce, ciphertext, []byte("other")); err == nil {
+		t.Error("Decrypt() should fail when additional data differs")
+	}
+	wrong := DeriveKey([]byte("wrong"), salt, nil)
+	if _, err := Decrypt(wrong, nonce, ciphertext, aad); err == nil {
+		t.Error("Decrypt() should fail with wrong key")
+	}
+}
+
+func TestWipe(t *testing.T) {
+	b := []byte{1, 2, 3}
+	Wipe(b)
+	if !bytes.Equal(b, []byte{0, 0, 0}) {
+		t.Errorf("Wipe() left %v", b)
+	}
+}
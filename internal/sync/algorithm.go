@@ -21,6 +21,9 @@ const (
 	SyncPull SyncDirection = "pull"
 	// SyncSkip indicates no sync is needed (already in sync).
 	SyncSkip SyncDirection = "skip"
+	// SyncConflict indicates both sides changed since they last synced; the
+	// remote version is kept locally as a conflict copy.
+	SyncConflict SyncDirection = "conflict"
 )
 
 // SyncOperation represents a planned sync operation.
@@ -42,6 +45,13 @@ type SyncOperation struct {
 
 	// RemoteFreshness is the freshness of the remote token.
 	RemoteFreshness *TokenFreshness
+
+	// LocalHash and RemoteHash are the content hashes of both sides.
+	LocalHash  string
+	RemoteHash string
+
+	// Reason explains the direction, e.g. "changed locally".
+	Reason string
 }
 
 // SyncResult represents the result of a sync operation.
@@ -116,6 +126,9 @@ func NewSyncer(config SyncerConfig) (*Syncer, error) {
 		config.RemoteVaultPath = DefaultSyncerConfig().RemoteVaultPath
 	}
 
+	// Publish this machine's key for peers to trust (best effort)
+	_ = PublishLocalKey(config.VaultPath, state.Identity)
+
 	return &Syncer{
 		pool:            NewConnectionPool(config.ConnectOptions),
 		state:           state,
@@ -130,6 +143,15 @@ func (s *Syncer) Close() error {
 	return s.state.Save()
 }
 
+// remoteVault returns the vault path on the other end of a transport: the
+// configured remote vault path over SSH, the transport's root otherwise.
+func (s *Syncer) remoteVault(client Transport) string {
+	if _, ok := client.(*SSHClient); ok {
+		return s.remoteVaultPath
+	}
+	return ""
+}
+
 // SyncWithMachine synchronizes all profiles with a single machine.
 func (s *Syncer) SyncWithMachine(ctx context.Context, m *Machine) ([]*SyncResult, error) {
 	results := []*SyncResult{}
@@ -390,9 +412,9 @@ func (s *Syncer) SyncAll(ctx context.Context) ([]*SyncResult, error) {
 }
 
 // determineSyncOperation determines what sync operation is needed for a profile.
-func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p ProfileRef) (*SyncOperation, error) {
+func (s *Syncer) determineSyncOperation(client Transport, m *Machine, p ProfileRef) (*SyncOperation, error) {
 	localFresh, localErr := s.getLocalFreshness(p)
-	remoteFresh, remoteErr := s.getRemoteFreshness(client, p)
+	remoteFresh, remoteHash, remoteErr := s.getRemoteFreshness(client, m, p)
 
 	// Check if errors are "not found" vs other errors
 	localNotFound := localErr != nil && os.IsNotExist(localErr)
@@ -406,6 +428,18 @@ func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p Profile
 		Machine:         m,
 		LocalFreshness:  localFresh,
 		RemoteFreshness: remoteFresh,
+		RemoteHash:      remoteHash,
+	}
+	if localErr == nil {
+		op.LocalHash, localErr = s.getLocalContentHash(p)
+		localOtherErr = localErr != nil
+	}
+
+	// The content both sides had after their last sync tells a change on
+	// one side from changes on both.
+	base := ""
+	if s.state != nil && m != nil {
+		base = s.state.LastSyncedHash(p.Provider, p.Profile, m.ID)
 	}
 
 	switch {
@@ -428,33 +462,70 @@ func (s *Syncer) determineSyncOperation(client *SSHClient, m *Machine, p Profile
 
 	case localNotFound && remoteFresh != nil:
 		// Only exists on remote: pull
-		op.Direction = SyncPull
+		op.Direction, op.Reason = SyncPull, "only on remote"
 		return op, nil
 
 	case localFresh != nil && remoteNotFound:
 		// Only exists locally: push
-		op.Direction = SyncPush
+		op.Direction, op.Reason = SyncPush, "only local"
 		return op, nil
 
-	case CompareFreshness(localFresh, remoteFresh):
-		// Local is fresher: push
-		op.Direction = SyncPush
+	default:
+		op.Direction, op.Reason = chooseDirection(localFresh, remoteFresh, op.LocalHash, op.RemoteHash, base)
+		if op.Direction == SyncSkip && op.LocalHash == op.RemoteHash {
+			// Nothing to transfer, but remember the shared content as
+			// the base for next time
+			s.markSynced(op, op.LocalHash)
+		}
 		return op, nil
+	}
+}
+
+// chooseDirection decides how to sync a profile present on both sides,
+// given their content hashes and the hash both had after their last sync
+// (base, empty if they never synced). Without a base it falls back to
+// token freshness.
+func chooseDirection(localFresh, remoteFresh *TokenFreshness, localHash, remoteHash, base string) (SyncDirection, string) {
+	switch {
+	case localHash != "" && localHash == remoteHash:
+		return SyncSkip, "up to date"
+
+	case base != "" && localHash == base:
+		// Only the remote changed: fast-forward
+		return SyncPull, "changed on remote"
+
+	case base != "" && remoteHash == base:
+		// Only the local copy changed: fast-forward
+		return SyncPush, "changed locally"
+
+	case base != "":
+		// Both changed: don't overwrite either
+		return SyncConflict, "changed on both machines"
+
+	case CompareFreshness(localFresh, remoteFresh):
+		return SyncPush, "local fresher"
 
 	case CompareFreshness(remoteFresh, localFresh):
-		// Remote is fresher: pull
-		op.Direction = SyncPull
-		return op, nil
+		return SyncPull, "remote fresher"
 
 	default:
 		// Equal freshness: no action
-		op.Direction = SyncSkip
-		return op, nil
+		return SyncSkip, "equally fresh"
 	}
 }
 
+// markSynced records that both sides of op hold content with the given
+// hash, which also settles any conflict copy from that machine.
+func (s *Syncer) markSynced(op *SyncOperation, hash string) {
+	if s.state == nil || op.Machine == nil || hash == "" {
+		return
+	}
+	s.state.SetLastSyncedHash(op.Provider, op.Profile, op.Machine.ID, hash)
+	_ = removeConflict(s.vaultPath, op.Provider, op.Profile, op.Machine.Name)
+}
+
 // executeOperation executes a sync operation.
-func (s *Syncer) executeOperation(client *SSHClient, op *SyncOperation) *SyncResult {
+func (s *Syncer) executeOperation(client Transport, op *SyncOperation) *SyncResult {
 	start := time.Now()
 
 	result := &SyncResult{
@@ -466,11 +537,22 @@ func (s *Syncer) executeOperation(client *SSHClient, op *SyncOperation) *SyncRes
 		err := s.pushProfile(client, op.Provider, op.Profile)
 		result.Error = err
 		result.Success = err == nil
+		if err == nil {
+			s.markSynced(op, op.LocalHash)
+		}
 
 	case SyncPull:
 		err := s.pullProfile(client, op.Provider, op.Profile)
 		result.Error = err
 		result.Success = err == nil
+		if err == nil {
+			s.markSynced(op, op.RemoteHash)
+		}
+
+	case SyncConflict:
+		err := s.saveConflict(client, op)
+		result.Error = err
+		result.Success = err == nil
 
 	case SyncSkip:
 		result.Success = true
@@ -481,10 +563,10 @@ func (s *Syncer) executeOperation(client *SSHClient, op *SyncOperation) *SyncRes
 }
 
 // pushProfile pushes a local profile to the remote machine.
-func (s *Syncer) pushProfile(client *SSHClient, provider, profile string) error {
+func (s *Syncer) pushProfile(client Transport, provider, profile string) error {
 	localPath := filepath.Join(s.vaultPath, provider, profile)
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, provider, profile)
+	remotePath := posixJoin(s.remoteVault(client), provider, profile)
 
 	// Read local files
 	files, err := s.readLocalProfileFiles(localPath)
@@ -492,22 +574,28 @@ func (s *Syncer) pushProfile(client *SSHClient, provider, profile string) error
 		return fmt.Errorf("read local files: %w", err)
 	}
 
-	// Write to remote
+	// Seal auth files to the trusted machines' keys
+	if files, err = s.sealForPush(files); err != nil {
+		return fmt.Errorf("seal local files: %w", err)
+	}
+
+	// Write to remote in one batch (a single commit for git)
+	remoteFiles := make(map[string][]byte, len(files))
 	for filename, data := range files {
-		remoteFilePath := posixJoin(remotePath, filename)
-		if err := client.WriteFile(remoteFilePath, data, 0600); err != nil {
-			return fmt.Errorf("write remote file %s: %w", filename, err)
-		}
+		remoteFiles[posixJoin(remotePath, filename)] = data
+	}
+	if err := client.BatchWrite(remoteFiles, 0600); err != nil {
+		return fmt.Errorf("write remote files: %w", err)
 	}
 
 	return nil
 }
 
 // pullProfile pulls a remote profile to the local machine.
-func (s *Syncer) pullProfile(client *SSHClient, provider, profile string) error {
+func (s *Syncer) pullProfile(client Transport, provider, profile string) error {
 	localPath := filepath.Join(s.vaultPath, provider, profile)
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, provider, profile)
+	remotePath := posixJoin(s.remoteVault(client), provider, profile)
 
 	// List remote files
 	remoteFiles, err := client.ListDir(remotePath)
@@ -520,25 +608,74 @@ func (s *Syncer) pullProfile(client *SSHClient, provider, profile string) error
 		return fmt.Errorf("create local directory: %w", err)
 	}
 
-	// Read remote files and write locally using atomic writes
+	// Read remote files and write locally using atomic writes; the replaced
+	// local state is kept in the profile's revision history.
+	vault := authfile.NewVault(s.vaultPath)
+	return vault.TrackRevision(provider, profile, authfile.RevisionSyncPull, func() error {
+		for _, fi := range remoteFiles {
+			if fi.IsDir() {
+				continue
+			}
+
+			remoteFilePath := posixJoin(remotePath, fi.Name())
+			data, err := client.ReadFile(remoteFilePath)
+			if err != nil {
+				return fmt.Errorf("read remote file %s: %w", fi.Name(), err)
+			}
+
+			// Auth files are sealed if the vault is encrypted.
+			localFilePath := filepath.Join(localPath, fi.Name())
+			if isContentFile(fi.Name()) {
+				err = vault.WriteFile(localFilePath, data)
+			} else {
+				err = atomicWriteFile(localFilePath, data, 0600)
+			}
+			if err != nil {
+				return fmt.Errorf("write local file %s: %w", fi.Name(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// saveConflict keeps the remote version of a diverged profile as a local
+// conflict copy, leaving both the local and the remote profile as they are.
+func (s *Syncer) saveConflict(client Transport, op *SyncOperation) error {
+	remotePath := posixJoin(s.remoteVault(client), op.Provider, op.Profile)
+
+	remoteFiles, err := client.ListDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("list remote files: %w", err)
+	}
+
+	files := make(map[string][]byte)
 	for _, fi := range remoteFiles {
 		if fi.IsDir() {
 			continue
 		}
-
-		remoteFilePath := posixJoin(remotePath, fi.Name())
-		data, err := client.ReadFile(remoteFilePath)
+		data, err := client.ReadFile(posixJoin(remotePath, fi.Name()))
 		if err != nil {
 			return fmt.Errorf("read remote file %s: %w", fi.Name(), err)
 		}
+		files[fi.Name()] = data
+	}
 
-		localFilePath := filepath.Join(localPath, fi.Name())
-		if err := atomicWriteFile(localFilePath, data, 0600); err != nil {
-			return fmt.Errorf("write local file %s: %w", fi.Name(), err)
-		}
+	// The copy keeps the files as they are; the hash is of their content
+	opened, err := s.openPayloads(files)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	c := &Conflict{
+		Provider:   op.Provider,
+		Profile:    op.Profile,
+		Machine:    op.Machine.Name,
+		MachineID:  op.Machine.ID,
+		LocalHash:  op.LocalHash,
+		RemoteHash: ContentHash(opened),
+		DetectedAt: time.Now(),
+	}
+	return writeConflict(s.vaultPath, c, files)
 }
 
 // atomicWriteFile writes data to a file atomically using temp file + fsync + rename.
@@ -598,6 +735,8 @@ func localRandomString(n int) string {
 }
 
 // readLocalProfileFiles reads all files from a local profile directory.
+// Files sealed by an encrypted vault are decrypted: the vault key never
+// leaves this machine, so other machines could not read them.
 func (s *Syncer) readLocalProfileFiles(profilePath string) (map[string][]byte, error) {
 	files := make(map[string][]byte)
 
@@ -612,7 +751,7 @@ func (s *Syncer) readLocalProfileFiles(profilePath string) (map[string][]byte, e
 		}
 
 		filePath := filepath.Join(profilePath, entry.Name())
-		data, err := os.ReadFile(filePath)
+		data, err := authfile.ReadVaultFile(filePath)
 		if err != nil {
 			return nil, fmt.Errorf("read %s: %w", entry.Name(), err)
 		}
@@ -632,44 +771,55 @@ func (s *Syncer) getLocalFreshness(p ProfileRef) (*TokenFreshness, error) {
 		return nil, err
 	}
 
-	// Find auth files
-	var authFiles []string
-	entries, err := os.ReadDir(profilePath)
+	// Read auth files, opening sealed ones
+	files, err := s.readLocalPlain(profilePath)
 	if err != nil {
 		return nil, err
 	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			authFiles = append(authFiles, filepath.Join(profilePath, entry.Name()))
-		}
+	authFiles := make(map[string][]byte, len(files))
+	for name, data := range files {
+		authFiles[filepath.Join(profilePath, name)] = data
+	}
+	if len(authFiles) == 0 {
+		return nil, fmt.Errorf("no auth files found for %s/%s", p.Provider, p.Profile)
 	}
 
-	return ExtractFreshnessFromFiles(p.Provider, p.Profile, authFiles)
+	return ExtractFreshnessFromBytes(p.Provider, p.Profile, authFiles)
 }
 
-// getRemoteFreshness gets the freshness of a remote profile.
-func (s *Syncer) getRemoteFreshness(client *SSHClient, p ProfileRef) (*TokenFreshness, error) {
+// getLocalContentHash gets the content hash of a local profile.
+func (s *Syncer) getLocalContentHash(p ProfileRef) (string, error) {
+	files, err := s.readLocalPlain(filepath.Join(s.vaultPath, p.Provider, p.Profile))
+	if err != nil {
+		return "", err
+	}
+	return ContentHash(files), nil
+}
+
+// getRemoteFreshness gets the freshness and content hash of a remote
+// profile.
+func (s *Syncer) getRemoteFreshness(client Transport, m *Machine, p ProfileRef) (*TokenFreshness, string, error) {
 	// Use posixJoin for remote paths since SFTP always uses forward slashes
-	remotePath := posixJoin(s.remoteVaultPath, p.Provider, p.Profile)
+	remotePath := posixJoin(s.remoteVault(client), p.Provider, p.Profile)
 
 	// Check if remote directory exists
 	exists, err := client.FileExists(remotePath)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if !exists {
-		return nil, os.ErrNotExist
+		return nil, "", os.ErrNotExist
 	}
 
 	// List remote files
 	files, err := client.ListDir(remotePath)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Read remote auth files
 	authFiles := make(map[string][]byte)
+	contents := make(map[string][]byte)
 	for _, fi := range files {
 		if fi.IsDir() {
 			continue
@@ -680,21 +830,27 @@ func (s *Syncer) getRemoteFreshness(client *SSHClient, p ProfileRef) (*TokenFres
 		if err != nil {
 			continue // Skip files we can't read
 		}
+		if data, err = s.openPayload(data); err != nil {
+			return nil, "", fmt.Errorf("%s: %w", fi.Name(), err)
+		}
 
 		authFiles[filePath] = data
+		contents[fi.Name()] = data
 	}
 
 	if len(authFiles) == 0 {
-		return nil, fmt.Errorf("no auth files found in remote profile")
+		return nil, "", fmt.Errorf("no auth files found in remote profile")
 	}
 
 	freshness, err := ExtractFreshnessFromBytes(p.Provider, p.Profile, authFiles)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	freshness.Source = client.machine.Name
-	return freshness, nil
+	if m != nil {
+		freshness.Source = m.Name
+	}
+	return freshness, ContentHash(contents), nil
 }
 
 // listLocalProfiles lists all profiles in the local vault.
@@ -715,7 +871,7 @@ func (s *Syncer) listLocalProfiles() ([]ProfileRef, error) {
 		}
 
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() && !authfile.IsConflictCopy(entry.Name()) {
 				profiles = append(profiles, ProfileRef{
 					Provider: provider,
 					Profile:  entry.Name(),
@@ -728,14 +884,14 @@ func (s *Syncer) listLocalProfiles() ([]ProfileRef, error) {
 }
 
 // listRemoteProfiles lists all profiles in the remote vault.
-func (s *Syncer) listRemoteProfiles(client *SSHClient) ([]ProfileRef, error) {
+func (s *Syncer) listRemoteProfiles(client Transport) ([]ProfileRef, error) {
 	var profiles []ProfileRef
 
 	providers := []string{"claude", "codex", "gemini"}
 
 	for _, provider := range providers {
 		// Use posixJoin for remote paths since SFTP always uses forward slashes
-		providerPath := posixJoin(s.remoteVaultPath, provider)
+		providerPath := posixJoin(s.remoteVault(client), provider)
 
 		entries, err := client.ListDir(providerPath)
 		if err != nil {
@@ -744,7 +900,7 @@ func (s *Syncer) listRemoteProfiles(client *SSHClient) ([]ProfileRef, error) {
 		}
 
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() && !authfile.IsConflictCopy(entry.Name()) {
 				profiles = append(profiles, ProfileRef{
 					Provider: provider,
 					Profile:  entry.Name(),
@@ -794,6 +950,7 @@ type SyncStats struct {
 	Pushed    int
 	Pulled    int
 	Skipped   int
+	Conflicts int
 	Failed    int
 	BytesSent int64
 	BytesRecv int64
@@ -819,6 +976,8 @@ func AggregateResults(results []*SyncResult) SyncStats {
 			stats.Pulled++
 		case SyncSkip:
 			stats.Skipped++
+		case SyncConflict:
+			stats.Conflicts++
 		}
 
 		stats.BytesSent += r.BytesSent
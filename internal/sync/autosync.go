@@ -211,8 +211,8 @@ func logSyncResults(results []*SyncResult, verbose bool) {
 	}
 
 	stats := AggregateResults(results)
-	log.Printf("Sync complete: %d pushed, %d pulled, %d skipped, %d failed",
-		stats.Pushed, stats.Pulled, stats.Skipped, stats.Failed)
+	log.Printf("Sync complete: %d pushed, %d pulled, %d skipped, %d conflicts, %d failed",
+		stats.Pushed, stats.Pulled, stats.Skipped, stats.Conflicts, stats.Failed)
 }
 
 // logSyncError logs a sync error.
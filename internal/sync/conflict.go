@@ -0,0 +1,250 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+// conflictInfoFile holds a conflict copy's metadata. It is not part of the
+// copy's content.
+const conflictInfoFile = authfile.ConflictInfoFileName
+
+// Conflict is a profile that changed both locally and on another machine
+// since they last synced. The other machine's version is kept next to the
+// local profile as a conflict copy until the conflict is resolved.
+type Conflict struct {
+	// Provider is the auth provider (claude, codex, gemini).
+	Provider string `json:"provider"`
+
+	// Profile is the profile name.
+	Profile string `json:"profile"`
+
+	// Machine is the name of the machine the conflict copy came from.
+	Machine string `json:"machine"`
+
+	// MachineID is the pool ID of that machine.
+	MachineID string `json:"machine_id"`
+
+	// LocalHash and RemoteHash are the content hashes of both sides when
+	// the conflict was detected.
+	LocalHash  string `json:"local_hash"`
+	RemoteHash string `json:"remote_hash"`
+
+	// DetectedAt is when the conflict was (last) detected.
+	DetectedAt time.Time `json:"detected_at"`
+
+	// Path is the conflict copy's directory.
+	Path string `json:"path"`
+}
+
+// ContentHash returns a hash of a profile's auth files, given by file name.
+// Metadata and temp files are ignored, so only changes to the credentials
+// themselves count.
+func ContentHash(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if isContentFile(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(files[name]))
+		h.Write(files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isContentFile reports whether a profile file is part of its content.
+// Auth files may be dotfiles (.credentials.json, .env), so only metadata
+// and temp files are left out.
+func isContentFile(name string) bool {
+	return name != "meta.json" && name != conflictInfoFile &&
+		!strings.Contains(name, ".tmp") && !strings.HasPrefix(name, ".caam_tmp_")
+}
+
+// ConflictName returns the vault directory name of the conflict copy of a
+// profile from a machine: "<profile>.conflict-<machine>".
+func ConflictName(profile, machine string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, machine)
+	return profile + authfile.ConflictMarker + clean
+}
+
+// writeConflict stores files as the conflict copy described by c,
+// replacing any earlier copy from the same machine. Auth files are sealed
+// if the vault is encrypted.
+func writeConflict(vaultPath string, c *Conflict, files map[string][]byte) error {
+	c.Path = filepath.Join(vaultPath, c.Provider, ConflictName(c.Profile, c.Machine))
+	if err := os.RemoveAll(c.Path); err != nil {
+		return fmt.Errorf("remove old conflict copy: %w", err)
+	}
+	if err := os.MkdirAll(c.Path, 0700); err != nil {
+		return fmt.Errorf("create conflict copy: %w", err)
+	}
+
+	vault := authfile.NewVault(vaultPath)
+	for name, data := range files {
+		if !isContentFile(name) {
+			continue
+		}
+		if err := vault.WriteFile(filepath.Join(c.Path, name), data); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+
+	info, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal conflict: %w", err)
+	}
+	return atomicWriteFile(filepath.Join(c.Path, conflictInfoFile), info, 0600)
+}
+
+// removeConflict deletes the conflict copy of a profile from a machine, if
+// there is one.
+func removeConflict(vaultPath, provider, profile, machine string) error {
+	path := filepath.Join(vaultPath, provider, ConflictName(profile, machine))
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	return os.RemoveAll(path)
+}
+
+// ListConflicts returns the unresolved conflicts in a vault, ordered by
+// provider, profile and machine.
+func ListConflicts(vaultPath string) ([]*Conflict, error) {
+	var conflicts []*Conflict
+
+	for _, provider := range []string{"claude", "codex", "gemini"} {
+		entries, err := os.ReadDir(filepath.Join(vaultPath, provider))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || !authfile.IsConflictCopy(entry.Name()) {
+				continue
+			}
+			dir := filepath.Join(vaultPath, provider, entry.Name())
+			c := &Conflict{}
+			if data, err := os.ReadFile(filepath.Join(dir, conflictInfoFile)); err == nil {
+				_ = json.Unmarshal(data, c)
+			}
+			if c.Profile == "" {
+				// Metadata lost; recover what the name tells.
+				c.Profile, c.Machine, _ = strings.Cut(entry.Name(), authfile.ConflictMarker)
+			}
+			c.Provider = provider
+			c.Path = dir
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Profile != b.Profile {
+			return a.Profile < b.Profile
+		}
+		return a.Machine < b.Machine
+	})
+	return conflicts, nil
+}
+
+// ResolveConflict settles a conflict. With keepRemote the local profile is
+// replaced by the conflict copy (the replaced files stay in its revision
+// history); otherwise the local profile is kept and pushed on the next
+// sync. Either way the conflict copy is removed.
+func ResolveConflict(vaultPath string, state *SyncState, c *Conflict, keepRemote bool) error {
+	if keepRemote {
+		files, err := readContentFiles(c.Path)
+		if err != nil {
+			return fmt.Errorf("read conflict copy: %w", err)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("conflict copy %s has no auth files", c.Path)
+		}
+
+		localPath := filepath.Join(vaultPath, c.Provider, c.Profile)
+		vault := authfile.NewVault(vaultPath)
+		err = vault.TrackRevision(c.Provider, c.Profile, authfile.RevisionSyncPull, func() error {
+			if err := os.MkdirAll(localPath, 0700); err != nil {
+				return err
+			}
+			old, err := readContentFiles(localPath)
+			if err != nil {
+				return err
+			}
+			for name := range old {
+				if _, ok := files[name]; !ok {
+					if err := os.Remove(filepath.Join(localPath, name)); err != nil {
+						return err
+					}
+				}
+			}
+			for name, data := range files {
+				if err := vault.WriteFile(filepath.Join(localPath, name), data); err != nil {
+					return fmt.Errorf("write %s: %w", name, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("restore conflict copy: %w", err)
+		}
+	}
+
+	// The other machine's version is now known here, so the next sync
+	// sees a one-sided change (or none) instead of a divergence.
+	if state != nil && c.MachineID != "" && c.RemoteHash != "" {
+		state.SetLastSyncedHash(c.Provider, c.Profile, c.MachineID, c.RemoteHash)
+	}
+	return os.RemoveAll(c.Path)
+}
+
+// readContentFiles reads the content files of a profile directory, opening
+// sealed files.
+func readContentFiles(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || !isContentFile(entry.Name()) {
+			continue
+		}
+		data, err := authfile.ReadVaultFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+		files[entry.Name()] = data
+	}
+	return files, nil
+}
@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestContentHash(t *testing.T) {
+	a := ContentHash(map[string][]byte{".credentials.json": []byte(`{"a":1}`), "settings.json": []byte(`{}`)})
+	b := ContentHash(map[string][]byte{"settings.json": []byte(`{}`), ".credentials.json": []byte(`{"a":1}`), "meta.json": []byte(`{"x":2}`)})
+	if a == "" || a != b {
+		t.Errorf("ContentHash should ignore metadata and order: %q vs %q", a, b)
+	}
+	if ContentHash(map[string][]byte{"auth.json": []byte("x")}) == ContentHash(map[string][]byte{"auth.json": []byte("y")}) {
+		t.Error("ContentHash should change with content")
+	}
+	if ContentHash(map[string][]byte{"meta.json": []byte("{}")}) != "" {
+		t.Error("ContentHash of metadata only should be empty")
+	}
+}
+
+func TestChooseDirection(t *testing.T) {
+	older := &TokenFreshness{ExpiresAt: time.Now().Add(time.Hour)}
+	newer := &TokenFreshness{ExpiresAt: time.Now().Add(2 * time.Hour)}
+
+	tests := []struct {
+		name                  string
+		local, remote         *TokenFreshness
+		localHash, remoteHash string
+		base                  string
+		want                  SyncDirection
+	}{
+		{"same content", older, newer, "a", "a", "", SyncSkip},
+		{"changed on remote", newer, older, "a", "b", "a", SyncPull},
+		{"changed locally", older, newer, "b", "a", "a", SyncPush},
+		{"changed on both", newer, older, "b", "c", "a", SyncConflict},
+		{"no base, local fresher", newer, older, "a", "b", "", SyncPush},
+		{"no base, remote fresher", older, newer, "a", "b", "", SyncPull},
+		{"no base, equally fresh", older, older, "a", "b", "", SyncSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := chooseDirection(tt.local, tt.remote, tt.localHash, tt.remoteHash, tt.base)
+			if got != tt.want {
+				t.Errorf("chooseDirection() = %s (%s), want %s", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastSyncedHashPersists(t *testing.T) {
+	dir := t.TempDir()
+	state := NewSyncState(dir)
+	state.SetLastSyncedHash("claude", "work", "m1", "abc")
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := NewSyncState(dir)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.LastSyncedHash("claude", "work", "m1"); got != "abc" {
+		t.Errorf("LastSyncedHash() = %q, want abc", got)
+	}
+	if got := loaded.LastSyncedHash("claude", "work", "m2"); got != "" {
+		t.Errorf("LastSyncedHash() for other machine = %q, want empty", got)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	for _, keepRemote := range []bool{false, true} {
+		vaultPath := t.TempDir()
+		localDir := filepath.Join(vaultPath, "claude", "work")
+		if err := os.MkdirAll(localDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(localDir, ".credentials.json"), []byte(`{"local":true}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		remote := map[string][]byte{".credentials.json": []byte(`{"remote":true}`)}
+		c := &Conflict{
+			Provider:   "claude",
+			Profile:    "work",
+			Machine:    "laptop.local",
+			MachineID:  "m1",
+			RemoteHash: ContentHash(remote),
+			DetectedAt: time.Now(),
+		}
+		if err := writeConflict(vaultPath, c, remote); err != nil {
+			t.Fatalf("writeConflict() error = %v", err)
+		}
+		if filepath.Base(c.Path) != "work.conflict-laptop-local" {
+			t.Errorf("conflict copy = %s, want work.conflict-laptop-local", filepath.Base(c.Path))
+		}
+
+		profiles, err := authfile.NewVault(vaultPath).List("claude")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(profiles) != 1 || profiles[0] != "work" {
+			t.Errorf("List() = %v, want [work]", profiles)
+		}
+
+		conflicts, err := ListConflicts(vaultPath)
+		if err != nil {
+			t.Fatalf("ListConflicts() error = %v", err)
+		}
+		if len(conflicts) != 1 || conflicts[0].Machine != "laptop.local" || conflicts[0].MachineID != "m1" {
+			t.Fatalf("ListConflicts() = %+v, want one from laptop.local", conflicts)
+		}
+
+		state := NewSyncState(t.TempDir())
+		if err := ResolveConflict(vaultPath, state, conflicts[0], keepRemote); err != nil {
+			t.Fatalf("ResolveConflict(%v) error = %v", keepRemote, err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(localDir, ".credentials.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"local":true}`
+		if keepRemote {
+			want = `{"remote":true}`
+		}
+		if string(data) != want {
+			t.Errorf("keepRemote=%v: local = %s, want %s", keepRemote, data, want)
+		}
+		if got := state.LastSyncedHash("claude", "work", "m1"); got != c.RemoteHash {
+			t.Errorf("keepRemote=%v: base = %q, want remote hash", keepRemote, got)
+		}
+		if _, err := os.Stat(c.Path); !os.IsNotExist(err) {
+			t.Errorf("keepRemote=%v: conflict copy still exists", keepRemote)
+		}
+		if conflicts, _ := ListConflicts(vaultPath); len(conflicts) != 0 {
+			t.Errorf("keepRemote=%v: ListConflicts() = %d after resolve, want 0", keepRemote, len(conflicts))
+		}
+	}
+}
+
+func TestResolveConflict_EncryptedVault(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	authfile.ForgetKeys()
+	t.Cleanup(authfile.ForgetKeys)
+	vaultPath := t.TempDir()
+	if err := authfile.NewVault(vaultPath).EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	remote := map[string][]byte{".credentials.json": []byte(`{"remote":true}`)}
+	c := &Conflict{Provider: "claude", Profile: "work", Machine: "laptop", RemoteHash: ContentHash(remote)}
+	if err := writeConflict(vaultPath, c, remote); err != nil {
+		t.Fatalf("writeConflict() error = %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(c.Path, ".credentials.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authfile.IsSealed(raw) {
+		t.Fatal("conflict copy written in plaintext to an encrypted vault")
+	}
+
+	if err := ResolveConflict(vaultPath, nil, c, true); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	path := filepath.Join(vaultPath, "claude", "work", ".credentials.json")
+	if raw, _ := os.ReadFile(path); !authfile.IsSealed(raw) {
+		t.Error("resolved profile written in plaintext to an encrypted vault")
+	}
+	if data, err := authfile.ReadVaultFile(path); err != nil || string(data) != `{"remote":true}` {
+		t.Errorf("ReadVaultFile() = %q, %v; want the remote content sealed once", data, err)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"sync"
 )
 
-// ConnectionPool manages a pool of SSH connections.
+// ConnectionPool manages a pool of transport connections (SSH or other).
 type ConnectionPool struct {
-	clients map[string]*SSHClient
+	clients map[string]Transport
 	mu      sync.RWMutex
 	opts    ConnectOptions
 }
@@ -14,14 +14,14 @@ type ConnectionPool struct {
 // NewConnectionPool creates a new connection pool with the given options.
 func NewConnectionPool(opts ConnectOptions) *ConnectionPool {
 	return &ConnectionPool{
-		clients: make(map[string]*SSHClient),
+		clients: make(map[string]Transport),
 		opts:    opts,
 	}
 }
 
-// Get returns a connected SSH client for the given machine.
-// If a connection already exists, it is reused.
-func (p *ConnectionPool) Get(machine *Machine) (*SSHClient, error) {
+// Get returns a connected transport for the given machine, chosen by the
+// scheme of its address. If a connection already exists, it is reused.
+func (p *ConnectionPool) Get(machine *Machine) (Transport, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -36,7 +36,10 @@ func (p *ConnectionPool) Get(machine *Machine) (*SSHClient, error) {
 	}
 
 	// Create new connection
-	client := NewSSHClient(machine)
+	client, err := NewTransport(machine)
+	if err != nil {
+		return nil, err
+	}
 	if err := client.Connect(p.opts); err != nil {
 		return nil, err
 	}
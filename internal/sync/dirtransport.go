@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DirTransport syncs with a vault in a shared directory, such as an NFS
+// mount or a Syncthing folder.
+type DirTransport struct {
+	root      string
+	connected bool
+}
+
+// NewDirTransport creates a transport for the vault at root.
+func NewDirTransport(root string) *DirTransport {
+	return &DirTransport{root: root}
+}
+
+// Connect checks that the shared directory exists. It is not created, so
+// an unmounted share is an error rather than an empty vault.
+func (t *DirTransport) Connect(opts ConnectOptions) error {
+	info, err := os.Stat(t.root)
+	if err != nil {
+		return fmt.Errorf("shared directory %s: %w", t.root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("shared directory %s is not a directory", t.root)
+	}
+	t.connected = true
+	return nil
+}
+
+// Disconnect closes the transport.
+func (t *DirTransport) Disconnect() error {
+	t.connected = false
+	return nil
+}
+
+// IsConnected returns true after a successful Connect.
+func (t *DirTransport) IsConnected() bool {
+	return t.connected
+}
+
+// localPath maps a transport path to a path under the root.
+func (t *DirTransport) localPath(p string) string {
+	return filepath.Join(t.root, filepath.FromSlash(cleanTransportPath(p)))
+}
+
+// ReadFile reads a file from the shared directory.
+func (t *DirTransport) ReadFile(p string) ([]byte, error) {
+	return os.ReadFile(t.localPath(p))
+}
+
+// WriteFile writes a file to the shared directory atomically.
+func (t *DirTransport) WriteFile(p string, data []byte, mode os.FileMode) error {
+	full := t.localPath(p)
+	if err := os.MkdirAll(filepath.Dir(full), 0700); err != nil {
+		return err
+	}
+	return atomicWriteFile(full, data, mode)
+}
+
+// FileExists checks if a file exists in the shared directory.
+func (t *DirTransport) FileExists(p string) (bool, error) {
+	_, err := os.Stat(t.localPath(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListDir lists a directory in the shared directory.
+func (t *DirTransport) ListDir(p string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(t.localPath(p))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Removed while listing
+			}
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// BatchRead reads multiple files, skipping missing ones.
+func (t *DirTransport) BatchRead(paths []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	for _, p := range paths {
+		data, err := t.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return result, err
+		}
+		result[p] = data
+	}
+	return result, nil
+}
+
+// BatchWrite writes multiple files.
+func (t *DirTransport) BatchWrite(files map[string][]byte, mode os.FileMode) error {
+	for p, data := range files {
+		if err := t.WriteFile(p, data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
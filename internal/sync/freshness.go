@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 )
 
 // TokenFreshness represents the freshness of authentication tokens for a profile.
@@ -366,6 +368,7 @@ func containsPath(path, filename string) bool {
 }
 
 // ExtractFreshnessFromFiles reads auth files from disk and extracts freshness.
+// Sealed files are opened in memory (see authfile.ReadVaultFile).
 func ExtractFreshnessFromFiles(provider, profile string, filePaths []string) (*TokenFreshness, error) {
 	extractor := GetExtractor(provider)
 	if extractor == nil {
@@ -374,7 +377,7 @@ func ExtractFreshnessFromFiles(provider, profile string, filePaths []string) (*T
 
 	authFiles := make(map[string][]byte)
 	for _, path := range filePaths {
-		data, err := os.ReadFile(path)
+		data, err := authfile.ReadVaultFile(path)
 		if err != nil {
 			if os.IsNotExist(err) {
 				continue // Skip missing files
@@ -0,0 +1,567 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
+)
+
+// SyncPassphraseEnvVar holds the passphrase that encrypts profiles in git
+// sync repositories. Every machine sharing a repository needs the same one.
+const SyncPassphraseEnvVar = "CAAM_SYNC_PASSPHRASE"
+
+// gitKeyFile sits at the root of a git sync repository and holds what is
+// needed to derive its key from the passphrase.
+const gitKeyFile = ".caam-sync.json"
+
+// gitBlobMagic prefixes every encrypted blob in a git sync repository.
+var gitBlobMagic = []byte("CAAMGIT1")
+
+// gitKeyCheck is sealed into gitKeyFile to detect a wrong passphrase.
+const gitKeyCheck = "caam-sync-key-check"
+
+// gitKeyInfo is the content of gitKeyFile.
+type gitKeyInfo struct {
+	Version      int                `json:"version"`
+	KDF          string             `json:"kdf"`
+	Argon2Params *seal.Argon2Params `json:"argon2_params"`
+	Salt         string             `json:"salt"`
+	Check        string             `json:"check"`
+}
+
+// GitTransport syncs with a local bare git repository. Each write is a
+// commit, so the repository keeps the history of every profile and can be
+// shared with git itself (push/pull to a common remote). Blobs are sealed
+// with AES-256-GCM under a key derived from SyncPassphraseEnvVar; only the
+// vault layout (provider/profile/file names) is visible in the repository.
+type GitTransport struct {
+	repo string
+
+	// Passphrase overrides SyncPassphraseEnvVar.
+	Passphrase string
+
+	mu        sync.Mutex
+	connected bool
+	key       []byte
+}
+
+// NewGitTransport creates a transport for the bare repository at repo.
+func NewGitTransport(repo string) *GitTransport {
+	return &GitTransport{repo: repo}
+}
+
+// Connect opens the repository, creating an empty bare repository if
+// nothing exists at its path.
+func (t *GitTransport) Connect(opts ConnectOptions) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.connected {
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git transport needs git installed: %w", err)
+	}
+
+	if _, err := os.Stat(t.repo); os.IsNotExist(err) {
+		if out, err := exec.Command("git", "init", "--bare", "-q", t.repo).CombinedOutput(); err != nil {
+			return fmt.Errorf("create git repository %s: %s", t.repo, strings.TrimSpace(string(out)))
+		}
+	}
+
+	out, err := t.git(nil, nil, "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return fmt.Errorf("open git repository %s: %w", t.repo, err)
+	}
+	if strings.TrimSpace(string(out)) != "true" {
+		return fmt.Errorf("%s is not a bare git repository", t.repo)
+	}
+
+	t.connected = true
+	return nil
+}
+
+// Disconnect closes the transport and forgets the derived key.
+func (t *GitTransport) Disconnect() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.forgetKey()
+	t.connected = false
+	return nil
+}
+
+func (t *GitTransport) forgetKey() {
+	if t.key != nil {
+		seal.Wipe(t.key)
+		t.key = nil
+	}
+}
+
+// IsConnected returns true after a successful Connect.
+func (t *GitTransport) IsConnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.connected
+}
+
+// ReadFile reads and decrypts a file from the current commit.
+func (t *GitTransport) ReadFile(p string) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	head, err := t.head()
+	if err != nil {
+		return nil, err
+	}
+	return t.readFile(head, p)
+}
+
+func (t *GitTransport) readFile(head, p string) ([]byte, error) {
+	clean := cleanTransportPath(p)
+	if head == "" || clean == "" {
+		return nil, &os.PathError{Op: "read", Path: p, Err: os.ErrNotExist}
+	}
+	if typ, _ := t.objectType(head, clean); typ != "blob" {
+		return nil, &os.PathError{Op: "read", Path: p, Err: os.ErrNotExist}
+	}
+
+	sealed, err := t.git(nil, nil, "cat-file", "blob", head+":"+clean)
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := t.loadKey(head, false)
+	if err != nil {
+		return nil, err
+	}
+	return openGitBlob(key, clean, sealed)
+}
+
+// WriteFile commits one encrypted file.
+func (t *GitTransport) WriteFile(p string, data []byte, mode os.FileMode) error {
+	return t.BatchWrite(map[string][]byte{p: data}, mode)
+}
+
+// FileExists checks if a file or directory exists in the current commit.
+func (t *GitTransport) FileExists(p string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	head, err := t.head()
+	if err != nil || head == "" {
+		return false, err
+	}
+	clean := cleanTransportPath(p)
+	if clean == "" {
+		return true, nil
+	}
+	typ, _ := t.objectType(head, clean)
+	return typ != "", nil
+}
+
+// ListDir lists a directory in the current commit.
+func (t *GitTransport) ListDir(p string) ([]os.FileInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	head, err := t.head()
+	if err != nil {
+		return nil, err
+	}
+	clean := cleanTransportPath(p)
+	if head == "" {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	if clean != "" {
+		if typ, _ := t.objectType(head, clean); typ != "tree" {
+			return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+		}
+	}
+
+	out, err := t.git(nil, nil, "ls-tree", "-z", "-l", head+":"+clean)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []os.FileInfo
+	for _, line := range strings.Split(string(out), "\x00") {
+		// "<mode> <type> <object> <size>\t<name>"
+		meta, name, ok := strings.Cut(line, "\t")
+		if !ok || (clean == "" && name == gitKeyFile) {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		infos = append(infos, transportFileInfo{name: name, size: size, dir: fields[1] == "tree"})
+	}
+	return infos, nil
+}
+
+// BatchRead reads multiple files, skipping missing ones.
+func (t *GitTransport) BatchRead(paths []string) (map[string][]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	head, err := t.head()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte)
+	for _, p := range paths {
+		data, err := t.readFile(head, p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return result, err
+		}
+		result[p] = data
+	}
+	return result, nil
+}
+
+// errHeadMoved reports that another writer committed while a write was
+// building its commit.
+var errHeadMoved = errors.New("repository changed during write")
+
+// gitWriteAttempts is how many times BatchWrite starts over when another
+// writer commits first.
+const gitWriteAttempts = 3
+
+// BatchWrite commits multiple encrypted files at once. Files whose content
+// is unchanged are left alone; if nothing changed, no commit is made.
+func (t *GitTransport) BatchWrite(files map[string][]byte, mode os.FileMode) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		err := t.batchWrite(files, mode)
+		if !errors.Is(err, errHeadMoved) || attempt >= gitWriteAttempts {
+			return err
+		}
+		// The other writer may have stored key info first, so derive the
+		// key again from its commit.
+		t.forgetKey()
+	}
+}
+
+func (t *GitTransport) batchWrite(files map[string][]byte, mode os.FileMode) error {
+	head, err := t.head()
+	if err != nil {
+		return err
+	}
+	key, keyFile, err := t.loadKey(head, true)
+	if err != nil {
+		return err
+	}
+	// A new key is kept only once its key info is committed.
+	committed := false
+	if keyFile != nil {
+		defer func() {
+			if committed {
+				t.key = key
+			} else {
+				seal.Wipe(key)
+			}
+		}()
+	}
+
+	// Build the new tree in a private index so nothing else is disturbed.
+	tmpDir, err := os.MkdirTemp("", "caam-git-sync-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
+
+	if head != "" {
+		_, err = t.git(env, nil, "read-tree", head)
+	} else {
+		_, err = t.git(env, nil, "read-tree", "--empty")
+	}
+	if err != nil {
+		return err
+	}
+
+	add := func(p string, blob []byte, fileMode string) error {
+		out, err := t.git(env, blob, "hash-object", "-w", "--stdin")
+		if err != nil {
+			return err
+		}
+		sha := strings.TrimSpace(string(out))
+		_, err = t.git(env, nil, "update-index", "--add", "--cacheinfo", fileMode+","+sha+","+p)
+		return err
+	}
+
+	if keyFile != nil {
+		if err := add(gitKeyFile, keyFile, "100644"); err != nil {
+			return fmt.Errorf("store sync key info: %w", err)
+		}
+	}
+
+	fileMode := "100644"
+	if mode&0111 != 0 {
+		fileMode = "100755"
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var changed []string
+	for _, p := range paths {
+		clean := cleanTransportPath(p)
+		if clean == "" || clean == gitKeyFile {
+			return fmt.Errorf("invalid path %q", p)
+		}
+		if old, err := t.readFile(head, clean); err == nil && bytes.Equal(old, files[p]) {
+			continue
+		}
+
+		blob, err := sealGitBlob(key, clean, files[p])
+		if err != nil {
+			return err
+		}
+		if err := add(clean, blob, fileMode); err != nil {
+			return fmt.Errorf("store %s: %w", clean, err)
+		}
+		changed = append(changed, clean)
+	}
+	if len(changed) == 0 && keyFile == nil {
+		return nil
+	}
+
+	out, err := t.git(env, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+	tree := strings.TrimSpace(string(out))
+
+	args := []string{"commit-tree", tree, "-m", gitCommitMessage(changed)}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+	out, err = t.git(gitIdentityEnv(), nil, args...)
+	if err != nil {
+		return err
+	}
+	commit := strings.TrimSpace(string(out))
+
+	ref := "HEAD"
+	if out, err := t.git(nil, nil, "symbolic-ref", "-q", "HEAD"); err == nil {
+		ref = strings.TrimSpace(string(out))
+	}
+	// Compare-and-swap, so a concurrent writer is never overwritten.
+	if _, err := t.git(nil, nil, "update-ref", ref, commit, head); err != nil {
+		if current, _ := t.head(); current != head {
+			return fmt.Errorf("update %s: %w", ref, errHeadMoved)
+		}
+		return fmt.Errorf("update %s: %w", ref, err)
+	}
+	committed = true
+	return nil
+}
+
+// git runs a git command against the repository and returns its stdout.
+func (t *GitTransport) git(env []string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"--git-dir", t.repo}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// head returns the current commit, or "" if the repository has none yet.
+func (t *GitTransport) head() (string, error) {
+	if !t.connected {
+		return "", errors.New("not connected")
+	}
+	out, err := t.git(nil, nil, "rev-parse", "-q", "--verify", "HEAD^{commit}")
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// objectType returns "blob" or "tree" for a path in a commit, or "" if the
+// path does not exist.
+func (t *GitTransport) objectType(head, clean string) (string, error) {
+	out, err := t.git(nil, nil, "cat-file", "-t", head+":"+clean)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// loadKey derives the repository key from the passphrase. If the
+// repository has no key info yet and create is set, new key info is
+// returned for the caller to commit.
+func (t *GitTransport) loadKey(head string, create bool) (key, keyFile []byte, err error) {
+	if t.key != nil {
+		return t.key, nil, nil
+	}
+
+	passphrase := t.Passphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(SyncPassphraseEnvVar)
+	}
+	if passphrase == "" {
+		return nil, nil, fmt.Errorf("git sync repository %s is encrypted; set %s", t.repo, SyncPassphraseEnvVar)
+	}
+
+	var info gitKeyInfo
+	var data []byte
+	if head != "" {
+		data, err = t.git(nil, nil, "cat-file", "blob", head+":"+gitKeyFile)
+	}
+	if head == "" || err != nil {
+		if !create {
+			return nil, nil, fmt.Errorf("git sync repository %s has no %s", t.repo, gitKeyFile)
+		}
+		return t.newKey(passphrase)
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", gitKeyFile, err)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(info.Salt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", gitKeyFile, err)
+	}
+	check, err := base64.StdEncoding.DecodeString(info.Check)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", gitKeyFile, err)
+	}
+	key = seal.DeriveKey([]byte(passphrase), salt, info.Argon2Params)
+	if _, err := openGitBlob(key, gitKeyFile, check); err != nil {
+		seal.Wipe(key)
+		return nil, nil, fmt.Errorf("wrong %s for git sync repository %s", SyncPassphraseEnvVar, t.repo)
+	}
+
+	t.key = key
+	return key, nil, nil
+}
+
+// newKey derives a key with a fresh salt and returns the key info to store.
+// The key is not kept until the caller has committed the key info.
+func (t *GitTransport) newKey(passphrase string) (key, keyFile []byte, err error) {
+	salt, err := seal.RandomBytes(seal.SaltSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := seal.DefaultArgon2Params()
+	key = seal.DeriveKey([]byte(passphrase), salt, params)
+
+	check, err := sealGitBlob(key, gitKeyFile, []byte(gitKeyCheck))
+	if err != nil {
+		return nil, nil, err
+	}
+	keyFile, err = json.MarshalIndent(gitKeyInfo{
+		Version:      1,
+		KDF:          "argon2id",
+		Argon2Params: params,
+		Salt:         base64.StdEncoding.EncodeToString(salt),
+		Check:        base64.StdEncoding.EncodeToString(check),
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, keyFile, nil
+}
+
+// sealGitBlob encrypts a file for the repository:
+// magic | nonce | AES-256-GCM ciphertext, with the magic and the file's path
+// as additional data so blobs cannot be swapped between paths.
+func sealGitBlob(key []byte, p string, plaintext []byte) ([]byte, error) {
+	nonce, err := seal.RandomBytes(seal.NonceSize)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal.Encrypt(key, nonce, plaintext, gitBlobAAD(p))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(gitBlobMagic)+len(nonce)+len(ciphertext))
+	out = append(out, gitBlobMagic...)
+	out = append(out, nonce...)
+	return append(out, ciphertext...), nil
+}
+
+// openGitBlob decrypts a blob sealed by sealGitBlob for path p.
+func openGitBlob(key []byte, p string, blob []byte) ([]byte, error) {
+	headerLen := len(gitBlobMagic) + seal.NonceSize
+	if len(blob) < headerLen || !bytes.HasPrefix(blob, gitBlobMagic) {
+		return nil, fmt.Errorf("%s is not an encrypted caam file", p)
+	}
+	plaintext, err := seal.Decrypt(key, blob[len(gitBlobMagic):headerLen], blob[headerLen:], gitBlobAAD(p))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", p, err)
+	}
+	return plaintext, nil
+}
+
+func gitBlobAAD(p string) []byte {
+	return append(append([]byte(nil), gitBlobMagic...), p...)
+}
+
+// gitCommitMessage describes a commit by the profiles it touches.
+func gitCommitMessage(changed []string) string {
+	if len(changed) == 0 {
+		return "Initialize caam sync repository"
+	}
+	seen := make(map[string]bool)
+	var profiles []string
+	for _, p := range changed {
+		dir := posixDir(p)
+		if !seen[dir] {
+			seen[dir] = true
+			profiles = append(profiles, dir)
+		}
+	}
+	return "Update " + strings.Join(profiles, ", ")
+}
+
+// gitIdentityEnv sets the commit author, so commits work without any git
+// configuration.
+func gitIdentityEnv() []string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	email := "caam@" + host
+	return []string{
+		"GIT_AUTHOR_NAME=caam",
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=caam",
+		"GIT_COMMITTER_EMAIL=" + email,
+	}
+}
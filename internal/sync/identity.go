@@ -20,6 +20,25 @@ type LocalIdentity struct {
 
 	// CreatedAt is when this identity was first created.
 	CreatedAt time.Time `json:"created_at"`
+
+	// PublicKey and PrivateKey are this machine's X25519 keypair
+	// (base64). Sync payloads are sealed to the public key; the identity
+	// file is only readable by the user.
+	PublicKey  string `json:"public_key,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+// Fingerprint returns the fingerprint of the identity's public key, or ""
+// if it has none.
+func (i *LocalIdentity) Fingerprint() string {
+	if i == nil || i.PublicKey == "" {
+		return ""
+	}
+	pub, err := ParsePublicKey(i.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return KeyFingerprint(pub)
 }
 
 // identityFileName is the name of the identity file.
@@ -55,6 +74,15 @@ func GetOrCreateLocalIdentity() (*LocalIdentity, error) {
 	// Try to load existing identity
 	identity, err := loadIdentity(path)
 	if err == nil {
+		if identity.PublicKey == "" {
+			// Identities from before sealed sync get a keypair now
+			if identity.PublicKey, identity.PrivateKey, err = GenerateKeyPair(); err != nil {
+				return nil, fmt.Errorf("generate sync key: %w", err)
+			}
+			if err := saveIdentity(path, identity); err != nil {
+				return nil, fmt.Errorf("save identity: %w", err)
+			}
+		}
 		return identity, nil
 	}
 
@@ -94,10 +122,17 @@ func createIdentity() (*LocalIdentity, error) {
 		hostname = "unknown"
 	}
 
+	publicKey, privateKey, err := GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("generate sync key: %w", err)
+	}
+
 	return &LocalIdentity{
-		ID:        uuid.New().String(),
-		Hostname:  hostname,
-		CreatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		Hostname:   hostname,
+		CreatedAt:  time.Now(),
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
 	}, nil
 }
 
@@ -1,7 +1,8 @@
 // Package sync provides multi-machine vault synchronization capabilities.
 //
 // This package implements the infrastructure for syncing authentication tokens
-// across multiple machines using SSH transport. It provides:
+// across multiple machines over SSH, a shared directory or a git repository
+// (see Transport). It provides:
 //   - Machine identity and discovery (SSH config, CSV file)
 //   - Sync pool management
 //   - State persistence
@@ -44,7 +45,8 @@ type Machine struct {
 	// Name is a friendly name for this machine.
 	Name string `json:"name"`
 
-	// Address is the IP address or hostname.
+	// Address is the IP address or hostname, or a transport URL such as
+	// "dir:///mnt/shared/caam" (see ParseTransport).
 	Address string `json:"address"`
 
 	// Port is the SSH port (default: 22).
@@ -134,9 +136,19 @@ func (m *Machine) Validate() error {
 	if m.Address == "" {
 		return &ValidationError{Field: "address", Message: "machine address is required"}
 	}
+	if err := validateTransportAddress(m.Address); err != nil {
+		return &ValidationError{Field: "address", Message: err.Error()}
+	}
 	return nil
 }
 
+// TransportScheme returns the transport used to reach the machine: one of
+// TransportSSH, TransportDir or TransportGit.
+func (m *Machine) TransportScheme() string {
+	scheme, _ := ParseTransport(m.Address)
+	return scheme
+}
+
 // ValidationError represents a validation error for a specific field.
 type ValidationError struct {
 	Field   string
@@ -0,0 +1,233 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/seal"
+)
+
+// Sealed sync payloads.
+//
+// When machines are trusted (see TrustStore), pushed auth files are sealed
+// to the public keys of this machine and every trusted machine, so they
+// travel and rest as ciphertext on every remote, including shared jump boxes
+// and shared directories. Pulled files stay sealed in the local vault and
+// are opened in memory only when read (e.g. on activation).
+//
+// Format:
+//
+//	magic | ephemeral X25519 public key | recipient count (1 byte) |
+//	count x (key id | wrap nonce | wrapped file key) | nonce | ciphertext
+//
+// The file key encrypts the content with AES-256-GCM, using everything
+// before the nonce as additional data. For each recipient it is wrapped
+// with AES-256-GCM under HKDF-SHA256 of the X25519 shared secret.
+
+// payloadMagic prefixes every sealed sync payload.
+var payloadMagic = []byte("CAAMBOX1")
+
+const (
+	payloadKeyIDSize   = 8
+	payloadPubKeySize  = 32
+	payloadWrappedSize = seal.KeySize + 16 // key + GCM tag
+	payloadEntrySize   = payloadKeyIDSize + seal.NonceSize + payloadWrappedSize
+	payloadMaxKeys     = 255
+	payloadHKDFInfo    = "caam-sync-payload-v1"
+)
+
+// ErrNotRecipient is returned when a payload was not sealed to this
+// machine's key.
+var ErrNotRecipient = errors.New("sync payload is not sealed to this machine's key; ask a machine that has it to run 'caam sync trust' for this one")
+
+// IsSealedPayload reports whether data is a sealed sync payload.
+func IsSealedPayload(data []byte) bool {
+	return len(data) > len(payloadMagic)+payloadPubKeySize+1 && bytes.HasPrefix(data, payloadMagic)
+}
+
+// GenerateKeyPair returns a new X25519 keypair, base64-encoded.
+func GenerateKeyPair() (publicKey, privateKey string, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()),
+		base64.StdEncoding.EncodeToString(priv.Bytes()), nil
+}
+
+// ParsePublicKey decodes a base64 X25519 public key.
+func ParsePublicKey(s string) (*ecdh.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %w", err)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey decodes a base64 X25519 private key.
+func ParsePrivateKey(s string) (*ecdh.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode private key: %w", err)
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return priv, nil
+}
+
+// KeyFingerprint returns a short, human-comparable fingerprint of a public
+// key, e.g. "3f2a:91c0:7d4e:b815".
+func KeyFingerprint(pub *ecdh.PublicKey) string {
+	id := hex.EncodeToString(payloadKeyID(pub))
+	return id[0:4] + ":" + id[4:8] + ":" + id[8:12] + ":" + id[12:16]
+}
+
+func payloadKeyID(pub *ecdh.PublicKey) []byte {
+	sum := sha256.Sum256(append([]byte("caam-sync-key-id:"), pub.Bytes()...))
+	return sum[:payloadKeyIDSize]
+}
+
+// SealPayload seals plaintext to the given recipients.
+func SealPayload(plaintext []byte, recipients []*ecdh.PublicKey) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	if len(recipients) > payloadMaxKeys {
+		return nil, fmt.Errorf("too many recipients (%d, max %d)", len(recipients), payloadMaxKeys)
+	}
+
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := seal.RandomBytes(seal.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer seal.Wipe(fileKey)
+
+	header := append(append([]byte(nil), payloadMagic...), eph.PublicKey().Bytes()...)
+	header = append(header, byte(len(recipients)))
+	for _, pub := range recipients {
+		id := payloadKeyID(pub)
+		kek, err := payloadKEK(eph, pub, eph.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := seal.RandomBytes(seal.NonceSize)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := seal.Encrypt(kek, nonce, fileKey, payloadWrapAAD(eph.PublicKey(), id))
+		seal.Wipe(kek)
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, id...)
+		header = append(header, nonce...)
+		header = append(header, wrapped...)
+	}
+
+	nonce, err := seal.RandomBytes(seal.NonceSize)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal.Encrypt(fileKey, nonce, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(ciphertext))
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return append(out, ciphertext...), nil
+}
+
+// OpenPayload opens a sealed payload with a recipient's private key.
+func OpenPayload(data []byte, priv *ecdh.PrivateKey) ([]byte, error) {
+	if !IsSealedPayload(data) {
+		return nil, errors.New("not a sealed sync payload")
+	}
+	ephStart := len(payloadMagic)
+	ephRaw := data[ephStart : ephStart+payloadPubKeySize]
+	count := int(data[ephStart+payloadPubKeySize])
+	entries := ephStart + payloadPubKeySize + 1
+	headerLen := entries + count*payloadEntrySize
+	if len(data) < headerLen+seal.NonceSize {
+		return nil, errors.New("truncated sync payload")
+	}
+	ephPub, err := ecdh.X25519().NewPublicKey(ephRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync payload: %w", err)
+	}
+
+	myID := payloadKeyID(priv.PublicKey())
+	for i := 0; i < count; i++ {
+		entry := data[entries+i*payloadEntrySize : entries+(i+1)*payloadEntrySize]
+		id := entry[:payloadKeyIDSize]
+		if !bytes.Equal(id, myID) {
+			continue
+		}
+		kek, err := payloadKEK(priv, ephPub, ephPub)
+		if err != nil {
+			return nil, err
+		}
+		nonce := entry[payloadKeyIDSize : payloadKeyIDSize+seal.NonceSize]
+		fileKey, err := seal.Decrypt(kek, nonce, entry[payloadKeyIDSize+seal.NonceSize:], payloadWrapAAD(ephPub, id))
+		seal.Wipe(kek)
+		if err != nil {
+			return nil, fmt.Errorf("open sync payload: %w", err)
+		}
+		defer seal.Wipe(fileKey)
+
+		nonce = data[headerLen : headerLen+seal.NonceSize]
+		plaintext, err := seal.Decrypt(fileKey, nonce, data[headerLen+seal.NonceSize:], data[:headerLen])
+		if err != nil {
+			return nil, fmt.Errorf("open sync payload: %w", err)
+		}
+		return plaintext, nil
+	}
+	return nil, ErrNotRecipient
+}
+
+// payloadKEK derives the key that wraps a file key for one recipient.
+// Both sides pass the ephemeral public key, which goes into the salt.
+func payloadKEK(priv *ecdh.PrivateKey, peer, eph *ecdh.PublicKey) ([]byte, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	defer seal.Wipe(shared)
+
+	// The recipient is whichever side is not the ephemeral key.
+	recipient := peer
+	if bytes.Equal(peer.Bytes(), eph.Bytes()) {
+		recipient = priv.PublicKey()
+	}
+	salt := append(append([]byte(nil), eph.Bytes()...), recipient.Bytes()...)
+
+	kek := make([]byte, seal.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(payloadHKDFInfo)), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+func payloadWrapAAD(eph *ecdh.PublicKey, id []byte) []byte {
+	aad := append(append([]byte(nil), payloadMagic...), eph.Bytes()...)
+	return append(aad, id...)
+}
@@ -610,6 +610,10 @@ type ConnectivityResult struct {
 
 // TestMachineConnectivity tests SSH connectivity to a machine.
 func TestMachineConnectivity(m *Machine, opts ConnectOptions) *ConnectivityResult {
+	if m.TransportScheme() != TransportSSH {
+		return testTransportConnectivity(m, opts)
+	}
+
 	result := &ConnectivityResult{
 		Machine: m,
 	}
@@ -23,6 +23,13 @@ type SyncState struct {
 	// History records recent sync operations.
 	History *SyncHistory
 
+	// Synced records what each profile held when it was last synced with
+	// each machine, to tell one-sided changes from divergence.
+	Synced *SyncedContent
+
+	// Trust lists the machines pushed profiles are sealed to.
+	Trust *TrustStore
+
 	basePath string
 	mu       sync.RWMutex
 }
@@ -99,10 +106,27 @@ type HistoryEntry struct {
 	Duration time.Duration `json:"duration"`
 }
 
-// Queue and History file names.
+// SyncedContent records the content hash of each profile as of its last
+// successful sync with each machine.
+type SyncedContent struct {
+	// Entries are keyed by provider/profile@machine ID.
+	Entries map[string]SyncedEntry `json:"entries"`
+}
+
+// SyncedEntry is the last-synced content of a profile on one machine.
+type SyncedEntry struct {
+	// Hash is the profile's content hash (see ContentHash).
+	Hash string `json:"hash"`
+
+	// SyncedAt is when both sides last held this content.
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Queue, History and Synced file names.
 const (
 	queueFileName   = "queue.json"
 	historyFileName = "history.json"
+	syncedFileName  = "synced.json"
 )
 
 // Default sizes.
@@ -131,6 +155,8 @@ func NewSyncState(basePath string) *SyncState {
 			Entries: make([]HistoryEntry, 0),
 			MaxSize: DefaultHistoryMaxSize,
 		},
+		Synced:   &SyncedContent{Entries: make(map[string]SyncedEntry)},
+		Trust:    &TrustStore{},
 		basePath: basePath,
 	}
 }
@@ -178,6 +204,21 @@ func (s *SyncState) Load() error {
 		}
 	}
 
+	// Load last-synced content
+	if err := s.loadSynced(); err != nil {
+		// Non-fatal - sync falls back to comparing token freshness
+		s.Synced = &SyncedContent{Entries: make(map[string]SyncedEntry)}
+	}
+
+	// Load trusted keys. Unlike the files above, a damaged trust store is
+	// fatal: starting empty would quietly push profiles unsealed.
+	if err := s.loadTrust(); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("load trust store: %w", err)
+		}
+		s.Trust = &TrustStore{}
+	}
+
 	return nil
 }
 
@@ -203,6 +244,20 @@ func (s *SyncState) Save() error {
 		return fmt.Errorf("save history: %w", err)
 	}
 
+	// Save last-synced content
+	if s.Synced != nil {
+		if err := s.saveJSON(syncedFileName, s.Synced); err != nil {
+			return fmt.Errorf("save synced content: %w", err)
+		}
+	}
+
+	// Save trusted keys
+	if s.Trust != nil {
+		if err := s.saveJSON(trustFileName, s.Trust); err != nil {
+			return fmt.Errorf("save trust store: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -276,6 +331,25 @@ func (s *SyncState) saveHistory() error {
 	return s.saveJSON(historyFileName, s.History)
 }
 
+// loadSynced loads the last-synced content hashes from disk.
+func (s *SyncState) loadSynced() error {
+	data, err := os.ReadFile(filepath.Join(s.basePath, syncedFileName))
+	if err != nil {
+		return err
+	}
+
+	var synced SyncedContent
+	if err := json.Unmarshal(data, &synced); err != nil {
+		return err
+	}
+
+	if synced.Entries == nil {
+		synced.Entries = make(map[string]SyncedEntry)
+	}
+	s.Synced = &synced
+	return nil
+}
+
 // saveJSON saves a value to a JSON file atomically.
 func (s *SyncState) saveJSON(filename string, v interface{}) error {
 	// Ensure directory exists
@@ -434,6 +508,37 @@ func (s *SyncState) RecentHistory(limit int) []HistoryEntry {
 	return result
 }
 
+func syncedKey(provider, profile, machineID string) string {
+	return provider + "/" + profile + "@" + machineID
+}
+
+// LastSyncedHash returns the content hash a profile had when it was last
+// synced with a machine, or "" if it never was.
+func (s *SyncState) LastSyncedHash(provider, profile, machineID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.Synced == nil {
+		return ""
+	}
+	return s.Synced.Entries[syncedKey(provider, profile, machineID)].Hash
+}
+
+// SetLastSyncedHash records that a profile held content with the given hash
+// on both this machine and another after a sync.
+func (s *SyncState) SetLastSyncedHash(provider, profile, machineID, hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Synced == nil || s.Synced.Entries == nil {
+		s.Synced = &SyncedContent{Entries: make(map[string]SyncedEntry)}
+	}
+	s.Synced.Entries[syncedKey(provider, profile, machineID)] = SyncedEntry{
+		Hash:     hash,
+		SyncedAt: time.Now(),
+	}
+}
+
 // LoadSyncState loads or creates the sync state.
 func LoadSyncState() (*SyncState, error) {
 	state := NewSyncState("")
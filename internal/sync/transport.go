@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Transport moves vault files to and from a machine in the sync pool.
+// Paths use forward slashes. SSH paths are relative to the remote home
+// directory; the other transports hold a vault at their root, so their paths
+// are relative to it.
+type Transport interface {
+	// Connect opens the transport. It is a no-op if already connected.
+	Connect(opts ConnectOptions) error
+
+	// Disconnect closes the transport.
+	Disconnect() error
+
+	// IsConnected reports whether the transport is open.
+	IsConnected() bool
+
+	// ReadFile reads a file. A missing file is an os.IsNotExist error.
+	ReadFile(path string) ([]byte, error)
+
+	// WriteFile replaces a file atomically, creating parent directories.
+	WriteFile(path string, data []byte, mode os.FileMode) error
+
+	// FileExists reports whether a file or directory exists.
+	FileExists(path string) (bool, error)
+
+	// ListDir lists a directory.
+	ListDir(path string) ([]os.FileInfo, error)
+
+	// BatchRead reads several files, skipping missing ones.
+	BatchRead(paths []string) (map[string][]byte, error)
+
+	// BatchWrite writes several files, as one change where the transport
+	// supports it.
+	BatchWrite(files map[string][]byte, mode os.FileMode) error
+}
+
+// Transport schemes, selected by the scheme of Machine.Address. Addresses
+// without a scheme use SSH.
+const (
+	// TransportSSH syncs over SFTP: "host", "user@host:port" or "ssh://host".
+	TransportSSH = "ssh"
+	// TransportDir syncs with a shared directory (NFS, a Syncthing folder):
+	// "dir:///mnt/shared/caam".
+	TransportDir = "dir"
+	// TransportGit commits encrypted profiles to a local bare repository:
+	// "git:///srv/caam-sync.git".
+	TransportGit = "git"
+)
+
+// ParseTransport splits a machine address into its transport scheme and
+// target (host or path).
+func ParseTransport(address string) (scheme, target string) {
+	address = strings.TrimSpace(address)
+	scheme, target, ok := strings.Cut(address, "://")
+	if !ok {
+		return TransportSSH, address
+	}
+	return strings.ToLower(scheme), target
+}
+
+// NewTransport returns an unconnected transport for a machine.
+func NewTransport(m *Machine) (Transport, error) {
+	scheme, target := ParseTransport(m.Address)
+	switch scheme {
+	case TransportSSH:
+		return NewSSHClient(m), nil
+	case TransportDir:
+		return NewDirTransport(target), nil
+	case TransportGit:
+		return NewGitTransport(target), nil
+	default:
+		return nil, fmt.Errorf("unsupported transport %q in address %q", scheme, m.Address)
+	}
+}
+
+// testTransportConnectivity tests a machine reached by a transport other
+// than SSH: it opens the transport and counts the profiles at its root.
+func testTransportConnectivity(m *Machine, opts ConnectOptions) *ConnectivityResult {
+	result := &ConnectivityResult{Machine: m}
+
+	t, err := NewTransport(m)
+	if err != nil {
+		result.Error = err
+		result.ErrorType = "config"
+		return result
+	}
+
+	start := time.Now()
+	if err := t.Connect(opts); err != nil {
+		result.Error = err
+		result.ErrorType = "unknown"
+		return result
+	}
+	defer t.Disconnect()
+
+	result.Latency = time.Since(start)
+	result.Success = true
+
+	exists, err := t.FileExists("")
+	if err != nil || !exists {
+		return result
+	}
+	result.CAAMFound = true
+	for _, provider := range []string{"claude", "codex", "gemini"} {
+		if profiles, err := t.ListDir(provider); err == nil {
+			result.ProfileCount += len(profiles)
+		}
+	}
+	return result
+}
+
+// validateTransportAddress checks that an address names a known transport
+// with a usable target.
+func validateTransportAddress(address string) error {
+	scheme, target := ParseTransport(address)
+	switch scheme {
+	case TransportSSH:
+		return nil
+	case TransportDir, TransportGit:
+		if !filepath.IsAbs(target) {
+			return fmt.Errorf("%s:// address needs an absolute path, e.g. %s:///mnt/shared/caam", scheme, scheme)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported transport %q (use ssh, dir or git)", scheme)
+	}
+}
+
+// cleanTransportPath turns a transport path into a clean relative path
+// that cannot leave the transport's root. The root itself is "".
+func cleanTransportPath(p string) string {
+	clean := path.Clean("/" + filepath.ToSlash(p))
+	return strings.TrimPrefix(clean, "/")
+}
+
+// transportFileInfo is an os.FileInfo for entries of transports that are
+// not backed by a real file system.
+type transportFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi transportFileInfo) Name() string { return fi.name }
+func (fi transportFileInfo) Size() int64  { return fi.size }
+func (fi transportFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0700
+	}
+	return 0600
+}
+func (fi transportFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi transportFileInfo) IsDir() bool        { return fi.dir }
+func (fi transportFileInfo) Sys() interface{}   { return nil }
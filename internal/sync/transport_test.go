@@ -0,0 +1,323 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func TestParseTransport(t *testing.T) {
+	tests := []struct {
+		address    string
+		wantScheme string
+		wantTarget string
+	}{
+		{"192.168.1.100", TransportSSH, "192.168.1.100"},
+		{"jeff@host:2222", TransportSSH, "jeff@host:2222"},
+		{"ssh://jeff@host", TransportSSH, "jeff@host"},
+		{"dir:///mnt/shared/caam", TransportDir, "/mnt/shared/caam"},
+		{"GIT:///srv/caam.git", TransportGit, "/srv/caam.git"},
+	}
+	for _, tt := range tests {
+		scheme, target := ParseTransport(tt.address)
+		if scheme != tt.wantScheme || target != tt.wantTarget {
+			t.Errorf("ParseTransport(%q) = %q, %q; want %q, %q", tt.address, scheme, target, tt.wantScheme, tt.wantTarget)
+		}
+	}
+}
+
+func TestMachineValidateTransport(t *testing.T) {
+	valid := []string{"host", "dir:///mnt/shared", "git:///srv/caam.git"}
+	for _, addr := range valid {
+		if err := NewMachine("m", addr).Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", addr, err)
+		}
+	}
+	invalid := []string{"dir://relative/path", "ftp://host/vault"}
+	for _, addr := range invalid {
+		if err := NewMachine("m", addr).Validate(); err == nil {
+			t.Errorf("Validate(%q) should fail", addr)
+		}
+	}
+}
+
+// exerciseTransport runs the operations the syncer relies on.
+func exerciseTransport(t *testing.T, tr Transport) {
+	t.Helper()
+
+	if exists, err := tr.FileExists("claude/work"); err != nil || exists {
+		t.Fatalf("FileExists() before write = %v, %v; want false", exists, err)
+	}
+	if _, err := tr.ReadFile("claude/work/.credentials.json"); !os.IsNotExist(err) {
+		t.Fatalf("ReadFile() of missing file error = %v, want not-exist", err)
+	}
+
+	err := tr.BatchWrite(map[string][]byte{
+		"claude/work/.credentials.json": []byte(`{"token":"one"}`),
+		"claude/work/settings.json":     []byte(`{}`),
+	}, 0600)
+	if err != nil {
+		t.Fatalf("BatchWrite() error = %v", err)
+	}
+
+	if exists, err := tr.FileExists("claude/work"); err != nil || !exists {
+		t.Errorf("FileExists(dir) = %v, %v; want true", exists, err)
+	}
+	data, err := tr.ReadFile("claude/work/.credentials.json")
+	if err != nil || string(data) != `{"token":"one"}` {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+
+	entries, err := tr.ListDir("claude")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "work" || !entries[0].IsDir() {
+		t.Errorf("ListDir(claude) = %v, %v; want [work/]", entries, err)
+	}
+	entries, err = tr.ListDir("claude/work")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("ListDir(claude/work) = %d entries, %v; want 2", len(entries), err)
+	}
+	if _, err := tr.ListDir("codex"); !os.IsNotExist(err) {
+		t.Errorf("ListDir() of missing dir error = %v, want not-exist", err)
+	}
+
+	if err := tr.WriteFile("claude/work/.credentials.json", []byte(`{"token":"two"}`), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	read, err := tr.BatchRead([]string{"claude/work/.credentials.json", "claude/work/missing.json"})
+	if err != nil || len(read) != 1 || string(read["claude/work/.credentials.json"]) != `{"token":"two"}` {
+		t.Errorf("BatchRead() = %v, %v", read, err)
+	}
+}
+
+func TestDirTransport(t *testing.T) {
+	root := t.TempDir()
+	tr := NewDirTransport(root)
+	if err := tr.Connect(DefaultConnectOptions()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	exerciseTransport(t, tr)
+
+	// Paths cannot leave the shared directory
+	if err := tr.WriteFile("../escape.json", []byte("x"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.json")); err != nil {
+		t.Errorf("write outside root was not kept under it: %v", err)
+	}
+
+	missing := NewDirTransport(filepath.Join(root, "not-mounted"))
+	if err := missing.Connect(DefaultConnectOptions()); err == nil {
+		t.Error("Connect() to a missing directory should fail")
+	}
+}
+
+func gitCommitCount(t *testing.T, repo string) int {
+	t.Helper()
+	out, err := exec.Command("git", "--git-dir", repo, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("rev-list: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("rev-list output %q: %v", out, err)
+	}
+	return n
+}
+
+func TestGitTransport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "caam-sync.git")
+
+	tr := NewGitTransport(repo)
+	tr.Passphrase = "correct horse"
+	if err := tr.Connect(DefaultConnectOptions()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	exerciseTransport(t, tr)
+
+	if n := gitCommitCount(t, repo); n != 2 {
+		t.Errorf("commits = %d, want 2 (one per write)", n)
+	}
+	// Writing unchanged content makes no commit
+	if err := tr.WriteFile("claude/work/settings.json", []byte(`{}`), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if n := gitCommitCount(t, repo); n != 2 {
+		t.Errorf("commits after unchanged write = %d, want 2", n)
+	}
+
+	// Blobs are encrypted at rest
+	raw, err := exec.Command("git", "--git-dir", repo, "cat-file", "blob", "HEAD:claude/work/.credentials.json").Output()
+	if err != nil {
+		t.Fatalf("cat-file: %v", err)
+	}
+	if strings.Contains(string(raw), "token") {
+		t.Error("blob is stored in plaintext")
+	}
+
+	// Another machine with the same passphrase can read it
+	other := NewGitTransport(repo)
+	other.Passphrase = "correct horse"
+	if err := other.Connect(DefaultConnectOptions()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if data, err := other.ReadFile("claude/work/.credentials.json"); err != nil || string(data) != `{"token":"two"}` {
+		t.Errorf("ReadFile() from second transport = %q, %v", data, err)
+	}
+
+	wrong := NewGitTransport(repo)
+	wrong.Passphrase = "wrong"
+	if err := wrong.Connect(DefaultConnectOptions()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := wrong.ReadFile("claude/work/.credentials.json"); err == nil || !strings.Contains(err.Error(), "wrong") {
+		t.Errorf("ReadFile() with wrong passphrase error = %v", err)
+	}
+}
+
+func TestGitTransportConcurrentFirstWrites(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "caam-sync.git")
+
+	// Machines racing to make the first commit each derive their own key;
+	// the ones that lose must start over with the winner's key info.
+	const writers = 3
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		tr := NewGitTransport(repo)
+		tr.Passphrase = "correct horse"
+		if err := tr.Connect(DefaultConnectOptions()); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		go func(i int) {
+			errs <- tr.WriteFile(fmt.Sprintf("codex/m%d/auth.json", i), []byte(`{"token":"t"}`), 0600)
+		}(i)
+	}
+	for i := 0; i < writers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("WriteFile() error = %v", err)
+		}
+	}
+
+	reader := NewGitTransport(repo)
+	reader.Passphrase = "correct horse"
+	if err := reader.Connect(DefaultConnectOptions()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	for i := 0; i < writers; i++ {
+		p := fmt.Sprintf("codex/m%d/auth.json", i)
+		if data, err := reader.ReadFile(p); err != nil || string(data) != `{"token":"t"}` {
+			t.Errorf("ReadFile(%s) = %q, %v", p, data, err)
+		}
+	}
+}
+
+func TestSyncWithDirTransport(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	vaultPath := t.TempDir()
+	shared := t.TempDir()
+
+	writeCodex := func(dir, expiresAt string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		auth := `{"access_token":"tok","refresh_token":"ref","expires_at":` + expiresAt + `}`
+		if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(auth), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCodex(filepath.Join(vaultPath, "codex", "work"), "1766245740")
+
+	m := NewMachine("nas", "dir://"+shared)
+	s := &Syncer{
+		pool:      NewConnectionPool(DefaultConnectOptions()),
+		state:     NewSyncState(t.TempDir()),
+		vaultPath: vaultPath,
+	}
+	defer s.pool.CloseAll()
+
+	results, err := s.SyncWithMachine(context.Background(), m)
+	if err != nil {
+		t.Fatalf("SyncWithMachine() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Operation.Direction != SyncPush || !results[0].Success {
+		t.Fatalf("first sync = %+v, want one successful push", results)
+	}
+	if _, err := os.Stat(filepath.Join(shared, "codex", "work", "auth.json")); err != nil {
+		t.Fatalf("profile not pushed to shared directory: %v", err)
+	}
+
+	// Another machine refreshes the token in the shared directory
+	writeCodex(filepath.Join(shared, "codex", "work"), "1766249340")
+	results, err = s.SyncWithMachine(context.Background(), m)
+	if err != nil {
+		t.Fatalf("SyncWithMachine() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Operation.Direction != SyncPull || !results[0].Success {
+		t.Fatalf("second sync = %+v, want one successful pull", results)
+	}
+	data, err := os.ReadFile(filepath.Join(vaultPath, "codex", "work", "auth.json"))
+	if err != nil || !strings.Contains(string(data), "1766249340") {
+		t.Errorf("local profile after pull = %s, %v", data, err)
+	}
+}
+
+func TestSyncPullIntoEncryptedVault(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	authfile.ForgetKeys()
+	t.Cleanup(authfile.ForgetKeys)
+	vaultPath := t.TempDir()
+	shared := t.TempDir()
+
+	vault := authfile.NewVault(vaultPath)
+	if err := vault.EnableEncryption("pass"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	remote := filepath.Join(shared, "codex", "work")
+	if err := os.MkdirAll(remote, 0700); err != nil {
+		t.Fatal(err)
+	}
+	auth := `{"access_token":"tok","refresh_token":"ref","expires_at":1766249340}`
+	if err := os.WriteFile(filepath.Join(remote, "auth.json"), []byte(auth), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Syncer{
+		pool:      NewConnectionPool(DefaultConnectOptions()),
+		state:     NewSyncState(t.TempDir()),
+		vaultPath: vaultPath,
+	}
+	defer s.pool.CloseAll()
+	results, err := s.SyncWithMachine(context.Background(), NewMachine("nas", "dir://"+shared))
+	if err != nil {
+		t.Fatalf("SyncWithMachine() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Operation.Direction != SyncPull || !results[0].Success {
+		t.Fatalf("sync = %+v, want one successful pull", results)
+	}
+
+	path := filepath.Join(vaultPath, "codex", "work", "auth.json")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !authfile.IsSealed(raw) || strings.Contains(string(raw), "refresh_token") {
+		t.Fatal("pulled auth file is not sealed in the encrypted vault")
+	}
+	if data, err := authfile.ReadVaultFile(path); err != nil || string(data) != auth {
+		t.Errorf("ReadVaultFile() = %q, %v", data, err)
+	}
+}
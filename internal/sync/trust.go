@@ -0,0 +1,393 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+// trustFileName is the name of the trust store file.
+const trustFileName = "trust.json"
+
+// PublicKeyFileName is where a machine publishes its sync public key, at
+// the root of its vault, for peers to fetch with 'caam sync trust'.
+const PublicKeyFileName = ".caam_sync_key.json"
+
+// TrustStore lists the machines whose keys pushed profiles are sealed to.
+type TrustStore struct {
+	// Keys are the trusted machines' public keys.
+	Keys []TrustedKey `json:"keys"`
+
+	// Sealed is set once a machine has been trusted. From then on pushes
+	// are sealed, to this machine alone if every key has been revoked.
+	Sealed bool `json:"sealed"`
+}
+
+// TrustedKey is a machine trusted to open sealed profiles.
+type TrustedKey struct {
+	// Name is the machine's name in the sync pool.
+	Name string `json:"name"`
+
+	// PublicKey is its X25519 public key (base64).
+	PublicKey string `json:"public_key"`
+
+	// Fingerprint is the key's fingerprint, for comparing out of band.
+	Fingerprint string `json:"fingerprint"`
+
+	// AddedAt is when the key was trusted.
+	AddedAt time.Time `json:"added_at"`
+}
+
+// PublishedKey is the content of PublicKeyFileName.
+type PublishedKey struct {
+	MachineID string `json:"machine_id"`
+	Hostname  string `json:"hostname"`
+	PublicKey string `json:"public_key"`
+}
+
+// loadTrust loads the trust store from disk.
+func (s *SyncState) loadTrust() error {
+	data, err := os.ReadFile(filepath.Join(s.basePath, trustFileName))
+	if err != nil {
+		return err
+	}
+
+	var trust TrustStore
+	if err := json.Unmarshal(data, &trust); err != nil {
+		return err
+	}
+	s.Trust = &trust
+	return nil
+}
+
+// TrustKey trusts a machine's public key, replacing any key trusted under
+// the same name.
+func (s *SyncState) TrustKey(name, publicKey string) (*TrustedKey, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Identity != nil && s.Identity.PublicKey == publicKey {
+		return nil, fmt.Errorf("%s is this machine's own key", KeyFingerprint(pub))
+	}
+	if s.Trust == nil {
+		s.Trust = &TrustStore{}
+	}
+
+	key := TrustedKey{
+		Name:        name,
+		PublicKey:   publicKey,
+		Fingerprint: KeyFingerprint(pub),
+		AddedAt:     time.Now(),
+	}
+	for i := range s.Trust.Keys {
+		if strings.EqualFold(s.Trust.Keys[i].Name, name) {
+			s.Trust.Keys[i] = key
+			s.Trust.Sealed = true
+			return &key, nil
+		}
+	}
+	s.Trust.Keys = append(s.Trust.Keys, key)
+	s.Trust.Sealed = true
+	return &key, nil
+}
+
+// RevokeKey stops trusting a machine's key.
+func (s *SyncState) RevokeKey(name string) (*TrustedKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Trust != nil {
+		for i, k := range s.Trust.Keys {
+			if strings.EqualFold(k.Name, name) {
+				s.Trust.Keys = append(s.Trust.Keys[:i], s.Trust.Keys[i+1:]...)
+				return &k, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("machine %q is not trusted", name)
+}
+
+// TrustedKeys returns a copy of the trusted keys.
+func (s *SyncState) TrustedKeys() []TrustedKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.Trust == nil {
+		return nil
+	}
+	return append([]TrustedKey(nil), s.Trust.Keys...)
+}
+
+// Recipients returns the keys pushed profiles are sealed to: this
+// machine's and every trusted machine's. It returns nil if sealing is not
+// in use (no machine was ever trusted).
+func (s *SyncState) Recipients() ([]*ecdh.PublicKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.Trust == nil || !s.Trust.Sealed {
+		return nil, nil
+	}
+	if s.Identity == nil || s.Identity.PublicKey == "" {
+		return nil, fmt.Errorf("this machine has no sync key")
+	}
+
+	self, err := ParsePublicKey(s.Identity.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("this machine's sync key: %w", err)
+	}
+	recipients := []*ecdh.PublicKey{self}
+	for _, k := range s.Trust.Keys {
+		pub, err := ParsePublicKey(k.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("key of %s: %w", k.Name, err)
+		}
+		recipients = append(recipients, pub)
+	}
+	return recipients, nil
+}
+
+// PublishLocalKey writes this machine's public key to the root of its
+// vault, where peers fetch it when trusting it.
+func PublishLocalKey(vaultPath string, identity *LocalIdentity) error {
+	if identity == nil || identity.PublicKey == "" {
+		return fmt.Errorf("this machine has no sync key")
+	}
+	data, err := json.MarshalIndent(PublishedKey{
+		MachineID: identity.ID,
+		Hostname:  identity.Hostname,
+		PublicKey: identity.PublicKey,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(vaultPath, PublicKeyFileName)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if err := os.MkdirAll(vaultPath, 0700); err != nil {
+		return err
+	}
+	return atomicWriteFile(path, data, 0644)
+}
+
+// FetchPublishedKey reads the public key a machine published in the vault
+// at root.
+func FetchPublishedKey(t Transport, root string) (*PublishedKey, error) {
+	data, err := t.ReadFile(posixJoin(root, PublicKeyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no sync key published; run 'caam sync key' on that machine, or pass its key with --key")
+		}
+		return nil, err
+	}
+
+	var key PublishedKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", PublicKeyFileName, err)
+	}
+	if _, err := ParsePublicKey(key.PublicKey); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// OpenLocalPayload opens a sealed sync payload with this machine's key. It
+// is an authfile.PayloadOpener, so sealed profiles are opened on activation.
+func OpenLocalPayload(data []byte) ([]byte, bool, error) {
+	if !IsSealedPayload(data) {
+		return nil, false, nil
+	}
+	identity, err := LoadLocalIdentity()
+	if err != nil {
+		return nil, true, err
+	}
+	plaintext, err := openWithIdentity(identity, data)
+	return plaintext, true, err
+}
+
+func openWithIdentity(identity *LocalIdentity, data []byte) ([]byte, error) {
+	if identity == nil || identity.PrivateKey == "" {
+		return nil, ErrNotRecipient
+	}
+	priv, err := ParsePrivateKey(identity.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return OpenPayload(data, priv)
+}
+
+// openPayload returns data, opened if it is a sealed payload.
+func (s *Syncer) openPayload(data []byte) ([]byte, error) {
+	if !IsSealedPayload(data) {
+		return data, nil
+	}
+	var identity *LocalIdentity
+	if s.state != nil {
+		identity = s.state.Identity
+	}
+	return openWithIdentity(identity, data)
+}
+
+// openPayloads opens every sealed payload among files.
+func (s *Syncer) openPayloads(files map[string][]byte) (map[string][]byte, error) {
+	opened := make(map[string][]byte, len(files))
+	for name, data := range files {
+		plain, err := s.openPayload(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		opened[name] = plain
+	}
+	return opened, nil
+}
+
+// readLocalPlain reads the files of a local profile directory, keyed by
+// name, with vault-sealed and sync-sealed files opened in memory.
+func (s *Syncer) readLocalPlain(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := authfile.ReadVaultFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = s.openPayload(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		files[entry.Name()] = data
+	}
+	return files, nil
+}
+
+// sealForPush seals a profile's content files to the current recipients,
+// if sealing is in use. Other files (meta.json) are sent as they are.
+func (s *Syncer) sealForPush(files map[string][]byte) (map[string][]byte, error) {
+	if s.state == nil {
+		return files, nil
+	}
+	recipients, err := s.state.Recipients()
+	if err != nil || recipients == nil {
+		return files, err
+	}
+
+	sealed := make(map[string][]byte, len(files))
+	for name, data := range files {
+		if !isContentFile(name) {
+			sealed[name] = data
+			continue
+		}
+		plain, err := s.openPayload(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if sealed[name], err = SealPayload(plain, recipients); err != nil {
+			return nil, fmt.Errorf("seal %s: %w", name, err)
+		}
+	}
+	return sealed, nil
+}
+
+// ResealResult counts what Reseal did on one machine.
+type ResealResult struct {
+	// Resealed is the number of profiles sealed to the current recipients.
+	Resealed int
+	// Skipped lists profiles this machine could not open.
+	Skipped []string
+}
+
+// Reseal re-seals the sealed profiles on a machine to the current
+// recipients, e.g. so a revoked machine can no longer open them. Profiles
+// this machine cannot open are skipped; plaintext profiles are left alone.
+func (s *Syncer) Reseal(ctx context.Context, m *Machine) (*ResealResult, error) {
+	recipients, err := s.state.Recipients()
+	if err != nil {
+		return nil, err
+	}
+	if recipients == nil {
+		return &ResealResult{}, nil
+	}
+
+	client, err := s.pool.Get(m)
+	if err != nil {
+		m.SetError(err.Error())
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	profiles, err := s.listRemoteProfiles(client)
+	if err != nil {
+		return nil, fmt.Errorf("list remote profiles: %w", err)
+	}
+
+	res := &ResealResult{}
+	for _, p := range profiles {
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		default:
+		}
+
+		remotePath := posixJoin(s.remoteVault(client), p.Provider, p.Profile)
+		entries, err := client.ListDir(remotePath)
+		if err != nil {
+			return res, fmt.Errorf("list %s/%s: %w", p.Provider, p.Profile, err)
+		}
+
+		resealed := make(map[string][]byte)
+		skip := false
+		for _, fi := range entries {
+			if fi.IsDir() || !isContentFile(fi.Name()) {
+				continue
+			}
+			path := posixJoin(remotePath, fi.Name())
+			data, err := client.ReadFile(path)
+			if err != nil {
+				return res, fmt.Errorf("read %s: %w", path, err)
+			}
+			if !IsSealedPayload(data) {
+				continue
+			}
+			plain, err := s.openPayload(data)
+			if err != nil {
+				skip = true
+				break
+			}
+			if resealed[path], err = SealPayload(plain, recipients); err != nil {
+				return res, err
+			}
+		}
+
+		switch {
+		case skip:
+			res.Skipped = append(res.Skipped, p.Provider+"/"+p.Profile)
+		case len(resealed) > 0:
+			if err := client.BatchWrite(resealed, 0600); err != nil {
+				return res, fmt.Errorf("write %s/%s: %w", p.Provider, p.Profile, err)
+			}
+			res.Resealed++
+		}
+	}
+	return res, nil
+}
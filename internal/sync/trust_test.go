@@ -0,0 +1,254 @@
+package sync
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+func mustKeyPair(t *testing.T) (pubB64, privB64 string) {
+	t.Helper()
+	pub, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() error = %v", err)
+	}
+	return pub, priv
+}
+
+func TestSealPayload(t *testing.T) {
+	alicePub, alicePriv := mustKeyPair(t)
+	bobPub, bobPriv := mustKeyPair(t)
+	_, evePriv := mustKeyPair(t)
+
+	plaintext := []byte(`{"access_token":"secret"}`)
+	sealed, err := SealPayload(plaintext, mustParsePublicKeys(t, alicePub, bobPub))
+	if err != nil {
+		t.Fatalf("SealPayload() error = %v", err)
+	}
+	if !IsSealedPayload(sealed) || strings.Contains(string(sealed), "secret") {
+		t.Fatal("sealed payload is not opaque")
+	}
+	if IsSealedPayload(plaintext) {
+		t.Error("IsSealedPayload(plaintext) = true")
+	}
+
+	for name, priv := range map[string]string{"alice": alicePriv, "bob": bobPriv} {
+		key, err := ParsePrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := OpenPayload(sealed, key)
+		if err != nil || string(got) != string(plaintext) {
+			t.Errorf("OpenPayload(%s) = %q, %v", name, got, err)
+		}
+	}
+
+	eve, _ := ParsePrivateKey(evePriv)
+	if _, err := OpenPayload(sealed, eve); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("OpenPayload(non-recipient) error = %v, want ErrNotRecipient", err)
+	}
+
+	alice, _ := ParsePrivateKey(alicePriv)
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := OpenPayload(tampered, alice); err == nil {
+		t.Error("OpenPayload() of tampered payload should fail")
+	}
+}
+
+func mustParsePublicKeys(t *testing.T, keys ...string) []*ecdh.PublicKey {
+	t.Helper()
+	var pubs []*ecdh.PublicKey
+	for _, k := range keys {
+		pub, err := ParsePublicKey(k)
+		if err != nil {
+			t.Fatalf("ParsePublicKey() error = %v", err)
+		}
+		pubs = append(pubs, pub)
+	}
+	return pubs
+}
+
+func TestTrustStorePersists(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	state := NewSyncState(dir)
+	if err := state.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if state.Identity.Fingerprint() == "" {
+		t.Fatal("identity has no sync key")
+	}
+	if r, err := state.Recipients(); err != nil || r != nil {
+		t.Fatalf("Recipients() before trust = %v, %v; want nil", r, err)
+	}
+	if _, err := state.TrustKey("self", state.Identity.PublicKey); err == nil {
+		t.Error("TrustKey() of own key should fail")
+	}
+
+	laptopPub, _ := mustKeyPair(t)
+	if _, err := state.TrustKey("laptop", laptopPub); err != nil {
+		t.Fatalf("TrustKey() error = %v", err)
+	}
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := NewSyncState(dir)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	keys := loaded.TrustedKeys()
+	if len(keys) != 1 || keys[0].Name != "laptop" || keys[0].Fingerprint == "" {
+		t.Fatalf("TrustedKeys() after reload = %+v", keys)
+	}
+	if r, err := loaded.Recipients(); err != nil || len(r) != 2 {
+		t.Errorf("Recipients() = %d keys, %v; want 2", len(r), err)
+	}
+
+	// Revoking the last key keeps sealing on, to this machine alone
+	if _, err := loaded.RevokeKey("laptop"); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	if _, err := loaded.RevokeKey("laptop"); err == nil {
+		t.Error("RevokeKey() of untrusted machine should fail")
+	}
+	if r, err := loaded.Recipients(); err != nil || len(r) != 1 {
+		t.Errorf("Recipients() after revoke = %d keys, %v; want 1", len(r), err)
+	}
+
+	// A damaged trust store must not silently turn sealing off
+	if err := os.WriteFile(filepath.Join(dir, trustFileName), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSyncState(dir).Load(); err == nil {
+		t.Error("Load() with damaged trust store should fail")
+	}
+}
+
+func TestSealedSyncWithDirTransport(t *testing.T) {
+	t.Setenv("CAAM_HOME", t.TempDir())
+	shared := t.TempDir()
+	m := NewMachine("nas", "dir://"+shared)
+
+	// Two machines share a directory; each has its own identity.
+	newPeer := func(name string) *Syncer {
+		state := NewSyncState(t.TempDir())
+		pub, priv := mustKeyPair(t)
+		state.Identity = &LocalIdentity{ID: name, Hostname: name, PublicKey: pub, PrivateKey: priv}
+		return &Syncer{
+			pool:      NewConnectionPool(DefaultConnectOptions()),
+			state:     state,
+			vaultPath: t.TempDir(),
+		}
+	}
+	alice, bob := newPeer("alice"), newPeer("bob")
+	defer alice.pool.CloseAll()
+	defer bob.pool.CloseAll()
+	carol, _ := mustKeyPair(t)
+
+	for _, trust := range []struct {
+		s    *Syncer
+		name string
+		key  string
+	}{
+		{alice, "bob", bob.state.Identity.PublicKey},
+		{alice, "carol", carol},
+		{bob, "alice", alice.state.Identity.PublicKey},
+	} {
+		if _, err := trust.s.state.TrustKey(trust.name, trust.key); err != nil {
+			t.Fatalf("TrustKey(%s) error = %v", trust.name, err)
+		}
+	}
+
+	profile := filepath.Join(alice.vaultPath, "codex", "work")
+	if err := os.MkdirAll(profile, 0700); err != nil {
+		t.Fatal(err)
+	}
+	auth := `{"access_token":"secret-token","refresh_token":"ref","expires_at":1766245740}`
+	if err := os.WriteFile(filepath.Join(profile, "auth.json"), []byte(auth), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := alice.SyncWithMachine(context.Background(), m)
+	if err != nil || len(results) != 1 || results[0].Operation.Direction != SyncPush || !results[0].Success {
+		t.Fatalf("alice sync = %+v, %v; want one successful push", results, err)
+	}
+	remote, err := os.ReadFile(filepath.Join(shared, "codex", "work", "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealedPayload(remote) || strings.Contains(string(remote), "secret-token") {
+		t.Fatal("pushed profile is not sealed")
+	}
+
+	// Bob pulls it; it stays sealed in his vault until read
+	results, err = bob.SyncWithMachine(context.Background(), m)
+	if err != nil || len(results) != 1 || results[0].Operation.Direction != SyncPull || !results[0].Success {
+		t.Fatalf("bob sync = %+v, %v; want one successful pull", results, err)
+	}
+	pulled := filepath.Join(bob.vaultPath, "codex", "work", "auth.json")
+	if raw, _ := os.ReadFile(pulled); !IsSealedPayload(raw) {
+		t.Error("pulled profile is not sealed at rest")
+	}
+	authfile.SetPayloadOpener(func(data []byte) ([]byte, bool, error) {
+		if !IsSealedPayload(data) {
+			return nil, false, nil
+		}
+		plain, err := openWithIdentity(bob.state.Identity, data)
+		return plain, true, err
+	})
+	defer authfile.SetPayloadOpener(nil)
+	if data, err := authfile.ReadVaultFile(pulled); err != nil || string(data) != auth {
+		t.Errorf("ReadVaultFile(pulled) = %q, %v", data, err)
+	}
+
+	// Nothing changed, so a second sync is a no-op on both sides
+	for name, s := range map[string]*Syncer{"alice": alice, "bob": bob} {
+		results, err := s.SyncWithMachine(context.Background(), m)
+		if err != nil || len(results) != 0 {
+			t.Errorf("%s resync = %+v, %v; want nothing to do", name, results, err)
+		}
+	}
+
+	// Revoking carol re-seals the shared copy without her key
+	if n := len(payloadKeyIDs(t, remote)); n != 3 {
+		t.Fatalf("recipients before revoke = %d, want 3", n)
+	}
+	if _, err := alice.state.RevokeKey("carol"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := alice.Reseal(context.Background(), m)
+	if err != nil || res.Resealed != 1 || len(res.Skipped) != 0 {
+		t.Fatalf("Reseal() = %+v, %v; want 1 re-sealed", res, err)
+	}
+	remote, _ = os.ReadFile(filepath.Join(shared, "codex", "work", "auth.json"))
+	if n := len(payloadKeyIDs(t, remote)); n != 2 {
+		t.Errorf("recipients after revoke = %d, want 2", n)
+	}
+	if data, err := authfile.ReadVaultFile(pulled); err != nil || string(data) != auth {
+		t.Errorf("bob can no longer read the profile: %q, %v", data, err)
+	}
+}
+
+// payloadKeyIDs returns the key ids a payload is sealed to.
+func payloadKeyIDs(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	if !IsSealedPayload(data) {
+		t.Fatal("not a sealed payload")
+	}
+	start := len(payloadMagic) + payloadPubKeySize
+	var ids [][]byte
+	for i := 0; i < int(data[start]); i++ {
+		off := start + 1 + i*payloadEntrySize
+		ids = append(ids, data[off:off+payloadKeyIDSize])
+	}
+	return ids
+}
@@ -261,6 +261,13 @@ caam run <tool> -- <args>       # Wrap CLI with auto-failover on rate limits
 caam cooldown set <profile>     # Mark profile as rate-limited
 caam cooldown list              # View active cooldowns
 caam next <tool>                # Preview which profile rotation would pick
+caam rotation set <tool> <name> --weight 0.7   # Weights, caps, --reserve
+caam policy test <tool>         # Dry-run ~/.caam/policy.yaml selection rules
+caam budget                     # Budget burn this period (--override to bypass)
+caam cost roi --format csv      # Subscription price vs API-equivalent value
+caam notify test                # Send a test alert to each channel
+caam sync conflicts             # Profiles changed on two machines since last sync
+caam sync trust <machine>       # Seal synced profiles to that machine's key
 ` + "```" + `
 
 ### Rotation Algorithms
@@ -269,6 +276,7 @@ Configure in ` + "`config.yaml`" + ` → ` + "`stealth.rotation.algorithm`" + `:
 - **smart** — Multi-factor scoring: health, cooldown, recency, plan type
 - **round_robin** — Sequential cycling through profiles
 - **random** — Random selection
+- **reset_aware** — Spend quota that resets soonest, keep distant resets in reserve
 
 ---
 
@@ -20,6 +20,7 @@ import (
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/identity"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/project"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/refresh"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/signals"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/sync"
@@ -123,6 +124,10 @@ type Model struct {
 	providers      []string // codex, claude, gemini
 	activeProvider int      // Currently selected provider index
 
+	// capabilities by provider ID; actions are offered for providers not
+	// listed here (no registry given).
+	capabilities map[string]provider.Capabilities
+
 	// Profile state
 	profiles            map[string][]Profile // Profiles by provider
 	selected            int                  // Currently selected profile index
@@ -494,6 +499,28 @@ func (m Model) loadProfiles() tea.Msg {
 	return profilesLoadedMsg{profiles: profiles, meta: meta, vaultMeta: vaultMeta}
 }
 
+// SetCapabilities records the capabilities of the providers in reg so
+// unsupported actions are refused up front.
+func (m *Model) SetCapabilities(reg *provider.Registry) {
+	if reg == nil {
+		return
+	}
+	m.capabilities = make(map[string]provider.Capabilities)
+	for _, p := range reg.All() {
+		m.capabilities[p.ID()] = p.Capabilities()
+	}
+}
+
+// requireCapability returns a *provider.UnsupportedError if providerID is
+// known not to support capability.
+func (m Model) requireCapability(providerID string, capability provider.Capability) error {
+	caps, ok := m.capabilities[providerID]
+	if !ok || caps.Has(capability) {
+		return nil
+	}
+	return &provider.UnsupportedError{Provider: providerID, Capability: capability}
+}
+
 func authFileSetForProvider(provider string) (authfile.AuthFileSet, bool) {
 	switch provider {
 	case "codex":
@@ -1489,12 +1516,16 @@ func (m Model) handleLoginProfile() (tea.Model, tea.Cmd) {
 		m.statusMsg = "No profile selected"
 		return m, nil
 	}
-	provider := m.currentProvider()
+	providerID := m.currentProvider()
+	if err := m.requireCapability(providerID, provider.CapTokenRefresh); err != nil {
+		m.statusMsg = err.Error()
+		return m, nil
+	}
 
 	m.statusMsg = fmt.Sprintf("Refreshing %s token...", info.Name)
 
 	// Return a command that performs the async refresh
-	return m, m.doRefreshProfile(provider, info.Name)
+	return m, m.doRefreshProfile(providerID, info.Name)
 }
 
 // doRefreshProfile returns a tea.Cmd that performs the token refresh.
@@ -3201,6 +3232,12 @@ func (m Model) dumpStatsLine() string {
 
 // Run starts the TUI application.
 func Run() error {
+	return RunWithRegistry(nil)
+}
+
+// RunWithRegistry starts the TUI application, offering per-profile actions
+// according to the capabilities of the providers in reg.
+func RunWithRegistry(reg *provider.Registry) error {
 	spmCfg, err := config.LoadSPMConfig()
 	if err != nil {
 		// Keep the TUI usable even with a broken config file.
@@ -3227,6 +3264,7 @@ func Run() error {
 	)
 
 	m := NewWithConfig(spmCfg)
+	m.SetCapabilities(reg)
 
 	pidPath := signals.DefaultPIDFilePath()
 	pidWritten := false
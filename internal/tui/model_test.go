@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/profile"
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/provider"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/watcher"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
@@ -299,6 +300,26 @@ func TestHandleLoginProfile(t *testing.T) {
 	}
 }
 
+func TestHandleLoginProfile_RefreshUnsupported(t *testing.T) {
+	m := New()
+	m.profiles = map[string][]Profile{
+		"claude": {{Name: "test@example.com", Provider: "claude"}},
+	}
+	m.capabilities = map[string]provider.Capabilities{
+		"claude": {SessionResume: true},
+	}
+
+	result, cmd := m.handleLoginProfile()
+	updated := result.(Model)
+
+	if cmd != nil {
+		t.Error("expected no refresh command for a provider without token refresh")
+	}
+	if updated.statusMsg != "claude does not support token_refresh" {
+		t.Errorf("statusMsg = %q", updated.statusMsg)
+	}
+}
+
 func TestHandleOpenInBrowser(t *testing.T) {
 	m := New()
 
@@ -179,11 +179,15 @@ func (m Model) testSyncMachine(machineID string) tea.Cmd {
 			}
 		}
 
-		// Test actual SSH connectivity
+		// Test actual connectivity
 		result := sync.TestMachineConnectivity(machine, sync.DefaultConnectOptions())
 		msg := ""
 		if result.Success {
-			msg = fmt.Sprintf("Connected (latency: %v, SFTP: %v)", result.Latency, result.SFTPWorks)
+			if machine.TransportScheme() == sync.TransportSSH {
+				msg = fmt.Sprintf("Connected (latency: %v, SFTP: %v)", result.Latency, result.SFTPWorks)
+			} else {
+				msg = fmt.Sprintf("Connected (latency: %v, %d profiles)", result.Latency, result.ProfileCount)
+			}
 		} else if result.Error != nil {
 			msg = result.Error.Error()
 		}
@@ -0,0 +1,256 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
+)
+
+// Gemini API constants.
+//
+// Gemini CLI's "Login with Google" talks to the Code Assist backend, which
+// reports per-model request quotas that reset daily.
+const (
+	GeminiDefaultBaseURL = "https://cloudcode-pa.googleapis.com"
+	GeminiAPIVersion     = "v1internal"
+	GeminiUserAgent      = "caam/1.0"
+	geminiTimeout        = 30 * time.Second
+	geminiQuotaWindow    = 24 * time.Hour
+)
+
+// GeminiFetcher fetches quota data from the Gemini Code Assist API.
+type GeminiFetcher struct {
+	client  *http.Client
+	baseURL string // For testing
+}
+
+// NewGeminiFetcher creates a new Gemini usage fetcher.
+func NewGeminiFetcher() *GeminiFetcher {
+	return &GeminiFetcher{
+		client: &http.Client{Timeout: geminiTimeout},
+	}
+}
+
+// GeminiFetchOptions provides optional parameters for fetching.
+type GeminiFetchOptions struct {
+	// ProjectID is the profile's Google Cloud project, used when
+	// GOOGLE_CLOUD_PROJECT is unset. If both are empty the project is
+	// discovered from the account.
+	ProjectID string
+}
+
+// geminiLoadResponse is the subset of loadCodeAssist we need.
+type geminiLoadResponse struct {
+	CurrentTier *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"currentTier"`
+	Project string `json:"cloudaicompanionProject"`
+}
+
+// geminiQuotaResponse represents the retrieveUserQuota response.
+type geminiQuotaResponse struct {
+	Buckets []geminiQuotaBucket `json:"buckets"`
+}
+
+type geminiQuotaBucket struct {
+	ModelID           string   `json:"modelId"`
+	TokenType         string   `json:"tokenType"`         // "REQUESTS"
+	RemainingFraction *float64 `json:"remainingFraction"` // 0-1
+	ResetTime         string   `json:"resetTime"`         // ISO8601 timestamp
+}
+
+// Fetch retrieves quota data from the Gemini Code Assist API.
+func (f *GeminiFetcher) Fetch(ctx context.Context, accessToken string) (*UsageInfo, error) {
+	return f.FetchWithOptions(ctx, accessToken, nil)
+}
+
+// FetchWithOptions retrieves quota data with optional parameters.
+//
+// Each model's daily quota becomes an entry in ModelWindows. The most
+// constrained one is also reported as PrimaryWindow so availability scoring
+// reflects the model that runs out first.
+func (f *GeminiFetcher) FetchWithOptions(ctx context.Context, accessToken string, opts *GeminiFetchOptions) (*UsageInfo, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("access token is empty")
+	}
+
+	info := &UsageInfo{
+		Provider:  "gemini",
+		FetchedAt: time.Now(),
+	}
+
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" && opts != nil {
+		project = opts.ProjectID
+	}
+
+	// loadCodeAssist reports the subscription tier and the managed project
+	// quota is tracked against.
+	loadReq := map[string]interface{}{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	if project != "" {
+		loadReq["cloudaicompanionProject"] = project
+	}
+	var load geminiLoadResponse
+	if err := f.call(ctx, accessToken, "loadCodeAssist", loadReq, &load, info); err != nil {
+		return info, err
+	}
+	if load.CurrentTier != nil {
+		info.PlanType = load.CurrentTier.ID
+	}
+	if load.Project != "" {
+		project = load.Project
+	}
+	if project == "" {
+		info.Error = "no Code Assist project for this account"
+		return info, fmt.Errorf("no Code Assist project for this account")
+	}
+
+	var quota geminiQuotaResponse
+	if err := f.call(ctx, accessToken, "retrieveUserQuota", map[string]string{"project": project}, &quota, info); err != nil {
+		return info, err
+	}
+
+	for _, b := range quota.Buckets {
+		if b.ModelID == "" || b.RemainingFraction == nil {
+			continue
+		}
+		if b.TokenType != "" && b.TokenType != "REQUESTS" {
+			continue
+		}
+		util := 1 - *b.RemainingFraction
+		if util < 0 {
+			util = 0
+		}
+		if info.ModelWindows == nil {
+			info.ModelWindows = make(map[string]*UsageWindow)
+		}
+		info.ModelWindows[b.ModelID] = &UsageWindow{
+			Utilization:    util,
+			UsedPercent:    int(util*100 + 0.5),
+			ResetsAt:       parseISO8601(b.ResetTime),
+			WindowDuration: geminiQuotaWindow,
+		}
+	}
+
+	// Pick the most constrained model in a stable order.
+	models := make([]string, 0, len(info.ModelWindows))
+	for model := range info.ModelWindows {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		w := info.ModelWindows[model]
+		if info.PrimaryWindow == nil || w.Utilization > info.PrimaryWindow.Utilization {
+			cp := *w
+			info.PrimaryWindow = &cp
+		}
+	}
+
+	return info, nil
+}
+
+// call POSTs body to a Code Assist method and decodes the response into out.
+// Failures are also recorded in info.Error.
+func (f *GeminiFetcher) call(ctx context.Context, accessToken, method string, body, out interface{}, info *UsageInfo) error {
+	baseURL := GeminiDefaultBaseURL
+	if f.baseURL != "" {
+		baseURL = f.baseURL
+	}
+	url := fmt.Sprintf("%s/%s:%s", strings.TrimRight(baseURL, "/"), GeminiAPIVersion, method)
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("User-Agent", GeminiUserAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		info.Error = fmt.Sprintf("request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Success - parse response
+	case http.StatusUnauthorized, http.StatusForbidden:
+		info.Error = "unauthorized: token expired or invalid"
+		return fmt.Errorf("unauthorized: status %d", resp.StatusCode)
+	default:
+		info.Error = fmt.Sprintf("API error: status %d", resp.StatusCode)
+		return fmt.Errorf("API error: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		info.Error = fmt.Sprintf("decode error: %v", err)
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// ReadGeminiCredentials reads the OAuth access token from a Gemini auth
+// file: oauth_credentials.json (flat) or settings.json (nested under "oauth"
+// or "credentials").
+func ReadGeminiCredentials(path string) (accessToken string, projectID string, err error) {
+	data, err := authfile.ReadVaultFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", "", err
+	}
+
+	str := func(m map[string]interface{}, keys ...string) string {
+		for _, k := range keys {
+			if v, ok := m[k].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	candidates := []map[string]interface{}{raw}
+	for _, key := range []string{"oauth", "credentials"} {
+		if nested, ok := raw[key].(map[string]interface{}); ok {
+			candidates = append(candidates, nested)
+		}
+	}
+	for _, m := range candidates {
+		if token := str(m, "access_token", "accessToken"); token != "" {
+			return token, str(m, "project_id", "projectId", "quota_project_id"), nil
+		}
+	}
+
+	// API key auth does not have Code Assist quotas.
+	if str(raw, "apiKey", "api_key") != "" {
+		return "", "", fmt.Errorf("API key auth does not support usage fetch")
+	}
+
+	return "", "", fmt.Errorf("no access token found in credentials")
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/authfile"
 	"github.com/Dicklesworthstone/coding_agent_account_manager/internal/logs"
 )
 
@@ -25,7 +26,9 @@ type ProfileUsage struct {
 type MultiProfileFetcher struct {
 	claudeFetcher *ClaudeFetcher
 	codexFetcher  *CodexFetcher
+	geminiFetcher *GeminiFetcher
 	logScanner    logs.Scanner // Optional scanner for burn rate calculation
+	vaultDir      string       // Optional vault for per-profile settings
 }
 
 // FetcherOption configures the MultiProfileFetcher.
@@ -38,11 +41,20 @@ func WithLogScanner(scanner logs.Scanner) FetcherOption {
 	}
 }
 
+// WithVaultDir lets fetches read per-profile settings, such as a Gemini
+// profile's Google Cloud project, from the vault at dir.
+func WithVaultDir(dir string) FetcherOption {
+	return func(m *MultiProfileFetcher) {
+		m.vaultDir = dir
+	}
+}
+
 // NewMultiProfileFetcher creates a new multi-profile fetcher.
 func NewMultiProfileFetcher(opts ...FetcherOption) *MultiProfileFetcher {
 	m := &MultiProfileFetcher{
 		claudeFetcher: NewClaudeFetcher(),
 		codexFetcher:  NewCodexFetcher(),
+		geminiFetcher: NewGeminiFetcher(),
 	}
 	for _, opt := range opts {
 		opt(m)
@@ -90,6 +102,16 @@ func (m *MultiProfileFetcher) FetchAllProfiles(ctx context.Context, provider str
 				} else {
 					info, err = m.codexFetcher.Fetch(ctx, token)
 				}
+			case "gemini":
+				if m.geminiFetcher == nil {
+					info = &UsageInfo{
+						Provider:  provider,
+						FetchedAt: time.Now(),
+						Error:     "gemini fetcher unavailable",
+					}
+				} else {
+					info, err = m.geminiFetcher.FetchWithOptions(ctx, token, m.geminiOptions(name))
+				}
 			default:
 				info = &UsageInfo{
 					Provider:  provider,
@@ -226,7 +248,7 @@ func (m *MultiProfileFetcher) GetProfilesAboveThreshold(ctx context.Context, pro
 
 // ReadClaudeCredentials reads the access token from Claude credentials file.
 func ReadClaudeCredentials(path string) (accessToken string, accountID string, err error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return "", "", err
 	}
@@ -280,7 +302,7 @@ func ReadClaudeCredentials(path string) (accessToken string, accountID string, e
 
 // ReadCodexCredentials reads the access token from Codex auth file.
 func ReadCodexCredentials(path string) (accessToken string, accountID string, err error) {
-	data, err := os.ReadFile(path)
+	data, err := authfile.ReadVaultFile(path)
 	if err != nil {
 		return "", "", err
 	}
@@ -320,6 +342,18 @@ func ReadCodexCredentials(path string) (accessToken string, accountID string, er
 	return "", "", fmt.Errorf("no access token found in credentials")
 }
 
+// geminiOptions returns the fetch options for a Gemini profile.
+func (m *MultiProfileFetcher) geminiOptions(profileName string) *GeminiFetchOptions {
+	if m.vaultDir == "" {
+		return nil
+	}
+	_, project, err := ReadProfileToken(filepath.Join(m.vaultDir, "gemini", profileName), "gemini")
+	if err != nil || project == "" {
+		return nil
+	}
+	return &GeminiFetchOptions{ProjectID: project}
+}
+
 // LoadProfileCredentials loads credentials for all profiles of a provider from the vault.
 func LoadProfileCredentials(vaultDir, provider string) (map[string]string, error) {
 	providerDir := filepath.Join(vaultDir, provider)
@@ -341,29 +375,7 @@ func LoadProfileCredentials(vaultDir, provider string) (map[string]string, error
 		profileName := entry.Name()
 		profileDir := filepath.Join(providerDir, profileName)
 
-		var token string
-		var readErr error
-
-		switch provider {
-		case "claude":
-			// Try new location first
-			credPath := filepath.Join(profileDir, ".credentials.json")
-			token, _, readErr = ReadClaudeCredentials(credPath)
-			if readErr != nil {
-				// Fall back to old location
-				oldPath := filepath.Join(profileDir, ".claude.json")
-				token, _, readErr = ReadClaudeCredentials(oldPath)
-				if readErr != nil {
-					// Fall back to claude-code auth.json (optional file)
-					authPath := filepath.Join(profileDir, "auth.json")
-					token, _, readErr = ReadClaudeCredentials(authPath)
-				}
-			}
-		case "codex":
-			authPath := filepath.Join(profileDir, "auth.json")
-			token, _, readErr = ReadCodexCredentials(authPath)
-		}
-
+		token, _, readErr := ReadProfileToken(profileDir, provider)
 		if readErr != nil {
 			continue // Skip profiles with invalid credentials
 		}
@@ -375,3 +387,35 @@ func LoadProfileCredentials(vaultDir, provider string) (map[string]string, error
 
 	return credentials, nil
 }
+
+// ReadProfileToken reads the usage API access token from a vault profile
+// directory, trying each auth file the provider may store it in. For Gemini
+// it also returns the Google Cloud project recorded with the credentials,
+// if any.
+func ReadProfileToken(profileDir, provider string) (token, projectID string, err error) {
+	switch provider {
+	case "claude":
+		// Try new location first
+		token, _, err = ReadClaudeCredentials(filepath.Join(profileDir, ".credentials.json"))
+		if err != nil {
+			// Fall back to old location
+			token, _, err = ReadClaudeCredentials(filepath.Join(profileDir, ".claude.json"))
+			if err != nil {
+				// Fall back to claude-code auth.json (optional file)
+				token, _, err = ReadClaudeCredentials(filepath.Join(profileDir, "auth.json"))
+			}
+		}
+		return token, "", err
+	case "codex":
+		token, _, err = ReadCodexCredentials(filepath.Join(profileDir, "auth.json"))
+		return token, "", err
+	case "gemini":
+		token, projectID, err = ReadGeminiCredentials(filepath.Join(profileDir, "oauth_credentials.json"))
+		if err != nil {
+			token, projectID, err = ReadGeminiCredentials(filepath.Join(profileDir, "settings.json"))
+		}
+		return token, projectID, err
+	default:
+		return "", "", fmt.Errorf("usage fetch unsupported for provider %s", provider)
+	}
+}
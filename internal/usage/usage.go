@@ -5,6 +5,7 @@ package usage
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
@@ -25,7 +26,7 @@ type UsageWindow struct {
 
 // UsageInfo contains rate limit and usage information for a provider account.
 type UsageInfo struct {
-	// Provider is "claude" or "codex".
+	// Provider is "claude", "codex" or "gemini".
 	Provider string `json:"provider"`
 
 	// ProfileName is the CAAM profile name (if known).
@@ -269,20 +270,89 @@ func (u *UsageInfo) MostConstrainedWindow() *UsageWindow {
 }
 
 // WindowForModel returns the rate limit window for a specific model.
-// Falls back to TertiaryWindow if no model-specific window exists.
+// Per-model windows match the model name exactly or, failing that, by
+// case-insensitive substring ("pro" matches "gemini-2.5-pro"); when several
+// match, the most constrained wins. Premium models (Opus) fall back to
+// TertiaryWindow. Returns nil for models without a window of their own.
 func (u *UsageInfo) WindowForModel(model string) *UsageWindow {
-	if u == nil {
-		return nil
+	_, w := u.modelWindow(model)
+	return w
+}
+
+// modelWindow is WindowForModel that also returns the ModelWindows key of the
+// match ("" for the tertiary fallback).
+func (u *UsageInfo) modelWindow(model string) (string, *UsageWindow) {
+	if u == nil || model == "" {
+		return "", nil
+	}
+
+	if w, ok := u.ModelWindows[model]; ok {
+		return model, w
 	}
 
-	if u.ModelWindows != nil {
-		if w, ok := u.ModelWindows[model]; ok {
-			return w
+	lower := strings.ToLower(model)
+	var (
+		bestKey string
+		best    *UsageWindow
+	)
+	for key, w := range u.ModelWindows {
+		k := strings.ToLower(key)
+		if !strings.Contains(k, lower) && !strings.Contains(lower, k) {
+			continue
+		}
+		if best == nil || windowUtilization(w) > windowUtilization(best) ||
+			(windowUtilization(w) == windowUtilization(best) && key < bestKey) {
+			bestKey, best = key, w
 		}
 	}
+	if best != nil {
+		return bestKey, best
+	}
 
 	// Fall back to tertiary (premium model) window
-	return u.TertiaryWindow
+	if isPremiumModel(model) {
+		return "", u.TertiaryWindow
+	}
+	return "", nil
+}
+
+// ForModel returns a copy of u scoped to one model. Windows that only limit
+// other models are dropped, so an exhausted Opus window does not count
+// against Sonnet: TertiaryWindow and ModelWindows keep just the model's own
+// window, if any. For providers whose limits are all per-model (Gemini),
+// PrimaryWindow becomes the model's window. An empty model returns u.
+func (u *UsageInfo) ForModel(model string) *UsageInfo {
+	if u == nil || model == "" {
+		return u
+	}
+
+	scoped := *u
+	key, w := u.modelWindow(model)
+	scoped.TertiaryWindow = w
+	scoped.ModelWindows = nil
+	if key != "" {
+		scoped.ModelWindows = map[string]*UsageWindow{key: w}
+	}
+	if u.Provider == "gemini" && w != nil {
+		scoped.PrimaryWindow = w
+		scoped.TertiaryWindow = nil
+	}
+	return &scoped
+}
+
+// isPremiumModel reports whether model is limited by the premium model
+// (tertiary) window.
+func isPremiumModel(model string) bool {
+	return strings.Contains(strings.ToLower(model), "opus")
+}
+
+// windowUtilization returns w's utilization as a fraction, falling back to
+// UsedPercent when Utilization is unset.
+func windowUtilization(w *UsageWindow) float64 {
+	if w.Utilization == 0 && w.UsedPercent > 0 {
+		return float64(w.UsedPercent) / 100.0
+	}
+	return w.Utilization
 }
 
 // PredictDepletion calculates when the rate limit will be hit based on burn rate.
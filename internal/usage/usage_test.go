@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -311,6 +312,18 @@ func TestMultiProfileFetcher_NilFetcherDoesNotPanic(t *testing.T) {
 			t.Fatal("results[0].Usage.Error is empty, want error")
 		}
 	})
+	t.Run("gemini nil fetcher", func(t *testing.T) {
+		m := &MultiProfileFetcher{}
+		results := m.FetchAllProfiles(context.Background(), "gemini", map[string]string{
+			"work": "token",
+		})
+		if len(results) != 1 {
+			t.Fatalf("results len = %d, want 1", len(results))
+		}
+		if results[0].Usage == nil || results[0].Usage.Error == "" {
+			t.Fatal("expected usage error for nil gemini fetcher")
+		}
+	})
 }
 
 func TestCodexFetcher_Fetch_BalanceParsing(t *testing.T) {
@@ -523,6 +536,176 @@ func TestReadCodexCredentials(t *testing.T) {
 	}
 }
 
+func TestGeminiFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1internal:loadCodeAssist":
+			_, _ = io.WriteString(w, `{"currentTier":{"id":"standard-tier"},"cloudaicompanionProject":"proj-1"}`)
+		case "/v1internal:retrieveUserQuota":
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), `"project":"proj-1"`) {
+				t.Errorf("quota request body = %s", body)
+			}
+			_, _ = io.WriteString(w, `{"buckets":[
+				{"modelId":"gemini-2.5-pro","tokenType":"REQUESTS","remainingFraction":0.25,"resetTime":"2026-01-02T00:00:00Z"},
+				{"modelId":"gemini-2.5-flash","tokenType":"REQUESTS","remainingFraction":0.9,"resetTime":"2026-01-02T00:00:00Z"},
+				{"modelId":"gemini-2.5-flash","tokenType":"TOKENS","remainingFraction":0.0}
+			]}`)
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewGeminiFetcher()
+	fetcher.baseURL = server.URL
+
+	info, err := fetcher.Fetch(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if info.Provider != "gemini" || info.PlanType != "standard-tier" {
+		t.Fatalf("provider/plan = %q/%q", info.Provider, info.PlanType)
+	}
+	if len(info.ModelWindows) != 2 {
+		t.Fatalf("ModelWindows = %v, want 2 entries", info.ModelWindows)
+	}
+	pro := info.WindowForModel("gemini-2.5-pro")
+	if pro == nil || pro.UsedPercent != 75 || pro.WindowDuration != 24*time.Hour {
+		t.Fatalf("pro window = %+v", pro)
+	}
+	if want := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC); !pro.ResetsAt.Equal(want) {
+		t.Errorf("ResetsAt = %v, want %v", pro.ResetsAt, want)
+	}
+	if flash := info.ModelWindows["gemini-2.5-flash"]; flash == nil || flash.UsedPercent != 10 {
+		t.Fatalf("flash window = %+v (TOKENS bucket must be ignored)", flash)
+	}
+	if info.PrimaryWindow == nil || info.PrimaryWindow.UsedPercent != 75 {
+		t.Fatalf("PrimaryWindow = %+v, want most constrained model", info.PrimaryWindow)
+	}
+}
+
+func TestGeminiFetcher_Fetch_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	fetcher := NewGeminiFetcher()
+	fetcher.baseURL = server.URL
+
+	info, err := fetcher.Fetch(context.Background(), "expired")
+	if err == nil {
+		t.Fatal("expected error for 401")
+	}
+	if info == nil || !strings.Contains(info.Error, "unauthorized") {
+		t.Fatalf("info = %+v, want unauthorized error", info)
+	}
+}
+
+func TestReadGeminiCredentials(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		content     string
+		wantToken   string
+		wantProject string
+		wantErr     bool
+	}{
+		{name: "flat", content: `{"access_token":"flat-token","project_id":"proj"}`, wantToken: "flat-token", wantProject: "proj"},
+		{name: "nested oauth", content: `{"oauth":{"accessToken":"oauth-token"}}`, wantToken: "oauth-token"},
+		{name: "nested credentials", content: `{"credentials":{"access_token":"cred-token"}}`, wantToken: "cred-token"},
+		{name: "api key", content: `{"apiKey":"AIza-test"}`, wantErr: true},
+		{name: "empty", content: `{}`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, strings.ReplaceAll(tc.name, " ", "_")+".json")
+			if err := os.WriteFile(path, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			token, project, err := ReadGeminiCredentials(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ReadGeminiCredentials() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if token != tc.wantToken || project != tc.wantProject {
+				t.Fatalf("token/project = %q/%q, want %q/%q", token, project, tc.wantToken, tc.wantProject)
+			}
+		})
+	}
+}
+
+func TestLoadProfileCredentials_Gemini(t *testing.T) {
+	vaultDir := t.TempDir()
+
+	write := func(profile, file, content string) {
+		dir := filepath.Join(vaultDir, "gemini", profile)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("oauth", "oauth_credentials.json", `{"access_token":"tok-oauth"}`)
+	write("settings", "settings.json", `{"oauth":{"access_token":"tok-settings"}}`)
+	write("apikey", "settings.json", `{"apiKey":"AIza-test"}`)
+
+	creds, err := LoadProfileCredentials(vaultDir, "gemini")
+	if err != nil {
+		t.Fatalf("LoadProfileCredentials() error = %v", err)
+	}
+	if len(creds) != 2 || creds["oauth"] != "tok-oauth" || creds["settings"] != "tok-settings" {
+		t.Fatalf("credentials = %v", creds)
+	}
+}
+
+func TestMultiProfileFetcher_GeminiProfileProject(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	vaultDir := t.TempDir()
+	dir := filepath.Join(vaultDir, "gemini", "work")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "oauth_credentials.json"), []byte(`{"access_token":"tok","project_id":"work-proj"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var quotaBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1internal:loadCodeAssist":
+			_, _ = io.WriteString(w, `{"currentTier":{"id":"standard-tier"}}`)
+		case "/v1internal:retrieveUserQuota":
+			quotaBody = string(body)
+			_, _ = io.WriteString(w, `{"buckets":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	m := NewMultiProfileFetcher(WithVaultDir(vaultDir))
+	m.geminiFetcher.baseURL = server.URL
+	m.FetchAllProfiles(context.Background(), "gemini", map[string]string{"work": "tok"})
+	if !strings.Contains(quotaBody, `"project":"work-proj"`) {
+		t.Errorf("quota request body = %q, want the profile's project", quotaBody)
+	}
+
+	// GOOGLE_CLOUD_PROJECT takes precedence.
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-proj")
+	m.FetchAllProfiles(context.Background(), "gemini", map[string]string{"work": "tok"})
+	if !strings.Contains(quotaBody, `"project":"env-proj"`) {
+		t.Errorf("quota request body = %q, want GOOGLE_CLOUD_PROJECT", quotaBody)
+	}
+}
+
 func TestUsageInfo_WindowForModel(t *testing.T) {
 	tertiaryWindow := &UsageWindow{Utilization: 0.7}
 	opusWindow := &UsageWindow{Utilization: 0.9}
@@ -565,9 +748,28 @@ func TestUsageInfo_WindowForModel(t *testing.T) {
 					"claude-3-opus": opusWindow,
 				},
 			},
-			model:      "claude-3-sonnet",
+			model:      "claude-opus-4",
 			expectUtil: 0.7,
 		},
+		{
+			name: "tertiary only applies to premium models",
+			info: &UsageInfo{
+				TertiaryWindow: tertiaryWindow,
+			},
+			model:     "claude-sonnet-4",
+			expectNil: true,
+		},
+		{
+			name: "matches model window by substring",
+			info: &UsageInfo{
+				ModelWindows: map[string]*UsageWindow{
+					"gemini-2.5-pro":   {Utilization: 0.4},
+					"gemini-2.5-flash": {Utilization: 0.2},
+				},
+			},
+			model:      "pro",
+			expectUtil: 0.4,
+		},
 		{
 			name: "returns nil if no match and no tertiary",
 			info: &UsageInfo{
@@ -602,6 +804,51 @@ func TestUsageInfo_WindowForModel(t *testing.T) {
 	}
 }
 
+func TestUsageInfo_ForModel(t *testing.T) {
+	claude := &UsageInfo{
+		Provider:        "claude",
+		PrimaryWindow:   &UsageWindow{Utilization: 0.3},
+		SecondaryWindow: &UsageWindow{Utilization: 0.2},
+		TertiaryWindow:  &UsageWindow{Utilization: 1.0},
+	}
+
+	if got := claude.ForModel(""); got != claude {
+		t.Error("ForModel(\"\") should return the receiver")
+	}
+
+	sonnet := claude.ForModel("sonnet")
+	if sonnet.TertiaryWindow != nil {
+		t.Error("sonnet should not be limited by the Opus window")
+	}
+	if sonnet.IsNearLimit(0.8) {
+		t.Error("sonnet should not be near its limit")
+	}
+	if claude.TertiaryWindow == nil {
+		t.Error("ForModel modified the receiver")
+	}
+
+	opus := claude.ForModel("opus")
+	if opus.TertiaryWindow == nil || !opus.IsNearLimit(0.8) {
+		t.Error("opus should be limited by the Opus window")
+	}
+
+	gemini := &UsageInfo{
+		Provider:      "gemini",
+		PrimaryWindow: &UsageWindow{Utilization: 0.95},
+		ModelWindows: map[string]*UsageWindow{
+			"gemini-2.5-pro":   {Utilization: 0.95},
+			"gemini-2.5-flash": {Utilization: 0.1},
+		},
+	}
+	flash := gemini.ForModel("gemini-2.5-flash")
+	if flash.PrimaryWindow.Utilization != 0.1 {
+		t.Errorf("flash PrimaryWindow = %v, want the flash window", flash.PrimaryWindow.Utilization)
+	}
+	if w := flash.MostConstrainedWindow(); w.Utilization != 0.1 {
+		t.Errorf("flash MostConstrainedWindow = %v, want 0.1", w.Utilization)
+	}
+}
+
 func TestCreditInfo(t *testing.T) {
 	t.Run("has credits", func(t *testing.T) {
 		info := &UsageInfo{
@@ -70,7 +70,7 @@ type Config struct {
 	// If empty, default patterns for the provider are used.
 	CustomPatterns []string
 
-	// Algorithm is the rotation algorithm to use (smart, round_robin, random).
+	// Algorithm is the rotation algorithm to use (smart, round_robin, random, reset_aware).
 	Algorithm rotation.Algorithm
 
 	// Stdout is where to write stdout. Defaults to os.Stdout.
@@ -522,6 +522,7 @@ func (w *Wrapper) recordSession(result *Result) {
 		EndedAt:      result.StartTime.Add(result.Duration),
 		ExitCode:     result.ExitCode,
 		RateLimitHit: result.RateLimitHit,
+		ProjectDir:   w.config.WorkDir,
 	}
 
 	// Notes can include retry count or error info